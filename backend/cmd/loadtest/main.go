@@ -0,0 +1,135 @@
+// Command loadtest simulates many concurrent players against a running
+// server: each synthetic user signs in, opens a WebSocket, queues and plays
+// random or bot-chosen moves, while latencies and errors are collected for a
+// final report.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Options holds the command-line configuration shared by every player
+type Options struct {
+	URL         string
+	Users       int
+	Ramp        int
+	Duration    time.Duration
+	Mode        string
+	Difficulty  string
+	Games       int
+	Moves       string // how players pick moves: random or bot
+	MoveLevel   string // bot difficulty picking moves when Moves is bot
+	MoveDelay   time.Duration
+	MoveJitter  time.Duration
+	Prefix      string
+	Password    string
+	SpoofIP     bool
+	Progress    time.Duration
+	HTTPTimeout time.Duration
+}
+
+func main() {
+	opts := &Options{}
+	flag.StringVar(&opts.URL, "url", "http://localhost:8080", "server base URL")
+	flag.IntVar(&opts.Users, "users", 100, "number of simulated players")
+	flag.IntVar(&opts.Ramp, "ramp", 50, "players started per second (0 = all at once)")
+	flag.DurationVar(&opts.Duration, "duration", time.Minute, "how long to run after the first player starts")
+	flag.StringVar(&opts.Mode, "mode", "pvp", "pvp (players are matched with each other) or bot")
+	flag.StringVar(&opts.Difficulty, "difficulty", "easy", "bot difficulty when -mode=bot")
+	flag.IntVar(&opts.Games, "games", 0, "games per player (0 = keep playing until -duration)")
+	flag.StringVar(&opts.Moves, "moves", "random", "random, or bot to pick moves with the server's bot engine so games last realistically")
+	flag.StringVar(&opts.MoveLevel, "move-level", "medium", "bot difficulty picking moves when -moves=bot (hard is CPU-heavy)")
+	flag.DurationVar(&opts.MoveDelay, "move-delay", 200*time.Millisecond, "think time before each move")
+	flag.DurationVar(&opts.MoveJitter, "move-jitter", 300*time.Millisecond, "random extra think time added to -move-delay")
+	flag.StringVar(&opts.Prefix, "prefix", "loadtest", "username prefix for synthetic accounts")
+	flag.StringVar(&opts.Password, "password", "LoadTest#2024", "password for synthetic accounts")
+	flag.BoolVar(&opts.SpoofIP, "spoof-ip", true, "send a distinct X-Forwarded-For per player to avoid the per-IP connection cap and rate limits (needs this host in the server's TRUSTED_PROXIES)")
+	flag.DurationVar(&opts.Progress, "progress", 5*time.Second, "interval between progress lines (0 = off)")
+	flag.DurationVar(&opts.HTTPTimeout, "http-timeout", time.Minute, "timeout for login/register (bcrypt makes these slow under load)")
+	flag.Parse()
+
+	if opts.Mode != "pvp" && opts.Mode != "bot" {
+		log.Fatalf("Unknown -mode %q (expected pvp or bot)", opts.Mode)
+	}
+	if opts.Moves != "random" && opts.Moves != "bot" {
+		log.Fatalf("Unknown -moves %q (expected random or bot)", opts.Moves)
+	}
+	if opts.MoveLevel != "easy" && opts.MoveLevel != "medium" && opts.MoveLevel != "hard" {
+		log.Fatalf("Unknown -move-level %q (expected easy, medium or hard)", opts.MoveLevel)
+	}
+	if opts.Users <= 0 {
+		log.Fatal("-users must be positive")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), opts.Duration)
+	defer cancel()
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-quit
+		log.Println("[LOADTEST] Interrupted, stopping players...")
+		cancel()
+	}()
+
+	stats := NewStats()
+	started := time.Now()
+	log.Printf("[LOADTEST] %d players against %s (mode=%s, moves=%s, ramp=%d/s, duration=%s)",
+		opts.Users, opts.URL, opts.Mode, opts.Moves, opts.Ramp, opts.Duration)
+
+	stopProgress := make(chan struct{})
+	if opts.Progress > 0 {
+		go func() {
+			ticker := time.NewTicker(opts.Progress)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ticker.C:
+					stats.Progress(os.Stdout, time.Since(started))
+				case <-stopProgress:
+					return
+				}
+			}
+		}()
+	}
+
+	var wg sync.WaitGroup
+	var interval time.Duration
+	if opts.Ramp > 0 {
+		interval = time.Second / time.Duration(opts.Ramp)
+	}
+
+spawn:
+	for i := 1; i <= opts.Users; i++ {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			newPlayer(id, opts, stats).run(ctx)
+		}(i)
+
+		if interval > 0 {
+			select {
+			case <-time.After(interval):
+			case <-ctx.Done():
+				break spawn
+			}
+		}
+	}
+
+	wg.Wait()
+	close(stopProgress)
+	stats.Report(os.Stdout, opts.Users, time.Since(started))
+
+	if stats.ConnectOK.Load() == 0 {
+		fmt.Fprintln(os.Stderr, "no player managed to connect")
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"time"
+
+	"github.com/iamasit07/connect4/backend/internal/client"
+	"github.com/iamasit07/connect4/backend/internal/domain"
+	"github.com/iamasit07/connect4/backend/internal/service/bot"
+)
+
+// pollInterval bounds how long a player blocks on the socket before re-checking ctx
+const pollInterval = 500 * time.Millisecond
+
+// player is one synthetic user: it signs in, connects and plays games until ctx ends
+type player struct {
+	id    int
+	cfg   *Options
+	stats *Stats
+	rng   *rand.Rand
+	c     *client.Client
+}
+
+func newPlayer(id int, cfg *Options, stats *Stats) *player {
+	c := client.New(cfg.URL)
+	c.HTTP.Timeout = cfg.HTTPTimeout
+	if cfg.SpoofIP {
+		// Each player appears to come from its own address so the per-IP
+		// WebSocket connection cap does not limit the test. The server only
+		// honours this when our address is one of its TRUSTED_PROXIES.
+		c.Header = http.Header{}
+		c.Header.Set("X-Forwarded-For", fmt.Sprintf("10.%d.%d.%d", (id>>16)&0xff, (id>>8)&0xff, id&0xff))
+	}
+	return &player{
+		id:    id,
+		cfg:   cfg,
+		stats: stats,
+		rng:   rand.New(rand.NewSource(time.Now().UnixNano() + int64(id))),
+		c:     c,
+	}
+}
+
+func (p *player) run(ctx context.Context) {
+	username := fmt.Sprintf("%s%d", p.cfg.Prefix, p.id)
+
+	start := time.Now()
+	if err := p.signIn(username); err != nil {
+		p.stats.Error("auth: " + err.Error())
+		p.stats.ConnectFailed.Add(1)
+		return
+	}
+	p.stats.Observe(latencyAuth, time.Since(start))
+
+	start = time.Now()
+	if err := p.c.Connect(); err != nil {
+		p.stats.Error("ws_connect: " + err.Error())
+		p.stats.ConnectFailed.Add(1)
+		return
+	}
+	p.stats.Observe(latencyConnect, time.Since(start))
+	p.stats.ConnectOK.Add(1)
+	p.stats.Active.Add(1)
+	defer func() {
+		p.c.Close()
+		p.stats.Active.Add(-1)
+	}()
+
+	for games := 0; p.cfg.Games == 0 || games < p.cfg.Games; games++ {
+		if ctx.Err() != nil {
+			return
+		}
+		if !p.playGame(ctx) {
+			return
+		}
+	}
+}
+
+// signIn logs in an existing load-test account, registering it on first use
+func (p *player) signIn(username string) error {
+	if err := p.c.Login(username, p.cfg.Password); err == nil {
+		return nil
+	}
+	return p.c.Register(username, username+"@loadtest.local", p.cfg.Password)
+}
+
+// playGame queues for one game and plays it to the end. It returns false when
+// the player should stop (context cancelled or connection lost).
+func (p *player) playGame(ctx context.Context) bool {
+	difficulty := ""
+	if p.cfg.Mode == "bot" {
+		difficulty = p.cfg.Difficulty
+	}
+
+	queuedAt := time.Now()
+	if err := p.c.FindMatch(difficulty, nil); err != nil {
+		p.stats.Error("send")
+		return false
+	}
+
+	var board [][]domain.PlayerID
+	var me, turn int
+	var pendingColumn = -1
+	var sentAt time.Time
+	lastSent := "find_match"
+
+	for {
+		if ctx.Err() != nil {
+			return false
+		}
+
+		msg, err := p.c.Next(pollInterval)
+		if err != nil {
+			if errors.Is(err, client.ErrTimeout) {
+				continue
+			}
+			p.stats.Error("connection_lost")
+			return false
+		}
+		p.stats.Messages.Add(1)
+
+		switch msg.Type {
+		case "game_start":
+			p.stats.Observe(latencyMatchWait, time.Since(queuedAt))
+			p.stats.GamesStarted.Add(1)
+			board, me, turn = msg.Board, msg.YourPlayer, msg.CurrentTurn
+
+		case "move_made":
+			board, turn = msg.Board, msg.NextTurn
+			if msg.Player == me && msg.Column == pendingColumn {
+				p.stats.Observe(latencyMove, time.Since(sentAt))
+				pendingColumn = -1
+			}
+
+		case "game_over":
+			p.stats.GamesFinished.Add(1)
+			return true
+
+		case "queue_timeout":
+			// Nobody else searching; try again
+			queuedAt = time.Now()
+			if err := p.c.FindMatch(difficulty, nil); err != nil {
+				p.stats.Error("send")
+				return false
+			}
+			lastSent = "find_match"
+			continue
+
+		case "error":
+			p.stats.Error("server:" + msg.Message)
+			if msg.Code == domain.ErrRateLimited {
+				// Wait out the limit, then resend what was throttled. Queuing
+				// again mid-game would abandon the game.
+				if !p.sleep(ctx, time.Duration(max(msg.RetryAfter, 1))*time.Second) {
+					return false
+				}
+				if lastSent == "find_match" {
+					queuedAt = time.Now()
+					if err := p.c.FindMatch(difficulty, nil); err != nil {
+						p.stats.Error("send")
+						return false
+					}
+					continue
+				}
+			}
+			pendingColumn = -1 // the move below is retried
+
+		case "force_disconnect":
+			p.stats.Error("force_disconnect")
+			return false
+		}
+
+		if board != nil && me != 0 && turn == me && pendingColumn == -1 {
+			if !p.think(ctx) {
+				return false
+			}
+			pendingColumn = p.pickColumn(board, domain.PlayerID(me))
+			if pendingColumn < 0 {
+				continue
+			}
+			sentAt = time.Now()
+			if err := p.c.MakeMove(pendingColumn); err != nil {
+				p.stats.Error("send")
+				return false
+			}
+			lastSent = "make_move"
+			p.stats.MovesSent.Add(1)
+		}
+	}
+}
+
+// think waits the configured delay (plus jitter) before a move
+func (p *player) think(ctx context.Context) bool {
+	delay := p.cfg.MoveDelay
+	if p.cfg.MoveJitter > 0 {
+		delay += time.Duration(p.rng.Int63n(int64(p.cfg.MoveJitter)))
+	}
+	return p.sleep(ctx, delay)
+}
+
+// sleep waits for d, returning false if ctx ends first
+func (p *player) sleep(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return true
+	}
+	select {
+	case <-time.After(d):
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// pickColumn returns the bot engine's choice for me with -moves=bot, and
+// otherwise a random column that still has room
+func (p *player) pickColumn(board [][]domain.PlayerID, me domain.PlayerID) int {
+	if p.cfg.Moves == "bot" {
+		return bot.CalculateBestMove(board, me, p.cfg.MoveLevel)
+	}
+
+	var open []int
+	for col := 0; col < domain.Columns; col++ {
+		if len(board) > 0 && board[0][col] == domain.Empty {
+			open = append(open, col)
+		}
+	}
+	if len(open) == 0 {
+		return -1
+	}
+	return open[p.rng.Intn(len(open))]
+}
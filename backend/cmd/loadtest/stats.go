@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Latency series recorded by the load test
+const (
+	latencyAuth      = "auth"       // login/register round trip
+	latencyConnect   = "ws_connect" // WebSocket dial + init
+	latencyMatchWait = "match_wait" // find_match → game_start
+	latencyMove      = "move_rtt"   // make_move → own move_made
+)
+
+// Stats aggregates counters and latency samples from every simulated player
+type Stats struct {
+	mu        sync.Mutex
+	latencies map[string][]time.Duration
+	errors    map[string]int64
+
+	ConnectOK     atomic.Int64
+	ConnectFailed atomic.Int64
+	Active        atomic.Int64
+	GamesStarted  atomic.Int64
+	GamesFinished atomic.Int64
+	MovesSent     atomic.Int64
+	Messages      atomic.Int64
+}
+
+func NewStats() *Stats {
+	return &Stats{
+		latencies: make(map[string][]time.Duration),
+		errors:    make(map[string]int64),
+	}
+}
+
+func (s *Stats) Observe(series string, d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.latencies[series] = append(s.latencies[series], d)
+}
+
+// Error counts a failure under a short category (e.g. "auth", "server:not your turn")
+func (s *Stats) Error(category string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.errors[category]++
+}
+
+// Progress prints a one-line snapshot, used while the test is running
+func (s *Stats) Progress(w io.Writer, elapsed time.Duration) {
+	fmt.Fprintf(w, "[%6s] active=%d connected=%d failed=%d games=%d/%d moves=%d msgs=%d\n",
+		elapsed.Truncate(time.Second), s.Active.Load(), s.ConnectOK.Load(), s.ConnectFailed.Load(),
+		s.GamesFinished.Load(), s.GamesStarted.Load(), s.MovesSent.Load(), s.Messages.Load())
+}
+
+// Report prints the final summary with latency percentiles and error counts
+func (s *Stats) Report(w io.Writer, users int, elapsed time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	seconds := elapsed.Seconds()
+	fmt.Fprintf(w, "\n=== Load test summary (%s) ===\n", elapsed.Truncate(time.Millisecond))
+	fmt.Fprintf(w, "Connections:  %d/%d succeeded, %d failed\n", s.ConnectOK.Load(), users, s.ConnectFailed.Load())
+	fmt.Fprintf(w, "Games:        %d started, %d finished (%.1f/s)\n", s.GamesStarted.Load(), s.GamesFinished.Load(), float64(s.GamesFinished.Load())/seconds)
+	fmt.Fprintf(w, "Moves sent:   %d (%.1f/s)\n", s.MovesSent.Load(), float64(s.MovesSent.Load())/seconds)
+	fmt.Fprintf(w, "Messages in:  %d (%.1f/s)\n", s.Messages.Load(), float64(s.Messages.Load())/seconds)
+
+	fmt.Fprintf(w, "\n%-12s %8s %10s %10s %10s %10s %10s\n", "latency", "count", "p50", "p90", "p95", "p99", "max")
+	for _, series := range []string{latencyAuth, latencyConnect, latencyMatchWait, latencyMove} {
+		samples := s.latencies[series]
+		if len(samples) == 0 {
+			fmt.Fprintf(w, "%-12s %8d\n", series, 0)
+			continue
+		}
+		sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+		fmt.Fprintf(w, "%-12s %8d %10s %10s %10s %10s %10s\n", series, len(samples),
+			percentile(samples, 50), percentile(samples, 90), percentile(samples, 95),
+			percentile(samples, 99), samples[len(samples)-1])
+	}
+
+	if len(s.errors) == 0 {
+		fmt.Fprintln(w, "\nErrors:       none")
+		return
+	}
+	categories := make([]string, 0, len(s.errors))
+	for category := range s.errors {
+		categories = append(categories, category)
+	}
+	sort.Strings(categories)
+	fmt.Fprintln(w, "\nErrors:")
+	for _, category := range categories {
+		fmt.Fprintf(w, "  %-40s %d\n", category, s.errors[category])
+	}
+}
+
+// percentile expects sorted samples and uses the nearest-rank method
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1].Round(time.Microsecond)
+}
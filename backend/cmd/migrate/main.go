@@ -0,0 +1,101 @@
+// Command migrate applies, rolls back and reports the versioned SQL
+// migrations embedded in the postgres repository package.
+//
+// Usage:
+//
+//	go run ./cmd/migrate up [-steps N]     apply pending migrations (all by default)
+//	go run ./cmd/migrate down [-steps N]   roll back the latest migrations (1 by default)
+//	go run ./cmd/migrate status            list migrations and whether they are applied
+package main
+
+import (
+	"database/sql"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/iamasit07/connect4/backend/internal/config"
+	"github.com/iamasit07/connect4/backend/internal/repository/postgres"
+	"github.com/joho/godotenv"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: migrate <up|down|status> [-steps N]")
+	os.Exit(2)
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	command := os.Args[1]
+
+	flags := flag.NewFlagSet(command, flag.ExitOnError)
+	steps := flags.Int("steps", 0, "number of migrations to apply or roll back (up: 0 = all, down: 0 = 1)")
+	flags.Parse(os.Args[2:])
+
+	if err := godotenv.Load(); err != nil {
+		if err := godotenv.Load("../.env"); err != nil {
+			log.Println("No .env file found")
+		}
+	}
+
+	cfg := config.LoadConfig()
+	if cfg.DatabaseURL == "" {
+		log.Fatal("DATABASE_URL is not set")
+	}
+
+	db, err := sql.Open("pgx", cfg.DatabaseURL)
+	if err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
+	defer db.Close()
+
+	if err := db.Ping(); err != nil {
+		log.Fatal("Database unreachable:", err)
+	}
+
+	migrator, err := postgres.NewMigrator(db)
+	if err != nil {
+		log.Fatalf("Failed to load migrations: %v", err)
+	}
+
+	switch command {
+	case "up":
+		applied, err := migrator.Up(*steps)
+		if err != nil {
+			log.Fatalf("Migration failed after applying %d migration(s): %v", applied, err)
+		}
+		log.Printf("Applied %d migration(s)", applied)
+
+	case "down":
+		n := *steps
+		if n <= 0 {
+			n = 1
+		}
+		reverted, err := migrator.Down(n)
+		if err != nil {
+			log.Fatalf("Rollback failed after reverting %d migration(s): %v", reverted, err)
+		}
+		log.Printf("Rolled back %d migration(s)", reverted)
+
+	case "status":
+		statuses, err := migrator.Status()
+		if err != nil {
+			log.Fatalf("Failed to read migration status: %v", err)
+		}
+		for _, s := range statuses {
+			state := "pending"
+			if s.Applied {
+				state = "applied " + s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d  %-30s  %s\n", s.Version, s.Name, state)
+		}
+
+	default:
+		usage()
+	}
+}
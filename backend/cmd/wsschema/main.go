@@ -0,0 +1,33 @@
+// Command wsschema writes the JSON Schema of the WebSocket protocol, generated
+// from the payload types in the protocol package.
+//
+// Usage:
+//
+//	go generate ./internal/protocol         rewrite docs/websocket-protocol.schema.json
+//	go run ./cmd/wsschema [-o FILE]         write the schema to FILE (stdout by default)
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+
+	"github.com/iamasit07/connect4/backend/internal/protocol"
+)
+
+func main() {
+	out := flag.String("o", "", "file to write the schema to (default stdout)")
+	flag.Parse()
+
+	schema, err := protocol.Schema()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *out == "" {
+		os.Stdout.Write(schema)
+		return
+	}
+	if err := os.WriteFile(*out, schema, 0o644); err != nil {
+		log.Fatalf("Failed to write schema: %v", err)
+	}
+}
@@ -0,0 +1,170 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/iamasit07/connect4/backend/internal/domain"
+)
+
+// VerifyEmail confirms an email address with the token from a verification link
+func (c *Client) VerifyEmail(token string) error {
+	return c.Do(http.MethodPost, "/api/auth/verify-email", map[string]string{"token": token}, nil)
+}
+
+// ResendVerification mails the signed-in user a new verification link
+func (c *Client) ResendVerification() error {
+	return c.Do(http.MethodPost, "/api/auth/verify-email/resend", nil, nil)
+}
+
+// ForgotPassword asks for a password reset link to be mailed to email
+func (c *Client) ForgotPassword(email string) error {
+	return c.Do(http.MethodPost, "/api/auth/forgot-password", map[string]string{"email": email}, nil)
+}
+
+// ResetPassword sets a new password with the token from a reset link
+func (c *Client) ResetPassword(token, password string) error {
+	return c.Do(http.MethodPost, "/api/auth/reset-password", map[string]string{"token": token, "password": password}, nil)
+}
+
+// TwoFactorEnrollment is the setup data returned by EnrollTwoFactor
+type TwoFactorEnrollment struct {
+	Secret          string   `json:"secret"`
+	ProvisioningURI string   `json:"provisioning_uri"`
+	RecoveryCodes   []string `json:"recovery_codes"`
+}
+
+// EnrollTwoFactor starts two-factor setup; ConfirmTwoFactor turns it on
+func (c *Client) EnrollTwoFactor() (*TwoFactorEnrollment, error) {
+	var out TwoFactorEnrollment
+	if err := c.Do(http.MethodPost, "/api/auth/2fa/enroll", nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ConfirmTwoFactor enables two-factor with a code from the authenticator app
+func (c *Client) ConfirmTwoFactor(code string) error {
+	return c.Do(http.MethodPost, "/api/auth/2fa/confirm", map[string]string{"code": code}, nil)
+}
+
+// DisableTwoFactor turns two-factor off and stores the reissued access token
+func (c *Client) DisableTwoFactor(code string) error {
+	var out struct {
+		Token string `json:"token"`
+	}
+	if err := c.Do(http.MethodPost, "/api/auth/2fa/disable", map[string]string{"code": code}, &out); err != nil {
+		return err
+	}
+	c.Token = out.Token
+	return nil
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes and stores the reissued access token
+func (c *Client) RegenerateRecoveryCodes(code string) ([]string, error) {
+	var out struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+		Token         string   `json:"token"`
+	}
+	if err := c.Do(http.MethodPost, "/api/auth/2fa/recovery-codes", map[string]string{"code": code}, &out); err != nil {
+		return nil, err
+	}
+	c.Token = out.Token
+	return out.RecoveryCodes, nil
+}
+
+// OAuthProvider is a login provider the server offers
+type OAuthProvider struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name"`
+}
+
+// OAuthProviders lists the configured login providers
+func (c *Client) OAuthProviders() ([]OAuthProvider, error) {
+	var out []OAuthProvider
+	if err := c.GetJSON("/api/auth/providers", &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Identities lists the login providers linked to the account
+func (c *Client) Identities() ([]domain.UserIdentity, error) {
+	var out []domain.UserIdentity
+	if err := c.GetJSON("/api/auth/identities", &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// StartLinkIdentity returns the provider URL that links it to the account; the
+// browser (or test) follows it through the provider and back to the server
+func (c *Client) StartLinkIdentity(provider string) (string, error) {
+	var out struct {
+		URL string `json:"url"`
+	}
+	if err := c.Do(http.MethodPost, "/api/auth/identities/"+provider, nil, &out); err != nil {
+		return "", err
+	}
+	return out.URL, nil
+}
+
+// UnlinkIdentity removes a login provider from the account
+func (c *Client) UnlinkIdentity(provider string) error {
+	return c.Do(http.MethodDelete, "/api/auth/identities/"+provider, nil, nil)
+}
+
+// Device is a session the account is signed in with
+type Device struct {
+	ID           int64     `json:"id"`
+	DeviceInfo   string    `json:"device_info"`
+	IPAddress    string    `json:"ip_address"`
+	CreatedAt    time.Time `json:"created_at"`
+	LastActivity time.Time `json:"last_activity"`
+	Current      bool      `json:"current"` // the session this client is using
+	Online       bool      `json:"online"`  // has a WebSocket open
+	Playing      bool      `json:"playing"` // receives the account's game
+}
+
+// ActiveSessions lists the devices the account is signed in on, most recently used first
+func (c *Client) ActiveSessions() ([]Device, error) {
+	var out []Device
+	if err := c.GetJSON("/api/sessions/active", &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// RevokeSession signs one device out
+func (c *Client) RevokeSession(id int64) error {
+	return c.Do(http.MethodDelete, fmt.Sprintf("/api/sessions/%d", id), nil, nil)
+}
+
+// CreateAPIKey issues a personal API key and returns it with its metadata.
+// expiresInDays of 0 means the key never expires.
+func (c *Client) CreateAPIKey(name string, scopes []string, expiresInDays int) (string, *domain.APIKey, error) {
+	var out struct {
+		Key    string        `json:"key"`
+		APIKey domain.APIKey `json:"api_key"`
+	}
+	body := map[string]interface{}{"name": name, "scopes": scopes, "expires_in_days": expiresInDays}
+	if err := c.Do(http.MethodPost, "/api/keys", body, &out); err != nil {
+		return "", nil, err
+	}
+	return out.Key, &out.APIKey, nil
+}
+
+// APIKeys lists the account's API keys, newest first
+func (c *Client) APIKeys() ([]domain.APIKey, error) {
+	var out []domain.APIKey
+	if err := c.GetJSON("/api/keys", &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// RevokeAPIKey deletes one of the account's API keys
+func (c *Client) RevokeAPIKey(id int64) error {
+	return c.Do(http.MethodDelete, fmt.Sprintf("/api/keys/%d", id), nil, nil)
+}
@@ -0,0 +1,76 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/iamasit07/connect4/backend/internal/domain"
+)
+
+// The admin endpoints require the logged-in user to have the admin role
+
+func (c *Client) AdminActions(limit int) ([]domain.AdminAction, error) {
+	var actions []domain.AdminAction
+	err := c.GetJSON(fmt.Sprintf("/api/admin/actions?limit=%d", limit), &actions)
+	return actions, err
+}
+
+func (c *Client) BanUser(userID int64, reason string) error {
+	return c.Do(http.MethodPost, fmt.Sprintf("/api/admin/users/%d/ban", userID), map[string]string{"reason": reason}, nil)
+}
+
+func (c *Client) SuspendUser(userID int64, hours int, reason string) error {
+	body := map[string]interface{}{"hours": hours, "reason": reason}
+	return c.Do(http.MethodPost, fmt.Sprintf("/api/admin/users/%d/suspend", userID), body, nil)
+}
+
+func (c *Client) UnbanUser(userID int64, reason string) error {
+	return c.Do(http.MethodDelete, fmt.Sprintf("/api/admin/users/%d/ban", userID), map[string]string{"reason": reason}, nil)
+}
+
+func (c *Client) SetUserRole(userID int64, role string) error {
+	return c.Do(http.MethodPut, fmt.Sprintf("/api/admin/users/%d/role", userID), map[string]string{"role": role}, nil)
+}
+
+func (c *Client) TerminateGame(gameID, reason string) error {
+	return c.Do(http.MethodPost, fmt.Sprintf("/api/admin/games/%s/terminate", gameID), map[string]string{"reason": reason}, nil)
+}
+
+func (c *Client) VoidGame(gameID, reason string) error {
+	return c.Do(http.MethodPost, fmt.Sprintf("/api/admin/games/%s/void", gameID), map[string]string{"reason": reason}, nil)
+}
+
+// IntegrityFlags lists the win-trading review queue ("" status lists every flag)
+func (c *Client) IntegrityFlags(status string) ([]domain.IntegrityFlag, error) {
+	var flags []domain.IntegrityFlag
+	err := c.GetJSON("/api/admin/flags?status="+status, &flags)
+	return flags, err
+}
+
+// ScanIntegrity runs the win-trading detector and returns how many flags it raised
+func (c *Client) ScanIntegrity() (int, error) {
+	var out struct {
+		Flagged int `json:"flagged"`
+	}
+	err := c.Do(http.MethodPost, "/api/admin/flags/scan", nil, &out)
+	return out.Flagged, err
+}
+
+// AnalyzeGames starts the engine-assistance analyser and returns the run it started
+func (c *Client) AnalyzeGames() (domain.EngineAnalysisRun, error) {
+	var run domain.EngineAnalysisRun
+	err := c.Do(http.MethodPost, "/api/admin/flags/analyze", nil, &run)
+	return run, err
+}
+
+// AnalysisStatus returns the latest engine-analysis run
+func (c *Client) AnalysisStatus() (domain.EngineAnalysisRun, error) {
+	var run domain.EngineAnalysisRun
+	err := c.GetJSON("/api/admin/flags/analyze", &run)
+	return run, err
+}
+
+func (c *Client) ReviewFlag(flagID int64, status, reason string) error {
+	body := map[string]string{"status": status, "reason": reason}
+	return c.Do(http.MethodPost, fmt.Sprintf("/api/admin/flags/%d/review", flagID), body, nil)
+}
@@ -0,0 +1,456 @@
+// Package client is a typed Go client for the Connect 4 HTTP and WebSocket
+// API. It is used by the end-to-end tests and is suitable for scripted
+// players (e.g. load testing).
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/iamasit07/connect4/backend/internal/domain"
+	"github.com/iamasit07/connect4/backend/internal/protocol"
+)
+
+// DefaultOrigin is always present in the server's allowed origin list
+const DefaultOrigin = "http://localhost:5173"
+
+// ErrTimeout is returned (wrapped) by Next and WaitFor when no matching message arrives in time
+var ErrTimeout = errors.New("timed out")
+
+// ErrTwoFactorRequired is returned by Login when the account needs a second
+// factor; finish with LoginTwoFactor
+var ErrTwoFactorRequired = errors.New("two-factor code required")
+
+// Client represents a single player: an HTTP identity plus one WebSocket connection
+type Client struct {
+	BaseURL  string
+	Origin   string
+	HTTP     *http.Client
+	Header   http.Header // extra headers sent with every HTTP request and the WebSocket handshake
+	Token    string
+	UserID   int64
+	Username string
+
+	// TwoFactorChallenge is set when Login returns ErrTwoFactorRequired
+	TwoFactorChallenge string
+
+	// Protocol is the WebSocket protocol version Connect asks for. Version 1
+	// connects the way older clients do, without offering versions.
+	Protocol int
+
+	conn     *websocket.Conn
+	writeMu  sync.Mutex
+	messages chan domain.ServerMessage
+	done     chan struct{} // closed when the read loop exits
+	stop     chan struct{} // closed by Close so a blocked read loop can exit
+
+	// pending holds messages received while waiting for a different type.
+	// The server delivers game events from separate goroutines, so ordering
+	// between two messages of different types is not guaranteed.
+	pending []domain.ServerMessage
+}
+
+func New(baseURL string) *Client {
+	return &Client{
+		BaseURL:  strings.TrimRight(baseURL, "/"),
+		Origin:   DefaultOrigin,
+		HTTP:     &http.Client{Timeout: 10 * time.Second},
+		Protocol: protocol.Latest,
+	}
+}
+
+type authResponse struct {
+	Token string `json:"token"`
+	User  struct {
+		ID       int64  `json:"id"`
+		Username string `json:"username"`
+	} `json:"user"`
+	TwoFactorRequired bool   `json:"two_factor_required"`
+	Challenge         string `json:"challenge"`
+	Error             string `json:"error"`
+}
+
+// Register creates a new account and stores the returned access token
+func (c *Client) Register(username, email, password string) error {
+	body := map[string]string{"username": username, "name": username, "email": email, "password": password}
+	return c.authenticate("/api/auth/register", body)
+}
+
+// Login signs in with a username or email and stores the returned access token
+func (c *Client) Login(identifier, password string) error {
+	body := map[string]string{"username": identifier, "password": password}
+	return c.authenticate("/api/auth/login", body)
+}
+
+// CompleteOAuthSignup creates the account for a provider login that had no
+// player yet. The setup token is the cookie the signup redirect set, so
+// c.HTTP needs the cookie jar that followed the provider flow.
+func (c *Client) CompleteOAuthSignup(username, password string) error {
+	body := map[string]string{"username": username, "password": password}
+	return c.authenticate("/api/auth/oauth/complete", body)
+}
+
+// LoginTwoFactor finishes a Login that returned ErrTwoFactorRequired with a TOTP or recovery code
+func (c *Client) LoginTwoFactor(code string) error {
+	body := map[string]string{"challenge": c.TwoFactorChallenge, "code": code}
+	return c.authenticate("/api/auth/login/2fa", body)
+}
+
+// Guest signs in as a new temporary guest and stores the returned access token
+func (c *Client) Guest() error {
+	return c.authenticate("/api/auth/guest", map[string]string{})
+}
+
+// UpgradeGuest turns the signed-in guest into a registered account and stores the new access token
+func (c *Client) UpgradeGuest(username, email, password string) error {
+	body := map[string]string{"username": username, "name": username, "email": email, "password": password}
+	return c.authenticate("/api/auth/guest/upgrade", body)
+}
+
+func (c *Client) authenticate(path string, body map[string]string) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %v", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, c.BaseURL+path, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to build request: %v", err)
+	}
+	c.applyHeaders(req.Header)
+	req.Header.Set("Content-Type", "application/json")
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call %s: %v", path, err)
+	}
+	defer resp.Body.Close()
+
+	var out authResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return fmt.Errorf("failed to decode %s response: %v", path, err)
+	}
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s returned %d: %s", path, resp.StatusCode, out.Error)
+	}
+	if out.TwoFactorRequired {
+		c.TwoFactorChallenge = out.Challenge
+		return ErrTwoFactorRequired
+	}
+
+	c.TwoFactorChallenge = ""
+	c.Token = out.Token
+	c.UserID = out.User.ID
+	c.Username = out.User.Username
+	return nil
+}
+
+// GetJSON performs an authenticated GET and decodes the response into out
+func (c *Client) GetJSON(path string, out interface{}) error {
+	return c.Do(http.MethodGet, path, nil, out)
+}
+
+// Do performs an authenticated request with an optional JSON body and decodes
+// the response into out (when non-nil). Error responses include the server's message.
+func (c *Client) Do(method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %v", err)
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequest(method, c.BaseURL+path, reader)
+	if err != nil {
+		return fmt.Errorf("failed to build request: %v", err)
+	}
+	c.applyHeaders(req.Header)
+	req.Header.Set("Authorization", "Bearer "+c.Token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call %s: %v", path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		var apiErr struct {
+			Error string `json:"error"`
+		}
+		json.NewDecoder(resp.Body).Decode(&apiErr)
+		return fmt.Errorf("%s returned %d: %s", path, resp.StatusCode, apiErr.Error)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// Connect opens the WebSocket and sends the init message with the access
+// token and, above version 1, the protocol version to use
+func (c *Client) Connect() error {
+	u, err := url.Parse(c.BaseURL)
+	if err != nil {
+		return fmt.Errorf("invalid base url: %v", err)
+	}
+	switch u.Scheme {
+	case "https":
+		u.Scheme = "wss"
+	default:
+		u.Scheme = "ws"
+	}
+	u.Path = "/ws"
+
+	header := http.Header{}
+	c.applyHeaders(header)
+	header.Set("Origin", c.Origin)
+	conn, _, err := websocket.DefaultDialer.Dial(u.String(), header)
+	if err != nil {
+		return fmt.Errorf("failed to dial websocket: %v", err)
+	}
+
+	c.conn = conn
+	c.messages = make(chan domain.ServerMessage, 64)
+	c.done = make(chan struct{})
+	c.stop = make(chan struct{})
+	c.pending = nil
+	go c.readLoop(conn, c.messages, c.done, c.stop)
+
+	init := protocol.Init{JWT: c.Token}
+	if c.Protocol > protocol.V1 {
+		init.Versions = []int{c.Protocol}
+	}
+	return c.Send("init", init)
+}
+
+func (c *Client) applyHeaders(h http.Header) {
+	for key, values := range c.Header {
+		for _, v := range values {
+			h.Add(key, v)
+		}
+	}
+}
+
+func (c *Client) readLoop(conn *websocket.Conn, messages chan<- domain.ServerMessage, done chan<- struct{}, stop <-chan struct{}) {
+	defer close(done)
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		msg, err := protocol.DecodeServer(data)
+		if err != nil {
+			return
+		}
+		select {
+		case messages <- msg:
+		case <-stop:
+			return
+		}
+	}
+}
+
+// Close closes the WebSocket; the server treats this as a disconnect
+func (c *Client) Close() error {
+	if c.conn == nil {
+		return nil
+	}
+	close(c.stop)
+	err := c.conn.Close()
+	<-c.done
+	c.conn = nil
+	return err
+}
+
+// Send writes a client message of msgType in c.Protocol. payload is one of
+// the protocol package's client payloads, or nil for types without fields.
+func (c *Client) Send(msgType string, payload any) error {
+	data, err := protocol.EncodeClient(c.Protocol, msgType, payload)
+	if err != nil {
+		return err
+	}
+	return c.SendRaw(data)
+}
+
+// SendRaw writes data to the WebSocket as it is
+func (c *Client) SendRaw(data []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if c.conn == nil {
+		return fmt.Errorf("not connected")
+	}
+	return c.conn.WriteMessage(websocket.TextMessage, data)
+}
+
+// Next returns the next message (pending first), or an error after timeout
+func (c *Client) Next(timeout time.Duration) (domain.ServerMessage, error) {
+	if len(c.pending) > 0 {
+		msg := c.pending[0]
+		c.pending = c.pending[1:]
+		return msg, nil
+	}
+	select {
+	case msg := <-c.messages:
+		return msg, nil
+	case <-c.done:
+		// Messages read before the connection closed are still delivered
+		select {
+		case msg := <-c.messages:
+			return msg, nil
+		default:
+		}
+		return domain.ServerMessage{}, fmt.Errorf("connection closed")
+	case <-time.After(timeout):
+		return domain.ServerMessage{}, fmt.Errorf("%w after %s", ErrTimeout, timeout)
+	}
+}
+
+// WaitFor returns the first message of the given type. Messages of other
+// types are kept and returned by later Next/WaitFor calls.
+func (c *Client) WaitFor(msgType string, timeout time.Duration) (domain.ServerMessage, error) {
+	for i, msg := range c.pending {
+		if msg.Type == msgType {
+			c.pending = append(c.pending[:i], c.pending[i+1:]...)
+			return msg, nil
+		}
+	}
+
+	deadline := time.After(timeout)
+	for {
+		select {
+		case msg := <-c.messages:
+			if msg.Type == msgType {
+				return msg, nil
+			}
+			c.pending = append(c.pending, msg)
+		case <-c.done:
+			// Drain messages read before the connection closed
+			for {
+				select {
+				case msg := <-c.messages:
+					if msg.Type == msgType {
+						return msg, nil
+					}
+					c.pending = append(c.pending, msg)
+					continue
+				default:
+				}
+				break
+			}
+			return domain.ServerMessage{}, fmt.Errorf("connection closed while waiting for %s", msgType)
+		case <-deadline:
+			return domain.ServerMessage{}, fmt.Errorf("%w after %s waiting for %s (pending: %s)", ErrTimeout, timeout, msgType, c.pendingTypes())
+		}
+	}
+}
+
+func (c *Client) pendingTypes() string {
+	types := make([]string, len(c.pending))
+	for i, msg := range c.pending {
+		types[i] = msg.Type
+	}
+	return strings.Join(types, ",")
+}
+
+// --- Protocol helpers ---
+
+// FindMatch joins the PvP queue (difficulty "") or starts a bot game.
+// rated nil uses the server default for the mode.
+func (c *Client) FindMatch(difficulty string, rated *bool) error {
+	return c.Send("find_match", protocol.FindMatch{Difficulty: difficulty, Rated: rated})
+}
+
+func (c *Client) CancelSearch() error {
+	return c.Send("cancel_search", nil)
+}
+
+func (c *Client) MakeMove(column int) error {
+	return c.Send("make_move", protocol.MakeMove{Column: column})
+}
+
+func (c *Client) RequestRematch() error {
+	return c.Send("request_rematch", nil)
+}
+
+func (c *Client) RespondRematch(accept bool) error {
+	return c.Send("rematch_response", protocol.RematchResponse{Response: answer(accept)})
+}
+
+func (c *Client) OfferDraw() error {
+	return c.Send("offer_draw", nil)
+}
+
+func (c *Client) RespondDraw(accept bool) error {
+	return c.Send("draw_response", protocol.DrawResponse{Response: answer(accept)})
+}
+
+func (c *Client) Abandon() error {
+	return c.Send("abandon_game", nil)
+}
+
+func (c *Client) WatchGame(gameID string) error {
+	return c.Send("watch_game", protocol.WatchGame{GameID: gameID})
+}
+
+func (c *Client) LeaveSpectate(gameID string) error {
+	return c.Send("leave_spectate", protocol.LeaveSpectate{GameID: gameID})
+}
+
+func (c *Client) GetGameState(gameID string) error {
+	return c.Send("get_game_state", protocol.GetGameState{GameID: gameID})
+}
+
+// ClaimGame moves the player's game to this connection's device
+func (c *Client) ClaimGame() error {
+	return c.Send("claim_game", nil)
+}
+
+// Chat posts to the player's own game, or to gameID when spectating
+func (c *Client) Chat(gameID, text string) error {
+	return c.Send("chat_message", protocol.PostChat{GameID: gameID, Text: text})
+}
+
+func (c *Client) Mute(gameID string, userID int64) error {
+	return c.Send("mute_user", protocol.MuteUser{GameID: gameID, UserID: userID})
+}
+
+func (c *Client) Unmute(gameID string, userID int64) error {
+	return c.Send("unmute_user", protocol.UnmuteUser{GameID: gameID, UserID: userID})
+}
+
+func (c *Client) ReportMessage(gameID string, messageID int64, reason string) error {
+	return c.Send("report_message", protocol.ReportMessage{GameID: gameID, MessageID: messageID, Reason: reason})
+}
+
+// Challenge invites a friend to a game (rated unless rated points to false)
+func (c *Client) Challenge(userID int64, rated *bool) error {
+	return c.Send("challenge_user", protocol.ChallengeUser{UserID: userID, Rated: rated})
+}
+
+func (c *Client) RespondChallenge(challengeID string, accept bool) error {
+	return c.Send("challenge_response", protocol.ChallengeResponse{ChallengeID: challengeID, Response: answer(accept)})
+}
+
+func answer(accept bool) string {
+	if accept {
+		return "accept"
+	}
+	return "decline"
+}
@@ -0,0 +1,49 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/iamasit07/connect4/backend/internal/domain"
+)
+
+func (c *Client) ListFriends() ([]domain.Friend, error) {
+	var friends []domain.Friend
+	err := c.GetJSON("/api/friends", &friends)
+	return friends, err
+}
+
+// AddFriend sends a friend request (or accepts theirs) and returns the resulting relation
+func (c *Client) AddFriend(username string) (string, error) {
+	var out struct {
+		Relation string `json:"relation"`
+	}
+	err := c.Do(http.MethodPost, "/api/friends/requests", map[string]string{"username": username}, &out)
+	return out.Relation, err
+}
+
+func (c *Client) AcceptFriend(userID int64) error {
+	return c.Do(http.MethodPost, fmt.Sprintf("/api/friends/requests/%d/accept", userID), nil, nil)
+}
+
+func (c *Client) DeclineFriend(userID int64) error {
+	return c.Do(http.MethodPost, fmt.Sprintf("/api/friends/requests/%d/decline", userID), nil, nil)
+}
+
+func (c *Client) RemoveFriend(userID int64) error {
+	return c.Do(http.MethodDelete, fmt.Sprintf("/api/friends/%d", userID), nil, nil)
+}
+
+func (c *Client) Block(userID int64) error {
+	return c.Do(http.MethodPost, fmt.Sprintf("/api/friends/%d/block", userID), nil, nil)
+}
+
+// BlockSpectating blocks userID and also stops them watching this user's games
+func (c *Client) BlockSpectating(userID int64) error {
+	body := map[string]bool{"blockSpectating": true}
+	return c.Do(http.MethodPost, fmt.Sprintf("/api/friends/%d/block", userID), body, nil)
+}
+
+func (c *Client) Unblock(userID int64) error {
+	return c.Do(http.MethodDelete, fmt.Sprintf("/api/friends/%d/block", userID), nil, nil)
+}
@@ -0,0 +1,31 @@
+// Package clock abstracts time so game and matchmaking timers can be driven
+// deterministically in tests. Production code uses Real; tests use Fake.
+package clock
+
+import "time"
+
+// Clock is the subset of the time package used by timed game logic
+type Clock interface {
+	Now() time.Time
+	// AfterFunc calls f in its own goroutine after d (fake clocks call it from Advance)
+	AfterFunc(d time.Duration, f func()) Timer
+	// After returns a channel that receives the current time after d
+	After(d time.Duration) <-chan time.Time
+}
+
+// Timer is a cancellable timer created by Clock.AfterFunc
+type Timer interface {
+	Stop() bool
+	Reset(d time.Duration) bool
+}
+
+// Real is the wall clock backed by the time package
+var Real Clock = realClock{}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time { return time.Now() }
+
+func (realClock) AfterFunc(d time.Duration, f func()) Timer { return time.AfterFunc(d, f) }
+
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
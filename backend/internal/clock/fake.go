@@ -0,0 +1,123 @@
+package clock
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Fake is a manually advanced clock. Timers fire synchronously, in deadline
+// order, from within Advance.
+type Fake struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*fakeTimer
+}
+
+// NewFake returns a fake clock set to start
+func NewFake(start time.Time) *Fake {
+	return &Fake{now: start}
+}
+
+type fakeTimer struct {
+	clock  *Fake
+	when   time.Time
+	fn     func()
+	active bool
+}
+
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+func (f *Fake) AfterFunc(d time.Duration, fn func()) Timer {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	t := &fakeTimer{clock: f, when: f.now.Add(d), fn: fn, active: true}
+	f.timers = append(f.timers, t)
+	return t
+}
+
+func (f *Fake) After(d time.Duration) <-chan time.Time {
+	ch := make(chan time.Time, 1)
+	f.AfterFunc(d, func() {
+		ch <- f.Now()
+	})
+	return ch
+}
+
+// Advance moves the clock forward by d, firing every timer that falls due.
+// Timers created by a firing callback also fire if they are due before the
+// new time.
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	target := f.now.Add(d)
+	f.mu.Unlock()
+
+	for {
+		f.mu.Lock()
+		next := f.nextDueLocked(target)
+		if next == nil {
+			f.now = target
+			f.mu.Unlock()
+			return
+		}
+		next.active = false
+		if next.when.After(f.now) {
+			f.now = next.when
+		}
+		f.removeLocked(next)
+		f.mu.Unlock()
+
+		next.fn()
+	}
+}
+
+// Pending returns how many timers are waiting to fire
+func (f *Fake) Pending() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.timers)
+}
+
+// nextDueLocked returns the earliest active timer due at or before target
+func (f *Fake) nextDueLocked(target time.Time) *fakeTimer {
+	sort.SliceStable(f.timers, func(i, j int) bool {
+		return f.timers[i].when.Before(f.timers[j].when)
+	})
+	if len(f.timers) == 0 || f.timers[0].when.After(target) {
+		return nil
+	}
+	return f.timers[0]
+}
+
+func (f *Fake) removeLocked(t *fakeTimer) {
+	for i, candidate := range f.timers {
+		if candidate == t {
+			f.timers = append(f.timers[:i], f.timers[i+1:]...)
+			return
+		}
+	}
+}
+
+func (t *fakeTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	wasActive := t.active
+	t.active = false
+	t.clock.removeLocked(t)
+	return wasActive
+}
+
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	wasActive := t.active
+	t.clock.removeLocked(t)
+	t.when = t.clock.now.Add(d)
+	t.active = true
+	t.clock.timers = append(t.clock.timers, t)
+	return wasActive
+}
@@ -0,0 +1,97 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFakeFiresTimersInOrder(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := NewFake(start)
+
+	var fired []string
+	c.AfterFunc(3*time.Second, func() { fired = append(fired, "c") })
+	c.AfterFunc(1*time.Second, func() { fired = append(fired, "a") })
+	c.AfterFunc(2*time.Second, func() { fired = append(fired, "b") })
+
+	c.Advance(1500 * time.Millisecond)
+	if len(fired) != 1 || fired[0] != "a" {
+		t.Fatalf("after 1.5s fired %v, want [a]", fired)
+	}
+
+	c.Advance(2 * time.Second)
+	if got := len(fired); got != 3 || fired[1] != "b" || fired[2] != "c" {
+		t.Fatalf("after 3.5s fired %v, want [a b c]", fired)
+	}
+	if !c.Now().Equal(start.Add(3500 * time.Millisecond)) {
+		t.Errorf("Now = %v, want start+3.5s", c.Now())
+	}
+	if c.Pending() != 0 {
+		t.Errorf("Pending = %d, want 0", c.Pending())
+	}
+}
+
+func TestFakeStopAndReset(t *testing.T) {
+	c := NewFake(time.Unix(0, 0))
+
+	fired := 0
+	stopped := c.AfterFunc(time.Second, func() { fired++ })
+	if !stopped.Stop() {
+		t.Error("Stop on an active timer returned false")
+	}
+	if stopped.Stop() {
+		t.Error("second Stop returned true")
+	}
+
+	reset := c.AfterFunc(time.Second, func() { fired++ })
+	c.Advance(500 * time.Millisecond)
+	reset.Reset(time.Second) // now due at 1.5s
+
+	c.Advance(700 * time.Millisecond)
+	if fired != 0 {
+		t.Fatalf("timer fired before its reset deadline")
+	}
+	c.Advance(300 * time.Millisecond)
+	if fired != 1 {
+		t.Fatalf("fired = %d, want 1", fired)
+	}
+}
+
+func TestFakeTimerScheduledByCallback(t *testing.T) {
+	c := NewFake(time.Unix(0, 0))
+
+	var firedAt []time.Time
+	c.AfterFunc(time.Second, func() {
+		firedAt = append(firedAt, c.Now())
+		c.AfterFunc(time.Second, func() { firedAt = append(firedAt, c.Now()) })
+	})
+
+	c.Advance(5 * time.Second)
+	if len(firedAt) != 2 {
+		t.Fatalf("fired %d timers, want 2", len(firedAt))
+	}
+	if firedAt[1].Sub(firedAt[0]) != time.Second {
+		t.Errorf("chained timer fired %v after the first, want 1s", firedAt[1].Sub(firedAt[0]))
+	}
+}
+
+func TestFakeAfter(t *testing.T) {
+	c := NewFake(time.Unix(0, 0))
+	ch := c.After(time.Minute)
+
+	select {
+	case <-ch:
+		t.Fatal("After fired before the clock advanced")
+	default:
+	}
+
+	c.Advance(time.Minute)
+	select {
+	case got := <-ch:
+		if !got.Equal(time.Unix(60, 0)) {
+			t.Errorf("After delivered %v, want 1m", got)
+		}
+	default:
+		t.Fatal("After did not fire")
+	}
+}
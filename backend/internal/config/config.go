@@ -2,11 +2,18 @@ package config
 
 import (
 	"log"
+	"log/slog"
+	"net"
+	"net/http"
 	"net/url"
 	"os"
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/iamasit07/connect4/backend/internal/ratelimit"
+	"github.com/iamasit07/connect4/backend/pkg/useragent"
+	"golang.org/x/crypto/bcrypt"
 )
 
 type Config struct {
@@ -23,10 +30,178 @@ type Config struct {
 	JWTSecret            string
 	AccessTokenTTLMinutes int
 	RefreshTokenTTLDays   int
+	GuestSessionTTLHours  int
+	MaxSessionsPerUser    int // devices a user can be signed in on at once; older sessions are signed out
+	TOTPEncryptionKey     string
+	PasswordHashCost      int // bcrypt work factor
+
+	// Game timers
+	TurnTimeout       time.Duration
+	DisconnectTimeout time.Duration
+	PostGameTimeout   time.Duration
+	RematchTimeout    time.Duration
+	DrawOfferTimeout  time.Duration
+	ChallengeTimeout  time.Duration
+
+	// Win-trading detection
+	IntegrityScanInterval time.Duration
+	IntegrityWindow       time.Duration
+	RatedPairDailyLimit   int
+
+	// Engine-assistance analysis
+	EngineAnalysisInterval time.Duration
+	EngineAnalysisDepth    int
+
+	// Account email (SMTP when SMTPHost is set, otherwise written to MailDir or the log)
+	SMTPHost         string
+	SMTPPort         int
+	SMTPUsername     string
+	SMTPPassword     string
+	MailFrom         string
+	MailDir          string
+	EmailVerifyTTL   time.Duration
+	PasswordResetTTL time.Duration
+
+	// Rate limits, keyed "METHOD /route" for HTTP (per client IP) and
+	// "ws:<message type>" for WebSocket messages (per user); "ws:*" covers
+	// message types without their own policy
+	RateLimits    map[string]ratelimit.Policy
+	LoginLockout  ratelimit.Policy // failed logins allowed per account from one client IP before that client is locked out
+	MaxConnsPerIP int              // open WebSockets per client IP
+
+	// Failed logins allowed per account from all clients together. It caps
+	// guessing spread over many addresses; it is kept loose so that no single
+	// client can lock the owner out.
+	LoginAccountLockout ratelimit.Policy
+
+	// Proxies whose ClientIPHeader is believed; it is the only forwarding
+	// header read, so set it to the one the proxy in front of the server sets
+	TrustedProxies []*net.IPNet
+	ClientIPHeader useragent.Header
+
+	// Logging: JSON unless LogFormat is "text"
+	LogLevel  slog.Level
+	LogFormat string
+
+	// OpenTelemetry tracing, exported over OTLP/HTTP (see OTEL_EXPORTER_OTLP_ENDPOINT)
+	TracingEnabled       bool
+	TracingServiceName   string
+	TracingSamplePercent int
 }
 
-var AppConfig *Config
 
+// DefaultRateLimits are the built-in policies; RATE_LIMITS overrides them one by one
+func DefaultRateLimits() map[string]ratelimit.Policy {
+	return map[string]ratelimit.Policy{
+		"POST /api/auth/login":               {Limit: 10, Window: time.Minute},
+		"POST /api/auth/login/2fa":           {Limit: 10, Window: time.Minute},
+		"POST /api/auth/register":            {Limit: 20, Window: time.Hour},
+		"POST /api/auth/guest":               {Limit: 20, Window: time.Hour},
+		"POST /api/auth/refresh":             {Limit: 30, Window: time.Minute},
+		"POST /api/auth/forgot-password":     {Limit: 5, Window: 15 * time.Minute},
+		"POST /api/auth/verify-email/resend": {Limit: 5, Window: 15 * time.Minute},
+		"POST /api/auth/avatar":              {Limit: 10, Window: 10 * time.Minute},
+		"GET /ws":                            {Limit: 30, Window: time.Minute},
+		"ws:find_match":                      {Limit: 5, Window: 15 * time.Second},
+		"ws:chat_message":                    {Limit: 5, Window: 10 * time.Second},
+		"ws:*":                               {Limit: 60, Window: 10 * time.Second},
+	}
+}
+
+// loadRateLimits applies RATE_LIMITS, a comma-separated list of KEY=LIMIT/WINDOW
+// entries (e.g. "POST /api/auth/login=5/1m,ws:make_move=off"), to the defaults
+func loadRateLimits() map[string]ratelimit.Policy {
+	limits := DefaultRateLimits()
+	for _, entry := range strings.Split(GetEnv("RATE_LIMITS", ""), ",") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(entry, "=")
+		if !ok {
+			log.Printf("Invalid RATE_LIMITS entry %q, ignoring", entry)
+			continue
+		}
+		policy, err := ratelimit.ParsePolicy(value)
+		if err != nil {
+			log.Printf("Invalid RATE_LIMITS entry %q: %v, ignoring", entry, err)
+			continue
+		}
+		limits[strings.TrimSpace(key)] = policy
+	}
+	return limits
+}
+
+// defaultTrustedProxies is loopback only. Private ranges aren't trusted by
+// default: with Docker port publishing, direct clients arrive from the bridge
+// gateway and could spoof their address. Proxies elsewhere must be listed in
+// TRUSTED_PROXIES.
+const defaultTrustedProxies = "127.0.0.0/8,::1/128"
+
+// loadTrustedProxies parses TRUSTED_PROXIES, a comma-separated list of CIDRs or
+// single IPs; "none" trusts no proxy, so forwarding headers are ignored
+func loadTrustedProxies() []*net.IPNet {
+	value := GetEnv("TRUSTED_PROXIES", defaultTrustedProxies)
+	if strings.TrimSpace(value) == "none" {
+		return nil
+	}
+	var proxies []*net.IPNet
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		cidr := entry
+		if !strings.Contains(cidr, "/") {
+			if ip := net.ParseIP(cidr); ip != nil && ip.To4() != nil {
+				cidr += "/32"
+			} else {
+				cidr += "/128"
+			}
+		}
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			log.Printf("Invalid TRUSTED_PROXIES entry %q: %v, ignoring", entry, err)
+			continue
+		}
+		proxies = append(proxies, network)
+	}
+	return proxies
+}
+
+// loadClientIPHeader parses CLIENT_IP_HEADER: xff (X-Forwarded-For, the
+// default), forwarded or x-real-ip
+func loadClientIPHeader() useragent.Header {
+	value := GetEnv("CLIENT_IP_HEADER", string(useragent.HeaderXForwardedFor))
+	header, ok := useragent.ParseHeader(value)
+	if !ok {
+		log.Printf("Invalid CLIENT_IP_HEADER %q, using xff", value)
+		return useragent.HeaderXForwardedFor
+	}
+	return header
+}
+
+// ClientIP returns the address of the client that sent r, reading the
+// forwarding header only from the trusted proxies
+func (c *Config) ClientIP(r *http.Request) string {
+	return useragent.ClientIP(r, c.TrustedProxies, c.ClientIPHeader)
+}
+
+// loadLogLevel parses LOG_LEVEL (debug, info, warn or error)
+func loadLogLevel() slog.Level {
+	var level slog.Level
+	value := GetEnv("LOG_LEVEL", "info")
+	if err := level.UnmarshalText([]byte(value)); err != nil {
+		log.Printf("Invalid LOG_LEVEL %q, using info", value)
+		return slog.LevelInfo
+	}
+	return level
+}
+
+// DefaultPasswordHashCost is the bcrypt work factor unless BCRYPT_COST says
+// otherwise. Tests lower it to keep suites fast.
+const DefaultPasswordHashCost = 14
+
+var AppConfig *Config
 func LoadConfig() *Config {
 	port := GetEnv("PORT", "8080")
 	matchmakingTimeoutSec := GetEnvAsInt("MATCHMAKING_TIMEOUT_SECONDS", 300)
@@ -72,23 +247,94 @@ func LoadConfig() *Config {
 	jwtSecret := GetEnv("JWT_SECRET", "your-secret-key-change-this-in-production")
 	accessTokenTTL := GetEnvAsInt("ACCESS_TOKEN_TTL_MINUTES", 15)
 	refreshTokenTTL := GetEnvAsInt("REFRESH_TOKEN_TTL_DAYS", 7)
+	guestSessionTTL := GetEnvAsInt("GUEST_SESSION_TTL_HOURS", 24)
+	maxSessions := GetEnvAsInt("MAX_SESSIONS_PER_USER", 5)
+	if maxSessions < 1 {
+		maxSessions = 1
+	}
+	hashCost := GetEnvAsInt("BCRYPT_COST", DefaultPasswordHashCost)
+	if hashCost < bcrypt.MinCost || hashCost > bcrypt.MaxCost {
+		log.Printf("Invalid BCRYPT_COST %d, using %d", hashCost, DefaultPasswordHashCost)
+		hashCost = DefaultPasswordHashCost
+	}
+
+	// Game timers
+	turnTimeoutSec := GetEnvAsInt("TURN_TIMEOUT_SECONDS", 900)
+	disconnectTimeoutSec := GetEnvAsInt("DISCONNECT_TIMEOUT_SECONDS", 60)
+	postGameTimeoutSec := GetEnvAsInt("POST_GAME_TIMEOUT_SECONDS", 30)
+	rematchTimeoutSec := GetEnvAsInt("REMATCH_TIMEOUT_SECONDS", 10)
+	drawOfferTimeoutSec := GetEnvAsInt("DRAW_OFFER_TIMEOUT_SECONDS", 15)
+	challengeTimeoutSec := GetEnvAsInt("CHALLENGE_TIMEOUT_SECONDS", 30)
+
+	// Win-trading detection
+	integrityScanMin := GetEnvAsInt("INTEGRITY_SCAN_INTERVAL_MINUTES", 60)
+	integrityWindowDays := GetEnvAsInt("INTEGRITY_WINDOW_DAYS", 7)
+	ratedPairDailyLimit := GetEnvAsInt("RATED_PAIR_DAILY_LIMIT", 0)
+
+	// Engine-assistance analysis (depth 7 matches the hard bot)
+	engineAnalysisMin := GetEnvAsInt("ENGINE_ANALYSIS_INTERVAL_MINUTES", 360)
+	engineAnalysisDepth := GetEnvAsInt("ENGINE_ANALYSIS_DEPTH", 7)
+
+	// Account email
+	smtpPort := GetEnvAsInt("SMTP_PORT", 587)
+	emailVerifyHours := GetEnvAsInt("EMAIL_VERIFY_TTL_HOURS", 24)
+	passwordResetMin := GetEnvAsInt("PASSWORD_RESET_TTL_MINUTES", 60)
+
+	// Rate limiting and login lockout
+	loginMaxFailures := GetEnvAsInt("LOGIN_MAX_FAILURES", 5)
+	loginMaxAccountFailures := GetEnvAsInt("LOGIN_MAX_FAILURES_PER_ACCOUNT", 50)
+	loginLockoutMin := GetEnvAsInt("LOGIN_LOCKOUT_MINUTES", 15)
 
 	oauthConfig := LoadOAuthConfig(frontendURL)
 
 	AppConfig = &Config{
-		Port:                  port,
-		MatchmakingTimeout:    time.Duration(matchmakingTimeoutSec) * time.Second,
-		BotToken:              botToken,
-		AllowedOrigins:        allowedOrigins,
-		OAuthConfig:           *oauthConfig,
-		DatabaseURL:           dbURL,
-		DBMaxOpenConns:        dbMaxOpenConns,
-		DBMaxIdleConns:        dbMaxIdleConns,
-		DBConnMaxLifetimeMin:  dbConnMaxLifetimeMin,
-		FrontendURL:           frontendURL,
-		JWTSecret:             jwtSecret,
-		AccessTokenTTLMinutes: accessTokenTTL,
-		RefreshTokenTTLDays:   refreshTokenTTL,
+		Port:                   port,
+		MatchmakingTimeout:     time.Duration(matchmakingTimeoutSec) * time.Second,
+		BotToken:               botToken,
+		AllowedOrigins:         allowedOrigins,
+		OAuthConfig:            *oauthConfig,
+		DatabaseURL:            dbURL,
+		DBMaxOpenConns:         dbMaxOpenConns,
+		DBMaxIdleConns:         dbMaxIdleConns,
+		DBConnMaxLifetimeMin:   dbConnMaxLifetimeMin,
+		FrontendURL:            frontendURL,
+		JWTSecret:              jwtSecret,
+		AccessTokenTTLMinutes:  accessTokenTTL,
+		RefreshTokenTTLDays:    refreshTokenTTL,
+		GuestSessionTTLHours:   guestSessionTTL,
+		MaxSessionsPerUser:     maxSessions,
+		TOTPEncryptionKey:      GetEnv("TOTP_ENCRYPTION_KEY", ""),
+		PasswordHashCost:       hashCost,
+		TurnTimeout:            time.Duration(turnTimeoutSec) * time.Second,
+		DisconnectTimeout:      time.Duration(disconnectTimeoutSec) * time.Second,
+		PostGameTimeout:        time.Duration(postGameTimeoutSec) * time.Second,
+		RematchTimeout:         time.Duration(rematchTimeoutSec) * time.Second,
+		DrawOfferTimeout:       time.Duration(drawOfferTimeoutSec) * time.Second,
+		ChallengeTimeout:       time.Duration(challengeTimeoutSec) * time.Second,
+		IntegrityScanInterval:  time.Duration(integrityScanMin) * time.Minute,
+		IntegrityWindow:        time.Duration(integrityWindowDays) * 24 * time.Hour,
+		RatedPairDailyLimit:    ratedPairDailyLimit,
+		EngineAnalysisInterval: time.Duration(engineAnalysisMin) * time.Minute,
+		EngineAnalysisDepth:    engineAnalysisDepth,
+		SMTPHost:               GetEnv("SMTP_HOST", ""),
+		SMTPPort:               smtpPort,
+		SMTPUsername:           GetEnv("SMTP_USERNAME", ""),
+		SMTPPassword:           GetEnv("SMTP_PASSWORD", ""),
+		MailFrom:               GetEnv("MAIL_FROM", "Connect 4 <no-reply@connect4.iamasit07.me>"),
+		MailDir:                GetEnv("MAIL_DIR", ""),
+		EmailVerifyTTL:         time.Duration(emailVerifyHours) * time.Hour,
+		PasswordResetTTL:       time.Duration(passwordResetMin) * time.Minute,
+		RateLimits:             loadRateLimits(),
+		LoginLockout:           ratelimit.Policy{Limit: loginMaxFailures, Window: time.Duration(loginLockoutMin) * time.Minute},
+		LoginAccountLockout:    ratelimit.Policy{Limit: loginMaxAccountFailures, Window: time.Duration(loginLockoutMin) * time.Minute},
+		MaxConnsPerIP:          GetEnvAsInt("WS_MAX_CONNS_PER_IP", 5),
+		TrustedProxies:         loadTrustedProxies(),
+		ClientIPHeader:         loadClientIPHeader(),
+		LogLevel:               loadLogLevel(),
+		LogFormat:              GetEnv("LOG_FORMAT", "json"),
+		TracingEnabled:         GetEnv("TRACING_ENABLED", "false") == "true",
+		TracingServiceName:     GetEnv("OTEL_SERVICE_NAME", "connect4-backend"),
+		TracingSamplePercent:   GetEnvAsInt("TRACING_SAMPLE_PERCENT", 100),
 	}
 
 	return AppConfig
@@ -1,68 +1,91 @@
 package config
 
 import (
-	"encoding/json"
-	"fmt"
-	"net/http"
+	"log"
 	"os"
+	"regexp"
+	"strings"
+)
 
-	"golang.org/x/oauth2"
-	"golang.org/x/oauth2/google"
+// OAuth provider kinds. Google, GitHub and Discord have fixed endpoints; OIDC
+// providers are found through their issuer's discovery document.
+const (
+	OAuthKindGoogle  = "google"
+	OAuthKindGitHub  = "github"
+	OAuthKindDiscord = "discord"
+	OAuthKindOIDC    = "oidc"
 )
 
-type OAuthConfig struct {
-	GoogleLoginConfig *oauth2.Config
+// OAuthProvider configures one external login provider
+type OAuthProvider struct {
+	Name         string // route segment and identity key, e.g. "github"
+	DisplayName  string
+	Kind         string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string   // defaults to RedirectBaseURL plus the provider's callback route
+	Issuer       string   // OIDC only
+	Scopes       []string // OIDC only; defaults to openid, email and profile
 }
 
-type GoogleUser struct {
-	ID            string `json:"id"`
-	Email         string `json:"email"`
-	VerifiedEmail bool   `json:"verified_email"`
-	Name          string `json:"name"`
-	Picture       string `json:"picture"`
+type OAuthConfig struct {
+	RedirectBaseURL string // origin providers send users back to; the frontend proxies /api
+	Providers       []OAuthProvider
 }
 
-var GoogleOAuthConfig *oauth2.Config
+var oidcNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
 
+// LoadOAuthConfig enables each built-in provider whose client ID is set, plus
+// the OIDC providers listed in OIDC_PROVIDERS
 func LoadOAuthConfig(frontendURL string) *OAuthConfig {
-	clientID := os.Getenv("GOOGLE_CLIENT_ID")
-	clientSecret := os.Getenv("GOOGLE_CLIENT_SECRET")
-	redirectURL := os.Getenv("GOOGLE_REDIRECT_URL")
-	
-	if redirectURL == "" {
-		redirectURL = frontendURL + "/api/auth/google/callback"
-	}
+	cfg := &OAuthConfig{RedirectBaseURL: GetEnv("OAUTH_REDIRECT_BASE_URL", frontendURL)}
 
-	config := &oauth2.Config{
-		RedirectURL:  redirectURL,
-		ClientID:     clientID,
-		ClientSecret: clientSecret,
-		Scopes: []string{
-			"https://www.googleapis.com/auth/userinfo.email",
-			"https://www.googleapis.com/auth/userinfo.profile",
-		},
-		Endpoint: google.Endpoint,
+	builtin := []struct{ name, display, env string }{
+		{OAuthKindGoogle, "Google", "GOOGLE"},
+		{OAuthKindGitHub, "GitHub", "GITHUB"},
+		{OAuthKindDiscord, "Discord", "DISCORD"},
 	}
-	
-	// Keep global for backward compatibility if needed, but return struct
-	GoogleOAuthConfig = config
-	
-	return &OAuthConfig{
-		GoogleLoginConfig: config,
-	}
-}
-
-func GetGoogleUserInfo(accessToken string) (*GoogleUser, error) {
-	resp, err := http.Get("https://www.googleapis.com/oauth2/v2/userinfo?access_token=" + accessToken)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get user info: %v", err)
+	for _, b := range builtin {
+		clientID := os.Getenv(b.env + "_CLIENT_ID")
+		if clientID == "" {
+			continue
+		}
+		cfg.Providers = append(cfg.Providers, OAuthProvider{
+			Name:         b.name,
+			DisplayName:  b.display,
+			Kind:         b.name,
+			ClientID:     clientID,
+			ClientSecret: os.Getenv(b.env + "_CLIENT_SECRET"),
+			RedirectURL:  os.Getenv(b.env + "_REDIRECT_URL"),
+		})
 	}
-	defer resp.Body.Close()
 
-	var user GoogleUser
-	if err := json.NewDecoder(resp.Body).Decode(&user); err != nil {
-		return nil, fmt.Errorf("failed to decode user info: %v", err)
+	for _, name := range strings.Split(os.Getenv("OIDC_PROVIDERS"), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if !oidcNamePattern.MatchString(name) || name == OAuthKindGoogle || name == OAuthKindGitHub || name == OAuthKindDiscord {
+			log.Printf("[OAUTH] Skipping OIDC provider %q: invalid or reserved name", name)
+			continue
+		}
+		prefix := "OIDC_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+		provider := OAuthProvider{
+			Name:         name,
+			DisplayName:  GetEnv(prefix+"DISPLAY_NAME", name),
+			Kind:         OAuthKindOIDC,
+			ClientID:     os.Getenv(prefix + "CLIENT_ID"),
+			ClientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+			RedirectURL:  os.Getenv(prefix + "REDIRECT_URL"),
+			Issuer:       strings.TrimRight(os.Getenv(prefix+"ISSUER"), "/"),
+			Scopes:       strings.Fields(strings.ReplaceAll(os.Getenv(prefix+"SCOPES"), ",", " ")),
+		}
+		if provider.Issuer == "" || provider.ClientID == "" {
+			log.Printf("[OAUTH] Skipping OIDC provider %q: %sISSUER and %sCLIENT_ID are required", name, prefix, prefix)
+			continue
+		}
+		cfg.Providers = append(cfg.Providers, provider)
 	}
 
-	return &user, nil
+	return cfg
 }
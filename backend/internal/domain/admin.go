@@ -0,0 +1,27 @@
+package domain
+
+import "time"
+
+// Admin actions recorded in the moderation audit log
+const (
+	AdminActionBan           = "ban"
+	AdminActionSuspend       = "suspend"
+	AdminActionUnban         = "unban"
+	AdminActionSetRole       = "set_role"
+	AdminActionTerminateGame = "terminate_game"
+	AdminActionVoidGame      = "void_game"
+	AdminActionReviewFlag    = "review_flag"
+)
+
+// AdminAction is one entry in the moderation audit log
+type AdminAction struct {
+	ID            int64     `json:"id"`
+	AdminID       int64     `json:"adminId"`
+	AdminUsername string    `json:"adminUsername"`
+	Action        string    `json:"action"`
+	TargetUserID  *int64    `json:"targetUserId,omitempty"`
+	TargetGameID  string    `json:"targetGameId,omitempty"`
+	Reason        string    `json:"reason,omitempty"`
+	Details       string    `json:"details,omitempty"` // e.g. suspension end or new role
+	CreatedAt     time.Time `json:"createdAt"`
+}
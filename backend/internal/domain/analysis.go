@@ -0,0 +1,55 @@
+package domain
+
+import "time"
+
+// MoveRecord is one move as played, kept so finished games can be replayed
+type MoveRecord struct {
+	Column  int      `json:"column"`
+	Player  PlayerID `json:"player"`
+	ThinkMs int64    `json:"thinkMs"` // time since the previous move (or game start)
+}
+
+// RecordedGame is a finished game's move list, as the engine analyser replays it
+type RecordedGame struct {
+	GameID        string
+	Player1ID     int64
+	Player2ID     *int64 // nil for bot games
+	Player1Rating int    // ratings going into the game
+	Player2Rating int
+	FinishedAt    time.Time
+	Moves         []MoveRecord
+}
+
+// PlayerGameAnalysis compares one player's moves in one game with the solver.
+// Opening moves and positions where every move scores the same are skipped.
+type PlayerGameAnalysis struct {
+	GameID        string
+	UserID        int64
+	Rating        int // the player's rating going into the game
+	Moves         int // moves compared with the solver
+	EngineMatches int // moves that matched the solver's best score
+	TotalLoss     int // sum of (best score - played score), capped per move
+	ThinkMeanMs   float64
+	ThinkStdDevMs float64
+	FinishedAt    time.Time
+}
+
+// BandStats totals the analysed moves of every player in a rating band
+type BandStats struct {
+	Players       int
+	Moves         int
+	EngineMatches int
+	TotalLoss     int
+}
+
+// EngineEvidence is attached to engine-assistance flags for the reviewing admin
+type EngineEvidence struct {
+	Games         int      `json:"games"`
+	Moves         int      `json:"moves"`
+	MatchRate     float64  `json:"matchRate"`
+	AvgLoss       float64  `json:"avgLoss"`
+	BandMatchRate float64  `json:"bandMatchRate"`
+	BandAvgLoss   float64  `json:"bandAvgLoss"`
+	ThinkTimeCV   float64  `json:"thinkTimeCv"` // move-time standard deviation / mean; low means machine-like
+	GameIDs       []string `json:"gameIds"`
+}
@@ -0,0 +1,46 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// API key scopes. A key only reaches the routes its scopes allow.
+const (
+	ScopeRead    = "read"    // profile, friends and live games
+	ScopeHistory = "history" // game history and replays
+	ScopePlay    = "play"    // the WebSocket: matchmaking and moves
+)
+
+// APIKeyScopes lists every scope a key can be given
+var APIKeyScopes = []string{ScopeRead, ScopeHistory, ScopePlay}
+
+// APIKey is a personal key for scripted access to the API. Only a keyed hash
+// of the key is stored; Prefix is kept so users can tell their keys apart.
+type APIKey struct {
+	ID         int64      `json:"id"`
+	UserID     int64      `json:"-"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	KeyHash    string     `json:"-"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at"` // nil when the key never expires
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// HasScope reports whether the key was granted scope
+func (k *APIKey) HasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// SessionID names the WebSocket device a key connects as, so each key is
+// routed like a separate signed-in device
+func (k *APIKey) SessionID() string {
+	return fmt.Sprintf("apikey:%d", k.ID)
+}
@@ -0,0 +1,34 @@
+package domain
+
+import "time"
+
+// Chat channels within a game. Players write to ChatChannelPlayers (visible to
+// players and spectators); spectators write to ChatChannelSpectators, which
+// players never see so spectators can't coach them.
+const (
+	ChatChannelPlayers    = "players"
+	ChatChannelSpectators = "spectators"
+)
+
+// ChatMessage is a single in-game chat line
+type ChatMessage struct {
+	ID             int64     `json:"id"`
+	GameID         string    `json:"gameId"`
+	Channel        string    `json:"channel"`
+	SenderID       int64     `json:"senderId"`
+	SenderUsername string    `json:"senderUsername"`
+	Text           string    `json:"text"`
+	CreatedAt      time.Time `json:"createdAt"`
+}
+
+// ChatReport is a player's report of an abusive chat message, kept for moderators
+type ChatReport struct {
+	ID             int64     `json:"id"`
+	GameID         string    `json:"gameId"`
+	MessageID      int64     `json:"messageId"`
+	ReporterID     int64     `json:"reporterId"`
+	ReportedUserID int64     `json:"reportedUserId"`
+	MessageText    string    `json:"messageText"`
+	Reason         string    `json:"reason"`
+	CreatedAt      time.Time `json:"createdAt"`
+}
@@ -0,0 +1,57 @@
+package domain
+
+import "time"
+
+// Friendship row states. A row is directed: UserID sent the request (pending,
+// accepted) or placed the block (blocked).
+const (
+	FriendshipPending  = "pending"
+	FriendshipAccepted = "accepted"
+	FriendshipBlocked  = "blocked"
+)
+
+// Friendship is a single row of the friendships table
+type Friendship struct {
+	UserID   int64
+	FriendID int64
+	Status   string
+	// BlockSpectating is set on blocked rows when the blocker also hides
+	// their live games from the blocked user
+	BlockSpectating bool
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
+
+// Friend relation as seen by one user
+const (
+	FriendAccepted = "accepted" // mutual friends
+	FriendIncoming = "incoming" // they sent you a request
+	FriendOutgoing = "outgoing" // you sent them a request
+	FriendBlocked  = "blocked"  // you blocked them
+)
+
+// Friend is an entry in a user's friends list
+type Friend struct {
+	UserID          int64     `json:"userId"`
+	Username        string    `json:"username"`
+	AvatarURL       string    `json:"avatarUrl"`
+	Relation        string    `json:"relation"`
+	Presence        string    `json:"presence,omitempty"`        // only set for accepted friends
+	BlockSpectating bool      `json:"blockSpectating,omitempty"` // only set for blocked users
+	Since           time.Time `json:"since"`
+}
+
+// FriendRelation maps a friendship row status onto the relation seen by one
+// side; outgoing is true when that side is the row's UserID.
+func FriendRelation(status string, outgoing bool) string {
+	switch status {
+	case FriendshipAccepted:
+		return FriendAccepted
+	case FriendshipBlocked:
+		return FriendBlocked
+	}
+	if outgoing {
+		return FriendOutgoing
+	}
+	return FriendIncoming
+}
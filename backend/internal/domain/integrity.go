@@ -0,0 +1,62 @@
+package domain
+
+import "time"
+
+// Integrity flag review states
+const (
+	FlagOpen      = "open"
+	FlagDismissed = "dismissed"
+	FlagConfirmed = "confirmed"
+)
+
+// What an integrity flag is about
+const (
+	FlagKindWinTrading = "win_trading"
+	FlagKindEngine     = "engine_assistance"
+)
+
+// Signals the win-trading detector scores a pairing on
+const (
+	SignalRepeatedPairing = "repeated_pairing"
+	SignalQuickSurrenders = "quick_surrenders"
+	SignalLopsided        = "lopsided_results"
+	SignalSharedIP        = "shared_ip"
+)
+
+// Signals the engine analyser scores a player on
+const (
+	SignalEngineMatch      = "engine_match"
+	SignalLowMoveLoss      = "low_move_loss"
+	SignalConsistentTiming = "consistent_move_times"
+)
+
+// IntegrityFlag puts an account in the admin review queue. For win trading
+// UserID is the player who gained from the pairing with OpponentID; engine
+// flags have no opponent and carry the analysis as Evidence.
+type IntegrityFlag struct {
+	ID               int64           `json:"id"`
+	Kind             string          `json:"kind"`
+	UserID           int64           `json:"userId"`
+	Username         string          `json:"username"`
+	OpponentID       int64           `json:"opponentId,omitempty"`
+	OpponentUsername string          `json:"opponentUsername,omitempty"`
+	Score            int             `json:"score"`
+	Signals          []string        `json:"signals"`
+	Games            int             `json:"games"` // games the flag is based on
+	Evidence         *EngineEvidence `json:"evidence,omitempty"`
+	Status           string          `json:"status"`
+	ReviewedBy       *int64          `json:"reviewedBy,omitempty"`
+	CreatedAt        time.Time       `json:"createdAt"`
+	UpdatedAt        time.Time       `json:"updatedAt"`
+}
+
+// EngineAnalysisRun reports the latest engine-analysis run. Error is set when
+// a finished run failed.
+type EngineAnalysisRun struct {
+	ID         int        `json:"id"`
+	Running    bool       `json:"running"`
+	Flagged    int        `json:"flagged"`
+	Error      string     `json:"error,omitempty"`
+	StartedAt  time.Time  `json:"startedAt"`
+	FinishedAt *time.Time `json:"finishedAt,omitempty"`
+}
@@ -1,17 +1,27 @@
 package domain
 
-type ClientMessage struct {
-	Type            string `json:"type"`
-	JWT             string `json:"jwt"` // JWT token for authentication
-	GameID          string `json:"gameId,omitempty"`
-	Column          int    `json:"column,omitempty"`
-	Difficulty      string `json:"difficulty,omitempty"` // Bot difficulty: "easy", "medium", "hard"
-	RequestRematch  bool   `json:"requestRematch,omitempty"`
-	RematchResponse string `json:"rematchResponse,omitempty"` // "accept" or "decline"
-}
+// ErrorCode says why the server answered a message with an error
+type ErrorCode string
+
+const (
+	ErrInvalidJSON        ErrorCode = "invalid_json"        // the frame is not a JSON message
+	ErrUnknownType        ErrorCode = "unknown_type"        // no such message type
+	ErrInvalidPayload     ErrorCode = "invalid_payload"     // fields missing, of the wrong type or out of range
+	ErrUnsupportedVersion ErrorCode = "unsupported_version" // init offered no protocol version the server speaks
+	ErrUnauthorized       ErrorCode = "unauthorized"        // bad token, or the session was revoked
+	ErrRateLimited        ErrorCode = "rate_limited"        // wait retryAfter seconds
+	ErrNotFound           ErrorCode = "not_found"           // the game or challenge doesn't exist (any more)
+	ErrForbidden          ErrorCode = "forbidden"           // not allowed for this user, e.g. blocked or a guest
+	ErrRejected           ErrorCode = "rejected"            // valid, but not possible in the current state (e.g. not your turn)
+	ErrInternal           ErrorCode = "internal"            // the server failed
+)
 
+// ServerMessage is everything the server sends, with the fields of every
+// type in one struct. Protocol version 1 writes it as is; version 2 keeps only
+// the fields of each type's payload (see the protocol package).
 type ServerMessage struct {
 	Type             string       `json:"type"`
+	Code             ErrorCode    `json:"code,omitempty"` // Why an error was sent
 	Message          string       `json:"message,omitempty"`
 	GameID           string       `json:"gameId,omitempty"`
 	Opponent         string       `json:"opponent,omitempty"`
@@ -35,9 +45,17 @@ type ServerMessage struct {
 	RematchTimeout   int          `json:"rematchTimeout,omitempty"`   // seconds remaining to respond
 	AllowRematch     *bool        `json:"allowRematch,omitempty"`     // Controls if rematch button shows (pointer for explicit false)
 	DisconnectTimeout int         `json:"disconnectTimeout,omitempty"` // Seconds until forfeit on disconnect
-}
-
-type ErrorMessage struct {
-	Type    string `json:"type"`
-	Message string `json:"message"`
+	DrawOfferer      string       `json:"drawOfferer,omitempty"`      // username who offered a draw
+	DrawTimeout      int          `json:"drawTimeout,omitempty"`      // seconds remaining to answer a draw offer
+	Rated            *bool        `json:"rated,omitempty"`            // Whether the game affects ratings (pointer for explicit false)
+	Chat             *ChatMessage  `json:"chat,omitempty"`             // New chat line (chat_message)
+	ChatHistory      []ChatMessage `json:"chatHistory,omitempty"`      // Visible chat so far (game_state, spectate_start)
+	UserID           int64         `json:"userId,omitempty"`           // Subject of user_muted, presence_update, friend and challenge events
+	Username         string        `json:"username,omitempty"`         // Username of UserID where the client may not know it
+	Presence         string        `json:"presence,omitempty"`         // offline, online, in_queue, playing, spectating
+	ChallengeID      string        `json:"challengeId,omitempty"`
+	ChallengeTimeout int           `json:"challengeTimeout,omitempty"` // seconds until a challenge expires
+	RetryAfter       int           `json:"retryAfter,omitempty"`       // seconds to wait after being rate limited
+	Version          int           `json:"version,omitempty"`          // Protocol version agreed in welcome
+	Versions         []int         `json:"versions,omitempty"`         // Protocol versions the server speaks (welcome, unsupported_version)
 }
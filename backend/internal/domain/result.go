@@ -0,0 +1,52 @@
+package domain
+
+import "time"
+
+// GameResult represents the result of a finished game
+type GameResult struct {
+	GameID          string
+	Player1ID       int64 // 0 once a deleted guest's account is gone
+	Player1Username string
+	Player2ID       *int64
+	Player2Username string
+	WinnerID        *int64
+	WinnerUsername  string
+	Reason          string
+	TotalMoves      int
+	DurationSeconds int
+	CreatedAt       time.Time
+	FinishedAt      time.Time
+	Rated           bool
+
+	// Rating change applied to each player, kept so the game can be voided
+	Player1RatingChange int
+	Player2RatingChange int
+	Voided              bool
+}
+
+// PlayerOutcome is one player's result ("won", "lost", "draw") and rating after a game
+type PlayerOutcome struct {
+	Result    string
+	NewRating int
+}
+
+// ScoreGame works out both players' results and new Elo ratings for a finished game.
+// Bot opponents should pass a rating of 1000. Unrated games keep the current ratings.
+func ScoreGame(player1ID int64, p1Rating, p2Rating int, winnerID *int64, reason string, rated bool) (p1, p2 PlayerOutcome) {
+	if IsDrawReason(reason) {
+		p1 = PlayerOutcome{Result: "draw", NewRating: CalculateElo(p1Rating, p2Rating, 0.5)}
+		p2 = PlayerOutcome{Result: "draw", NewRating: CalculateElo(p2Rating, p1Rating, 0.5)}
+	} else if winnerID != nil && *winnerID == player1ID {
+		p1 = PlayerOutcome{Result: "won", NewRating: CalculateElo(p1Rating, p2Rating, 1.0)}
+		p2 = PlayerOutcome{Result: "lost", NewRating: CalculateElo(p2Rating, p1Rating, 0.0)}
+	} else {
+		p1 = PlayerOutcome{Result: "lost", NewRating: CalculateElo(p1Rating, p2Rating, 0.0)}
+		p2 = PlayerOutcome{Result: "won", NewRating: CalculateElo(p2Rating, p1Rating, 1.0)}
+	}
+
+	if !rated {
+		p1.NewRating = p1Rating
+		p2.NewRating = p2Rating
+	}
+	return p1, p2
+}
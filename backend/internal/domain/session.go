@@ -24,3 +24,21 @@ type RefreshToken struct {
 	CreatedAt time.Time `json:"created_at"`
 	Revoked   bool      `json:"revoked"`
 }
+
+// Purposes of single-use account tokens
+const (
+	TokenVerifyEmail   = "verify_email"
+	TokenResetPassword = "reset_password"
+)
+
+// AccountToken is a single-use email verification or password reset token.
+// Only a keyed hash of the token is stored, never the token itself.
+type AccountToken struct {
+	ID        int64
+	UserID    int64
+	Purpose   string
+	TokenHash string
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
@@ -25,6 +25,16 @@ func IsBotName(username string) bool {
 	return false
 }
 
+// ReasonTerminated ends a game stopped by a moderator. It is saved for the
+// record but counts towards neither stats nor ratings.
+const ReasonTerminated = "terminated"
+
+// IsDrawReason reports whether a game end reason counts as a draw for
+// stats and rating purposes ("draw" is a full board, "agreement" a mutual draw)
+func IsDrawReason(reason string) bool {
+	return reason == "draw" || reason == "agreement"
+}
+
 type PlayerID int
 
 const (
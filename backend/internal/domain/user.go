@@ -0,0 +1,94 @@
+package domain
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Account roles. Admins can reach the moderation API.
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+// GuestUsernamePrefix starts every temporary guest username. Registered
+// usernames may not use it.
+const GuestUsernamePrefix = "guest_"
+
+type User struct {
+	ID           int64
+	Username     string
+	Name         string
+	AvatarURL    string
+	Email        sql.NullString
+	IsVerified   bool
+	PasswordHash string
+	GamesPlayed  int
+	GamesWon     int
+	GamesDrawn   int
+	Rating       int
+	CreatedAt    time.Time
+
+	Role           string
+	Banned         bool
+	SuspendedUntil sql.NullTime
+
+	// Guests play without registering until they upgrade to a full account
+	IsGuest bool
+
+	TOTPEnabled bool
+}
+
+// TwoFactor is a user's TOTP state
+type TwoFactor struct {
+	Secret        string   // AES-GCM encrypted; "" when not enrolled
+	Enabled       bool     // false while enrollment awaits its first code
+	RecoveryCodes []string // keyed hashes of the unused recovery codes
+	LastStep      int64    // last accepted TOTP time step, so a code can't be replayed
+}
+
+// UserIdentity links a player to an account at an external login provider
+type UserIdentity struct {
+	ID        int64     `json:"-"`
+	UserID    int64     `json:"-"`
+	Provider  string    `json:"provider"` // provider name from the registry, e.g. "google" or "github"
+	Subject   string    `json:"-"`        // the provider's stable user ID
+	Email     string    `json:"email"`    // as reported by the provider when linked
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// IsRestricted reports whether the account is banned or suspended at now
+func (u *User) IsRestricted(now time.Time) bool {
+	return u.Banned || (u.SuspendedUntil.Valid && now.Before(u.SuspendedUntil.Time))
+}
+
+type PlayerStats struct {
+	Rank     int    `json:"rank"`
+	Username string `json:"username"`
+	Rating   int    `json:"rating"`
+	Wins     int    `json:"wins"`
+	Losses   int    `json:"losses"`
+}
+
+// UserResponse returns a consistent JSON-friendly map of user data
+func (u *User) UserResponse() map[string]interface{} {
+	email := ""
+	if u.Email.Valid {
+		email = u.Email.String
+	}
+	return map[string]interface{}{
+		"id":          u.ID,
+		"username":    u.Username,
+		"name":        u.Name,
+		"avatar_url":  u.AvatarURL,
+		"email":       email,
+		"is_verified": u.IsVerified,
+		"rating":      u.Rating,
+		"wins":        u.GamesWon,
+		"losses":      u.GamesPlayed - u.GamesWon - u.GamesDrawn,
+		"draws":       u.GamesDrawn,
+		"role":        u.Role,
+		"is_guest":    u.IsGuest,
+		"two_factor":  u.TOTPEnabled,
+	}
+}
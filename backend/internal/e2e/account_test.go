@@ -0,0 +1,136 @@
+package e2e
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/iamasit07/connect4/backend/internal/client"
+)
+
+var mailTokenPattern = regexp.MustCompile(`token=([0-9a-f]+)`)
+
+// mailedTokens returns the link tokens mailed to `to` whose subject contains subject, oldest first
+func mailedTokens(t *testing.T, ts *testServer, to, subject string) []string {
+	t.Helper()
+	files, err := filepath.Glob(filepath.Join(ts.MailDir, "*_"+strings.ReplaceAll(to, "@", "_at_")+".eml"))
+	must(t, err)
+	sort.Strings(files)
+
+	var tokens []string
+	for _, f := range files {
+		data, err := os.ReadFile(f)
+		must(t, err)
+		if !strings.Contains(string(data), "Subject: "+subject) {
+			continue
+		}
+		if m := mailTokenPattern.FindStringSubmatch(string(data)); m != nil {
+			tokens = append(tokens, m[1])
+		}
+	}
+	return tokens
+}
+
+// awaitMail waits for the nth (1-based) matching email and returns its token
+func awaitMail(t *testing.T, ts *testServer, to, subject string, n int) string {
+	t.Helper()
+	var tokens []string
+	eventually(t, "email to "+to, func() bool {
+		tokens = mailedTokens(t, ts, to, subject)
+		return len(tokens) >= n
+	})
+	return tokens[n-1]
+}
+
+func isVerified(t *testing.T, c *client.Client) bool {
+	t.Helper()
+	var me struct {
+		IsVerified bool `json:"is_verified"`
+	}
+	must(t, c.GetJSON("/api/auth/me", &me))
+	return me.IsVerified
+}
+
+func TestEmailVerification(t *testing.T) {
+	ts := newTestServer(t)
+	p := ts.player(t)
+	email := p.Username + "@example.com"
+
+	first := awaitMail(t, ts, email, "Verify", 1)
+	if isVerified(t, p) {
+		t.Fatal("new account is already verified")
+	}
+
+	// A resend replaces the earlier link
+	must(t, p.ResendVerification())
+	second := awaitMail(t, ts, email, "Verify", 2)
+	if err := p.VerifyEmail(first); err == nil || !strings.Contains(err.Error(), "400") {
+		t.Errorf("superseded token error = %v, want 400", err)
+	}
+
+	must(t, p.VerifyEmail(second))
+	if !isVerified(t, p) {
+		t.Error("email not verified")
+	}
+	if err := p.VerifyEmail(second); err == nil || !strings.Contains(err.Error(), "400") {
+		t.Errorf("reused token error = %v, want 400", err)
+	}
+	if err := p.ResendVerification(); err == nil || !strings.Contains(err.Error(), "409") {
+		t.Errorf("resend after verification error = %v, want 409", err)
+	}
+}
+
+func TestPasswordReset(t *testing.T) {
+	ts := newTestServer(t)
+	p := ts.player(t)
+	email := p.Username + "@example.com"
+
+	// Unknown emails get the same answer and no mail
+	must(t, p.ForgotPassword("nobody@example.com"))
+	must(t, p.ForgotPassword(email))
+	token := awaitMail(t, ts, email, "Reset", 1)
+	if len(mailedTokens(t, ts, "nobody@example.com", "Reset")) != 0 {
+		t.Error("reset mail sent to an unknown address")
+	}
+
+	if err := p.ResetPassword(token, "weak"); err == nil || !strings.Contains(err.Error(), "400") {
+		t.Errorf("weak password error = %v, want 400", err)
+	}
+	const newPassword = "N3wPassw0rd!"
+	must(t, p.ResetPassword(token, newPassword))
+
+	// Every session ends
+	expect(t, p, "force_disconnect")
+	if _, err := p.ListFriends(); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("old token after reset: %v, want 401", err)
+	}
+	if err := p.Login(p.Username, testPassword); err == nil {
+		t.Error("old password still works")
+	}
+	must(t, p.Login(p.Username, newPassword))
+	if !isVerified(t, p) {
+		t.Error("reset didn't mark the email verified")
+	}
+
+	if err := p.ResetPassword(token, "An0therPassw0rd!"); err == nil || !strings.Contains(err.Error(), "400") {
+		t.Errorf("reused reset token error = %v, want 400", err)
+	}
+}
+
+func TestPasswordResetExpires(t *testing.T) {
+	ts := newTestServer(t)
+	p := ts.player(t)
+	email := p.Username + "@example.com"
+
+	must(t, p.ForgotPassword(email))
+	token := awaitMail(t, ts, email, "Reset", 1)
+	ts.Clock.Advance(61 * time.Minute)
+	if err := p.ResetPassword(token, "N3wPassw0rd!"); err == nil || !strings.Contains(err.Error(), "400") {
+		t.Errorf("expired token error = %v, want 400", err)
+	}
+	must(t, p.Login(p.Username, testPassword))
+}
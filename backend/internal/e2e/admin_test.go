@@ -0,0 +1,155 @@
+package e2e
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/iamasit07/connect4/backend/internal/client"
+	"github.com/iamasit07/connect4/backend/internal/domain"
+)
+
+// admin registers a player and promotes them to admin
+func (ts *testServer) admin(t *testing.T) *client.Client {
+	t.Helper()
+	c := ts.player(t)
+	must(t, ts.Users.SetUserRole(c.UserID, domain.RoleAdmin))
+	return c
+}
+
+func TestAdminRoutesRequireRole(t *testing.T) {
+	ts := newTestServer(t)
+	p := ts.player(t)
+
+	if _, err := p.AdminActions(10); err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("non-admin actions error = %v, want 403", err)
+	}
+	if err := p.BanUser(p.UserID, "nope"); err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("non-admin ban error = %v, want 403", err)
+	}
+
+	// Demotion applies to the next request
+	a := ts.admin(t)
+	_, err := a.AdminActions(10)
+	must(t, err)
+	must(t, ts.Users.SetUserRole(a.UserID, domain.RoleUser))
+	if _, err := a.AdminActions(10); err == nil {
+		t.Error("demoted admin still reached the admin API")
+	}
+}
+
+func TestAdminBanRevokesSessions(t *testing.T) {
+	ts := newTestServer(t)
+	a, p := ts.admin(t), ts.player(t)
+
+	must(t, a.BanUser(p.UserID, "cheating"))
+	expect(t, p, "force_disconnect")
+	if _, err := p.ListFriends(); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("banned user's token still works: %v", err)
+	}
+	if err := p.Login(p.Username, testPassword); err == nil || !strings.Contains(err.Error(), "banned") {
+		t.Errorf("banned login error = %v", err)
+	}
+	if err := a.BanUser(a.UserID, "oops"); err == nil || !strings.Contains(err.Error(), "400") {
+		t.Errorf("self-ban error = %v, want 400", err)
+	}
+
+	must(t, a.UnbanUser(p.UserID, "appeal accepted"))
+	must(t, p.Login(p.Username, testPassword))
+
+	actions, err := a.AdminActions(10)
+	must(t, err)
+	if len(actions) != 2 || actions[0].Action != domain.AdminActionUnban || actions[1].Action != domain.AdminActionBan {
+		t.Fatalf("audit log = %+v, want unban then ban", actions)
+	}
+	ban := actions[1]
+	if ban.AdminID != a.UserID || ban.AdminUsername != a.Username || ban.TargetUserID == nil || *ban.TargetUserID != p.UserID || ban.Reason != "cheating" {
+		t.Errorf("ban entry = %+v", ban)
+	}
+}
+
+func TestAdminSuspend(t *testing.T) {
+	ts := newTestServer(t)
+	a, p := ts.admin(t), ts.player(t)
+
+	if err := a.SuspendUser(p.UserID, 0, "spam"); err == nil || !strings.Contains(err.Error(), "400") {
+		t.Errorf("zero-length suspension error = %v, want 400", err)
+	}
+	must(t, a.SuspendUser(p.UserID, 24, "spam"))
+	expect(t, p, "force_disconnect")
+	if err := p.Login(p.Username, testPassword); err == nil || !strings.Contains(err.Error(), "suspended") {
+		t.Errorf("suspended login error = %v", err)
+	}
+
+	actions, err := a.AdminActions(1)
+	must(t, err)
+	if len(actions) != 1 || actions[0].Action != domain.AdminActionSuspend || !strings.HasPrefix(actions[0].Details, "until ") {
+		t.Errorf("audit log = %+v", actions)
+	}
+}
+
+func TestAdminTerminateGame(t *testing.T) {
+	ts := newTestServer(t)
+	a := ts.admin(t)
+	p1, p2, gameID := ts.startPvP(t)
+	must(t, p1.MakeMove(3))
+	expect(t, p2, "move_made")
+
+	must(t, a.TerminateGame(gameID, "bug abuse"))
+	for _, c := range []*client.Client{p1, p2} {
+		if over := expect(t, c, "game_over"); over.Reason != domain.ReasonTerminated {
+			t.Errorf("%s: game_over reason = %q", c.Username, over.Reason)
+		}
+	}
+
+	result := waitForSavedGame(t, ts, gameID)
+	if result.Reason != domain.ReasonTerminated || result.WinnerID != nil {
+		t.Errorf("saved game = %+v", result)
+	}
+	for _, id := range []int64{p1.UserID, p2.UserID} {
+		if u, _ := ts.Users.GetUserByID(id); u.Rating != 1000 || u.GamesPlayed != 0 {
+			t.Errorf("user %d: rating %d, played %d after terminated game", id, u.Rating, u.GamesPlayed)
+		}
+	}
+
+	must(t, p1.RequestRematch())
+	expect(t, p1, "error")
+	if err := a.TerminateGame(gameID, "again"); err == nil || !strings.Contains(err.Error(), "409") {
+		t.Errorf("second terminate error = %v, want 409", err)
+	}
+}
+
+func TestAdminVoidGame(t *testing.T) {
+	ts := newTestServer(t)
+	a := ts.admin(t)
+	p1, p2, gameID := ts.startPvP(t)
+	play(t, p1, p2, 0, 1, 0, 1, 0, 1, 0)
+	waitForSavedGame(t, ts, gameID)
+
+	winner, _ := ts.Users.GetUserByID(p1.UserID)
+	if winner.Rating == 1000 {
+		t.Fatal("rated game didn't change the winner's rating")
+	}
+
+	must(t, a.VoidGame(gameID, "win trading"))
+	for _, id := range []int64{p1.UserID, p2.UserID} {
+		if u, _ := ts.Users.GetUserByID(id); u.Rating != 1000 {
+			t.Errorf("user %d rating = %d after void, want 1000", id, u.Rating)
+		}
+	}
+	if result, _ := ts.Games.GetGameByID(gameID); !result.Voided {
+		t.Error("game not marked voided")
+	}
+
+	if err := a.VoidGame(gameID, "again"); err == nil || !strings.Contains(err.Error(), "409") {
+		t.Errorf("second void error = %v, want 409", err)
+	}
+	if err := a.VoidGame("missing", "x"); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("unknown game void error = %v, want 404", err)
+	}
+
+	actions, err := a.AdminActions(10)
+	must(t, err)
+	if len(actions) != 1 || actions[0].Action != domain.AdminActionVoidGame || actions[0].TargetGameID != gameID {
+		t.Errorf("audit log = %+v", actions)
+	}
+}
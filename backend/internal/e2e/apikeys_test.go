@@ -0,0 +1,133 @@
+package e2e
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/iamasit07/connect4/backend/internal/client"
+	"github.com/iamasit07/connect4/backend/internal/domain"
+)
+
+// keyClient returns a client for p's account that authenticates with an API key
+func keyClient(ts *testServer, p *client.Client, key string) *client.Client {
+	c := client.New(ts.URL)
+	c.Token = key
+	c.UserID = p.UserID
+	c.Username = p.Username + " (key)"
+	return c
+}
+
+func expectStatus(t *testing.T, err error, status string) {
+	t.Helper()
+	if err == nil || !strings.Contains(err.Error(), status) {
+		t.Errorf("error = %v, want %s", err, status)
+	}
+}
+
+func TestAPIKeyScopes(t *testing.T) {
+	ts := newTestServer(t)
+	p := ts.player(t)
+
+	plain, key, err := p.CreateAPIKey("stats export", []string{domain.ScopeHistory, domain.ScopeRead, domain.ScopeRead}, 0)
+	must(t, err)
+	if !strings.HasPrefix(plain, key.Prefix) || len(key.Prefix) >= len(plain) {
+		t.Errorf("prefix %q doesn't identify key", key.Prefix)
+	}
+	if len(key.Scopes) != 2 || key.ExpiresAt != nil {
+		t.Errorf("scopes = %v, expires = %v; want [read history] and no expiry", key.Scopes, key.ExpiresAt)
+	}
+
+	script := keyClient(ts, p, plain)
+	var me struct {
+		ID int64 `json:"id"`
+	}
+	must(t, script.GetJSON("/api/auth/me", &me))
+	if me.ID != p.UserID {
+		t.Errorf("/me as key = user %d, want %d", me.ID, p.UserID)
+	}
+	must(t, script.GetJSON("/api/history", nil))
+
+	// Only the listed read routes accept keys, and keys can't manage keys
+	expectStatus(t, script.Do(http.MethodPost, "/api/friends/requests", map[string]string{"username": "nobody"}, nil), "403")
+	_, err = script.APIKeys()
+	expectStatus(t, err, "403")
+	_, _, err = script.CreateAPIKey("escalate", []string{domain.ScopePlay}, 0)
+	expectStatus(t, err, "403")
+
+	// Without the play scope the WebSocket refuses the key
+	must(t, script.Connect())
+	defer script.Close()
+	if msg := expect(t, script, "error"); !strings.Contains(msg.Message, "Invalid token") {
+		t.Errorf("init error = %q", msg.Message)
+	}
+
+	readOnly, _, err := p.CreateAPIKey("dashboard", []string{domain.ScopeRead}, 0)
+	must(t, err)
+	expectStatus(t, keyClient(ts, p, readOnly).GetJSON("/api/history", nil), "403")
+
+	keys, err := p.APIKeys()
+	must(t, err)
+	if len(keys) != 2 || keys[1].ID != key.ID || keys[1].LastUsedAt == nil {
+		t.Fatalf("keys = %+v, want 2 with the used one last", keys)
+	}
+	if keys[0].LastUsedAt == nil {
+		t.Error("read-only key has no last use")
+	}
+
+	_, _, err = p.CreateAPIKey("bad", []string{"admin"}, 0)
+	expectStatus(t, err, "400")
+	_, _, err = p.CreateAPIKey("", []string{domain.ScopeRead}, 0)
+	expectStatus(t, err, "400")
+}
+
+func TestAPIKeyExpiryAndRevocation(t *testing.T) {
+	ts := newTestServer(t)
+	p := ts.player(t)
+
+	shortLived, _, err := p.CreateAPIKey("nightly", []string{domain.ScopeRead}, 1)
+	must(t, err)
+	must(t, keyClient(ts, p, shortLived).GetJSON("/api/auth/me", nil))
+	ts.Clock.Advance(25 * time.Hour)
+	expectStatus(t, keyClient(ts, p, shortLived).GetJSON("/api/auth/me", nil), "401")
+
+	plain, key, err := p.CreateAPIKey("cli", []string{domain.ScopeRead}, 0)
+	must(t, err)
+	must(t, p.RevokeAPIKey(key.ID))
+	expectStatus(t, keyClient(ts, p, plain).GetJSON("/api/auth/me", nil), "401")
+	expectStatus(t, p.RevokeAPIKey(key.ID), "404")
+
+	// Another user can't revoke someone else's key
+	other := ts.player(t)
+	_, otherKey, err := other.CreateAPIKey("theirs", []string{domain.ScopeRead}, 0)
+	must(t, err)
+	expectStatus(t, p.RevokeAPIKey(otherKey.ID), "404")
+
+	_, _, err = ts.guest(t).CreateAPIKey("guest", []string{domain.ScopeRead}, 0)
+	expectStatus(t, err, "403")
+}
+
+func TestAPIKeyPlays(t *testing.T) {
+	ts := newTestServer(t)
+	p := ts.player(t)
+
+	plain, key, err := p.CreateAPIKey("bot runner", []string{domain.ScopePlay}, 0)
+	must(t, err)
+	bot := keyClient(ts, p, plain)
+	must(t, bot.Connect())
+	defer bot.Close()
+	eventually(t, "key connected", func() bool { return len(ts.ConnManager.ConnectedSessions(p.UserID)) == 2 })
+
+	// A play-only key can't read over HTTP
+	expectStatus(t, bot.GetJSON("/api/auth/me", nil), "403")
+
+	must(t, bot.FindMatch("easy", nil))
+	expect(t, bot, "game_start")
+	must(t, bot.MakeMove(3))
+	expect(t, bot, "move_made")
+
+	// Revoking the key closes its socket
+	must(t, p.RevokeAPIKey(key.ID))
+	expect(t, bot, "force_disconnect")
+}
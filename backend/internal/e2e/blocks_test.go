@@ -0,0 +1,103 @@
+package e2e
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBlockedPlayersAreNotPaired(t *testing.T) {
+	ts := newTestServer(t)
+	a, b := ts.player(t), ts.player(t)
+	must(t, b.Block(a.UserID))
+
+	must(t, a.FindMatch("", nil))
+	expect(t, a, "queue_joined")
+	must(t, b.FindMatch("", nil))
+	expect(t, b, "queue_joined")
+	if _, err := a.WaitFor("game_start", 300*time.Millisecond); err == nil {
+		t.Fatal("blocked players were matched together")
+	}
+
+	c := ts.player(t)
+	must(t, c.FindMatch("", nil))
+	start := expect(t, c, "game_start")
+	if start.Opponent != a.Username && start.Opponent != b.Username {
+		t.Errorf("opponent = %q, want one of the waiting players", start.Opponent)
+	}
+}
+
+func TestBlockHidesChat(t *testing.T) {
+	ts := newTestServer(t)
+	p1, p2, gameID := ts.startPvP(t)
+	spectator := ts.player(t)
+
+	must(t, p1.Chat("", "hi"))
+	expectChat(t, p2, "hi")
+	must(t, p1.Block(spectator.UserID))
+
+	// Blocked users can still watch, but not see each other's chat
+	must(t, spectator.WatchGame(gameID))
+	if start := expect(t, spectator, "spectate_start"); len(start.ChatHistory) != 0 {
+		t.Errorf("spectator history = %+v, want blocked sender's lines hidden", start.ChatHistory)
+	}
+
+	must(t, p1.Chat("", "gl"))
+	expectChat(t, p2, "gl")
+	expectNoChat(t, spectator)
+
+	must(t, p2.Chat("", "you too"))
+	expectChat(t, spectator, "you too")
+}
+
+func TestBlockSpectating(t *testing.T) {
+	ts := newTestServer(t)
+	p1, _, gameID := ts.startPvP(t)
+	banned, blocked := ts.player(t), ts.player(t)
+	must(t, p1.BlockSpectating(banned.UserID))
+	must(t, p1.Block(blocked.UserID))
+
+	must(t, banned.WatchGame(gameID))
+	if msg := expect(t, banned, "error"); !strings.Contains(msg.Message, "watch") {
+		t.Errorf("error = %q, want spectate refusal", msg.Message)
+	}
+	if ts.SessionManager.IsSpectatorAnywhere(banned.UserID) {
+		t.Error("refused spectator was added to the game")
+	}
+
+	must(t, blocked.WatchGame(gameID))
+	expect(t, blocked, "spectate_start")
+}
+
+func TestBlockCancelsChallengesAndRematches(t *testing.T) {
+	ts := newTestServer(t)
+	a, b := ts.player(t), ts.player(t)
+	makeFriends(t, a, b)
+
+	must(t, a.Challenge(b.UserID, nil))
+	received := expect(t, b, "challenge_received")
+	expect(t, a, "challenge_sent")
+
+	must(t, b.Block(a.UserID))
+	must(t, b.RespondChallenge(received.ChallengeID, true))
+	expect(t, b, "error")
+	if _, err := a.WaitFor("game_start", 300*time.Millisecond); err == nil {
+		t.Fatal("challenge started a game after the block")
+	}
+
+	must(t, a.Challenge(b.UserID, nil))
+	expect(t, a, "error")
+
+	// A rematch is an invite too
+	must(t, b.Unblock(a.UserID))
+	p1, p2, _ := ts.startPvP(t)
+	play(t, p1, p2, 0, 1, 0, 1, 0, 1, 0)
+	expect(t, p1, "game_over")
+	expect(t, p2, "game_over")
+	must(t, p1.Block(p2.UserID))
+	must(t, p2.RequestRematch())
+	expect(t, p2, "error")
+	if _, err := p1.WaitFor("rematch_requested", 300*time.Millisecond); err == nil {
+		t.Error("blocked player received a rematch request")
+	}
+}
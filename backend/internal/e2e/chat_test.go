@@ -0,0 +1,174 @@
+package e2e
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/iamasit07/connect4/backend/internal/client"
+	"github.com/iamasit07/connect4/backend/internal/domain"
+)
+
+// expectChat waits for the next chat line and checks its text
+func expectChat(t *testing.T, c *client.Client, text string) *domain.ChatMessage {
+	t.Helper()
+	msg := expect(t, c, "chat_message")
+	if msg.Chat == nil || msg.Chat.Text != text {
+		t.Fatalf("%s: chat = %+v, want %q", c.Username, msg.Chat, text)
+	}
+	return msg.Chat
+}
+
+func expectNoChat(t *testing.T, c *client.Client) {
+	t.Helper()
+	if msg, err := c.WaitFor("chat_message", 300*time.Millisecond); err == nil {
+		t.Fatalf("%s: unexpected chat %+v", c.Username, msg.Chat)
+	}
+}
+
+func TestChatChannels(t *testing.T) {
+	ts := newTestServer(t)
+	p1, p2, gameID := ts.startPvP(t)
+	spectator := ts.player(t)
+	must(t, spectator.WatchGame(gameID))
+	expect(t, spectator, "spectate_start")
+
+	// Player chat reaches both players and spectators
+	must(t, p1.Chat("", "good luck"))
+	line := expectChat(t, p2, "good luck")
+	if line.Channel != domain.ChatChannelPlayers || line.SenderUsername != p1.Username || line.SenderID != p1.UserID {
+		t.Errorf("chat = %+v, want players channel from %s", line, p1.Username)
+	}
+	expectChat(t, p1, "good luck")
+	expectChat(t, spectator, "good luck")
+
+	// Spectator chat is hidden from players
+	must(t, spectator.Chat(gameID, "drop it in the middle"))
+	if line := expectChat(t, spectator, "drop it in the middle"); line.Channel != domain.ChatChannelSpectators {
+		t.Errorf("spectator chat channel = %q", line.Channel)
+	}
+	expectNoChat(t, p1)
+	expectNoChat(t, p2)
+
+	// Outsiders can't post into the game
+	outsider := ts.player(t)
+	must(t, outsider.Chat(gameID, "hello"))
+	expect(t, outsider, "error")
+}
+
+func TestChatFilter(t *testing.T) {
+	ts := newTestServer(t)
+	p1, p2, _ := ts.startPvP(t)
+
+	must(t, p1.Chat("", "visit https://example.com"))
+	if msg := expect(t, p1, "error"); !strings.Contains(msg.Message, "links") {
+		t.Errorf("error = %q, want link rejection", msg.Message)
+	}
+	must(t, p1.Chat("", strings.Repeat("a", 201)))
+	expect(t, p1, "error")
+	expectNoChat(t, p2)
+
+	must(t, p1.Chat("", "well shit"))
+	expectChat(t, p2, "well ****")
+}
+
+func TestChatRateLimit(t *testing.T) {
+	ts := newTestServer(t)
+	p1, p2, _ := ts.startPvP(t)
+
+	for i := 0; i < 5; i++ {
+		must(t, p1.Chat("", "spam"))
+		expectChat(t, p2, "spam")
+	}
+	must(t, p1.Chat("", "spam"))
+	if msg := expect(t, p1, "error"); msg.Code != domain.ErrRateLimited || msg.RetryAfter < 1 {
+		t.Errorf("error = %+v, want rate_limited with a retry delay", msg)
+	}
+	expectNoChat(t, p2)
+
+	ts.Clock.Advance(10 * time.Second)
+	must(t, p1.Chat("", "calm now"))
+	expectChat(t, p2, "calm now")
+}
+
+func TestChatMute(t *testing.T) {
+	ts := newTestServer(t)
+	p1, p2, gameID := ts.startPvP(t)
+
+	must(t, p2.Mute(gameID, p1.UserID))
+	if msg := expect(t, p2, "user_muted"); msg.UserID != p1.UserID {
+		t.Errorf("user_muted userId = %d, want %d", msg.UserID, p1.UserID)
+	}
+
+	must(t, p1.Chat("", "can you hear me"))
+	expectChat(t, p1, "can you hear me")
+	expectNoChat(t, p2)
+
+	must(t, p2.Unmute(gameID, p1.UserID))
+	expect(t, p2, "user_unmuted")
+	must(t, p1.Chat("", "now?"))
+	expectChat(t, p2, "now?")
+}
+
+func TestChatReport(t *testing.T) {
+	ts := newTestServer(t)
+	p1, p2, gameID := ts.startPvP(t)
+
+	must(t, p1.Chat("", "you are bad"))
+	line := expectChat(t, p2, "you are bad")
+	expectChat(t, p1, "you are bad")
+
+	must(t, p1.ReportMessage(gameID, line.ID, "own"))
+	expect(t, p1, "error")
+
+	must(t, p2.ReportMessage(gameID, line.ID, "harassment"))
+	expect(t, p2, "report_received")
+
+	reports := ts.Chats.Reports()
+	if len(reports) != 1 {
+		t.Fatalf("stored %d reports, want 1", len(reports))
+	}
+	r := reports[0]
+	if r.GameID != gameID || r.ReporterID != p2.UserID || r.ReportedUserID != p1.UserID || r.MessageText != "you are bad" || r.Reason != "harassment" {
+		t.Errorf("report = %+v", r)
+	}
+}
+
+func TestChatHistoryAndTranscript(t *testing.T) {
+	ts := newTestServer(t)
+	p1, p2, gameID := ts.startPvP(t)
+	spectator := ts.player(t)
+	must(t, spectator.WatchGame(gameID))
+	expect(t, spectator, "spectate_start")
+
+	must(t, p1.Chat("", "hi"))
+	expectChat(t, p2, "hi")
+	expectChat(t, spectator, "hi")
+	must(t, spectator.Chat(gameID, "go p2"))
+	expectChat(t, spectator, "go p2")
+
+	// A reconnecting player only gets the players channel back
+	must(t, p2.Close())
+	expect(t, p1, "opponent_disconnected")
+	must(t, p2.Connect())
+	state := expect(t, p2, "game_state")
+	if len(state.ChatHistory) != 1 || state.ChatHistory[0].Text != "hi" {
+		t.Errorf("player history = %+v, want [hi]", state.ChatHistory)
+	}
+
+	// A late spectator sees both channels
+	late := ts.player(t)
+	must(t, late.WatchGame(gameID))
+	start := expect(t, late, "spectate_start")
+	if len(start.ChatHistory) != 2 {
+		t.Errorf("spectator history = %+v, want 2 lines", start.ChatHistory)
+	}
+
+	play(t, p1, p2, 0, 1, 0, 1, 0, 1, 0)
+	waitForSavedGame(t, ts, gameID)
+	transcript, err := ts.Games.GetGameChat(gameID)
+	must(t, err)
+	if len(transcript) != 2 || transcript[0].Text != "hi" || transcript[1].Text != "go p2" {
+		t.Errorf("transcript = %+v", transcript)
+	}
+}
@@ -0,0 +1,135 @@
+package e2e
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/iamasit07/connect4/backend/internal/client"
+	"github.com/iamasit07/connect4/backend/internal/config"
+	"github.com/iamasit07/connect4/backend/internal/domain"
+	"github.com/iamasit07/connect4/backend/internal/service/bot"
+)
+
+const testAnalysisDepth = 4
+
+// solverMove returns the lowest column with the solver's best score
+func solverMove(board [][]domain.PlayerID, player domain.PlayerID) int {
+	scores := bot.ScoreMoves(board, player, testAnalysisDepth)
+	best := -1
+	for _, col := range domain.GetValidMoves(board) {
+		if best < 0 || scores[col] > scores[best] {
+			best = col
+		}
+	}
+	return best
+}
+
+// playEngineGame has cheater (player 1) copy the solver with a steady 3s per
+// move while opponent plays like the easy bot at a human, uneven pace
+func playEngineGame(t *testing.T, ts *testServer, cheater, opponent *client.Client) {
+	t.Helper()
+	gameID := rematchViaQueue(t, cheater, opponent)
+
+	board := domain.NewBoard()
+	for turn := 0; ; turn++ {
+		mover, player := cheater, domain.Player1
+		var col int
+		if turn%2 == 0 {
+			ts.Clock.Advance(3 * time.Second)
+			col = solverMove(board, player)
+		} else {
+			mover, player = opponent, domain.Player2
+			ts.Clock.Advance(time.Duration(1+turn*7%9) * time.Second)
+			col = bot.CalculateBestMove(board, player, "easy")
+		}
+		must(t, mover.MakeMove(col))
+		expect(t, cheater, "move_made")
+		expect(t, opponent, "move_made")
+
+		row, _ := domain.DropDisk(board, col, player)
+		if _, won := domain.CheckWin(board, row, col, player); won || domain.IsBoardFull(board) {
+			break
+		}
+	}
+	expect(t, cheater, "game_over")
+	expect(t, opponent, "game_over")
+	waitForSavedGame(t, ts, gameID)
+}
+
+// analyzeGames starts an engine analysis and waits for it, returning how many
+// players it flagged
+func analyzeGames(t *testing.T, a *client.Client) int {
+	t.Helper()
+	run, err := a.AnalyzeGames()
+	must(t, err)
+	if !run.Running {
+		t.Fatalf("analysis run = %+v, want it running", run)
+	}
+	var status domain.EngineAnalysisRun
+	eventually(t, "engine analysis", func() bool {
+		status, err = a.AnalysisStatus()
+		must(t, err)
+		return status.ID == run.ID && !status.Running
+	})
+	if status.Error != "" {
+		t.Fatalf("analysis failed: %s", status.Error)
+	}
+	return status.Flagged
+}
+
+func TestEngineAssistanceFlagged(t *testing.T) {
+	ts := newTestServerWith(t, func(cfg *config.Config) { cfg.EngineAnalysisDepth = testAnalysisDepth })
+	a := ts.admin(t)
+	cheater, opponent := ts.player(t), ts.player(t)
+	for i := 0; i < 16; i++ {
+		playEngineGame(t, ts, cheater, opponent)
+	}
+
+	if flagged := analyzeGames(t, a); flagged != 1 {
+		t.Fatalf("analysis flagged %d players, want 1", flagged)
+	}
+	flags, err := a.IntegrityFlags(domain.FlagOpen)
+	must(t, err)
+	if len(flags) != 1 || flags[0].Kind != domain.FlagKindEngine || flags[0].UserID != cheater.UserID {
+		t.Fatalf("open flags = %+v, want one engine flag on %s", flags, cheater.Username)
+	}
+	flag := flags[0]
+	for _, signal := range []string{domain.SignalEngineMatch, domain.SignalLowMoveLoss, domain.SignalConsistentTiming} {
+		if !slices.Contains(flag.Signals, signal) {
+			t.Errorf("signals %v missing %s", flag.Signals, signal)
+		}
+	}
+	ev := flag.Evidence
+	if ev == nil || ev.Games != 16 || len(ev.GameIDs) != 16 || ev.MatchRate != 1 || ev.AvgLoss != 0 || ev.ThinkTimeCV != 0 {
+		t.Fatalf("evidence = %+v", ev)
+	}
+	if ev.Moves < 20 || ev.BandMatchRate == 0 {
+		t.Errorf("evidence = %+v, want at least 20 moves and a band baseline", ev)
+	}
+
+	// Games are analysed once
+	if flagged := analyzeGames(t, a); flagged != 0 {
+		t.Errorf("second analysis flagged %d players, want 0", flagged)
+	}
+
+	// Dismissed players stay off the queue until a newer game is analysed
+	must(t, a.ReviewFlag(flag.ID, domain.FlagDismissed, "strong player"))
+	ts.Clock.Advance(time.Minute)
+	playEngineGame(t, ts, cheater, opponent)
+	if flagged := analyzeGames(t, a); flagged != 1 {
+		t.Errorf("analysis after a new game flagged %d players, want 1", flagged)
+	}
+}
+
+func TestEngineAnalysisIgnoresBotsAndShortHistories(t *testing.T) {
+	ts := newTestServerWith(t, func(cfg *config.Config) { cfg.EngineAnalysisDepth = testAnalysisDepth })
+	a := ts.admin(t)
+	p1, p2, gameID := ts.startPvP(t)
+	play(t, p1, p2, 3, 3, 4, 4, 5, 5, 6)
+	waitForSavedGame(t, ts, gameID)
+
+	if flagged := analyzeGames(t, a); flagged != 0 {
+		t.Errorf("a single short game flagged %d players", flagged)
+	}
+}
@@ -0,0 +1,196 @@
+package e2e
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/iamasit07/connect4/backend/internal/client"
+	"github.com/iamasit07/connect4/backend/internal/domain"
+)
+
+// makeFriends sends a request from a to b and accepts it
+func makeFriends(t *testing.T, a, b *client.Client) {
+	t.Helper()
+	relation, err := a.AddFriend(b.Username)
+	must(t, err)
+	if relation != domain.FriendOutgoing {
+		t.Fatalf("relation after request = %q, want outgoing", relation)
+	}
+	must(t, b.AcceptFriend(a.UserID))
+	expect(t, b, "friend_request")
+	expect(t, a, "friend_accepted")
+}
+
+// expectPresence waits until c is told that userID has the given presence
+func expectPresence(t *testing.T, c *client.Client, userID int64, presence string) {
+	t.Helper()
+	deadline := time.Now().Add(waitTimeout)
+	for time.Now().Before(deadline) {
+		msg, err := c.WaitFor("presence_update", time.Until(deadline))
+		if err != nil {
+			break
+		}
+		if msg.UserID == userID && msg.Presence == presence {
+			return
+		}
+	}
+	t.Fatalf("%s: no presence_update %d=%s", c.Username, userID, presence)
+}
+
+func friendEntry(t *testing.T, c *client.Client, userID int64) *domain.Friend {
+	t.Helper()
+	list, err := c.ListFriends()
+	must(t, err)
+	for i := range list {
+		if list[i].UserID == userID {
+			return &list[i]
+		}
+	}
+	return nil
+}
+
+func TestFriendRequestFlow(t *testing.T) {
+	ts := newTestServer(t)
+	a, b := ts.player(t), ts.player(t)
+
+	relation, err := a.AddFriend(b.Username)
+	must(t, err)
+	if relation != domain.FriendOutgoing {
+		t.Errorf("relation = %q, want outgoing", relation)
+	}
+	if req := expect(t, b, "friend_request"); req.UserID != a.UserID || req.Username != a.Username {
+		t.Errorf("friend_request = %d/%s, want %d/%s", req.UserID, req.Username, a.UserID, a.Username)
+	}
+	if f := friendEntry(t, b, a.UserID); f == nil || f.Relation != domain.FriendIncoming {
+		t.Errorf("b sees %+v, want incoming request", f)
+	}
+	if _, err := a.AddFriend(b.Username); err == nil || !strings.Contains(err.Error(), "409") {
+		t.Errorf("duplicate request error = %v, want 409", err)
+	}
+
+	must(t, b.AcceptFriend(a.UserID))
+	expect(t, a, "friend_accepted")
+	f := friendEntry(t, a, b.UserID)
+	if f == nil || f.Relation != domain.FriendAccepted || f.Presence != "online" {
+		t.Errorf("a sees %+v, want accepted and online", f)
+	}
+
+	must(t, a.RemoveFriend(b.UserID))
+	if f := friendEntry(t, b, a.UserID); f != nil {
+		t.Errorf("friendship still listed after removal: %+v", f)
+	}
+}
+
+func TestMutualRequestsBecomeFriends(t *testing.T) {
+	ts := newTestServer(t)
+	a, b := ts.player(t), ts.player(t)
+
+	_, err := a.AddFriend(b.Username)
+	must(t, err)
+	relation, err := b.AddFriend(a.Username)
+	must(t, err)
+	if relation != domain.FriendAccepted {
+		t.Errorf("relation = %q, want accepted", relation)
+	}
+	expect(t, a, "friend_accepted")
+}
+
+func TestBlockedUserCannotSendRequests(t *testing.T) {
+	ts := newTestServer(t)
+	a, b := ts.player(t), ts.player(t)
+	makeFriends(t, a, b)
+
+	must(t, b.Block(a.UserID))
+	if f := friendEntry(t, a, b.UserID); f != nil {
+		t.Errorf("blocked user still sees %+v", f)
+	}
+	if f := friendEntry(t, b, a.UserID); f == nil || f.Relation != domain.FriendBlocked {
+		t.Errorf("blocker sees %+v, want blocked", f)
+	}
+	if _, err := a.AddFriend(b.Username); err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("request to blocker error = %v, want 403", err)
+	}
+
+	must(t, b.Unblock(a.UserID))
+	_, err := a.AddFriend(b.Username)
+	must(t, err)
+}
+
+func TestPresenceUpdates(t *testing.T) {
+	ts := newTestServer(t)
+	a, b := ts.player(t), ts.player(t)
+	makeFriends(t, a, b)
+
+	must(t, b.FindMatch("", nil))
+	expectPresence(t, a, b.UserID, "in_queue")
+	must(t, b.CancelSearch())
+	expectPresence(t, a, b.UserID, "online")
+
+	must(t, b.FindMatch("easy", nil))
+	expectPresence(t, a, b.UserID, "playing")
+	must(t, b.Abandon())
+	expectPresence(t, a, b.UserID, "online")
+
+	must(t, b.Close())
+	expectPresence(t, a, b.UserID, "offline")
+}
+
+func TestChallengeAccepted(t *testing.T) {
+	ts := newTestServer(t)
+	a, b := ts.player(t), ts.player(t)
+	makeFriends(t, a, b)
+
+	must(t, a.Challenge(b.UserID, nil))
+	received := expect(t, b, "challenge_received")
+	if received.UserID != a.UserID || received.Username != a.Username || received.ChallengeTimeout != 30 {
+		t.Errorf("challenge_received = %+v", received)
+	}
+	expect(t, a, "challenge_sent")
+
+	must(t, b.RespondChallenge(received.ChallengeID, true))
+	startA := expect(t, a, "game_start")
+	startB := expect(t, b, "game_start")
+	if startA.GameID == "" || startA.GameID != startB.GameID {
+		t.Fatalf("challenge games differ: %q vs %q", startA.GameID, startB.GameID)
+	}
+	if startA.YourPlayer != int(domain.Player1) || startA.Rated == nil || !*startA.Rated {
+		t.Errorf("challenger start = player %d rated %v, want player 1 rated", startA.YourPlayer, startA.Rated)
+	}
+	play(t, a, b, 3)
+}
+
+func TestChallengeDeclinedAndExpired(t *testing.T) {
+	ts := newTestServer(t)
+	a, b := ts.player(t), ts.player(t)
+	makeFriends(t, a, b)
+
+	must(t, a.Challenge(b.UserID, nil))
+	first := expect(t, b, "challenge_received")
+	must(t, b.RespondChallenge(first.ChallengeID, false))
+	expect(t, a, "challenge_declined")
+
+	must(t, a.Challenge(b.UserID, nil))
+	second := expect(t, b, "challenge_received")
+	ts.Clock.Advance(30 * time.Second)
+	if msg := expect(t, a, "challenge_expired"); msg.ChallengeID != second.ChallengeID {
+		t.Errorf("expired %q, want %q", msg.ChallengeID, second.ChallengeID)
+	}
+	expect(t, b, "challenge_expired")
+
+	must(t, b.RespondChallenge(second.ChallengeID, true))
+	expect(t, b, "error")
+}
+
+func TestChallengeRequiresFriendship(t *testing.T) {
+	ts := newTestServer(t)
+	a, stranger := ts.player(t), ts.player(t)
+
+	must(t, a.Challenge(stranger.UserID, nil))
+	if msg := expect(t, a, "error"); !strings.Contains(msg.Message, "friends") {
+		t.Errorf("error = %q", msg.Message)
+	}
+	if _, err := stranger.WaitFor("challenge_received", 300*time.Millisecond); err == nil {
+		t.Error("stranger received a challenge")
+	}
+}
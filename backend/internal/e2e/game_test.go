@@ -0,0 +1,319 @@
+package e2e
+
+import (
+	"testing"
+	"time"
+
+	"github.com/iamasit07/connect4/backend/internal/client"
+	"github.com/iamasit07/connect4/backend/internal/domain"
+)
+
+// fullBoardDraw fills all 42 cells without either player connecting four
+var fullBoardDraw = []int{
+	0, 1, 2, 3, 4, 5, 6, 0, 1, 2, 3, 4, 5, 6, 0, 1, 2, 3, 4, 5, 6,
+	1, 0, 3, 2, 5, 4, 0, 6, 1, 2, 3, 4, 5, 6, 0, 1, 2, 3, 4, 5, 6,
+}
+
+func TestMatchmakingPairsPlayers(t *testing.T) {
+	ts := newTestServer(t)
+	p1, p2, gameID := ts.startPvP(t)
+
+	if _, ok := ts.SessionManager.GetSessionByGameID(gameID); !ok {
+		t.Fatalf("game %s not registered with the session manager", gameID)
+	}
+
+	must(t, p1.GetGameState(""))
+	state := expect(t, p1, "game_state")
+	if state.Opponent != p2.Username {
+		t.Errorf("opponent = %q, want %q", state.Opponent, p2.Username)
+	}
+	if state.Rated == nil || !*state.Rated {
+		t.Errorf("PvP game should be rated by default")
+	}
+}
+
+func TestCasualAndRatedQueuesAreSeparate(t *testing.T) {
+	ts := newTestServer(t)
+	rated, casual := ts.player(t), ts.player(t)
+	casualMode := false
+
+	must(t, rated.FindMatch("", nil))
+	expect(t, rated, "queue_joined")
+	must(t, casual.FindMatch("", &casualMode))
+	expect(t, casual, "queue_joined")
+
+	if _, err := rated.WaitFor("game_start", 300*time.Millisecond); err == nil {
+		t.Fatal("rated and casual players were matched together")
+	}
+
+	casual2 := ts.player(t)
+	must(t, casual2.FindMatch("", &casualMode))
+	start := expect(t, casual2, "game_start")
+	if start.Opponent != casual.Username {
+		t.Errorf("opponent = %q, want %q", start.Opponent, casual.Username)
+	}
+	if start.Rated == nil || *start.Rated {
+		t.Errorf("casual game reported as rated")
+	}
+}
+
+func TestConnectFourWin(t *testing.T) {
+	ts := newTestServer(t)
+	p1, p2, gameID := ts.startPvP(t)
+
+	play(t, p1, p2, 0, 1, 0, 1, 0, 1, 0)
+
+	for _, c := range []*client.Client{p1, p2} {
+		over := expect(t, c, "game_over")
+		if over.Winner != p1.Username || over.Reason != "connect_four" {
+			t.Errorf("%s: game_over = %s/%s, want %s/connect_four", c.Username, over.Winner, over.Reason, p1.Username)
+		}
+		if len(over.WinningCells) != 4 {
+			t.Errorf("%s: got %d winning cells, want 4", c.Username, len(over.WinningCells))
+		}
+	}
+
+	result := waitForSavedGame(t, ts, gameID)
+	if result.WinnerID == nil || *result.WinnerID != p1.UserID {
+		t.Errorf("saved winner = %v, want %d", result.WinnerID, p1.UserID)
+	}
+
+	winner, _ := ts.Users.GetUserByID(p1.UserID)
+	loser, _ := ts.Users.GetUserByID(p2.UserID)
+	if winner.Rating <= 1000 || loser.Rating >= 1000 {
+		t.Errorf("ratings not updated: winner %d, loser %d", winner.Rating, loser.Rating)
+	}
+
+	// Moves after the game ended are rejected
+	must(t, p2.MakeMove(3))
+	expect(t, p2, "error")
+}
+
+func TestRejectsOutOfTurnMove(t *testing.T) {
+	ts := newTestServer(t)
+	_, p2, _ := ts.startPvP(t)
+
+	must(t, p2.MakeMove(3))
+	if msg := expect(t, p2, "error"); msg.Message != "not your turn" {
+		t.Errorf("error = %q, want %q", msg.Message, "not your turn")
+	}
+}
+
+func TestFullBoardDraw(t *testing.T) {
+	ts := newTestServer(t)
+	p1, p2, gameID := ts.startPvP(t)
+
+	play(t, p1, p2, fullBoardDraw...)
+
+	for _, c := range []*client.Client{p1, p2} {
+		over := expect(t, c, "game_over")
+		if over.Winner != "draw" || over.Reason != "draw" {
+			t.Errorf("%s: game_over = %s/%s, want draw/draw", c.Username, over.Winner, over.Reason)
+		}
+	}
+
+	result := waitForSavedGame(t, ts, gameID)
+	if result.WinnerID != nil || result.TotalMoves != domain.Rows*domain.Columns {
+		t.Errorf("saved draw = winner %v, %d moves", result.WinnerID, result.TotalMoves)
+	}
+}
+
+func TestDrawByAgreement(t *testing.T) {
+	ts := newTestServer(t)
+	p1, p2, gameID := ts.startPvP(t)
+
+	play(t, p1, p2, 3, 3)
+	must(t, p1.OfferDraw())
+	offer := expect(t, p2, "draw_offered")
+	if offer.DrawOfferer != p1.Username {
+		t.Errorf("drawOfferer = %q, want %q", offer.DrawOfferer, p1.Username)
+	}
+
+	must(t, p2.RespondDraw(true))
+	for _, c := range []*client.Client{p1, p2} {
+		over := expect(t, c, "game_over")
+		if over.Winner != "draw" || over.Reason != "agreement" {
+			t.Errorf("%s: game_over = %s/%s, want draw/agreement", c.Username, over.Winner, over.Reason)
+		}
+	}
+
+	result := waitForSavedGame(t, ts, gameID)
+	if result.Reason != "agreement" {
+		t.Errorf("saved reason = %q, want agreement", result.Reason)
+	}
+}
+
+func TestDisconnectForfeit(t *testing.T) {
+	ts := newTestServer(t)
+	p1, p2, gameID := ts.startPvP(t)
+	play(t, p1, p2, 3)
+
+	must(t, p2.Close())
+	notice := expect(t, p1, "opponent_disconnected")
+	if notice.DisconnectTimeout != 60 {
+		t.Errorf("disconnectTimeout = %d, want 60", notice.DisconnectTimeout)
+	}
+
+	// Nothing happens inside the grace period
+	ts.Clock.Advance(59 * time.Second)
+	if _, err := p1.WaitFor("game_over", 100*time.Millisecond); err == nil {
+		t.Fatal("player forfeited before the grace period ended")
+	}
+
+	over := ts.advanceUntil(t, time.Second, p1, "game_over")
+	if over.Winner != p1.Username || over.Reason != "abandonment" {
+		t.Errorf("game_over = %s/%s, want %s/abandonment", over.Winner, over.Reason, p1.Username)
+	}
+	if over.AllowRematch == nil || *over.AllowRematch {
+		t.Errorf("rematch should not be offered after a forfeit")
+	}
+
+	result := waitForSavedGame(t, ts, gameID)
+	if result.WinnerID == nil || *result.WinnerID != p1.UserID {
+		t.Errorf("saved winner = %v, want %d", result.WinnerID, p1.UserID)
+	}
+}
+
+func TestSurrender(t *testing.T) {
+	ts := newTestServer(t)
+	p1, p2, _ := ts.startPvP(t)
+
+	must(t, p1.Abandon())
+	over := expect(t, p2, "game_over")
+	if over.Winner != p2.Username {
+		t.Errorf("winner = %q, want %q", over.Winner, p2.Username)
+	}
+}
+
+func TestReconnectRestoresGame(t *testing.T) {
+	ts := newTestServer(t)
+	p1, p2, gameID := ts.startPvP(t)
+	play(t, p1, p2, 3, 4)
+
+	must(t, p2.Close())
+	expect(t, p1, "opponent_disconnected")
+
+	must(t, p2.Connect())
+	state := expect(t, p2, "game_state")
+	if state.GameID != gameID || state.YourPlayer != int(domain.Player2) {
+		t.Fatalf("game_state = game %s player %d, want %s player 2", state.GameID, state.YourPlayer, gameID)
+	}
+	if state.CurrentTurn != int(domain.Player1) {
+		t.Errorf("currentTurn = %d, want 1", state.CurrentTurn)
+	}
+	if state.Board[domain.Rows-1][3] != domain.Player1 || state.Board[domain.Rows-1][4] != domain.Player2 {
+		t.Errorf("board not restored: %v", state.Board[domain.Rows-1])
+	}
+	expect(t, p1, "opponent_reconnected")
+
+	// The game carries on normally
+	play(t, p1, p2, 3, 4)
+}
+
+func TestRematchAccepted(t *testing.T) {
+	ts := newTestServer(t)
+	p1, p2, gameID := ts.startPvP(t)
+	play(t, p1, p2, 0, 1, 0, 1, 0, 1, 0)
+	expect(t, p1, "game_over")
+	expect(t, p2, "game_over")
+
+	must(t, p2.RequestRematch())
+	req := expect(t, p1, "rematch_requested")
+	if req.RematchRequester != p2.Username {
+		t.Errorf("rematchRequester = %q, want %q", req.RematchRequester, p2.Username)
+	}
+
+	must(t, p1.RespondRematch(true))
+	expect(t, p1, "rematch_accepted")
+	expect(t, p2, "rematch_accepted")
+
+	start1 := expect(t, p1, "game_start")
+	start2 := expect(t, p2, "game_start")
+	if start1.GameID == gameID || start1.GameID != start2.GameID {
+		t.Fatalf("rematch game ids: %q / %q (old %q)", start1.GameID, start2.GameID, gameID)
+	}
+	if _, ok := ts.SessionManager.GetSessionByGameID(gameID); ok {
+		t.Errorf("old game %s still registered after rematch", gameID)
+	}
+}
+
+func TestRematchDeclined(t *testing.T) {
+	ts := newTestServer(t)
+	p1, p2, _ := ts.startPvP(t)
+	play(t, p1, p2, 0, 1, 0, 1, 0, 1, 0)
+	expect(t, p1, "game_over")
+	expect(t, p2, "game_over")
+
+	must(t, p1.RequestRematch())
+	expect(t, p2, "rematch_requested")
+
+	must(t, p2.RespondRematch(false))
+	expect(t, p1, "rematch_declined")
+	expect(t, p2, "rematch_declined")
+
+	if _, err := p1.WaitFor("game_start", 300*time.Millisecond); err == nil {
+		t.Error("a new game started after the rematch was declined")
+	}
+}
+
+func TestSpectatorReceivesMoves(t *testing.T) {
+	ts := newTestServer(t)
+	p1, p2, gameID := ts.startPvP(t)
+	spectator := ts.player(t)
+
+	must(t, spectator.WatchGame(gameID))
+	start := expect(t, spectator, "spectate_start")
+	if start.Player1 != p1.Username || start.Player2 != p2.Username {
+		t.Errorf("spectate_start players = %s vs %s", start.Player1, start.Player2)
+	}
+
+	moves := []int{0, 1, 0, 1, 0, 1, 0}
+	play(t, p1, p2, moves...)
+	for range moves {
+		expect(t, spectator, "move_made")
+	}
+	if over := expect(t, spectator, "game_over"); over.Winner != p1.Username {
+		t.Errorf("spectator saw winner %q, want %q", over.Winner, p1.Username)
+	}
+
+	// Players cannot spectate their own game
+	must(t, p1.WatchGame(gameID))
+	if _, err := p1.WaitFor("spectate_start", 300*time.Millisecond); err == nil {
+		t.Error("player was allowed to spectate their own game")
+	}
+}
+
+func TestBotRepliesToMoves(t *testing.T) {
+	ts := newTestServer(t)
+	p := ts.player(t)
+
+	must(t, p.FindMatch("easy", nil))
+	start := expect(t, p, "game_start")
+	if start.Opponent != domain.GetBotName("easy") {
+		t.Errorf("opponent = %q, want %q", start.Opponent, domain.GetBotName("easy"))
+	}
+	if start.Rated == nil || *start.Rated {
+		t.Errorf("bot games should be casual by default")
+	}
+
+	must(t, p.MakeMove(3))
+	if own := expect(t, p, "move_made"); own.Player != int(domain.Player1) {
+		t.Fatalf("first move_made from player %d, want 1", own.Player)
+	}
+
+	// The bot replies after a short delay on the game clock
+	reply := ts.advanceUntil(t, 100*time.Millisecond, p, "move_made")
+	if reply.Player != int(domain.Player2) {
+		t.Errorf("bot move_made from player %d, want 2", reply.Player)
+	}
+}
+
+func waitForSavedGame(t *testing.T, ts *testServer, gameID string) *domain.GameResult {
+	t.Helper()
+	var result *domain.GameResult
+	eventually(t, "game "+gameID+" to be saved", func() bool {
+		result, _ = ts.Games.GetGameByID(gameID)
+		return result != nil
+	})
+	return result
+}
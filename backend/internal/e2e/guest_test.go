@@ -0,0 +1,127 @@
+package e2e
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/iamasit07/connect4/backend/internal/client"
+	"github.com/iamasit07/connect4/backend/internal/domain"
+)
+
+// guest signs in as a fresh guest and opens its WebSocket
+func (ts *testServer) guest(t *testing.T) *client.Client {
+	t.Helper()
+	c := client.New(ts.URL)
+	must(t, c.Guest())
+	must(t, c.Connect())
+	eventually(t, c.Username+" connected", func() bool { return ts.ConnManager.IsOnline(c.UserID) })
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func leaderboardHas(t *testing.T, c *client.Client, username string) (domain.PlayerStats, bool) {
+	t.Helper()
+	var board []domain.PlayerStats
+	must(t, c.GetJSON("/api/leaderboard", &board))
+	for _, entry := range board {
+		if entry.Username == username {
+			return entry, true
+		}
+	}
+	return domain.PlayerStats{}, false
+}
+
+func TestGuestPlaysCasualOnly(t *testing.T) {
+	ts := newTestServer(t)
+	g := ts.guest(t)
+	if !strings.HasPrefix(g.Username, domain.GuestUsernamePrefix) {
+		t.Errorf("guest username = %q", g.Username)
+	}
+
+	rated := true
+	must(t, g.FindMatch("", &rated))
+	if msg := expect(t, g, "error"); !strings.Contains(msg.Message, "casual") {
+		t.Errorf("rated queue error = %q", msg.Message)
+	}
+	must(t, g.FindMatch("easy", &rated))
+	expect(t, g, "error")
+
+	// Without an explicit mode a guest lands in the casual queue
+	must(t, g.FindMatch("", nil))
+	if joined := expect(t, g, "queue_joined"); joined.Rated == nil || *joined.Rated {
+		t.Fatalf("queue_joined rated = %v, want casual", joined.Rated)
+	}
+	p := ts.player(t)
+	casual := false
+	must(t, p.FindMatch("", &casual))
+	start := expect(t, g, "game_start")
+	expect(t, p, "game_start")
+	must(t, g.Abandon())
+	if result := waitForSavedGame(t, ts, start.GameID); result.Rated {
+		t.Error("guest game was rated")
+	}
+
+	// Bot games work too
+	bot := ts.guest(t)
+	must(t, bot.FindMatch("easy", nil))
+	if start := expect(t, bot, "game_start"); start.Opponent != domain.GetBotName("easy") {
+		t.Errorf("opponent = %q, want the easy bot", start.Opponent)
+	}
+}
+
+func TestGuestUpgradeKeepsHistory(t *testing.T) {
+	ts := newTestServer(t)
+	g := ts.guest(t)
+	guestID := g.UserID
+	must(t, g.FindMatch("", nil))
+	expect(t, g, "queue_joined")
+	p := ts.player(t)
+	casual := false
+	must(t, p.FindMatch("", &casual))
+	gameID := expect(t, g, "game_start").GameID
+	expect(t, p, "game_start")
+	play(t, g, p, 0, 1, 0, 1, 0, 1, 0)
+	waitForSavedGame(t, ts, gameID)
+
+	if _, listed := leaderboardHas(t, p, g.Username); listed {
+		t.Error("guest is on the leaderboard")
+	}
+
+	if err := p.UpgradeGuest("someone", "someone@example.com", testPassword); err == nil || !strings.Contains(err.Error(), "409") {
+		t.Errorf("registered user upgrade error = %v, want 409", err)
+	}
+	if err := g.UpgradeGuest("guest_me", "me@example.com", testPassword); err == nil || !strings.Contains(err.Error(), "400") {
+		t.Errorf("reserved username error = %v, want 400", err)
+	}
+	if err := g.UpgradeGuest(p.Username, "me@example.com", testPassword); err == nil || !strings.Contains(err.Error(), "409") {
+		t.Errorf("taken username error = %v, want 409", err)
+	}
+
+	must(t, g.UpgradeGuest("upgraded", "upgraded@example.com", testPassword))
+	expect(t, g, "force_disconnect")
+	if g.UserID != guestID || g.Username != "upgraded" {
+		t.Fatalf("upgraded account = %d %q, want id %d", g.UserID, g.Username, guestID)
+	}
+
+	var history []struct {
+		ID     string `json:"id"`
+		Result string `json:"result"`
+	}
+	must(t, g.GetJSON("/api/history", &history))
+	if len(history) != 1 || history[0].ID != gameID || history[0].Result != "win" {
+		t.Errorf("history = %+v, want the guest's win", history)
+	}
+	if entry, listed := leaderboardHas(t, p, "upgraded"); !listed || entry.Wins != 1 {
+		t.Errorf("leaderboard entry = %+v (listed %t), want 1 win", entry, listed)
+	}
+
+	// The new token opens a regular socket with access to the rated queue
+	g.Close()
+	must(t, g.Connect())
+	eventually(t, "upgraded player online", func() bool { return ts.ConnManager.IsOnline(g.UserID) })
+	must(t, g.FindMatch("", nil))
+	if joined := expect(t, g, "queue_joined"); joined.Rated == nil || !*joined.Rated {
+		t.Errorf("queue_joined rated = %v after upgrade, want rated", joined.Rated)
+	}
+	must(t, g.Login("upgraded", testPassword))
+}
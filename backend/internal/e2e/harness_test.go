@@ -0,0 +1,197 @@
+// Package e2e boots the full HTTP + WebSocket stack on httptest with
+// in-memory storage and drives it through the typed protocol client.
+package e2e
+
+import (
+	"fmt"
+	"io"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/iamasit07/connect4/backend/internal/client"
+	"github.com/iamasit07/connect4/backend/internal/clock"
+	"github.com/iamasit07/connect4/backend/internal/config"
+	"github.com/iamasit07/connect4/backend/internal/domain"
+	"github.com/iamasit07/connect4/backend/internal/repository/memory"
+	"github.com/iamasit07/connect4/backend/internal/server"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	testPassword = "Passw0rd!"
+	waitTimeout  = 5 * time.Second
+)
+
+var userSeq atomic.Int64
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	gin.DefaultWriter = io.Discard
+	os.Exit(m.Run())
+}
+
+// testServer is one isolated instance of the app backed by memory stores.
+// All game and matchmaking timers run on Clock, which only moves when a test
+// advances it.
+type testServer struct {
+	*server.Server
+	URL     string
+	Clock   *clock.Fake
+	Games   *memory.GameRepo
+	Users   *memory.UserRepo
+	Chats   *memory.ChatRepo
+	MailDir string // account emails are written here as .eml files
+}
+
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+	return newTestServerWith(t, nil)
+}
+
+// newTestServerWith lets a test adjust the config before the server is built
+func newTestServerWith(t *testing.T, configure func(*config.Config)) *testServer {
+	t.Helper()
+
+	// Listen first so OAuth providers can be configured with our callback URL
+	httpServer := httptest.NewUnstartedServer(nil)
+	t.Cleanup(httpServer.Close)
+	baseURL := "http://" + httpServer.Listener.Addr().String()
+
+	cfg := config.LoadConfig()
+	cfg.SMTPHost = ""
+	cfg.MailDir = t.TempDir()
+	cfg.OAuthConfig.RedirectBaseURL = baseURL
+	cfg.PasswordHashCost = bcrypt.MinCost
+	cfg.TOTPEncryptionKey = "test-totp-key"
+	if configure != nil {
+		configure(cfg)
+	}
+	clk := clock.NewFake(time.Now())
+	users := memory.NewUserRepo()
+	games := memory.NewGameRepo(users)
+	chats := memory.NewChatRepo()
+	app := server.New(cfg, server.Stores{
+		Games:      games,
+		Users:      users,
+		Sessions:   memory.NewSessionRepo(),
+		Chats:      chats,
+		Friends:    memory.NewFriendRepo(users),
+		Admin:      memory.NewAdminRepo(),
+		Flags:      memory.NewIntegrityRepo(users),
+		Analyses:   memory.NewAnalysisRepo(games),
+		Tokens:     memory.NewAccountTokenRepo(),
+		Identities: memory.NewIdentityRepo(),
+		APIKeys:    memory.NewAPIKeyRepo(),
+	}, nil, clk)
+
+	httpServer.Config.Handler = app.Router
+	httpServer.Start()
+
+	return &testServer{Server: app, URL: baseURL, Clock: clk, Games: games, Users: users, Chats: chats, MailDir: cfg.MailDir}
+}
+
+// player registers a fresh account and opens its WebSocket
+func (ts *testServer) player(t *testing.T) *client.Client {
+	t.Helper()
+
+	n := userSeq.Add(1)
+	c := client.New(ts.URL)
+	username := fmt.Sprintf("player%d", n)
+	if err := c.Register(username, username+"@example.com", testPassword); err != nil {
+		t.Fatalf("register %s: %v", username, err)
+	}
+	if err := c.Connect(); err != nil {
+		t.Fatalf("connect %s: %v", username, err)
+	}
+	// init is processed asynchronously; wait for it so pushes triggered over HTTP reach the socket
+	eventually(t, username+" registered", func() bool { return ts.ConnManager.IsOnline(c.UserID) })
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+// startPvP queues two players and returns them as (player 1, player 2, gameID)
+func (ts *testServer) startPvP(t *testing.T) (*client.Client, *client.Client, string) {
+	t.Helper()
+
+	a, b := ts.player(t), ts.player(t)
+	must(t, a.FindMatch("", nil))
+	expect(t, a, "queue_joined")
+	must(t, b.FindMatch("", nil))
+
+	startA := expect(t, a, "game_start")
+	startB := expect(t, b, "game_start")
+	if startA.GameID == "" || startA.GameID != startB.GameID {
+		t.Fatalf("players got different games: %q vs %q", startA.GameID, startB.GameID)
+	}
+
+	// The player who queued first is player 1 and moves first
+	if startA.YourPlayer == int(domain.Player1) {
+		return a, b, startA.GameID
+	}
+	return b, a, startA.GameID
+}
+
+// play alternates moves between p1 and p2, waiting for each move to be broadcast
+func play(t *testing.T, p1, p2 *client.Client, columns ...int) {
+	t.Helper()
+
+	for i, col := range columns {
+		mover := p1
+		if i%2 == 1 {
+			mover = p2
+		}
+		must(t, mover.MakeMove(col))
+		expect(t, p1, "move_made")
+		expect(t, p2, "move_made")
+	}
+}
+
+// advanceUntil steps the fake clock by step until c receives msgType. Timers
+// are scheduled by server goroutines, so a single Advance may run before the
+// timer it is meant to fire has been created.
+func (ts *testServer) advanceUntil(t *testing.T, step time.Duration, c *client.Client, msgType string) domain.ServerMessage {
+	t.Helper()
+
+	deadline := time.Now().Add(waitTimeout)
+	for time.Now().Before(deadline) {
+		ts.Clock.Advance(step)
+		if msg, err := c.WaitFor(msgType, 20*time.Millisecond); err == nil {
+			return msg
+		}
+	}
+	t.Fatalf("%s: %s not received while advancing the clock", c.Username, msgType)
+	return domain.ServerMessage{}
+}
+
+func expect(t *testing.T, c *client.Client, msgType string) domain.ServerMessage {
+	t.Helper()
+	msg, err := c.WaitFor(msgType, waitTimeout)
+	if err != nil {
+		t.Fatalf("%s: %v", c.Username, err)
+	}
+	return msg
+}
+
+func must(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// eventually polls cond until it holds or the wait timeout expires
+func eventually(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(waitTimeout)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for %s", what)
+}
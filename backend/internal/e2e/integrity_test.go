@@ -0,0 +1,196 @@
+package e2e
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/iamasit07/connect4/backend/internal/client"
+	"github.com/iamasit07/connect4/backend/internal/config"
+	"github.com/iamasit07/connect4/backend/internal/domain"
+)
+
+// rematchViaQueue puts a and b through the rated queue again and returns the game ID
+func rematchViaQueue(t *testing.T, a, b *client.Client) string {
+	t.Helper()
+	must(t, a.FindMatch("", nil))
+	expect(t, a, "queue_joined")
+	must(t, b.FindMatch("", nil))
+	start := expect(t, a, "game_start")
+	expect(t, b, "game_start")
+	return start.GameID
+}
+
+// throwGames has loser surrender n quick rated games to winner
+func throwGames(t *testing.T, ts *testServer, winner, loser *client.Client, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		gameID := rematchViaQueue(t, winner, loser)
+		must(t, loser.Abandon())
+		expect(t, winner, "game_over")
+		waitForSavedGame(t, ts, gameID)
+	}
+}
+
+func TestIntegrityFlagsWinTrading(t *testing.T) {
+	ts := newTestServer(t)
+	a := ts.admin(t)
+	booster, feeder := ts.player(t), ts.player(t)
+	throwGames(t, ts, booster, feeder, 5)
+
+	flagged, err := a.ScanIntegrity()
+	must(t, err)
+	if flagged != 1 {
+		t.Fatalf("scan flagged %d pairings, want 1", flagged)
+	}
+	flags, err := a.IntegrityFlags(domain.FlagOpen)
+	must(t, err)
+	if len(flags) != 1 {
+		t.Fatalf("open flags = %+v, want 1", flags)
+	}
+	flag := flags[0]
+	if flag.UserID != booster.UserID || flag.OpponentUsername != feeder.Username || flag.Games != 5 {
+		t.Errorf("flag = %+v, want %s flagged for games against %s", flag, booster.Username, feeder.Username)
+	}
+	// Every test client logs in from 127.0.0.1
+	for _, signal := range []string{domain.SignalRepeatedPairing, domain.SignalQuickSurrenders, domain.SignalLopsided, domain.SignalSharedIP} {
+		if !slices.Contains(flag.Signals, signal) {
+			t.Errorf("signals %v missing %s", flag.Signals, signal)
+		}
+	}
+	if flag.Score != 100 {
+		t.Errorf("score = %d, want 100", flag.Score)
+	}
+
+	// Rescanning refreshes the open flag instead of adding another
+	_, err = a.ScanIntegrity()
+	must(t, err)
+	if all, _ := a.IntegrityFlags(""); len(all) != 1 {
+		t.Errorf("flags after rescan = %d, want 1", len(all))
+	}
+
+	if err := a.ReviewFlag(flag.ID, "ignored", ""); err == nil || !strings.Contains(err.Error(), "400") {
+		t.Errorf("invalid review status error = %v, want 400", err)
+	}
+	must(t, a.ReviewFlag(flag.ID, domain.FlagDismissed, "friends practising"))
+	if open, _ := a.IntegrityFlags(domain.FlagOpen); len(open) != 0 {
+		t.Errorf("open flags after review = %+v", open)
+	}
+	actions, err := a.AdminActions(1)
+	must(t, err)
+	if len(actions) != 1 || actions[0].Action != domain.AdminActionReviewFlag || *actions[0].TargetUserID != booster.UserID {
+		t.Errorf("audit log = %+v", actions)
+	}
+
+	// A reviewed pairing stays quiet until the pair plays again
+	if flagged, _ := a.ScanIntegrity(); flagged != 0 {
+		t.Errorf("dismissed pairing re-flagged without new games")
+	}
+	ts.Clock.Advance(time.Minute)
+	throwGames(t, ts, booster, feeder, 1)
+	if flagged, _ := a.ScanIntegrity(); flagged != 1 {
+		t.Errorf("new games after review flagged %d pairings, want 1", flagged)
+	}
+	if open, _ := a.IntegrityFlags(domain.FlagOpen); len(open) != 1 || open[0].Games != 6 {
+		t.Errorf("open flags = %+v, want one covering 6 games", open)
+	}
+}
+
+func TestIntegrityIgnoresNormalPlay(t *testing.T) {
+	ts := newTestServer(t)
+	a := ts.admin(t)
+	p1, p2, gameID := ts.startPvP(t)
+	play(t, p1, p2, 0, 1, 0, 1, 0, 1, 0)
+	waitForSavedGame(t, ts, gameID)
+
+	flagged, err := a.ScanIntegrity()
+	must(t, err)
+	if flagged != 0 {
+		t.Errorf("a single game was flagged")
+	}
+}
+
+func TestRatedPairDailyLimit(t *testing.T) {
+	ts := newTestServerWith(t, func(cfg *config.Config) { cfg.RatedPairDailyLimit = 2 })
+	a, b := ts.player(t), ts.player(t)
+	throwGames(t, ts, a, b, 2)
+
+	must(t, a.FindMatch("", nil))
+	expect(t, a, "queue_joined")
+	must(t, b.FindMatch("", nil))
+	expect(t, b, "queue_joined")
+	if _, err := a.WaitFor("game_start", 300*time.Millisecond); err == nil {
+		t.Fatal("pair was matched past the daily rated limit")
+	}
+	must(t, a.CancelSearch())
+	must(t, b.CancelSearch())
+
+	// Casual games don't count towards the limit
+	casual := false
+	must(t, a.FindMatch("", &casual))
+	expect(t, a, "queue_joined")
+	must(t, b.FindMatch("", &casual))
+	expect(t, b, "game_start")
+
+	// The limit resets once the games are a day old
+	must(t, a.Abandon())
+	expect(t, b, "game_over")
+	ts.Clock.Advance(25 * time.Hour)
+	rematchViaQueue(t, a, b)
+}
+
+func TestRatedPairDailyLimitCoversChallenges(t *testing.T) {
+	ts := newTestServerWith(t, func(cfg *config.Config) { cfg.RatedPairDailyLimit = 2 })
+	a, b := ts.player(t), ts.player(t)
+	makeFriends(t, a, b)
+	throwGames(t, ts, a, b, 1)
+
+	// A challenge sent under the limit can't be accepted once the pair reaches it
+	must(t, a.Challenge(b.UserID, nil))
+	received := expect(t, b, "challenge_received")
+	expect(t, a, "challenge_sent")
+	throwGames(t, ts, a, b, 1)
+	must(t, b.RespondChallenge(received.ChallengeID, true))
+	expect(t, b, "error")
+	expect(t, a, "error")
+	if _, err := a.WaitFor("game_start", 300*time.Millisecond); err == nil {
+		t.Fatal("challenge started a rated game past the daily limit")
+	}
+
+	must(t, a.Challenge(b.UserID, nil))
+	if msg := expect(t, a, "error"); msg.Code != domain.ErrRejected {
+		t.Errorf("rated challenge past the limit: error code %q, want %q", msg.Code, domain.ErrRejected)
+	}
+
+	casual := false
+	must(t, a.Challenge(b.UserID, &casual))
+	received = expect(t, b, "challenge_received")
+	must(t, b.RespondChallenge(received.ChallengeID, true))
+	if start := expect(t, a, "game_start"); start.Rated == nil || *start.Rated {
+		t.Errorf("casual challenge start rated = %v, want false", start.Rated)
+	}
+}
+
+func TestRatedPairDailyLimitCoversRematches(t *testing.T) {
+	ts := newTestServerWith(t, func(cfg *config.Config) { cfg.RatedPairDailyLimit = 2 })
+	p1, p2, gameID := ts.startPvP(t)
+
+	// The first rematch is the pair's second rated game; the next one is casual
+	for _, wantRated := range []bool{true, false} {
+		play(t, p1, p2, 0, 1, 0, 1, 0, 1, 0)
+		expect(t, p1, "game_over")
+		expect(t, p2, "game_over")
+		waitForSavedGame(t, ts, gameID)
+
+		must(t, p2.RequestRematch())
+		expect(t, p1, "rematch_requested")
+		must(t, p1.RespondRematch(true))
+		start := expect(t, p1, "game_start")
+		expect(t, p2, "game_start")
+		if start.Rated == nil || *start.Rated != wantRated {
+			t.Fatalf("rematch rated = %v, want %v", start.Rated, wantRated)
+		}
+		gameID = start.GameID
+	}
+}
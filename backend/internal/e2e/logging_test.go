@@ -0,0 +1,138 @@
+package e2e
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/iamasit07/connect4/backend/internal/client"
+	"github.com/iamasit07/connect4/backend/internal/logging"
+)
+
+// logCapture collects JSON log records written while a test runs
+type logCapture struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (l *logCapture) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.buf.Write(p)
+}
+
+// records returns the records whose attributes include every key/value in match
+func (l *logCapture) records(match map[string]any) []map[string]any {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var out []map[string]any
+	for _, line := range strings.Split(l.buf.String(), "\n") {
+		var rec map[string]any
+		if json.Unmarshal([]byte(line), &rec) != nil {
+			continue
+		}
+		matched := true
+		for k, v := range match {
+			if rec[k] != v {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			out = append(out, rec)
+		}
+	}
+	return out
+}
+
+// captureLogs sends debug-level JSON logs to a buffer until the test ends.
+// Call it before building the server: components keep the logger they start with.
+func captureLogs(t *testing.T) *logCapture {
+	t.Helper()
+
+	capture := &logCapture{}
+	prevLogger, prevWriter, prevFlags := slog.Default(), log.Writer(), log.Flags()
+	logging.Setup(capture, slog.LevelDebug, "json")
+	t.Cleanup(func() {
+		slog.SetDefault(prevLogger)
+		log.SetOutput(prevWriter)
+		log.SetFlags(prevFlags)
+	})
+	return capture
+}
+
+// getMe calls /api/auth/me as c, optionally with a request ID
+func getMe(t *testing.T, ts *testServer, c *client.Client, requestID string) *http.Response {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodGet, ts.URL+"/api/auth/me", nil)
+	req.Header.Set("Authorization", "Bearer "+c.Token)
+	if requestID != "" {
+		req.Header.Set("X-Request-ID", requestID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	must(t, err)
+	resp.Body.Close()
+	return resp
+}
+
+func TestRequestIDs(t *testing.T) {
+	logs := captureLogs(t)
+	ts := newTestServer(t)
+	p := ts.player(t)
+
+	if got := getMe(t, ts, p, "trace-123").Header.Get("X-Request-ID"); got != "trace-123" {
+		t.Errorf("echoed request ID = %q, want trace-123", got)
+	}
+	generated := getMe(t, ts, p, "").Header.Get("X-Request-ID")
+	if generated == "" {
+		t.Error("no request ID generated")
+	}
+	if got := getMe(t, ts, p, "bad id\"}").Header.Get("X-Request-ID"); got == "" || strings.Contains(got, " ") {
+		t.Errorf("unsafe request ID kept: %q", got)
+	}
+
+	// The access line carries the ID and the authenticated user
+	recs := logs.records(map[string]any{"msg": "request", "request_id": "trace-123"})
+	if len(recs) != 1 {
+		t.Fatalf("got %d access records for trace-123, want 1", len(recs))
+	}
+	rec := recs[0]
+	if rec["user_id"] != float64(p.UserID) || rec["route"] != "/api/auth/me" || rec["status"] != float64(http.StatusOK) {
+		t.Errorf("access record = %v", rec)
+	}
+	if len(logs.records(map[string]any{"request_id": generated})) == 0 {
+		t.Error("generated request ID not logged")
+	}
+}
+
+func TestGameLogCorrelation(t *testing.T) {
+	logs := captureLogs(t)
+	ts := newTestServer(t)
+	p1, p2, gameID := ts.startPvP(t)
+	play(t, p1, p2, 0, 1, 0, 1, 0, 1, 0)
+	expect(t, p1, "game_over")
+
+	for _, want := range []struct{ component, msg string }{
+		{"matchmaking", "Match started"},
+		{"game", "Game started"},
+		{"game", "Game finished"},
+	} {
+		recs := logs.records(map[string]any{"component": want.component, "msg": want.msg, "game_id": gameID})
+		if len(recs) != 1 {
+			t.Errorf("got %d %q records from %s for the game, want 1", len(recs), want.msg, want.component)
+		}
+	}
+
+	// Each socket's lines carry its user
+	for _, p := range []*client.Client{p1, p2} {
+		if len(logs.records(map[string]any{"component": "ws", "user_id": float64(p.UserID)})) == 0 {
+			t.Errorf("no WebSocket records for user %d", p.UserID)
+		}
+	}
+}
@@ -0,0 +1,298 @@
+package e2e
+
+import (
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/iamasit07/connect4/backend/internal/client"
+	"github.com/iamasit07/connect4/backend/internal/config"
+	"github.com/iamasit07/connect4/backend/internal/oauth/mockoidc"
+	"github.com/iamasit07/connect4/backend/pkg/httputil"
+)
+
+// newOIDCTestServer starts the app with a mock OIDC issuer configured as provider "mock"
+func newOIDCTestServer(t *testing.T) (*testServer, *mockoidc.Server) {
+	t.Helper()
+	provider := mockoidc.New("connect4-test", "test-secret")
+	t.Cleanup(provider.Close)
+	ts := newTestServerWith(t, func(cfg *config.Config) {
+		cfg.OAuthConfig.Providers = append(cfg.OAuthConfig.Providers, config.OAuthProvider{
+			Name:         "mock",
+			DisplayName:  "Mock ID",
+			Kind:         config.OAuthKindOIDC,
+			ClientID:     provider.ClientID,
+			ClientSecret: provider.ClientSecret,
+			Issuer:       provider.URL,
+		})
+	})
+	return ts, provider
+}
+
+// newJar returns an empty cookie jar: one browser's cookies
+func newJar(t *testing.T) http.CookieJar {
+	t.Helper()
+	jar, err := cookiejar.New(nil)
+	must(t, err)
+	return jar
+}
+
+// followOAuth plays the browser holding jar: it follows redirects from start
+// through the provider and back until the app sends it to the frontend. It
+// returns that final location and the access token cookie set on the way, if any.
+func followOAuth(t *testing.T, jar http.CookieJar, start string) (*url.URL, string) {
+	t.Helper()
+	browser := &http.Client{Jar: jar, CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	next := start
+	var accessToken string
+	for hop := 0; hop < 10; hop++ {
+		resp, err := browser.Get(next)
+		must(t, err)
+		resp.Body.Close()
+		for _, cookie := range resp.Cookies() {
+			if cookie.Name == httputil.AuthCookieName && cookie.Value != "" {
+				accessToken = cookie.Value
+			}
+		}
+		location, err := resp.Location()
+		if err != nil {
+			t.Fatalf("GET %s returned %d without a redirect", next, resp.StatusCode)
+		}
+		if strings.HasPrefix(location.String(), config.AppConfig.FrontendURL) {
+			return location, accessToken
+		}
+		next = location.String()
+	}
+	t.Fatalf("too many redirects from %s", start)
+	return nil, ""
+}
+
+// oauthLogin signs in through the mock provider and returns a client holding the issued token
+func oauthLogin(t *testing.T, ts *testServer) *client.Client {
+	t.Helper()
+	location, token := followOAuth(t, newJar(t), ts.URL+"/api/auth/oauth/mock/login")
+	if location.Path != "/dashboard" || token == "" {
+		t.Fatalf("login landed on %s (token %t), want the dashboard", location, token != "")
+	}
+	c := client.New(ts.URL)
+	c.Token = token
+	var me struct {
+		ID       int64  `json:"id"`
+		Username string `json:"username"`
+	}
+	must(t, c.GetJSON("/api/auth/me", &me))
+	c.UserID, c.Username = me.ID, me.Username
+	return c
+}
+
+func TestOIDCSignupAndLogin(t *testing.T) {
+	ts, provider := newOIDCTestServer(t)
+
+	providers, err := client.New(ts.URL).OAuthProviders()
+	must(t, err)
+	if len(providers) != 1 || providers[0].Name != "mock" || providers[0].DisplayName != "Mock ID" {
+		t.Fatalf("providers = %+v, want only the mock", providers)
+	}
+
+	// Until a user is set the provider denies access
+	if location, _ := followOAuth(t, newJar(t), ts.URL+"/api/auth/oauth/mock/login"); location.Query().Get("error") != "auth_failed" {
+		t.Errorf("denied login landed on %s, want auth_failed", location)
+	}
+
+	provider.SetUser(mockoidc.User{Subject: "alice-1", Email: "alice@example.com", EmailVerified: true, Name: "Alice"})
+	jar := newJar(t)
+	location, token := followOAuth(t, jar, ts.URL+"/api/auth/oauth/mock/login")
+	if location.Path != "/complete-signup" || token != "" {
+		t.Fatalf("first login landed on %s, want signup", location)
+	}
+	if q := location.Query(); q.Get("email") != "alice@example.com" || q.Get("provider") != "mock" || q.Has("token") {
+		t.Errorf("signup query = %v", q)
+	}
+
+	// The setup token is only in the browser's cookie, not the URL
+	if err := client.New(ts.URL).CompleteOAuthSignup("alice", testPassword); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("signup without the setup cookie error = %v, want 401", err)
+	}
+	c := client.New(ts.URL)
+	c.HTTP.Jar = jar
+	must(t, c.CompleteOAuthSignup("alice", testPassword))
+	if !isVerified(t, c) {
+		t.Error("provider-verified email not marked verified")
+	}
+	identities, err := c.Identities()
+	must(t, err)
+	if len(identities) != 1 || identities[0].Provider != "mock" || identities[0].Email != "alice@example.com" {
+		t.Errorf("identities = %+v", identities)
+	}
+
+	// The next login goes straight to the dashboard as the same player
+	again := oauthLogin(t, ts)
+	if again.UserID != c.UserID {
+		t.Errorf("logged in as %d, want %d", again.UserID, c.UserID)
+	}
+
+	if location, _ := followOAuth(t, newJar(t), ts.URL+"/api/auth/oauth/nope/login"); location.Query().Get("error") != "unknown_provider" {
+		t.Errorf("unknown provider landed on %s", location)
+	}
+}
+
+// The signup page only knows the provider from the redirect; the setup token
+// travels in the cookie alone, and completing with it links the identity
+func TestOAuthSignupCompletesFromCookie(t *testing.T) {
+	ts, provider := newOIDCTestServer(t)
+	provider.SetUser(mockoidc.User{Subject: "bob-1", Email: "bob@example.com", EmailVerified: true, Name: "Bob"})
+
+	jar := newJar(t)
+	location, _ := followOAuth(t, jar, ts.URL+"/api/auth/oauth/mock/login")
+	if location.Path != "/complete-signup" || location.Query().Get("provider") != "mock" {
+		t.Fatalf("login landed on %s, want signup for provider mock", location)
+	}
+
+	// What the page posts: no token, no email, just the browser's cookies
+	c := client.New(ts.URL)
+	c.HTTP.Jar = jar
+	must(t, c.CompleteOAuthSignup("bob", testPassword))
+	identities, err := c.Identities()
+	must(t, err)
+	if len(identities) != 1 || identities[0].Provider != "mock" {
+		t.Fatalf("identities = %+v, want the mock provider linked", identities)
+	}
+	if again := oauthLogin(t, ts); again.UserID != c.UserID {
+		t.Errorf("provider login signed in as %d, want %d", again.UserID, c.UserID)
+	}
+
+	// The cookie is spent once the account exists
+	replay := client.New(ts.URL)
+	replay.HTTP.Jar = jar
+	if err := replay.CompleteOAuthSignup("bob2", testPassword); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("second completion error = %v, want 401", err)
+	}
+}
+
+func TestOIDCMatchesVerifiedEmail(t *testing.T) {
+	ts, provider := newOIDCTestServer(t)
+	p := ts.player(t)
+
+	// An unverified address must not sign anyone into an existing account
+	provider.SetUser(mockoidc.User{Subject: "p-unverified", Email: p.Username + "@example.com", Name: "Imposter"})
+	if location, _ := followOAuth(t, newJar(t), ts.URL+"/api/auth/oauth/mock/login"); location.Query().Get("error") != "email_in_use" {
+		t.Errorf("unverified email landed on %s, want email_in_use", location)
+	}
+
+	provider.SetUser(mockoidc.User{Subject: "p-1", Email: p.Username + "@example.com", EmailVerified: true})
+	c := oauthLogin(t, ts)
+	if c.UserID != p.UserID {
+		t.Fatalf("logged in as %d, want %d", c.UserID, p.UserID)
+	}
+	identities, err := c.Identities()
+	must(t, err)
+	if len(identities) != 1 || identities[0].Provider != "mock" {
+		t.Errorf("identities = %+v, want the auto-linked mock", identities)
+	}
+	if !isVerified(t, c) {
+		t.Error("email not marked verified after a verified provider login")
+	}
+}
+
+func TestLinkAndUnlinkIdentity(t *testing.T) {
+	ts, provider := newOIDCTestServer(t)
+	p := ts.player(t)
+	other := ts.player(t)
+
+	if _, err := p.StartLinkIdentity("nope"); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("unknown provider error = %v, want 404", err)
+	}
+
+	provider.SetUser(mockoidc.User{Subject: "gamer-42", Email: "gamer@example.net", Name: "Gamer"})
+	jar := newJar(t)
+	p.HTTP.Jar = jar
+	linkURL, err := p.StartLinkIdentity("mock")
+	must(t, err)
+	if location, _ := followOAuth(t, jar, linkURL); location.Path != "/settings" || location.Query().Get("linked") != "mock" {
+		t.Fatalf("link landed on %s", location)
+	}
+
+	// The provider account now signs in as p even though its email differs
+	if c := oauthLogin(t, ts); c.UserID != p.UserID {
+		t.Errorf("linked login as %d, want %d", c.UserID, p.UserID)
+	}
+
+	// Nobody else can claim the same provider account
+	other.HTTP.Jar = newJar(t)
+	linkURL, err = other.StartLinkIdentity("mock")
+	must(t, err)
+	if location, _ := followOAuth(t, other.HTTP.Jar, linkURL); location.Query().Get("error") != "identity_in_use" {
+		t.Errorf("second link landed on %s, want identity_in_use", location)
+	}
+
+	// oauthLogin replaced p's session, so sign in again with the password
+	must(t, p.Login(p.Username, testPassword))
+	must(t, p.UnlinkIdentity("mock"))
+	if err := p.UnlinkIdentity("mock"); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("second unlink error = %v, want 404", err)
+	}
+	identities, err := p.Identities()
+	must(t, err)
+	if len(identities) != 0 {
+		t.Errorf("identities after unlink = %+v", identities)
+	}
+
+	// Unlinked, the provider account starts a fresh signup instead
+	if location, _ := followOAuth(t, newJar(t), ts.URL+"/api/auth/oauth/mock/login"); location.Path != "/complete-signup" {
+		t.Errorf("login after unlink landed on %s, want signup", location)
+	}
+}
+
+func TestOAuthCallbackRequiresStartingBrowser(t *testing.T) {
+	ts, provider := newOIDCTestServer(t)
+	p := ts.player(t)
+	provider.SetUser(mockoidc.User{Subject: "attacker-1", Email: "attacker@example.com", EmailVerified: true})
+
+	// The attacker starts a flow and stops at the callback, which carries a
+	// valid code and state for their own provider account
+	attacker := &http.Client{Jar: newJar(t), CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := attacker.Get(ts.URL + "/api/auth/oauth/mock/login")
+	must(t, err)
+	resp.Body.Close()
+	authorize, err := resp.Location()
+	must(t, err)
+	if q := authorize.Query(); q.Get("code_challenge_method") != "S256" || q.Get("code_challenge") == "" || q.Get("state") == "" {
+		t.Fatalf("authorize URL %s lacks PKCE or state", authorize)
+	}
+	resp, err = attacker.Get(authorize.String())
+	must(t, err)
+	resp.Body.Close()
+	callback, err := resp.Location()
+	must(t, err)
+
+	// Opened in the victim's browser it is refused, so the victim isn't
+	// signed in as (or linked to) the attacker's account
+	if location, token := followOAuth(t, newJar(t), callback.String()); location.Query().Get("error") != "invalid_state" || token != "" {
+		t.Errorf("replayed callback landed on %s (token %t), want invalid_state", location, token != "")
+	}
+	p.HTTP.Jar = newJar(t)
+	_, err = p.StartLinkIdentity("mock")
+	must(t, err)
+	if location, _ := followOAuth(t, p.HTTP.Jar, callback.String()); location.Query().Get("error") != "invalid_state" {
+		t.Errorf("callback with another flow's state landed on %s, want invalid_state", location)
+	}
+	identities, err := p.Identities()
+	must(t, err)
+	if len(identities) != 0 {
+		t.Errorf("victim identities = %+v, want none", identities)
+	}
+
+	// A state that doesn't match the browser's own cookie is refused too
+	tampered := *callback
+	q := tampered.Query()
+	q.Set("state", "forged")
+	tampered.RawQuery = q.Encode()
+	resp, err = attacker.Get(ts.URL + "/api/auth/oauth/mock/login")
+	must(t, err)
+	resp.Body.Close()
+	if location, _ := followOAuth(t, attacker.Jar, tampered.String()); location.Query().Get("error") != "invalid_state" {
+		t.Errorf("forged state landed on %s, want invalid_state", location)
+	}
+}
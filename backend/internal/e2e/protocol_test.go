@@ -0,0 +1,158 @@
+package e2e
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/iamasit07/connect4/backend/internal/client"
+	"github.com/iamasit07/connect4/backend/internal/protocol"
+)
+
+// rawSocket opens a WebSocket and sends init as given, so the test sees
+// the frames exactly as the server writes them
+func (ts *testServer) rawSocket(t *testing.T, init string) *websocket.Conn {
+	t.Helper()
+	header := http.Header{"Origin": []string{client.DefaultOrigin}}
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(ts.URL, "http")+"/ws", header)
+	must(t, err)
+	t.Cleanup(func() { conn.Close() })
+	must(t, conn.WriteMessage(websocket.TextMessage, []byte(init)))
+	return conn
+}
+
+// registered signs up an account without opening its WebSocket
+func (ts *testServer) registered(t *testing.T) *client.Client {
+	t.Helper()
+	c := ts.player(t)
+	c.Close()
+	return c
+}
+
+// readFrame returns the next frame as generic JSON
+func readFrame(t *testing.T, conn *websocket.Conn) map[string]any {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(waitTimeout))
+	var frame map[string]any
+	if err := conn.ReadJSON(&frame); err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	return frame
+}
+
+// roundTrip sends data and returns the reply, skipping presence pushes
+func roundTrip(t *testing.T, conn *websocket.Conn, data string) map[string]any {
+	t.Helper()
+	must(t, conn.WriteMessage(websocket.TextMessage, []byte(data)))
+	for {
+		if frame := readFrame(t, conn); frame["type"] != "presence_update" {
+			return frame
+		}
+	}
+}
+
+func TestProtocolV1IsFlat(t *testing.T) {
+	ts := newTestServer(t)
+	p := ts.registered(t)
+	conn := ts.rawSocket(t, `{"type":"init","jwt":"`+p.Token+`"}`)
+
+	joined := roundTrip(t, conn, `{"type":"find_match"}`)
+	if joined["type"] != "queue_joined" || joined["rated"] != true || joined["payload"] != nil {
+		t.Errorf("queue_joined = %v, want a flat message with rated", joined)
+	}
+
+	// Unknown types used to be dropped silently
+	unknown := roundTrip(t, conn, `{"type":"resign"}`)
+	if unknown["type"] != "error" || unknown["code"] != "unknown_type" {
+		t.Errorf("reply = %v, want an unknown_type error", unknown)
+	}
+	bad := roundTrip(t, conn, `{"type":"make_move","column":"3"}`)
+	if bad["code"] != "invalid_payload" || !strings.Contains(bad["message"].(string), `"column"`) {
+		t.Errorf("reply = %v, want an invalid_payload error naming column", bad)
+	}
+}
+
+func TestProtocolV2Envelopes(t *testing.T) {
+	ts := newTestServer(t)
+	p := ts.registered(t)
+	conn := ts.rawSocket(t, `{"type":"init","payload":{"jwt":"`+p.Token+`","versions":[1,2,3]}}`)
+
+	welcome := readFrame(t, conn)
+	want := map[string]any{"type": "welcome", "payload": map[string]any{"version": 2.0, "versions": []any{1.0, 2.0}}}
+	if !reflect.DeepEqual(welcome, want) {
+		t.Errorf("welcome = %v, want %v", welcome, want)
+	}
+
+	// Only the type's own fields are sent
+	joined := roundTrip(t, conn, `{"type":"find_match","payload":{}}`)
+	want = map[string]any{"type": "queue_joined", "payload": map[string]any{"rated": true}}
+	if !reflect.DeepEqual(joined, want) {
+		t.Errorf("queue_joined = %v, want %v", joined, want)
+	}
+	if left := roundTrip(t, conn, `{"type":"cancel_search"}`); left["type"] != "queue_left" {
+		t.Errorf("reply = %v, want queue_left", left)
+	}
+
+	tests := []struct {
+		name string
+		send string
+		code string
+	}{
+		{"invalid JSON", `{"type":`, "invalid_json"},
+		{"unknown type", `{"type":"resign","payload":{}}`, "unknown_type"},
+		{"no type", `{"payload":{}}`, "invalid_payload"},
+		{"missing field", `{"type":"make_move","payload":{}}`, "invalid_payload"},
+		{"unknown field", `{"type":"make_move","payload":{"column":3,"row":1}}`, "invalid_payload"},
+		{"flat fields", `{"type":"make_move","column":3}`, "invalid_payload"},
+		{"out of range", `{"type":"make_move","payload":{"column":7}}`, "invalid_payload"},
+		{"bad enum", `{"type":"find_match","payload":{"difficulty":"expert"}}`, "invalid_payload"},
+		{"second init", `{"type":"init","payload":{"jwt":"x"}}`, "rejected"},
+		{"no game", `{"type":"make_move","payload":{"column":3}}`, "not_found"},
+	}
+	for _, tt := range tests {
+		reply := roundTrip(t, conn, tt.send)
+		payload, _ := reply["payload"].(map[string]any)
+		if reply["type"] != "error" || payload["code"] != tt.code || payload["message"] == "" {
+			t.Errorf("%s: reply = %v, want a %s error", tt.name, reply, tt.code)
+		}
+	}
+}
+
+func TestProtocolUnsupportedVersion(t *testing.T) {
+	ts := newTestServer(t)
+	p := ts.registered(t)
+	conn := ts.rawSocket(t, `{"type":"init","payload":{"jwt":"`+p.Token+`","versions":[9]}}`)
+
+	// The client's version is unknown, so the error is in version 1
+	reply := readFrame(t, conn)
+	if reply["code"] != "unsupported_version" || !reflect.DeepEqual(reply["versions"], []any{1.0, 2.0}) {
+		t.Errorf("reply = %v, want unsupported_version listing 1 and 2", reply)
+	}
+	if _, _, err := conn.ReadMessage(); err == nil {
+		t.Error("socket still open after unsupported_version")
+	}
+}
+
+func TestProtocolMixedVersionsPlay(t *testing.T) {
+	ts := newTestServer(t)
+	p1, p2, _ := ts.startPvP(t)
+
+	// Player 2 comes back on an old client
+	p2.Close()
+	p2.Protocol = protocol.V1
+	must(t, p2.Connect())
+	state := expect(t, p2, "game_state")
+	if state.YourPlayer != 2 {
+		t.Fatalf("game_state yourPlayer = %d, want 2", state.YourPlayer)
+	}
+	expect(t, p1, "opponent_reconnected")
+
+	play(t, p1, p2, 0, 1, 0, 1, 0, 1, 0)
+	if over := expect(t, p2, "game_over"); over.Winner != p1.Username || len(over.WinningCells) != 4 {
+		t.Errorf("game_over = %+v, want %s to win with 4 cells", over, p1.Username)
+	}
+	expect(t, p1, "game_over")
+}
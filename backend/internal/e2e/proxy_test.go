@@ -0,0 +1,114 @@
+package e2e
+
+import (
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/iamasit07/connect4/backend/internal/client"
+	"github.com/iamasit07/connect4/backend/internal/config"
+	"github.com/iamasit07/connect4/backend/pkg/useragent"
+)
+
+// behind signs p's account in on a client that sends the given forwarding header
+func (ts *testServer) behind(t *testing.T, p *client.Client, header, value string) *client.Client {
+	t.Helper()
+
+	c := client.New(ts.URL)
+	c.Header = http.Header{header: {value}}
+	must(t, c.Login(p.Username, testPassword))
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+// sessionIP returns the IP recorded for c's own session
+func sessionIP(t *testing.T, c *client.Client) string {
+	t.Helper()
+
+	devices, err := c.ActiveSessions()
+	must(t, err)
+	for _, d := range devices {
+		if d.Current {
+			return d.IPAddress
+		}
+	}
+	t.Fatal("current session not listed")
+	return ""
+}
+
+// cidrs parses a list of CIDRs for TrustedProxies
+func cidrs(t *testing.T, list ...string) []*net.IPNet {
+	t.Helper()
+	var networks []*net.IPNet
+	for _, cidr := range list {
+		_, n, err := net.ParseCIDR(cidr)
+		must(t, err)
+		networks = append(networks, n)
+	}
+	return networks
+}
+
+func TestTrustedProxyHeaders(t *testing.T) {
+	tests := []struct {
+		mode   useragent.Header
+		header string
+		value  string
+		want   string
+	}{
+		// Walked right to left past the private proxy hop
+		{useragent.HeaderXForwardedFor, "X-Forwarded-For", "198.51.100.9, 203.0.113.7, 10.0.0.2", "203.0.113.7"},
+		{useragent.HeaderXRealIP, "X-Real-IP", "203.0.113.8", "203.0.113.8"},
+		{useragent.HeaderForwarded, "Forwarded", `for=198.51.100.9, for="[2001:db8::1]:4711";proto=https`, "2001:db8::1"},
+
+		// Headers the deployment's proxy doesn't set are the client's own
+		{useragent.HeaderXForwardedFor, "Forwarded", "for=203.0.113.9", "127.0.0.1"},
+		{useragent.HeaderXForwardedFor, "X-Real-IP", "203.0.113.9", "127.0.0.1"},
+		{useragent.HeaderForwarded, "X-Forwarded-For", "203.0.113.9", "127.0.0.1"},
+	}
+	for _, tt := range tests {
+		ts := newTestServerWith(t, func(cfg *config.Config) {
+			cfg.TrustedProxies = cidrs(t, "127.0.0.0/8", "10.0.0.0/8")
+			cfg.ClientIPHeader = tt.mode
+		})
+		c := ts.behind(t, ts.player(t), tt.header, tt.value)
+		if got := sessionIP(t, c); got != tt.want {
+			t.Errorf("%s proxy, %s: %q: session IP = %q, want %q", tt.mode, tt.header, tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestForwardedClientsGetOwnAllowance(t *testing.T) {
+	// The test server is reached over loopback, which is trusted by default
+	ts := newTestServerWith(t, func(cfg *config.Config) { cfg.MaxConnsPerIP = 1 })
+	p := ts.player(t)
+
+	// Private ranges aren't trusted by default, so 10.0.0.2 is the client
+	if got := sessionIP(t, ts.behind(t, p, "X-Forwarded-For", "203.0.113.7, 10.0.0.2")); got != "10.0.0.2" {
+		t.Errorf("session IP = %q, want the untrusted private hop", got)
+	}
+
+	for _, ip := range []string{"203.0.113.10", "203.0.113.11"} {
+		c := ts.behind(t, p, "X-Forwarded-For", ip)
+		if err := c.Connect(); err != nil {
+			t.Errorf("connect as %s: %v", ip, err)
+		}
+	}
+}
+
+func TestUntrustedProxyHeaders(t *testing.T) {
+	ts := newTestServerWith(t, func(cfg *config.Config) {
+		cfg.TrustedProxies = nil
+		cfg.MaxConnsPerIP = 1
+	})
+	p := ts.player(t)
+
+	c := ts.behind(t, p, "X-Forwarded-For", "203.0.113.7")
+	if got := sessionIP(t, c); got != "127.0.0.1" {
+		t.Errorf("session IP = %q, want the peer address", got)
+	}
+
+	// A spoofed header doesn't get around the per-IP connection cap
+	if err := c.Connect(); err == nil {
+		t.Error("second connection from the same peer was accepted")
+	}
+}
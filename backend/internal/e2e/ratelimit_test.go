@@ -0,0 +1,170 @@
+package e2e
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/iamasit07/connect4/backend/internal/config"
+	"github.com/iamasit07/connect4/backend/internal/ratelimit"
+	"github.com/iamasit07/connect4/backend/pkg/useragent"
+)
+
+// login posts credentials directly so the test can see the status and headers
+func (ts *testServer) login(t *testing.T, username, password string) *http.Response {
+	t.Helper()
+	return ts.loginFrom(t, "", username, password)
+}
+
+// loginFrom is login sent through a proxy that sets X-Real-IP to ip, if given
+func (ts *testServer) loginFrom(t *testing.T, ip, username, password string) *http.Response {
+	t.Helper()
+	body, _ := json.Marshal(map[string]string{"username": username, "password": password})
+	req, _ := http.NewRequest(http.MethodPost, ts.URL+"/api/auth/login", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if ip != "" {
+		req.Header.Set("X-Real-IP", ip)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	must(t, err)
+	resp.Body.Close()
+	return resp
+}
+
+// expectRetryAfter checks for a 429 and returns its Retry-After in seconds
+func expectRetryAfter(t *testing.T, resp *http.Response) int {
+	t.Helper()
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want 429", resp.StatusCode)
+	}
+	seconds, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil || seconds < 1 {
+		t.Fatalf("Retry-After = %q", resp.Header.Get("Retry-After"))
+	}
+	return seconds
+}
+
+func TestLoginLockout(t *testing.T) {
+	ts := newTestServerWith(t, func(cfg *config.Config) {
+		cfg.LoginLockout = ratelimit.Policy{Limit: 3, Window: 15 * time.Minute}
+	})
+	p := ts.player(t)
+	other := ts.player(t)
+
+	for i := 0; i < 3; i++ {
+		if resp := ts.login(t, p.Username, "wrong"); resp.StatusCode != http.StatusUnauthorized {
+			t.Fatalf("attempt %d status = %d, want 401", i+1, resp.StatusCode)
+		}
+	}
+
+	// Locked out even with the right password; one attempt comes back every 5 minutes
+	if wait := expectRetryAfter(t, ts.login(t, p.Username, testPassword)); wait != 300 {
+		t.Errorf("Retry-After = %d, want 300", wait)
+	}
+	if resp := ts.login(t, other.Username, testPassword); resp.StatusCode != http.StatusOK {
+		t.Errorf("other account status = %d, want 200", resp.StatusCode)
+	}
+
+	ts.Clock.Advance(5 * time.Minute)
+	if resp := ts.login(t, p.Username, testPassword); resp.StatusCode != http.StatusOK {
+		t.Fatalf("status after waiting = %d, want 200", resp.StatusCode)
+	}
+
+	// A successful login clears the failures
+	for i := 0; i < 2; i++ {
+		ts.login(t, p.Username, "wrong")
+	}
+	if resp := ts.login(t, p.Username, testPassword); resp.StatusCode != http.StatusOK {
+		t.Errorf("status after reset = %d, want 200", resp.StatusCode)
+	}
+
+	// Unknown accounts lock out the same way
+	for i := 0; i < 3; i++ {
+		ts.login(t, "nobody", "wrong")
+	}
+	expectRetryAfter(t, ts.login(t, "nobody", "wrong"))
+}
+
+func TestLoginLockoutIsPerClient(t *testing.T) {
+	ts := newTestServerWith(t, func(cfg *config.Config) {
+		cfg.LoginLockout = ratelimit.Policy{Limit: 3, Window: 15 * time.Minute}
+		cfg.LoginAccountLockout = ratelimit.Policy{Limit: 6, Window: 15 * time.Minute}
+		cfg.TrustedProxies = cidrs(t, "127.0.0.0/8")
+		cfg.ClientIPHeader = useragent.HeaderXRealIP
+	})
+	p := ts.player(t)
+	const attacker, owner = "203.0.113.7", "198.51.100.9"
+
+	for i := 0; i < 3; i++ {
+		ts.loginFrom(t, attacker, p.Username, "wrong")
+	}
+	expectRetryAfter(t, ts.loginFrom(t, attacker, p.Username, testPassword))
+
+	// The owner, signing in from elsewhere, isn't locked out by the attacker
+	if resp := ts.loginFrom(t, owner, p.Username, testPassword); resp.StatusCode != http.StatusOK {
+		t.Fatalf("owner status = %d, want 200", resp.StatusCode)
+	}
+
+	// Guesses spread over many addresses hit the per-account limit
+	for i := 0; i < 3; i++ {
+		ip := "192.0.2." + strconv.Itoa(i+1)
+		if resp := ts.loginFrom(t, ip, p.Username, "wrong"); resp.StatusCode != http.StatusUnauthorized {
+			t.Fatalf("guess from %s status = %d, want 401", ip, resp.StatusCode)
+		}
+	}
+	if wait := expectRetryAfter(t, ts.loginFrom(t, "192.0.2.99", p.Username, testPassword)); wait != 150 {
+		t.Errorf("Retry-After = %d, want 150", wait)
+	}
+}
+
+func TestRouteRateLimit(t *testing.T) {
+	ts := newTestServerWith(t, func(cfg *config.Config) {
+		cfg.RateLimits["GET /api/auth/me"] = ratelimit.Policy{Limit: 2, Window: time.Minute}
+	})
+	p := ts.player(t)
+
+	get := func() *http.Response {
+		req, _ := http.NewRequest(http.MethodGet, ts.URL+"/api/auth/me", nil)
+		req.Header.Set("Authorization", "Bearer "+p.Token)
+		resp, err := http.DefaultClient.Do(req)
+		must(t, err)
+		resp.Body.Close()
+		return resp
+	}
+
+	for i := 0; i < 2; i++ {
+		if resp := get(); resp.StatusCode != http.StatusOK {
+			t.Fatalf("request %d status = %d, want 200", i+1, resp.StatusCode)
+		}
+	}
+	if wait := expectRetryAfter(t, get()); wait != 30 {
+		t.Errorf("Retry-After = %d, want 30", wait)
+	}
+
+	ts.Clock.Advance(30 * time.Second)
+	if resp := get(); resp.StatusCode != http.StatusOK {
+		t.Errorf("status after waiting = %d, want 200", resp.StatusCode)
+	}
+}
+
+func TestMessageRateLimit(t *testing.T) {
+	ts := newTestServerWith(t, func(cfg *config.Config) {
+		cfg.RateLimits["ws:find_match"] = ratelimit.Policy{Limit: 1, Window: 3 * time.Second}
+	})
+	p := ts.player(t)
+
+	must(t, p.FindMatch("", nil))
+	expect(t, p, "queue_joined")
+	must(t, p.CancelSearch())
+	must(t, p.FindMatch("", nil))
+	if msg := expect(t, p, "error"); msg.RetryAfter != 3 {
+		t.Errorf("retryAfter = %d, want 3", msg.RetryAfter)
+	}
+
+	ts.Clock.Advance(3 * time.Second)
+	must(t, p.FindMatch("", nil))
+	expect(t, p, "queue_joined")
+}
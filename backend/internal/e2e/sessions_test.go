@@ -0,0 +1,112 @@
+package e2e
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/iamasit07/connect4/backend/internal/client"
+	"github.com/iamasit07/connect4/backend/internal/config"
+)
+
+const (
+	firefoxLinux = "Mozilla/5.0 (X11; Linux x86_64; rv:128.0) Gecko/20100101 Firefox/128.0"
+	safariIPhone = "Mozilla/5.0 (iPhone; CPU iPhone OS 17_5 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.5 Mobile/15E148 Safari/604.1"
+)
+
+// device signs p's account in on another client with the given user agent and connects it
+func (ts *testServer) device(t *testing.T, p *client.Client, userAgent string) *client.Client {
+	t.Helper()
+
+	c := client.New(ts.URL)
+	c.Header = http.Header{"User-Agent": {userAgent}}
+	must(t, c.Login(p.Username, testPassword))
+	want := len(ts.ConnManager.ConnectedSessions(p.UserID)) + 1
+	must(t, c.Connect())
+	eventually(t, "device connected", func() bool { return len(ts.ConnManager.ConnectedSessions(p.UserID)) >= want })
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func TestMultipleDevices(t *testing.T) {
+	ts := newTestServerWith(t, func(cfg *config.Config) { cfg.MaxSessionsPerUser = 2 })
+	desktop := ts.player(t)
+	phone := ts.device(t, desktop, safariIPhone)
+
+	devices, err := phone.ActiveSessions()
+	must(t, err)
+	if len(devices) != 2 {
+		t.Fatalf("got %d sessions, want 2", len(devices))
+	}
+	current := devices[0]
+	if !current.Current || devices[1].Current {
+		t.Fatalf("current flags = %v, %v; want the phone first", devices[0].Current, devices[1].Current)
+	}
+	if !strings.Contains(current.DeviceInfo, "Safari") {
+		t.Errorf("device info = %q, want Safari", current.DeviceInfo)
+	}
+	for _, d := range devices {
+		if !d.Online {
+			t.Errorf("session %d not online", d.ID)
+		}
+	}
+	oldest := devices[1]
+
+	// A third sign-in goes over the limit and signs out the least recently used device
+	laptop := ts.device(t, desktop, firefoxLinux)
+	if msg := expect(t, desktop, "force_disconnect"); msg.Message == "" {
+		t.Error("force_disconnect without a reason")
+	}
+	if err := desktop.GetJSON("/api/auth/me", nil); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("evicted session /me error = %v, want 401", err)
+	}
+	devices, err = laptop.ActiveSessions()
+	must(t, err)
+	for _, d := range devices {
+		if d.ID == oldest.ID {
+			t.Errorf("evicted session %d still listed", d.ID)
+		}
+	}
+
+	// Revoking a device closes its socket and its token
+	must(t, laptop.RevokeSession(current.ID))
+	expect(t, phone, "force_disconnect")
+	if err := phone.GetJSON("/api/auth/me", nil); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("revoked session /me error = %v, want 401", err)
+	}
+	if err := laptop.RevokeSession(current.ID); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("revoking twice error = %v, want 404", err)
+	}
+	if err := phone.RevokeSession(1); err == nil {
+		t.Error("revoked session could still revoke")
+	}
+}
+
+func TestGameFollowsPlayingDevice(t *testing.T) {
+	ts := newTestServer(t)
+	desktop := ts.player(t)
+	phone := ts.device(t, desktop, safariIPhone)
+
+	must(t, desktop.FindMatch("easy", nil))
+	expect(t, desktop, "game_start")
+
+	// The phone is signed in too but the desktop is playing
+	must(t, phone.MakeMove(3))
+	expect(t, phone, "game_on_other_device")
+
+	// Moving the game to the phone tells the desktop and sends the board along
+	must(t, phone.ClaimGame())
+	expect(t, desktop, "game_on_other_device")
+	expect(t, phone, "game_state")
+
+	must(t, phone.MakeMove(3))
+	expect(t, phone, "move_made")
+	ts.advanceUntil(t, 100*time.Millisecond, phone, "move_made")
+	if _, err := desktop.WaitFor("move_made", 50*time.Millisecond); err == nil {
+		t.Error("desktop received moves after the game moved to the phone")
+	}
+
+	must(t, desktop.MakeMove(4))
+	expect(t, desktop, "game_on_other_device")
+}
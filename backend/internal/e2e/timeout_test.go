@@ -0,0 +1,80 @@
+package e2e
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMatchmakingQueueTimeout(t *testing.T) {
+	ts := newTestServer(t)
+	p := ts.player(t)
+
+	must(t, p.FindMatch("", nil))
+	expect(t, p, "queue_joined")
+
+	ts.advanceUntil(t, time.Minute, p, "queue_timeout")
+
+	// The player left the queue, so the next searcher waits instead of matching
+	other := ts.player(t)
+	must(t, other.FindMatch("", nil))
+	expect(t, other, "queue_joined")
+	if _, err := other.WaitFor("game_start", 200*time.Millisecond); err == nil {
+		t.Fatal("timed-out player was still matched")
+	}
+}
+
+func TestTurnTimeout(t *testing.T) {
+	ts := newTestServer(t)
+	p1, p2, _ := ts.startPvP(t)
+	play(t, p1, p2, 3)
+
+	// Player 2 is on the move and lets the clock run out
+	over := ts.advanceUntil(t, time.Minute, p1, "game_over")
+	if over.Winner != p1.Username || over.Reason != "timeout" {
+		t.Errorf("game_over = %s/%s, want %s/timeout", over.Winner, over.Reason, p1.Username)
+	}
+}
+
+func TestRematchRequestTimeout(t *testing.T) {
+	ts := newTestServer(t)
+	p1, p2, _ := ts.startPvP(t)
+	play(t, p1, p2, 0, 1, 0, 1, 0, 1, 0)
+	expect(t, p1, "game_over")
+	expect(t, p2, "game_over")
+
+	must(t, p1.RequestRematch())
+	req := expect(t, p2, "rematch_requested")
+	if req.RematchTimeout != 10 {
+		t.Errorf("rematchTimeout = %d, want 10", req.RematchTimeout)
+	}
+
+	ts.Clock.Advance(9 * time.Second)
+	if _, err := p1.WaitFor("rematch_timeout", 100*time.Millisecond); err == nil {
+		t.Fatal("rematch request expired early")
+	}
+	ts.advanceUntil(t, time.Second, p1, "rematch_timeout")
+	expect(t, p2, "rematch_timeout")
+
+	// A new request can be made once the old one expired
+	must(t, p2.RequestRematch())
+	expect(t, p1, "rematch_requested")
+}
+
+func TestDrawOfferTimeout(t *testing.T) {
+	ts := newTestServer(t)
+	p1, p2, _ := ts.startPvP(t)
+
+	must(t, p1.OfferDraw())
+	offer := expect(t, p2, "draw_offered")
+	if offer.DrawTimeout != 15 {
+		t.Errorf("drawTimeout = %d, want 15", offer.DrawTimeout)
+	}
+
+	ts.advanceUntil(t, 5*time.Second, p1, "draw_timeout")
+
+	// The expired offer can no longer be accepted
+	must(t, p2.RespondDraw(true))
+	if msg := expect(t, p2, "error"); msg.Message != "no draw offered" {
+		t.Errorf("error = %q, want %q", msg.Message, "no draw offered")
+	}
+}
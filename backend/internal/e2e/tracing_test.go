@@ -0,0 +1,154 @@
+package e2e
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/iamasit07/connect4/backend/internal/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// captureSpans records every span ended while the test runs
+func captureSpans(t *testing.T) *tracetest.InMemoryExporter {
+	t.Helper()
+
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	prev := otel.GetTracerProvider()
+	_, err := tracing.Setup(context.Background(), tracing.Options{})
+	must(t, err)
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prev)
+		provider.Shutdown(context.Background())
+	})
+	return exporter
+}
+
+// spansNamed returns the recorded spans called name
+func spansNamed(exporter *tracetest.InMemoryExporter, name string) tracetest.SpanStubs {
+	var out tracetest.SpanStubs
+	for _, s := range exporter.GetSpans() {
+		if s.Name == name {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// waitForSpan waits until a span called name has ended and returns the first one
+func waitForSpan(t *testing.T, exporter *tracetest.InMemoryExporter, name string) tracetest.SpanStub {
+	t.Helper()
+	eventually(t, "span "+name, func() bool { return len(spansNamed(exporter, name)) > 0 })
+	return spansNamed(exporter, name)[0]
+}
+
+func TestHTTPTracing(t *testing.T) {
+	spans := captureSpans(t)
+	logs := captureLogs(t)
+	ts := newTestServer(t)
+	p := ts.player(t)
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	req, _ := http.NewRequest(http.MethodGet, ts.URL+"/api/auth/me", nil)
+	req.Header.Set("Authorization", "Bearer "+p.Token)
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	resp, err := http.DefaultClient.Do(req)
+	must(t, err)
+	resp.Body.Close()
+
+	// The server span continues the caller's trace
+	span := waitForSpan(t, spans, "GET /api/auth/me")
+	if got := span.SpanContext.TraceID().String(); got != traceID {
+		t.Errorf("trace ID = %s, want the propagated %s", got, traceID)
+	}
+	if span.Status.Code == codes.Error {
+		t.Errorf("successful request marked as an error: %v", span.Status)
+	}
+
+	// and the access log line can be found by its trace ID
+	if len(logs.records(map[string]any{"msg": "request", "trace_id": traceID})) != 1 {
+		t.Error("access record does not carry the trace ID")
+	}
+}
+
+func TestMoveTracing(t *testing.T) {
+	spans := captureSpans(t)
+	ts := newTestServer(t)
+	p1, p2, gameID := ts.startPvP(t)
+
+	// Moving out of turn fails inside the game session
+	must(t, p2.MakeMove(0))
+	expect(t, p2, "error")
+	failed := waitForSpan(t, spans, "GameSession.HandleMove")
+	if failed.Status.Code != codes.Error {
+		t.Errorf("rejected move status = %v, want an error", failed.Status)
+	}
+	waitForSpan(t, spans, "ws make_move")
+	spans.Reset()
+
+	play(t, p1, p2, 0, 1, 0, 1, 0, 1, 0)
+	expect(t, p1, "game_over")
+	save := waitForSpan(t, spans, "GameSession.saveGameAsync")
+	eventually(t, "all move spans", func() bool { return len(spansNamed(spans, "ws make_move")) == 7 })
+
+	byID := map[string]tracetest.SpanStub{}
+	for _, s := range spans.GetSpans() {
+		byID[s.SpanContext.SpanID().String()] = s
+	}
+	parentName := func(s tracetest.SpanStub) string { return byID[s.Parent.SpanID().String()].Name }
+
+	for _, move := range spansNamed(spans, "GameSession.HandleMove") {
+		if parentName(move) != "ws make_move" {
+			t.Errorf("HandleMove parent = %q, want the WebSocket message", parentName(move))
+		}
+	}
+	for _, lock := range spansNamed(spans, "GameSession.lock") {
+		if parentName(lock) != "GameSession.HandleMove" {
+			t.Errorf("lock parent = %q, want HandleMove", parentName(lock))
+		}
+	}
+	if parentName(save) != "GameSession.HandleMove" {
+		t.Errorf("saveGameAsync parent = %q, want the winning move", parentName(save))
+	}
+
+	msg := spansNamed(spans, "ws make_move")[0]
+	attrs := map[string]string{}
+	for _, kv := range msg.Attributes {
+		attrs[string(kv.Key)] = kv.Value.Emit()
+	}
+	if attrs[string(tracing.KeyGameID)] != gameID || attrs[string(tracing.KeyMessageType)] != "make_move" {
+		t.Errorf("message span attributes = %v", attrs)
+	}
+	// Each message is its own trace, linked to the socket's upgrade request
+	if len(msg.Links) != 1 || msg.Parent.IsValid() {
+		t.Errorf("message span has parent %v and %d links, want a root with one link", msg.Parent, len(msg.Links))
+	}
+}
+
+func TestBotMoveTracing(t *testing.T) {
+	spans := captureSpans(t)
+	ts := newTestServer(t)
+	p := ts.player(t)
+
+	must(t, p.FindMatch("easy", nil))
+	expect(t, p, "game_start")
+	must(t, p.MakeMove(3))
+	expect(t, p, "move_made")
+	ts.advanceUntil(t, 100*time.Millisecond, p, "move_made")
+
+	// The bot's reply is traced in the trace of the move it answers
+	botMove := waitForSpan(t, spans, "GameSession.HandleBotMove")
+	move := waitForSpan(t, spans, "GameSession.HandleMove")
+	if botMove.Parent.SpanID() != move.SpanContext.SpanID() {
+		t.Error("bot move is not a child of the player's move")
+	}
+	if calc := waitForSpan(t, spans, "bot.CalculateBestMove"); calc.Parent.SpanID() != botMove.SpanContext.SpanID() {
+		t.Error("bot search is not a child of the bot move")
+	}
+}
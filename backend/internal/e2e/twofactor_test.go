@@ -0,0 +1,173 @@
+package e2e
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/iamasit07/connect4/backend/internal/client"
+	"github.com/iamasit07/connect4/backend/internal/config"
+	"github.com/iamasit07/connect4/backend/pkg/auth"
+)
+
+// totp returns the current code for secret, first moving the clock to the next
+// 30 second step since each step's code is accepted only once
+func totp(ts *testServer, secret string) string {
+	ts.Clock.Advance(30 * time.Second)
+	code, _ := auth.TOTPCode(secret, ts.Clock.Now())
+	return code
+}
+
+// loginWithCode signs in a new client for username using a second factor
+func loginWithCode(t *testing.T, ts *testServer, username, code string) (*client.Client, error) {
+	t.Helper()
+	c := client.New(ts.URL)
+	if err := c.Login(username, testPassword); !errors.Is(err, client.ErrTwoFactorRequired) {
+		t.Fatalf("login error = %v, want two-factor required", err)
+	}
+	if c.Token != "" {
+		t.Fatal("token issued before the second factor")
+	}
+	return c, c.LoginTwoFactor(code)
+}
+
+func TestTwoFactorLogin(t *testing.T) {
+	ts := newTestServer(t)
+	p := ts.player(t)
+
+	if err := p.ConfirmTwoFactor("123456"); err == nil || !strings.Contains(err.Error(), "400") {
+		t.Errorf("confirm before enroll error = %v, want 400", err)
+	}
+	enrollment, err := p.EnrollTwoFactor()
+	must(t, err)
+	if len(enrollment.RecoveryCodes) != 10 || !strings.HasPrefix(enrollment.ProvisioningURI, "otpauth://totp/") {
+		t.Fatalf("enrollment = %+v", enrollment)
+	}
+
+	// Enrollment alone doesn't change how login works
+	must(t, p.Login(p.Username, testPassword))
+
+	if err := p.ConfirmTwoFactor("000000"); err == nil || !strings.Contains(err.Error(), "400") {
+		t.Errorf("wrong confirm code error = %v, want 400", err)
+	}
+	must(t, p.ConfirmTwoFactor(totp(ts, enrollment.Secret)))
+	if _, err := p.EnrollTwoFactor(); err == nil || !strings.Contains(err.Error(), "409") {
+		t.Errorf("re-enroll error = %v, want 409", err)
+	}
+
+	if _, err := loginWithCode(t, ts, p.Username, "000000"); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("wrong code error = %v, want 401", err)
+	}
+	code := totp(ts, enrollment.Secret)
+	c, err := loginWithCode(t, ts, p.Username, code)
+	must(t, err)
+	if c.UserID != p.UserID {
+		t.Errorf("signed in as %d, want %d", c.UserID, p.UserID)
+	}
+	// The same code can't be replayed within its window
+	if _, err := loginWithCode(t, ts, p.Username, code); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("replayed code error = %v, want 401", err)
+	}
+
+	// A bad challenge is rejected outright
+	forged := client.New(ts.URL)
+	forged.TwoFactorChallenge = "not-a-challenge"
+	if err := forged.LoginTwoFactor(totp(ts, enrollment.Secret)); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("forged challenge error = %v, want 401", err)
+	}
+}
+
+func TestTwoFactorRecoveryCodes(t *testing.T) {
+	ts := newTestServer(t)
+	p := ts.player(t)
+	enrollment, err := p.EnrollTwoFactor()
+	must(t, err)
+	must(t, p.ConfirmTwoFactor(totp(ts, enrollment.Secret)))
+
+	// Recovery codes work once each, typed with or without the dash
+	recovery := enrollment.RecoveryCodes[0]
+	c, err := loginWithCode(t, ts, p.Username, strings.ToUpper(strings.ReplaceAll(recovery, "-", "")))
+	must(t, err)
+	if _, err := loginWithCode(t, ts, p.Username, recovery); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("spent recovery code error = %v, want 401", err)
+	}
+
+	// Regenerating invalidates the old set
+	codes, err := c.RegenerateRecoveryCodes(totp(ts, enrollment.Secret))
+	must(t, err)
+	if len(codes) != 10 {
+		t.Fatalf("regenerated %d codes, want 10", len(codes))
+	}
+	if _, err := loginWithCode(t, ts, p.Username, enrollment.RecoveryCodes[1]); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("old recovery code error = %v, want 401", err)
+	}
+	c, err = loginWithCode(t, ts, p.Username, codes[0])
+	must(t, err)
+
+	// Disabling restores the plain password login
+	if err := c.DisableTwoFactor("000000"); err == nil || !strings.Contains(err.Error(), "400") {
+		t.Errorf("disable with wrong code error = %v, want 400", err)
+	}
+	must(t, c.DisableTwoFactor(totp(ts, enrollment.Secret)))
+	must(t, c.GetJSON("/api/auth/me", nil))
+	if err := c.DisableTwoFactor(totp(ts, enrollment.Secret)); err == nil || !strings.Contains(err.Error(), "409") {
+		t.Errorf("second disable error = %v, want 409", err)
+	}
+	must(t, client.New(ts.URL).Login(p.Username, testPassword))
+}
+
+func TestGuestCannotEnrollTwoFactor(t *testing.T) {
+	ts := newTestServer(t)
+	g := ts.guest(t)
+	if _, err := g.EnrollTwoFactor(); err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("guest enroll error = %v, want 403", err)
+	}
+}
+
+func TestTwoFactorCodeRaceAcrossDevices(t *testing.T) {
+	ts := newTestServer(t)
+	p := ts.player(t)
+	enrollment, err := p.EnrollTwoFactor()
+	must(t, err)
+	must(t, p.ConfirmTwoFactor(totp(ts, enrollment.Secret)))
+
+	// Both devices pass the password step, then send the same code at once
+	devices := make([]*client.Client, 2)
+	for i := range devices {
+		devices[i] = client.New(ts.URL)
+		if err := devices[i].Login(p.Username, testPassword); !errors.Is(err, client.ErrTwoFactorRequired) {
+			t.Fatalf("device %d login error = %v, want two-factor required", i, err)
+		}
+	}
+	code := totp(ts, enrollment.Secret)
+	errs := make([]error, len(devices))
+	var wg sync.WaitGroup
+	for i, d := range devices {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = d.LoginTwoFactor(code)
+		}()
+	}
+	wg.Wait()
+	if (errs[0] == nil) == (errs[1] == nil) {
+		t.Fatalf("second factor errors = %v, want exactly one device signed in", errs)
+	}
+	for _, err := range errs {
+		if err != nil && !strings.Contains(err.Error(), "401") {
+			t.Errorf("losing device error = %v, want 401", err)
+		}
+	}
+}
+
+func TestTwoFactorWithoutEncryptionKey(t *testing.T) {
+	ts := newTestServerWith(t, func(cfg *config.Config) { cfg.TOTPEncryptionKey = "" })
+	p := ts.player(t)
+	if _, err := p.EnrollTwoFactor(); err == nil || !strings.Contains(err.Error(), "503") {
+		t.Errorf("enroll error = %v, want 503", err)
+	}
+	// Login is unaffected for accounts without two-factor
+	must(t, p.Login(p.Username, testPassword))
+}
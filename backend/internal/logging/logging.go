@@ -0,0 +1,91 @@
+// Package logging sets up the process-wide slog logger and carries
+// request-scoped loggers through contexts, so every line about one request,
+// player or game can be found by the same attributes.
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// Attribute keys shared by every component
+const (
+	KeyComponent = "component"
+	KeyRequestID = "request_id"
+	KeyTraceID   = "trace_id"
+	KeyGameID    = "game_id"
+	KeyUserID    = "user_id"
+	KeySessionID = "session_id"
+	KeyError     = "error"
+)
+
+// Setup makes a logger writing to w the default for slog and the standard log package
+func Setup(w io.Writer, level slog.Level, format string) {
+	slog.SetDefault(slog.New(NewHandler(w, level, format)))
+}
+
+// NewHandler returns a JSON handler, or a text handler when format is "text"
+func NewHandler(w io.Writer, level slog.Leveler, format string) slog.Handler {
+	opts := &slog.HandlerOptions{Level: level}
+	if strings.EqualFold(format, "text") {
+		return slog.NewTextHandler(w, opts)
+	}
+	return slog.NewJSONHandler(w, opts)
+}
+
+// For returns the default logger tagged with a component ("ws", "game", ...).
+// Call it after Setup; a logger kept from before keeps the old handler.
+func For(component string) *slog.Logger {
+	return slog.Default().With(KeyComponent, component)
+}
+
+type ctxKey struct{}
+
+// NewContext returns ctx carrying logger
+func NewContext(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, ctxKey{}, logger)
+}
+
+// FromContext returns the logger carried by ctx, or the default logger
+func FromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(ctxKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
+
+// ForContext returns ctx's logger tagged with a component
+func ForContext(ctx context.Context, component string) *slog.Logger {
+	return FromContext(ctx).With(KeyComponent, component)
+}
+
+// With returns ctx whose logger also carries args
+func With(ctx context.Context, args ...any) context.Context {
+	return NewContext(ctx, FromContext(ctx).With(args...))
+}
+
+func RequestID(id string) slog.Attr { return slog.String(KeyRequestID, id) }
+func TraceID(id string) slog.Attr   { return slog.String(KeyTraceID, id) }
+func GameID(id string) slog.Attr    { return slog.String(KeyGameID, id) }
+func UserID(id int64) slog.Attr     { return slog.Int64(KeyUserID, id) }
+
+// SessionID records a prefix of a session ID; that is enough to correlate
+// lines without writing the whole ID to the logs
+func SessionID(id string) slog.Attr {
+	if len(id) > sessionIDPrefix {
+		id = id[:sessionIDPrefix]
+	}
+	return slog.String(KeySessionID, id)
+}
+
+const sessionIDPrefix = 12
+
+// Err records err under the shared error key
+func Err(err error) slog.Attr {
+	if err == nil {
+		return slog.Attr{}
+	}
+	return slog.String(KeyError, err.Error())
+}
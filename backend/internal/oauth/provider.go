@@ -0,0 +1,264 @@
+// Package oauth signs players in through external OAuth 2.0 and OpenID Connect
+// providers. Google, GitHub and Discord are built in; any OIDC issuer can be
+// added from config and is set up through its discovery document.
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/iamasit07/connect4/backend/internal/config"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/endpoints"
+)
+
+// UserInfo is the provider account a login resolved to
+type UserInfo struct {
+	Subject       string // the provider's stable user ID
+	Email         string
+	EmailVerified bool
+	Name          string
+	Picture       string
+}
+
+// Provider is one configured login provider
+type Provider struct {
+	Name        string
+	DisplayName string
+
+	kind   string
+	issuer string
+
+	mu          sync.Mutex
+	discovered  bool // OIDC endpoints loaded (always true for built-in kinds)
+	oauth       oauth2.Config
+	userInfoURL string
+}
+
+func newProvider(cfg config.OAuthProvider, redirectURL string) *Provider {
+	p := &Provider{
+		Name:        cfg.Name,
+		DisplayName: cfg.DisplayName,
+		kind:        cfg.Kind,
+		issuer:      cfg.Issuer,
+		discovered:  cfg.Kind != config.OAuthKindOIDC,
+		oauth: oauth2.Config{
+			ClientID:     cfg.ClientID,
+			ClientSecret: cfg.ClientSecret,
+			RedirectURL:  redirectURL,
+		},
+	}
+	switch cfg.Kind {
+	case config.OAuthKindGoogle:
+		p.oauth.Endpoint = endpoints.Google
+		p.oauth.Scopes = []string{
+			"https://www.googleapis.com/auth/userinfo.email",
+			"https://www.googleapis.com/auth/userinfo.profile",
+		}
+		p.userInfoURL = "https://www.googleapis.com/oauth2/v2/userinfo"
+	case config.OAuthKindGitHub:
+		p.oauth.Endpoint = endpoints.GitHub
+		p.oauth.Scopes = []string{"read:user", "user:email"}
+		p.userInfoURL = "https://api.github.com/user"
+	case config.OAuthKindDiscord:
+		p.oauth.Endpoint = endpoints.Discord
+		p.oauth.Scopes = []string{"identify", "email"}
+		p.userInfoURL = "https://discord.com/api/users/@me"
+	default:
+		p.oauth.Scopes = cfg.Scopes
+		if len(p.oauth.Scopes) == 0 {
+			p.oauth.Scopes = []string{"openid", "email", "profile"}
+		}
+	}
+	return p
+}
+
+// NewVerifier returns a random PKCE code verifier for one login attempt
+func NewVerifier() string {
+	return oauth2.GenerateVerifier()
+}
+
+// AuthCodeURL returns the provider's consent page URL carrying state and the
+// S256 challenge for verifier
+func (p *Provider) AuthCodeURL(ctx context.Context, state, verifier string) (string, error) {
+	if err := p.discover(ctx); err != nil {
+		return "", err
+	}
+	return p.oauth.AuthCodeURL(state, oauth2.S256ChallengeOption(verifier)), nil
+}
+
+// Exchange trades the callback's code (plus the PKCE verifier the flow started
+// with) for a token and loads the account it belongs to
+func (p *Provider) Exchange(ctx context.Context, code, verifier string) (*UserInfo, error) {
+	if err := p.discover(ctx); err != nil {
+		return nil, err
+	}
+	token, err := p.oauth.Exchange(ctx, code, oauth2.VerifierOption(verifier))
+	if err != nil {
+		return nil, fmt.Errorf("failed to exchange code: %v", err)
+	}
+	client := p.oauth.Client(ctx, token)
+
+	var info *UserInfo
+	switch p.kind {
+	case config.OAuthKindGoogle:
+		info, err = p.googleUser(client)
+	case config.OAuthKindGitHub:
+		info, err = p.githubUser(client)
+	case config.OAuthKindDiscord:
+		info, err = p.discordUser(client)
+	default:
+		info, err = p.oidcUser(client)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if info.Subject == "" {
+		return nil, fmt.Errorf("failed to get user info: %s returned no user ID", p.Name)
+	}
+	return info, nil
+}
+
+// discover loads an OIDC provider's endpoints on first use, so a provider
+// that is down at startup doesn't stop the server
+func (p *Provider) discover(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.discovered {
+		return nil
+	}
+
+	var doc struct {
+		Issuer                string `json:"issuer"`
+		AuthorizationEndpoint string `json:"authorization_endpoint"`
+		TokenEndpoint         string `json:"token_endpoint"`
+		UserinfoEndpoint      string `json:"userinfo_endpoint"`
+	}
+	client := http.DefaultClient
+	if c, ok := ctx.Value(oauth2.HTTPClient).(*http.Client); ok {
+		client = c
+	}
+	if err := getJSON(client, p.issuer+"/.well-known/openid-configuration", &doc); err != nil {
+		return fmt.Errorf("failed to discover %s: %v", p.Name, err)
+	}
+	if strings.TrimRight(doc.Issuer, "/") != p.issuer {
+		return fmt.Errorf("failed to discover %s: issuer mismatch %q", p.Name, doc.Issuer)
+	}
+	if doc.AuthorizationEndpoint == "" || doc.TokenEndpoint == "" || doc.UserinfoEndpoint == "" {
+		return fmt.Errorf("failed to discover %s: discovery document is missing endpoints", p.Name)
+	}
+	p.oauth.Endpoint = oauth2.Endpoint{AuthURL: doc.AuthorizationEndpoint, TokenURL: doc.TokenEndpoint}
+	p.userInfoURL = doc.UserinfoEndpoint
+	p.discovered = true
+	return nil
+}
+
+func (p *Provider) googleUser(client *http.Client) (*UserInfo, error) {
+	var u struct {
+		ID            string `json:"id"`
+		Email         string `json:"email"`
+		VerifiedEmail bool   `json:"verified_email"`
+		Name          string `json:"name"`
+		Picture       string `json:"picture"`
+	}
+	if err := getJSON(client, p.userInfoURL, &u); err != nil {
+		return nil, fmt.Errorf("failed to get user info: %v", err)
+	}
+	return &UserInfo{Subject: u.ID, Email: u.Email, EmailVerified: u.VerifiedEmail, Name: u.Name, Picture: u.Picture}, nil
+}
+
+// githubUser reads the profile and then the primary address from /user/emails,
+// since the profile only shows an email the user made public
+func (p *Provider) githubUser(client *http.Client) (*UserInfo, error) {
+	var u struct {
+		ID        int64  `json:"id"`
+		Login     string `json:"login"`
+		Name      string `json:"name"`
+		AvatarURL string `json:"avatar_url"`
+	}
+	if err := getJSON(client, p.userInfoURL, &u); err != nil {
+		return nil, fmt.Errorf("failed to get user info: %v", err)
+	}
+	info := &UserInfo{Subject: strconv.FormatInt(u.ID, 10), Name: u.Name, Picture: u.AvatarURL}
+	if info.Name == "" {
+		info.Name = u.Login
+	}
+
+	var emails []struct {
+		Email    string `json:"email"`
+		Primary  bool   `json:"primary"`
+		Verified bool   `json:"verified"`
+	}
+	if err := getJSON(client, p.userInfoURL+"/emails", &emails); err != nil {
+		return nil, fmt.Errorf("failed to get user emails: %v", err)
+	}
+	for _, e := range emails {
+		if e.Primary {
+			info.Email, info.EmailVerified = e.Email, e.Verified
+		}
+	}
+	return info, nil
+}
+
+func (p *Provider) discordUser(client *http.Client) (*UserInfo, error) {
+	var u struct {
+		ID         string `json:"id"`
+		Username   string `json:"username"`
+		GlobalName string `json:"global_name"`
+		Email      string `json:"email"`
+		Verified   bool   `json:"verified"`
+		Avatar     string `json:"avatar"`
+	}
+	if err := getJSON(client, p.userInfoURL, &u); err != nil {
+		return nil, fmt.Errorf("failed to get user info: %v", err)
+	}
+	info := &UserInfo{Subject: u.ID, Email: u.Email, EmailVerified: u.Verified, Name: u.GlobalName}
+	if info.Name == "" {
+		info.Name = u.Username
+	}
+	if u.Avatar != "" {
+		info.Picture = fmt.Sprintf("https://cdn.discordapp.com/avatars/%s/%s.png", u.ID, u.Avatar)
+	}
+	return info, nil
+}
+
+func (p *Provider) oidcUser(client *http.Client) (*UserInfo, error) {
+	var u struct {
+		Sub               string `json:"sub"`
+		Email             string `json:"email"`
+		EmailVerified     bool   `json:"email_verified"`
+		Name              string `json:"name"`
+		PreferredUsername string `json:"preferred_username"`
+		Picture           string `json:"picture"`
+	}
+	if err := getJSON(client, p.userInfoURL, &u); err != nil {
+		return nil, fmt.Errorf("failed to get user info: %v", err)
+	}
+	info := &UserInfo{Subject: u.Sub, Email: u.Email, EmailVerified: u.EmailVerified, Name: u.Name, Picture: u.Picture}
+	if info.Name == "" {
+		info.Name = u.PreferredUsername
+	}
+	return info, nil
+}
+
+func getJSON(client *http.Client, url string, out interface{}) error {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned %d", url, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,46 @@
+package oauth
+
+import "github.com/iamasit07/connect4/backend/internal/config"
+
+// Registry holds the configured providers by name
+type Registry struct {
+	providers map[string]*Provider
+	order     []*Provider
+}
+
+// NewRegistry builds a provider for each entry in cfg.Providers
+func NewRegistry(cfg config.OAuthConfig) *Registry {
+	r := &Registry{providers: make(map[string]*Provider)}
+	for _, pc := range cfg.Providers {
+		if _, dup := r.providers[pc.Name]; dup {
+			continue
+		}
+		redirectURL := pc.RedirectURL
+		if redirectURL == "" {
+			redirectURL = cfg.RedirectBaseURL + CallbackPath(pc.Name)
+		}
+		p := newProvider(pc, redirectURL)
+		r.providers[pc.Name] = p
+		r.order = append(r.order, p)
+	}
+	return r
+}
+
+// CallbackPath is the route a provider redirects back to. Google keeps the
+// path it had before other providers existed, so registered apps still work.
+func CallbackPath(name string) string {
+	if name == config.OAuthKindGoogle {
+		return "/api/auth/google/callback"
+	}
+	return "/api/auth/oauth/" + name + "/callback"
+}
+
+// Get returns the named provider, or nil when it isn't configured
+func (r *Registry) Get(name string) *Provider {
+	return r.providers[name]
+}
+
+// List returns the providers in config order
+func (r *Registry) List() []*Provider {
+	return r.order
+}
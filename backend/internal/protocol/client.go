@@ -0,0 +1,116 @@
+package protocol
+
+// Payloads of the messages clients send. Fields without omitempty are
+// required in version 2; schema tags constrain values (see constraint).
+
+// Init is the first message on a socket. Versions lists the protocol
+// versions the client speaks; without it the connection uses V1.
+type Init struct {
+	JWT      string `json:"jwt" schema:"nonempty"`
+	Versions []int  `json:"versions,omitempty"`
+}
+
+type FindMatch struct {
+	Difficulty string `json:"difficulty,omitempty" schema:"enum=easy|medium|hard"` // bot game at this level; empty for PvP
+	Rated      *bool  `json:"rated,omitempty"`                                     // defaults to rated for PvP, casual for bots
+}
+
+type CancelSearch struct{}
+
+type MakeMove struct {
+	Column int `json:"column" schema:"min=0,max=6"`
+}
+
+type RequestRematch struct{}
+
+type RematchResponse struct {
+	Response string `json:"rematchResponse" schema:"enum=accept|decline"`
+}
+
+type OfferDraw struct{}
+
+type DrawResponse struct {
+	Response string `json:"drawResponse" schema:"enum=accept|decline"`
+}
+
+type AbandonGame struct{}
+
+type WatchGame struct {
+	GameID string `json:"gameId" schema:"nonempty"`
+}
+
+type LeaveSpectate struct {
+	GameID string `json:"gameId" schema:"nonempty"`
+}
+
+// GetGameState asks for the user's own game, or for GameID when spectating it
+type GetGameState struct {
+	GameID string `json:"gameId,omitempty"`
+}
+
+type ClaimGame struct{}
+
+// PostChat posts to the user's own game, or to GameID when spectating it
+type PostChat struct {
+	GameID string `json:"gameId,omitempty"`
+	Text   string `json:"text"`
+}
+
+type MuteUser struct {
+	GameID string `json:"gameId,omitempty"`
+	UserID int64  `json:"userId"`
+}
+
+type UnmuteUser struct {
+	GameID string `json:"gameId,omitempty"`
+	UserID int64  `json:"userId"`
+}
+
+type ReportMessage struct {
+	GameID    string `json:"gameId,omitempty"`
+	MessageID int64  `json:"messageId"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+type ChallengeUser struct {
+	UserID int64 `json:"userId"`
+	Rated  *bool `json:"rated,omitempty"` // defaults to rated unless either player is a guest
+}
+
+type ChallengeResponse struct {
+	ChallengeID string `json:"challengeId" schema:"nonempty"`
+	Response    string `json:"challengeResponse" schema:"enum=accept|decline"`
+}
+
+// Accepted reports whether the answer is "accept"
+func (r RematchResponse) Accepted() bool   { return r.Response == answerAccept }
+func (r DrawResponse) Accepted() bool      { return r.Response == answerAccept }
+func (r ChallengeResponse) Accepted() bool { return r.Response == answerAccept }
+
+const answerAccept = "accept"
+
+// clientMessages lists every message a client may send, in the order the
+// schema documents them
+var clientMessages = []messageType{
+	newMessageType[Init]("init", "First message on a socket: authenticates and picks the protocol version"),
+	newMessageType[FindMatch]("find_match", "Join the PvP queue, or start a bot game with a difficulty"),
+	newMessageType[CancelSearch]("cancel_search", "Leave the queue"),
+	newMessageType[MakeMove]("make_move", "Drop a disc in a column (0-6)"),
+	newMessageType[RequestRematch]("request_rematch", "Ask the opponent for a rematch after the game"),
+	newMessageType[RematchResponse]("rematch_response", "Answer a rematch request"),
+	newMessageType[OfferDraw]("offer_draw", "Offer the opponent a draw"),
+	newMessageType[DrawResponse]("draw_response", "Answer a draw offer"),
+	newMessageType[AbandonGame]("abandon_game", "Resign the current game"),
+	newMessageType[WatchGame]("watch_game", "Spectate a live game"),
+	newMessageType[LeaveSpectate]("leave_spectate", "Stop spectating a game"),
+	newMessageType[GetGameState]("get_game_state", "Ask for the full state of the current or spectated game"),
+	newMessageType[ClaimGame]("claim_game", "Move the user's game to this device"),
+	newMessageType[PostChat]("chat_message", "Post in the game chat"),
+	newMessageType[MuteUser]("mute_user", "Hide a user's chat in a game"),
+	newMessageType[UnmuteUser]("unmute_user", "Show a muted user's chat again"),
+	newMessageType[ReportMessage]("report_message", "Report a chat message to moderators"),
+	newMessageType[ChallengeUser]("challenge_user", "Invite a friend to a game"),
+	newMessageType[ChallengeResponse]("challenge_response", "Answer a friend's challenge"),
+}
+
+var clientTypes = indexTypes(clientMessages)
@@ -0,0 +1,258 @@
+// Package protocol defines the WebSocket wire format.
+//
+// Version 1 is the original format: flat JSON objects with every field next
+// to "type". Version 2 wraps each message in an envelope,
+// {"type": ..., "payload": {...}}, where each type has its own payload. Both
+// use the same field names. The client picks the version in init; a client
+// that doesn't ask speaks version 1.
+//
+//go:generate go run ../../cmd/wsschema -o ../../docs/websocket-protocol.schema.json
+package protocol
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/iamasit07/connect4/backend/internal/domain"
+)
+
+const (
+	V1     = 1
+	V2     = 2
+	Latest = V2
+)
+
+// Supported lists the versions the server speaks, oldest first
+var Supported = []int{V1, V2}
+
+// Negotiate picks the newest version offered by the client that the server
+// speaks. A client that offers nothing gets V1.
+func Negotiate(offered []int) (int, bool) {
+	if len(offered) == 0 {
+		return V1, true
+	}
+	best := 0
+	for _, v := range offered {
+		if slices.Contains(Supported, v) && v > best {
+			best = v
+		}
+	}
+	return best, best != 0
+}
+
+// DecodeError is a message the server could not accept. It goes back to the
+// client as an error message with its code.
+type DecodeError struct {
+	Code    domain.ErrorCode
+	Message string
+}
+
+func (e *DecodeError) Error() string {
+	return e.Message
+}
+
+func errorf(code domain.ErrorCode, format string, args ...any) *DecodeError {
+	return &DecodeError{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// Message is a decoded client message
+type Message struct {
+	Type    string
+	JWT     string // optional token checked against the connection's session
+	Payload any    // the payload type registered for Type, e.g. MakeMove
+}
+
+// frame is the part of a message shared by every type
+type frame struct {
+	Type    string          `json:"type"`
+	JWT     string          `json:"jwt,omitempty"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// DecodeClient parses a message sent in version. Version 2 messages must not
+// leave out required fields or add unknown ones; version 1 messages are read
+// leniently, as they always were. The returned Message has its Type even
+// when the payload is rejected, so the caller can rate limit and trace it.
+func DecodeClient(version int, data []byte) (Message, error) {
+	f, err := decodeFrame(data)
+	if err != nil {
+		return Message{}, err
+	}
+	msg := Message{Type: f.Type, JWT: f.JWT}
+	if f.Type == "" {
+		return msg, errorf(domain.ErrInvalidPayload, "Message has no type")
+	}
+	mt, ok := clientTypes[f.Type]
+	if !ok {
+		return msg, errorf(domain.ErrUnknownType, "Unknown message type %q", f.Type)
+	}
+
+	payload := reflect.New(mt.payload)
+	if version >= V2 {
+		if err := strictDecode(data, reflect.TypeFor[frame]()); err != nil {
+			return msg, err
+		}
+		raw := []byte(f.Payload)
+		if len(raw) == 0 || string(raw) == "null" {
+			raw = []byte("{}") // types without fields may leave the payload out
+		}
+		if err := strictDecode(raw, mt.payload); err != nil {
+			return msg, err
+		}
+		json.Unmarshal(raw, payload.Interface())
+	} else if err := json.Unmarshal(data, payload.Interface()); err != nil {
+		return msg, errorf(domain.ErrInvalidPayload, "Invalid %s: %v", f.Type, fieldError(err))
+	}
+
+	if err := checkConstraints(payload.Elem()); err != nil {
+		return msg, errorf(domain.ErrInvalidPayload, "Invalid %s: %v", f.Type, err)
+	}
+	msg.Payload = payload.Elem().Interface()
+	return msg, nil
+}
+
+func decodeFrame(data []byte) (frame, error) {
+	var f frame
+	if err := json.Unmarshal(data, &f); err != nil {
+		if _, ok := err.(*json.UnmarshalTypeError); ok {
+			return frame{}, errorf(domain.ErrInvalidPayload, "%v", fieldError(err))
+		}
+		return frame{}, errorf(domain.ErrInvalidJSON, "Message is not valid JSON")
+	}
+	return f, nil
+}
+
+// strictDecode checks data against t: an object with t's required fields,
+// none it doesn't know, and values of the right types
+func strictDecode(data []byte, t reflect.Type) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil || fields == nil {
+		return errorf(domain.ErrInvalidPayload, "Payload must be an object")
+	}
+	for _, name := range requiredFields(t) {
+		if _, ok := fields[name]; !ok {
+			return errorf(domain.ErrInvalidPayload, "Missing field %q", name)
+		}
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(reflect.New(t).Interface()); err != nil {
+		return errorf(domain.ErrInvalidPayload, "%v", fieldError(err))
+	}
+	return nil
+}
+
+// fieldError rewords encoding/json errors without Go type names
+func fieldError(err error) string {
+	if typeErr, ok := err.(*json.UnmarshalTypeError); ok {
+		return fmt.Sprintf("field %q must be of type %s", typeErr.Field, schemaType(typeErr.Type))
+	}
+	if name, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+		return "unknown field " + name
+	}
+	return err.Error()
+}
+
+// ParseInit reads the first message on a socket, in either version's shape.
+// The version isn't known yet, so both are accepted.
+func ParseInit(data []byte) (Init, error) {
+	f, err := decodeFrame(data)
+	if err != nil {
+		return Init{}, err
+	}
+	if f.Type != "init" {
+		return Init{}, errorf(domain.ErrUnauthorized, "The first message must be init")
+	}
+
+	var init Init
+	raw := []byte(f.Payload)
+	if len(raw) == 0 {
+		raw = data
+	}
+	if err := json.Unmarshal(raw, &init); err != nil {
+		return Init{}, errorf(domain.ErrInvalidPayload, "Invalid init: %v", fieldError(err))
+	}
+	if init.JWT == "" {
+		init.JWT = f.JWT
+	}
+	if init.JWT == "" {
+		return Init{}, errorf(domain.ErrUnauthorized, "init needs a token")
+	}
+	return init, nil
+}
+
+// Envelope is a version 2 message
+type Envelope struct {
+	Type    string `json:"type"`
+	Payload any    `json:"payload"`
+}
+
+// EncodeServer returns msg as it is written in version. Version 2 carries
+// only the fields of the type's payload; a type without one is sent whole.
+func EncodeServer(version int, msg domain.ServerMessage) any {
+	if version < V2 {
+		return msg
+	}
+	mt, ok := serverTypes[msg.Type]
+	if !ok {
+		return Envelope{Type: msg.Type, Payload: msg}
+	}
+	return Envelope{Type: msg.Type, Payload: mt.project(msg)}
+}
+
+// EncodeClient builds a client message of msgType in version
+func EncodeClient(version int, msgType string, payload any) ([]byte, error) {
+	if version >= V2 {
+		if payload == nil {
+			payload = struct{}{}
+		}
+		return json.Marshal(Envelope{Type: msgType, Payload: payload})
+	}
+
+	fields := map[string]json.RawMessage{}
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal %s: %v", msgType, err)
+		}
+		if err := json.Unmarshal(data, &fields); err != nil {
+			return nil, fmt.Errorf("failed to flatten %s: %v", msgType, err)
+		}
+	}
+	fields["type"], _ = json.Marshal(msgType)
+	return json.Marshal(fields)
+}
+
+// DecodeServer reads a server message of either version into the flat form
+func DecodeServer(data []byte) (domain.ServerMessage, error) {
+	var f frame
+	if err := json.Unmarshal(data, &f); err != nil {
+		return domain.ServerMessage{}, fmt.Errorf("failed to decode message: %v", err)
+	}
+	raw := []byte(f.Payload)
+	if len(raw) == 0 {
+		raw = data
+	}
+	var msg domain.ServerMessage
+	if err := json.Unmarshal(raw, &msg); err != nil {
+		return domain.ServerMessage{}, fmt.Errorf("failed to decode %s: %v", f.Type, err)
+	}
+	msg.Type = f.Type
+	return msg, nil
+}
+
+// CodeOf returns the error code to send for err: a DecodeError's own code,
+// or internal for anything else
+func CodeOf(err error) domain.ErrorCode {
+	var decodeErr *DecodeError
+	if errors.As(err, &decodeErr) {
+		return decodeErr.Code
+	}
+	return domain.ErrInternal
+}
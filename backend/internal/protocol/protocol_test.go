@@ -0,0 +1,96 @@
+package protocol
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/iamasit07/connect4/backend/internal/domain"
+)
+
+func TestDecodeClient(t *testing.T) {
+	tests := []struct {
+		version int
+		in      string
+		want    any
+		code    domain.ErrorCode
+	}{
+		{version: V1, in: `{"type":"make_move","column":3}`, want: MakeMove{Column: 3}},
+		{version: V1, in: `{"type":"make_move","column":3,"gameId":"g"}`, want: MakeMove{Column: 3}},
+		{version: V1, in: `{"type":"make_move"}`, want: MakeMove{}},
+		{version: V1, in: `{"type":"make_move","column":9}`, code: domain.ErrInvalidPayload},
+		{version: V1, in: `{"type":"make_move","column":"3"}`, code: domain.ErrInvalidPayload},
+		{version: V1, in: `{"type":"rematch_response","rematchResponse":"maybe"}`, code: domain.ErrInvalidPayload},
+		{version: V1, in: `{"type":"find_match"}`, want: FindMatch{}},
+		{version: V1, in: `{"type":"nope"}`, code: domain.ErrUnknownType},
+		{version: V1, in: `{"type":1}`, code: domain.ErrInvalidPayload},
+		{version: V1, in: `not json`, code: domain.ErrInvalidJSON},
+
+		{version: V2, in: `{"type":"make_move","payload":{"column":3}}`, want: MakeMove{Column: 3}},
+		{version: V2, in: `{"type":"make_move","payload":{}}`, code: domain.ErrInvalidPayload},
+		{version: V2, in: `{"type":"make_move","payload":{"column":3,"gameId":"g"}}`, code: domain.ErrInvalidPayload},
+		{version: V2, in: `{"type":"make_move","column":3}`, code: domain.ErrInvalidPayload},
+		{version: V2, in: `{"type":"make_move","payload":[3]}`, code: domain.ErrInvalidPayload},
+		{version: V2, in: `{"type":"cancel_search"}`, want: CancelSearch{}},
+		{version: V2, in: `{"type":"cancel_search","payload":null}`, want: CancelSearch{}},
+		{version: V2, in: `{"type":"find_match","payload":{"difficulty":"hard"}}`, want: FindMatch{Difficulty: "hard"}},
+		{version: V2, in: `{"type":"find_match","payload":{"difficulty":"expert"}}`, code: domain.ErrInvalidPayload},
+		{version: V2, in: `{"type":"watch_game","payload":{"gameId":""}}`, code: domain.ErrInvalidPayload},
+		{version: V2, in: `{"type":"challenge_response","payload":{"challengeId":"c","challengeResponse":"accept"}}`, want: ChallengeResponse{ChallengeID: "c", Response: "accept"}},
+		{version: V2, in: `{"type":"nope","payload":{}}`, code: domain.ErrUnknownType},
+	}
+
+	for _, tt := range tests {
+		msg, err := DecodeClient(tt.version, []byte(tt.in))
+		if tt.code != "" {
+			if err == nil || CodeOf(err) != tt.code {
+				t.Errorf("v%d %s: error = %v, want code %s", tt.version, tt.in, err, tt.code)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("v%d %s: unexpected error %v", tt.version, tt.in, err)
+			continue
+		}
+		if msg.Payload != tt.want {
+			t.Errorf("v%d %s: payload = %#v, want %#v", tt.version, tt.in, msg.Payload, tt.want)
+		}
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		offered []int
+		want    int
+		ok      bool
+	}{
+		{offered: nil, want: V1, ok: true},
+		{offered: []int{1}, want: V1, ok: true},
+		{offered: []int{2, 1}, want: V2, ok: true},
+		{offered: []int{2, 7}, want: V2, ok: true},
+		{offered: []int{7}, ok: false},
+	}
+
+	for _, tt := range tests {
+		got, ok := Negotiate(tt.offered)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("Negotiate(%v) = %d, %v; want %d, %v", tt.offered, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+// The checked-in schema must match the registered payloads; run go generate
+// after changing them
+func TestSchemaUpToDate(t *testing.T) {
+	want, err := Schema()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile("../../docs/websocket-protocol.schema.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Error("docs/websocket-protocol.schema.json is stale; run go generate ./internal/protocol")
+	}
+}
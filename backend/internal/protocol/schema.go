@@ -0,0 +1,147 @@
+package protocol
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Schema returns a JSON Schema (draft 2020-12) describing every version 2
+// message, built from the registered payload types. cmd/wsschema writes it
+// to docs/websocket-protocol.schema.json for client developers.
+func Schema() ([]byte, error) {
+	g := &schemaGen{defs: map[string]any{}}
+	g.defs["ClientMessage"] = g.messages("client", "A message sent by the client", clientMessages, true)
+	g.defs["ServerMessage"] = g.messages("server", "A message sent by the server", serverMessages, false)
+
+	doc := map[string]any{
+		"$schema":     "https://json-schema.org/draft/2020-12/schema",
+		"$id":         fmt.Sprintf("urn:connect4:websocket-protocol:v%d", Latest),
+		"title":       fmt.Sprintf("Connect 4 WebSocket protocol, version %d", Latest),
+		"description": "Every message is an envelope with a type and a payload whose shape depends on the type. Send init first, with the token and the versions the client speaks; the server answers with welcome.",
+		"oneOf": []any{
+			map[string]any{"$ref": "#/$defs/ClientMessage"},
+			map[string]any{"$ref": "#/$defs/ServerMessage"},
+		},
+		"$defs": g.defs,
+	}
+	out, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal schema: %v", err)
+	}
+	return append(out, '\n'), nil
+}
+
+type schemaGen struct {
+	defs map[string]any
+}
+
+// messages describes the envelopes of types as one choice keyed by "type"
+func (g *schemaGen) messages(side, doc string, types []messageType, withJWT bool) map[string]any {
+	var choices []any
+	for _, mt := range types {
+		name := side + "." + mt.name
+		properties := map[string]any{
+			"type":    map[string]any{"const": mt.name},
+			"payload": g.ref(mt.payload),
+		}
+		if withJWT {
+			properties["jwt"] = map[string]any{"type": "string", "description": "Optional; checked against the connection's session"}
+		}
+		required := []string{"type"}
+		if len(requiredFields(mt.payload)) > 0 {
+			required = append(required, "payload")
+		}
+		g.defs[name] = map[string]any{
+			"description":          mt.doc,
+			"type":                 "object",
+			"properties":           properties,
+			"required":             required,
+			"additionalProperties": false,
+		}
+		choices = append(choices, map[string]any{"$ref": "#/$defs/" + name})
+	}
+	return map[string]any{"description": doc, "oneOf": choices}
+}
+
+// ref returns a reference to t's definition, adding it on first use
+func (g *schemaGen) ref(t reflect.Type) map[string]any {
+	name := t.Name()
+	if pkg := t.PkgPath(); !strings.HasSuffix(pkg, "/protocol") {
+		name = pkg[strings.LastIndex(pkg, "/")+1:] + "." + name
+	}
+	if _, ok := g.defs[name]; !ok {
+		g.defs[name] = nil // reserve the name while t's fields are described
+		g.defs[name] = g.object(t)
+	}
+	return map[string]any{"$ref": "#/$defs/" + name}
+}
+
+func (g *schemaGen) object(t reflect.Type) map[string]any {
+	properties := map[string]any{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _ := jsonField(f)
+		s := g.schema(f.Type)
+		if tag, ok := f.Tag.Lookup("schema"); ok {
+			c := parseConstraint(tag)
+			if c.enum != nil {
+				s["enum"] = c.enum
+			}
+			if c.min != nil {
+				s["minimum"] = *c.min
+			}
+			if c.max != nil {
+				s["maximum"] = *c.max
+			}
+			if c.nonempty {
+				s["minLength"] = 1
+			}
+		}
+		properties[name] = s
+	}
+
+	s := map[string]any{"type": "object", "properties": properties, "additionalProperties": false}
+	if required := requiredFields(t); len(required) > 0 {
+		s["required"] = required
+	}
+	return s
+}
+
+func (g *schemaGen) schema(t reflect.Type) map[string]any {
+	switch {
+	case t == reflect.TypeFor[time.Time]():
+		return map[string]any{"type": "string", "format": "date-time"}
+	case t.Kind() == reflect.Pointer:
+		return g.schema(t.Elem())
+	case t.Kind() == reflect.Slice:
+		return map[string]any{"type": "array", "items": g.schema(t.Elem())}
+	case t.Kind() == reflect.Struct && t.Name() != "":
+		return g.ref(t)
+	case t.Kind() == reflect.Struct:
+		return g.object(t)
+	}
+	return map[string]any{"type": schemaType(t)}
+}
+
+// schemaType names the JSON type a Go type is encoded as
+func schemaType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	case reflect.Pointer:
+		return schemaType(t.Elem())
+	}
+	return "object"
+}
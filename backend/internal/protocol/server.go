@@ -0,0 +1,210 @@
+package protocol
+
+import "github.com/iamasit07/connect4/backend/internal/domain"
+
+// Payloads of the messages the server sends in version 2. Each field is
+// copied from the domain.ServerMessage field with the same JSON name.
+
+// Welcome answers an init that offered versions
+type Welcome struct {
+	Version  int   `json:"version"`
+	Versions []int `json:"versions"`
+}
+
+// Error answers a message the server could not handle
+type Error struct {
+	Code       domain.ErrorCode `json:"code" schema:"enum=invalid_json|unknown_type|invalid_payload|unsupported_version|unauthorized|rate_limited|not_found|forbidden|rejected|internal"`
+	Message    string           `json:"message"`
+	RetryAfter int              `json:"retryAfter,omitempty"` // rate_limited only
+	Presence   string           `json:"presence,omitempty"`   // why a challenge target is unavailable
+	Versions   []int            `json:"versions,omitempty"`   // unsupported_version only
+}
+
+type QueueJoined struct {
+	Rated bool `json:"rated"`
+}
+
+type QueueLeft struct{}
+
+type QueueTimeout struct{}
+
+type GameStart struct {
+	GameID      string              `json:"gameId"`
+	Opponent    string              `json:"opponent"`
+	YourPlayer  int                 `json:"yourPlayer" schema:"min=1,max=2"`
+	CurrentTurn int                 `json:"currentTurn" schema:"min=1,max=2"`
+	Board       [][]domain.PlayerID `json:"board"`
+	Rated       bool                `json:"rated"`
+}
+
+type MoveMade struct {
+	Column   int                 `json:"column"`
+	Row      int                 `json:"row"`
+	Player   int                 `json:"player" schema:"min=1,max=2"`
+	Board    [][]domain.PlayerID `json:"board"`
+	NextTurn int                 `json:"nextTurn" schema:"min=1,max=2"`
+}
+
+type GameOver struct {
+	Winner       string              `json:"winner"` // username, or "draw"
+	Reason       string              `json:"reason"`
+	Board        [][]domain.PlayerID `json:"board"`
+	WinningCells []struct {
+		Row int `json:"row"`
+		Col int `json:"col"`
+	} `json:"winningCells,omitempty"`
+	AllowRematch bool   `json:"allowRematch"`
+	Message      string `json:"message,omitempty"`
+}
+
+// GameState is the full state of the user's game, sent on request and on reconnect
+type GameState struct {
+	GameID           string               `json:"gameId"`
+	Opponent         string               `json:"opponent"`
+	YourPlayer       int                  `json:"yourPlayer"` // 0 for spectators
+	CurrentTurn      int                  `json:"currentTurn"`
+	Board            [][]domain.PlayerID  `json:"board"`
+	Rated            bool                 `json:"rated"`
+	Winner           string               `json:"winner,omitempty"` // set once the game is over
+	Reason           string               `json:"reason,omitempty"`
+	AllowRematch     bool                 `json:"allowRematch"`
+	RematchRequester string               `json:"rematchRequester,omitempty"`
+	DrawOfferer      string               `json:"drawOfferer,omitempty"`
+	ChatHistory      []domain.ChatMessage `json:"chatHistory"`
+}
+
+type SpectateStart struct {
+	GameID      string               `json:"gameId"`
+	Player1     string               `json:"player1"`
+	Player2     string               `json:"player2"`
+	CurrentTurn int                  `json:"currentTurn"`
+	Board       [][]domain.PlayerID  `json:"board"`
+	ChatHistory []domain.ChatMessage `json:"chatHistory"`
+}
+
+type OpponentDisconnected struct {
+	Message           string `json:"message"`
+	DisconnectTimeout int    `json:"disconnectTimeout"` // seconds until the game is forfeited
+}
+
+type OpponentReconnected struct{}
+
+type RematchRequested struct {
+	RematchRequester string `json:"rematchRequester"`
+	RematchTimeout   int    `json:"rematchTimeout"` // seconds left to answer
+	Message          string `json:"message"`
+}
+
+// Notice is a payload with only a human-readable message
+type Notice struct {
+	Message string `json:"message"`
+}
+
+type RematchTimeout struct {
+	Message      string `json:"message"`
+	AllowRematch bool   `json:"allowRematch"`
+}
+
+type DrawOffered struct {
+	DrawOfferer string `json:"drawOfferer"`
+	DrawTimeout int    `json:"drawTimeout"` // seconds left to answer
+	Message     string `json:"message"`
+}
+
+type ChatMessage struct {
+	GameID string             `json:"gameId"`
+	Chat   domain.ChatMessage `json:"chat"`
+}
+
+// ChatUser is the subject of user_muted and user_unmuted
+type ChatUser struct {
+	GameID string `json:"gameId"`
+	UserID int64  `json:"userId"`
+}
+
+type ReportReceived struct {
+	GameID string `json:"gameId"`
+}
+
+type PresenceUpdate struct {
+	UserID   int64  `json:"userId"`
+	Presence string `json:"presence" schema:"enum=offline|online|in_queue|playing|spectating"`
+}
+
+// Friend is the other user in a friend_request or friend_accepted
+type Friend struct {
+	UserID   int64  `json:"userId"`
+	Username string `json:"username"`
+}
+
+// Challenge describes challenge_received (UserID is the sender) and
+// challenge_sent (UserID is the friend challenged)
+type Challenge struct {
+	ChallengeID      string `json:"challengeId"`
+	UserID           int64  `json:"userId"`
+	Username         string `json:"username"`
+	Rated            bool   `json:"rated"`
+	ChallengeTimeout int    `json:"challengeTimeout"` // seconds until it expires
+}
+
+type ChallengeDeclined struct {
+	ChallengeID string `json:"challengeId"`
+	UserID      int64  `json:"userId"`
+	Username    string `json:"username"`
+}
+
+type ChallengeExpired struct {
+	ChallengeID string `json:"challengeId"`
+}
+
+// GameOnOtherDevice says another of the user's devices is playing their game
+type GameOnOtherDevice struct {
+	GameID  string `json:"gameId,omitempty"`
+	Message string `json:"message"`
+}
+
+// serverMessages lists every message the server sends, in the order the
+// schema documents them
+var serverMessages = []messageType{
+	newMessageType[Welcome]("welcome", "The protocol version agreed in init"),
+	newMessageType[Error]("error", "A message was rejected or failed"),
+	newMessageType[QueueJoined]("queue_joined", "The user is waiting for an opponent"),
+	newMessageType[QueueLeft]("queue_left", "The user left the queue"),
+	newMessageType[QueueTimeout]("queue_timeout", "No opponent was found in time"),
+	newMessageType[GameStart]("game_start", "A game began"),
+	newMessageType[MoveMade]("move_made", "A disc was dropped"),
+	newMessageType[GameOver]("game_over", "The game ended"),
+	newMessageType[GameState]("game_state", "Full state of the user's game"),
+	newMessageType[SpectateStart]("spectate_start", "Full state of a game the user started watching"),
+	newMessageType[OpponentDisconnected]("opponent_disconnected", "The opponent lost their connection"),
+	newMessageType[OpponentReconnected]("opponent_reconnected", "The opponent is back"),
+	newMessageType[RematchRequested]("rematch_requested", "The opponent wants a rematch"),
+	newMessageType[Notice]("rematch_accepted", "The rematch is starting"),
+	newMessageType[Notice]("rematch_declined", "The rematch request was declined"),
+	newMessageType[Notice]("rematch_cancelled", "The rematch can no longer happen"),
+	newMessageType[RematchTimeout]("rematch_timeout", "Nobody answered the rematch request in time"),
+	newMessageType[DrawOffered]("draw_offered", "The opponent offers a draw"),
+	newMessageType[Notice]("draw_declined", "The draw offer was declined"),
+	newMessageType[Notice]("draw_timeout", "Nobody answered the draw offer in time"),
+	newMessageType[ChatMessage]("chat_message", "A new chat line"),
+	newMessageType[ChatUser]("user_muted", "The user no longer sees this user's chat"),
+	newMessageType[ChatUser]("user_unmuted", "The user sees this user's chat again"),
+	newMessageType[ReportReceived]("report_received", "A chat report was filed"),
+	newMessageType[PresenceUpdate]("presence_update", "A friend's presence changed"),
+	newMessageType[Friend]("friend_request", "Someone sent the user a friend request"),
+	newMessageType[Friend]("friend_accepted", "A friend request was accepted"),
+	newMessageType[Challenge]("challenge_received", "A friend challenged the user"),
+	newMessageType[Challenge]("challenge_sent", "The user's challenge was delivered"),
+	newMessageType[ChallengeDeclined]("challenge_declined", "The friend declined the challenge"),
+	newMessageType[ChallengeExpired]("challenge_expired", "A challenge timed out"),
+	newMessageType[GameOnOtherDevice]("game_on_other_device", "The game is being played on another device"),
+	newMessageType[Notice]("no_active_game", "The user has no game to act on"),
+	newMessageType[Notice]("force_disconnect", "The server is closing this socket, e.g. after a sign-out"),
+}
+
+var serverTypes = func() map[string]messageType {
+	for i, mt := range serverMessages {
+		serverMessages[i] = withFields(mt)
+	}
+	return indexTypes(serverMessages)
+}()
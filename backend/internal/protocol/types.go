@@ -0,0 +1,185 @@
+package protocol
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/iamasit07/connect4/backend/internal/domain"
+)
+
+// messageType registers a message type with its payload
+type messageType struct {
+	name    string
+	payload reflect.Type
+	doc     string
+
+	// fields maps each payload field to the ServerMessage field it is copied
+	// from (server messages only)
+	fields []fieldCopy
+}
+
+type fieldCopy struct {
+	to, from int
+	deref    bool // from is a pointer to to's type
+}
+
+func newMessageType[T any](name, doc string) messageType {
+	return messageType{name: name, payload: reflect.TypeFor[T](), doc: doc}
+}
+
+func indexTypes(types []messageType) map[string]messageType {
+	index := make(map[string]messageType, len(types))
+	for _, mt := range types {
+		if _, dup := index[mt.name]; dup {
+			panic("protocol: duplicate message type " + mt.name)
+		}
+		index[mt.name] = mt
+	}
+	return index
+}
+
+// jsonField returns a field's JSON name and whether it has omitempty
+func jsonField(f reflect.StructField) (name string, optional bool) {
+	tag := f.Tag.Get("json")
+	name, opts, _ := strings.Cut(tag, ",")
+	if name == "" {
+		name = f.Name
+	}
+	return name, strings.Contains(opts, "omitempty")
+}
+
+// requiredFields lists the JSON names of t's fields without omitempty
+func requiredFields(t reflect.Type) []string {
+	var names []string
+	for i := 0; i < t.NumField(); i++ {
+		if name, optional := jsonField(t.Field(i)); !optional {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// constraint holds the rules in a field's schema tag: "enum=a|b",
+// "min=0,max=6" or "nonempty". Optional fields left empty aren't checked.
+type constraint struct {
+	enum     []string
+	min, max *int
+	nonempty bool
+}
+
+func parseConstraint(tag string) constraint {
+	var c constraint
+	for _, rule := range strings.Split(tag, ",") {
+		key, value, _ := strings.Cut(rule, "=")
+		switch key {
+		case "enum":
+			c.enum = strings.Split(value, "|")
+		case "min", "max":
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				panic(fmt.Sprintf("protocol: bad %s in schema tag %q", key, tag))
+			}
+			if key == "min" {
+				c.min = &n
+			} else {
+				c.max = &n
+			}
+		case "nonempty":
+			c.nonempty = true
+		case "":
+		default:
+			panic(fmt.Sprintf("protocol: unknown rule %q in schema tag %q", key, tag))
+		}
+	}
+	return c
+}
+
+func (c constraint) check(name string, v reflect.Value) error {
+	switch {
+	case c.nonempty && v.IsZero():
+		return fmt.Errorf("%s must not be empty", name)
+	case c.enum != nil && !slices.Contains(c.enum, v.String()):
+		return fmt.Errorf("%s must be one of %s", name, strings.Join(c.enum, ", "))
+	case c.min != nil && v.Int() < int64(*c.min):
+		return fmt.Errorf("%s must be at least %d", name, *c.min)
+	case c.max != nil && v.Int() > int64(*c.max):
+		return fmt.Errorf("%s must be at most %d", name, *c.max)
+	}
+	return nil
+}
+
+// checkConstraints applies the schema tags of payload's fields
+func checkConstraints(payload reflect.Value) error {
+	t := payload.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag, ok := f.Tag.Lookup("schema")
+		if !ok {
+			continue
+		}
+		name, optional := jsonField(f)
+		value := payload.Field(i)
+		if optional && value.IsZero() {
+			continue
+		}
+		if err := parseConstraint(tag).check(name, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// serverFields maps ServerMessage's JSON names to its field indexes
+var serverFields = func() map[string]int {
+	t := reflect.TypeFor[domain.ServerMessage]()
+	fields := make(map[string]int, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		name, _ := jsonField(t.Field(i))
+		fields[name] = i
+	}
+	return fields
+}()
+
+// withFields works out how to fill mt's payload from a ServerMessage. Each
+// payload field must match a ServerMessage field by JSON name and type
+// (or pointed-to type); anything else is a programming error.
+func withFields(mt messageType) messageType {
+	src := reflect.TypeFor[domain.ServerMessage]()
+	for i := 0; i < mt.payload.NumField(); i++ {
+		f := mt.payload.Field(i)
+		name, _ := jsonField(f)
+		from, ok := serverFields[name]
+		if !ok || name == "type" {
+			panic(fmt.Sprintf("protocol: %s payload field %s has no ServerMessage field", mt.name, name))
+		}
+		switch ft := src.Field(from).Type; {
+		case ft == f.Type:
+			mt.fields = append(mt.fields, fieldCopy{to: i, from: from})
+		case ft.Kind() == reflect.Pointer && ft.Elem() == f.Type:
+			mt.fields = append(mt.fields, fieldCopy{to: i, from: from, deref: true})
+		default:
+			panic(fmt.Sprintf("protocol: %s payload field %s is %s, ServerMessage has %s", mt.name, name, f.Type, ft))
+		}
+	}
+	return mt
+}
+
+// project copies msg's fields into a new payload of mt's type
+func (mt messageType) project(msg domain.ServerMessage) any {
+	src := reflect.ValueOf(msg)
+	dst := reflect.New(mt.payload).Elem()
+	for _, fc := range mt.fields {
+		from := src.Field(fc.from)
+		if fc.deref {
+			if from.IsNil() {
+				continue
+			}
+			from = from.Elem()
+		}
+		dst.Field(fc.to).Set(from)
+	}
+	return dst.Interface()
+}
@@ -0,0 +1,33 @@
+package ratelimit
+
+import "time"
+
+// Lockout blocks a key (an account, say) after repeated failures. Each
+// failure spends a token from the policy's bucket; once it is empty the key is
+// locked until a token refills. A success resets the key.
+type Lockout struct {
+	limiter *Limiter
+	policy  Policy
+	prefix  string
+}
+
+// NewLockout creates a lockout; prefix keeps its keys apart from other limits
+func NewLockout(limiter *Limiter, policy Policy, prefix string) *Lockout {
+	return &Lockout{limiter: limiter, policy: policy, prefix: prefix + ":"}
+}
+
+// Locked reports whether key is locked out, and for how long
+func (l *Lockout) Locked(key string) (time.Duration, bool) {
+	ok, wait := l.limiter.take(l.prefix+key, l.policy, 0)
+	return wait, !ok
+}
+
+// Fail records a failed attempt for key
+func (l *Lockout) Fail(key string) {
+	l.limiter.take(l.prefix+key, l.policy, 1)
+}
+
+// Reset clears key's failures
+func (l *Lockout) Reset(key string) {
+	l.limiter.reset(l.prefix + key)
+}
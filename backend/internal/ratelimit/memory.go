@@ -0,0 +1,77 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	// pruneInterval is how often MemoryStore drops buckets that have refilled
+	pruneInterval = time.Minute
+
+	// epsilon absorbs rounding so a bucket that has just refilled a token counts as having it
+	epsilon = 1e-9
+)
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	full    time.Time // when the bucket will be full again if left alone
+}
+
+// MemoryStore keeps buckets in process. Limits aren't shared between instances.
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastPrune time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: make(map[string]*bucket)}
+}
+
+func (s *MemoryStore) Take(_ context.Context, key string, p Policy, now time.Time, cost int) (Decision, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pruneLocked(now)
+
+	limit, window := float64(p.Limit), float64(p.Window)
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: limit, updated: now}
+		s.buckets[key] = b
+	}
+	if elapsed := now.Sub(b.updated); elapsed > 0 {
+		b.tokens = min(limit, b.tokens+float64(elapsed)*limit/window)
+		b.updated = now
+	}
+
+	if b.tokens < 1-epsilon {
+		return Decision{RetryAfter: time.Duration((1 - b.tokens) * window / limit)}, nil
+	}
+	b.tokens -= float64(cost)
+	b.full = now.Add(time.Duration((limit - b.tokens) * window / limit))
+	return Decision{Allowed: true}, nil
+}
+
+func (s *MemoryStore) Reset(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.buckets, key)
+	return nil
+}
+
+// pruneLocked forgets buckets that are full again; s.mu must be held
+func (s *MemoryStore) pruneLocked(now time.Time) {
+	if now.Sub(s.lastPrune) < pruneInterval {
+		return
+	}
+	s.lastPrune = now
+	for key, b := range s.buckets {
+		if !now.Before(b.full) {
+			delete(s.buckets, key)
+		}
+	}
+}
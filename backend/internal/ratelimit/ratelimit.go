@@ -0,0 +1,113 @@
+// Package ratelimit implements token-bucket rate limiting with pluggable
+// storage: in memory for a single instance, or Redis when several API
+// instances share limits. It backs the HTTP middleware, the WebSocket message
+// limits and the login lockout.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/iamasit07/connect4/backend/internal/clock"
+	"github.com/iamasit07/connect4/backend/internal/logging"
+)
+
+// Policy is a token bucket holding Limit tokens that refill evenly over
+// Window. A Limit of zero or less disables the policy.
+type Policy struct {
+	Limit  int
+	Window time.Duration
+}
+
+// Enabled reports whether the policy limits anything
+func (p Policy) Enabled() bool {
+	return p.Limit > 0 && p.Window > 0
+}
+
+func (p Policy) String() string {
+	if !p.Enabled() {
+		return "off"
+	}
+	return fmt.Sprintf("%d/%s", p.Limit, p.Window)
+}
+
+// ParsePolicy reads "LIMIT/WINDOW", e.g. "10/1m", or "off"
+func ParsePolicy(s string) (Policy, error) {
+	s = strings.TrimSpace(s)
+	if s == "off" || s == "0" {
+		return Policy{}, nil
+	}
+	limitStr, windowStr, ok := strings.Cut(s, "/")
+	if !ok {
+		return Policy{}, fmt.Errorf("invalid rate limit %q: want LIMIT/WINDOW", s)
+	}
+	limit, err := strconv.Atoi(strings.TrimSpace(limitStr))
+	if err != nil || limit < 0 {
+		return Policy{}, fmt.Errorf("invalid rate limit %q: bad limit", s)
+	}
+	window, err := time.ParseDuration(strings.TrimSpace(windowStr))
+	if err != nil || window <= 0 {
+		return Policy{}, fmt.Errorf("invalid rate limit %q: bad window", s)
+	}
+	return Policy{Limit: limit, Window: window}, nil
+}
+
+// Decision is the outcome of taking from a bucket
+type Decision struct {
+	Allowed    bool
+	RetryAfter time.Duration // until the next token when not allowed
+}
+
+// Store keeps the buckets. Take refills key's bucket up to now, then spends
+// cost tokens if at least one is available. A cost of zero only checks.
+type Store interface {
+	Take(ctx context.Context, key string, p Policy, now time.Time, cost int) (Decision, error)
+	Reset(ctx context.Context, key string) error
+}
+
+// Limiter applies policies to keys on top of a Store. Store errors let the
+// request through, so an unreachable Redis doesn't take the API down.
+type Limiter struct {
+	store Store
+	clock clock.Clock
+}
+
+func NewLimiter(store Store, clk clock.Clock) *Limiter {
+	return &Limiter{store: store, clock: clk}
+}
+
+// Allow spends a token from key's bucket. When none is left it reports how
+// long until one is.
+func (l *Limiter) Allow(key string, p Policy) (bool, time.Duration) {
+	return l.take(key, p, 1)
+}
+
+func (l *Limiter) take(key string, p Policy, cost int) (bool, time.Duration) {
+	if !p.Enabled() {
+		return true, 0
+	}
+	d, err := l.store.Take(context.Background(), key, p, l.clock.Now(), cost)
+	if err != nil {
+		logging.For("ratelimit").Error("Check failed", "key", key, logging.Err(err))
+		return true, 0
+	}
+	return d.Allowed, d.RetryAfter
+}
+
+func (l *Limiter) reset(key string) {
+	if err := l.store.Reset(context.Background(), key); err != nil {
+		logging.For("ratelimit").Error("Reset failed", "key", key, logging.Err(err))
+	}
+}
+
+// RetryAfterSeconds rounds a wait up to whole seconds for a Retry-After header
+func RetryAfterSeconds(d time.Duration) int {
+	seconds := int((d + time.Second - 1) / time.Second)
+	if seconds < 1 {
+		return 1
+	}
+	return seconds
+}
@@ -0,0 +1,88 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/iamasit07/connect4/backend/internal/clock"
+)
+
+func TestParsePolicy(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Policy
+		wantErr bool
+	}{
+		{in: "10/1m", want: Policy{Limit: 10, Window: time.Minute}},
+		{in: " 5 / 30s ", want: Policy{Limit: 5, Window: 30 * time.Second}},
+		{in: "off", want: Policy{}},
+		{in: "0", want: Policy{}},
+		{in: "10", wantErr: true},
+		{in: "x/1m", wantErr: true},
+		{in: "10/soon", wantErr: true},
+		{in: "10/0s", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParsePolicy(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParsePolicy(%q) error = %v, want error %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParsePolicy(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestTokenBucket(t *testing.T) {
+	clk := clock.NewFake(time.Now())
+	limiter := NewLimiter(NewMemoryStore(), clk)
+	policy := Policy{Limit: 3, Window: 30 * time.Second} // one token every 10s
+
+	for i := 0; i < 3; i++ {
+		if ok, _ := limiter.Allow("k", policy); !ok {
+			t.Fatalf("request %d refused within the burst", i+1)
+		}
+	}
+	ok, wait := limiter.Allow("k", policy)
+	if ok || wait != 10*time.Second {
+		t.Fatalf("after burst: allowed = %v, wait = %v; want refused for 10s", ok, wait)
+	}
+	if ok, _ := limiter.Allow("other", policy); !ok {
+		t.Error("keys share a bucket")
+	}
+
+	clk.Advance(4 * time.Second)
+	if ok, wait := limiter.Allow("k", policy); ok || wait != 6*time.Second {
+		t.Errorf("partly refilled: allowed = %v, wait = %v; want refused for 6s", ok, wait)
+	}
+	clk.Advance(6 * time.Second)
+	if ok, _ := limiter.Allow("k", policy); !ok {
+		t.Error("refused after a token refilled")
+	}
+
+	if ok, _ := limiter.Allow("k", Policy{}); !ok {
+		t.Error("disabled policy refused a request")
+	}
+}
+
+func TestLockout(t *testing.T) {
+	clk := clock.NewFake(time.Now())
+	lockout := NewLockout(NewLimiter(NewMemoryStore(), clk), Policy{Limit: 2, Window: 10 * time.Minute}, "login")
+
+	lockout.Fail("alice")
+	if _, locked := lockout.Locked("alice"); locked {
+		t.Fatal("locked after one failure")
+	}
+	lockout.Fail("alice")
+	wait, locked := lockout.Locked("alice")
+	if !locked || wait != 5*time.Minute {
+		t.Fatalf("after two failures: locked = %v, wait = %v; want locked for 5m", locked, wait)
+	}
+
+	lockout.Reset("alice")
+	if _, locked := lockout.Locked("alice"); locked {
+		t.Error("still locked after reset")
+	}
+}
@@ -0,0 +1,60 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// takeScript refills and spends from a bucket atomically. Buckets are hashes
+// of tokens and the last update in milliseconds, and expire once they would be full.
+var takeScript = redis.NewScript(`
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local cost = tonumber(ARGV[4])
+
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(state[1]) or limit
+local ts = tonumber(state[2]) or now
+if now > ts then
+  tokens = math.min(limit, tokens + (now - ts) * limit / window)
+  ts = now
+end
+
+if tokens < 1 - 1e-9 then
+  return {0, math.ceil((1 - tokens) * window / limit)}
+end
+tokens = tokens - cost
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', ts)
+redis.call('PEXPIRE', KEYS[1], math.ceil((limit - tokens) * window / limit) + 1)
+return {1, 0}
+`)
+
+// RedisStore keeps buckets in Redis so every API instance shares them
+type RedisStore struct {
+	client *redis.Client
+	prefix string
+}
+
+func NewRedisStore(client *redis.Client) *RedisStore {
+	return &RedisStore{client: client, prefix: "ratelimit:"}
+}
+
+func (s *RedisStore) Take(ctx context.Context, key string, p Policy, now time.Time, cost int) (Decision, error) {
+	result, err := takeScript.Run(ctx, s.client, []string{s.prefix + key},
+		p.Limit, p.Window.Milliseconds(), now.UnixMilli(), cost).Int64Slice()
+	if err != nil {
+		return Decision{}, fmt.Errorf("failed to take from bucket: %v", err)
+	}
+	return Decision{Allowed: result[0] == 1, RetryAfter: time.Duration(result[1]) * time.Millisecond}, nil
+}
+
+func (s *RedisStore) Reset(ctx context.Context, key string) error {
+	if err := s.client.Del(ctx, s.prefix+key).Err(); err != nil {
+		return fmt.Errorf("failed to reset bucket: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package memory
+
+import (
+	"sync"
+	"time"
+
+	"github.com/iamasit07/connect4/backend/internal/domain"
+)
+
+// AccountTokenRepo is a thread-safe in-memory implementation of repository.AccountTokenRepository
+type AccountTokenRepo struct {
+	mu     sync.Mutex
+	tokens map[string]*domain.AccountToken // token hash → token
+	nextID int64
+}
+
+func NewAccountTokenRepo() *AccountTokenRepo {
+	return &AccountTokenRepo{tokens: make(map[string]*domain.AccountToken), nextID: 1}
+}
+
+// CreateAccountToken stores a new token and drops the user's unused ones for the same purpose
+func (r *AccountTokenRepo) CreateAccountToken(token domain.AccountToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for hash, t := range r.tokens {
+		if t.UserID == token.UserID && t.Purpose == token.Purpose && t.UsedAt == nil {
+			delete(r.tokens, hash)
+		}
+	}
+	token.ID = r.nextID
+	r.nextID++
+	r.tokens[token.TokenHash] = &token
+	return nil
+}
+
+// ConsumeAccountToken marks a live token used and returns it, or nil when unknown, used or expired
+func (r *AccountTokenRepo) ConsumeAccountToken(purpose, tokenHash string, now time.Time) (*domain.AccountToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	t, ok := r.tokens[tokenHash]
+	if !ok || t.Purpose != purpose || t.UsedAt != nil || !now.Before(t.ExpiresAt) {
+		return nil, nil
+	}
+	usedAt := now
+	t.UsedAt = &usedAt
+	copied := *t
+	return &copied, nil
+}
@@ -0,0 +1,38 @@
+package memory
+
+import (
+	"sync"
+
+	"github.com/iamasit07/connect4/backend/internal/domain"
+)
+
+// AdminRepo is a thread-safe in-memory implementation of repository.AdminRepository
+type AdminRepo struct {
+	mu      sync.RWMutex
+	actions []domain.AdminAction
+	nextID  int64
+}
+
+func NewAdminRepo() *AdminRepo {
+	return &AdminRepo{nextID: 1}
+}
+
+func (r *AdminRepo) LogAdminAction(action domain.AdminAction) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	action.ID = r.nextID
+	r.nextID++
+	r.actions = append(r.actions, action)
+	return nil
+}
+
+// ListAdminActions returns up to limit entries, newest first
+func (r *AdminRepo) ListAdminActions(limit int) ([]domain.AdminAction, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	actions := make([]domain.AdminAction, 0, limit)
+	for i := len(r.actions) - 1; i >= 0 && len(actions) < limit; i-- {
+		actions = append(actions, r.actions[i])
+	}
+	return actions, nil
+}
@@ -0,0 +1,101 @@
+package memory
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/iamasit07/connect4/backend/internal/domain"
+)
+
+// AnalysisRepo is a thread-safe in-memory implementation of repository.AnalysisRepository.
+// It reads move lists from a shared GameRepo.
+type AnalysisRepo struct {
+	mu       sync.RWMutex
+	games    *GameRepo
+	analyzed map[string]bool
+	results  []domain.PlayerGameAnalysis
+}
+
+func NewAnalysisRepo(games *GameRepo) *AnalysisRepo {
+	return &AnalysisRepo{games: games, analyzed: make(map[string]bool)}
+}
+
+// GetUnanalyzedGames returns up to limit games with a move list that haven't been analysed, oldest first
+func (r *AnalysisRepo) GetUnanalyzedGames(limit int) ([]domain.RecordedGame, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	r.games.mu.RLock()
+	defer r.games.mu.RUnlock()
+
+	var games []domain.RecordedGame
+	for id, g := range r.games.games {
+		if r.analyzed[id] || len(r.games.moves[id]) == 0 {
+			continue
+		}
+		ratings := r.games.ratings[id]
+		games = append(games, domain.RecordedGame{
+			GameID:        id,
+			Player1ID:     g.Player1ID,
+			Player2ID:     copyID(g.Player2ID),
+			Player1Rating: ratings[0],
+			Player2Rating: ratings[1],
+			FinishedAt:    g.FinishedAt,
+			Moves:         append([]domain.MoveRecord(nil), r.games.moves[id]...),
+		})
+	}
+	sort.Slice(games, func(i, j int) bool {
+		return games[i].FinishedAt.Before(games[j].FinishedAt)
+	})
+	if len(games) > limit {
+		games = games[:limit]
+	}
+	return games, nil
+}
+
+func (r *AnalysisRepo) SaveGameAnalysis(gameID string, analyses []domain.PlayerGameAnalysis) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.analyzed[gameID] = true
+	r.results = append(r.results, analyses...)
+	return nil
+}
+
+// GetPlayerAnalyses returns the user's most recent analyses, newest game first
+func (r *AnalysisRepo) GetPlayerAnalyses(userID int64, limit int) ([]domain.PlayerGameAnalysis, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var analyses []domain.PlayerGameAnalysis
+	for _, a := range r.results {
+		if a.UserID == userID {
+			analyses = append(analyses, a)
+		}
+	}
+	sort.Slice(analyses, func(i, j int) bool {
+		return analyses[i].FinishedAt.After(analyses[j].FinishedAt)
+	})
+	if len(analyses) > limit {
+		analyses = analyses[:limit]
+	}
+	return analyses, nil
+}
+
+// GetBandStats totals the analyses of players rated minRating..maxRating, excluding one user
+func (r *AnalysisRepo) GetBandStats(minRating, maxRating int, excludeUserID int64) (domain.BandStats, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var stats domain.BandStats
+	players := make(map[int64]bool)
+	for _, a := range r.results {
+		if a.UserID == excludeUserID || a.Rating < minRating || a.Rating > maxRating {
+			continue
+		}
+		players[a.UserID] = true
+		stats.Moves += a.Moves
+		stats.EngineMatches += a.EngineMatches
+		stats.TotalLoss += a.TotalLoss
+	}
+	stats.Players = len(players)
+	return stats, nil
+}
@@ -0,0 +1,86 @@
+package memory
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/iamasit07/connect4/backend/internal/domain"
+)
+
+// APIKeyRepo is a thread-safe in-memory implementation of repository.APIKeyRepository
+type APIKeyRepo struct {
+	mu     sync.Mutex
+	keys   map[int64]*domain.APIKey
+	nextID int64
+}
+
+func NewAPIKeyRepo() *APIKeyRepo {
+	return &APIKeyRepo{keys: make(map[int64]*domain.APIKey), nextID: 1}
+}
+
+func (r *APIKeyRepo) CreateAPIKey(key domain.APIKey) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.keys {
+		if existing.KeyHash == key.KeyHash {
+			return 0, fmt.Errorf("failed to create api key: duplicate key hash")
+		}
+	}
+	key.ID = r.nextID
+	r.nextID++
+	if key.CreatedAt.IsZero() {
+		key.CreatedAt = time.Now()
+	}
+	key.Scopes = append([]string(nil), key.Scopes...)
+	r.keys[key.ID] = &key
+	return key.ID, nil
+}
+
+func (r *APIKeyRepo) GetAPIKeyByHash(keyHash string) (*domain.APIKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, key := range r.keys {
+		if key.KeyHash == keyHash {
+			copied := *key
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+// ListAPIKeys returns the user's keys, newest first
+func (r *APIKeyRepo) ListAPIKeys(userID int64) ([]domain.APIKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []domain.APIKey
+	for _, key := range r.keys {
+		if key.UserID == userID {
+			out = append(out, *key)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID > out[j].ID })
+	return out, nil
+}
+
+// DeleteAPIKey removes one of the user's keys, reporting whether it existed
+func (r *APIKeyRepo) DeleteAPIKey(userID, keyID int64) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key, ok := r.keys[keyID]
+	if !ok || key.UserID != userID {
+		return false, nil
+	}
+	delete(r.keys, keyID)
+	return true, nil
+}
+
+func (r *APIKeyRepo) TouchAPIKey(keyID int64, usedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if key, ok := r.keys[keyID]; ok {
+		key.LastUsedAt = &usedAt
+	}
+	return nil
+}
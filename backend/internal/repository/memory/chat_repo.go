@@ -0,0 +1,42 @@
+package memory
+
+import (
+	"sync"
+
+	"github.com/iamasit07/connect4/backend/internal/domain"
+)
+
+// ChatRepo is a thread-safe in-memory implementation of repository.ChatRepository.
+// Like the unique index on chat_reports, a user can report a message only once.
+type ChatRepo struct {
+	mu      sync.RWMutex
+	reports []domain.ChatReport
+	nextID  int64
+}
+
+func NewChatRepo() *ChatRepo {
+	return &ChatRepo{nextID: 1}
+}
+
+func (r *ChatRepo) SaveChatReport(report domain.ChatReport) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.reports {
+		if existing.GameID == report.GameID && existing.MessageID == report.MessageID && existing.ReporterID == report.ReporterID {
+			return nil
+		}
+	}
+
+	report.ID = r.nextID
+	r.nextID++
+	r.reports = append(r.reports, report)
+	return nil
+}
+
+// Reports returns every stored report, oldest first
+func (r *ChatRepo) Reports() []domain.ChatReport {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]domain.ChatReport(nil), r.reports...)
+}
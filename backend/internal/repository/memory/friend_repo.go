@@ -0,0 +1,129 @@
+package memory
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/iamasit07/connect4/backend/internal/domain"
+)
+
+type friendKey struct{ userID, friendID int64 }
+
+// FriendRepo is a thread-safe in-memory implementation of repository.FriendRepository.
+// It reads usernames and avatars from the shared UserRepo, like the Postgres join does.
+type FriendRepo struct {
+	mu    sync.RWMutex
+	rows  map[friendKey]domain.Friendship
+	users *UserRepo
+}
+
+func NewFriendRepo(users *UserRepo) *FriendRepo {
+	return &FriendRepo{
+		rows:  make(map[friendKey]domain.Friendship),
+		users: users,
+	}
+}
+
+// GetFriendship returns the directed row from userID to friendID, or nil if there is none
+func (r *FriendRepo) GetFriendship(userID, friendID int64) (*domain.Friendship, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	f, ok := r.rows[friendKey{userID, friendID}]
+	if !ok {
+		return nil, nil
+	}
+	return &f, nil
+}
+
+// SetFriendship inserts the directed row or updates its status
+func (r *FriendRepo) SetFriendship(userID, friendID int64, status string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	key := friendKey{userID, friendID}
+	f, ok := r.rows[key]
+	if !ok {
+		f = domain.Friendship{UserID: userID, FriendID: friendID, CreatedAt: now}
+	}
+	f.Status = status
+	f.BlockSpectating = false
+	f.UpdatedAt = now
+	r.rows[key] = f
+	return nil
+}
+
+// BlockUser inserts or updates a block from userID on targetID
+func (r *FriendRepo) BlockUser(userID, targetID int64, blockSpectating bool) error {
+	if err := r.SetFriendship(userID, targetID, domain.FriendshipBlocked); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := friendKey{userID, targetID}
+	f := r.rows[key]
+	f.BlockSpectating = blockSpectating
+	r.rows[key] = f
+	return nil
+}
+
+// GetBlocks returns every block placed by or on the user
+func (r *FriendRepo) GetBlocks(userID int64) ([]domain.Friendship, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var blocks []domain.Friendship
+	for _, f := range r.rows {
+		if f.Status == domain.FriendshipBlocked && (f.UserID == userID || f.FriendID == userID) {
+			blocks = append(blocks, f)
+		}
+	}
+	return blocks, nil
+}
+
+func (r *FriendRepo) DeleteFriendship(userID, friendID int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.rows, friendKey{userID, friendID})
+	return nil
+}
+
+// ListFriends returns the user's friends, pending requests in both directions
+// and the users they have blocked. Blocks placed on the user are not listed.
+func (r *FriendRepo) ListFriends(userID int64) ([]domain.Friend, error) {
+	r.mu.RLock()
+	var rows []domain.Friendship
+	for _, f := range r.rows {
+		if f.UserID == userID || (f.FriendID == userID && f.Status != domain.FriendshipBlocked) {
+			rows = append(rows, f)
+		}
+	}
+	r.mu.RUnlock()
+
+	var friends []domain.Friend
+	for _, f := range rows {
+		outgoing := f.UserID == userID
+		otherID := f.UserID
+		if outgoing {
+			otherID = f.FriendID
+		}
+		other, err := r.users.GetUserByID(otherID)
+		if err != nil || other == nil {
+			continue
+		}
+		friends = append(friends, domain.Friend{
+			UserID:          other.ID,
+			Username:        other.Username,
+			AvatarURL:       other.AvatarURL,
+			Relation:        domain.FriendRelation(f.Status, outgoing),
+			Since:           f.UpdatedAt,
+			BlockSpectating: f.BlockSpectating,
+		})
+	}
+	sort.Slice(friends, func(i, j int) bool {
+		return friends[i].Username < friends[j].Username
+	})
+	return friends, nil
+}
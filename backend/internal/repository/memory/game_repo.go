@@ -0,0 +1,244 @@
+package memory
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/iamasit07/connect4/backend/internal/domain"
+)
+
+// GameRepo is a thread-safe in-memory implementation of repository.GameRepository.
+// It shares a UserRepo so that saving a game updates player stats and ratings.
+type GameRepo struct {
+	mu      sync.RWMutex
+	games   map[string]domain.GameResult
+	boards  map[string][][]int
+	chats   map[string][]domain.ChatMessage
+	moves   map[string][]domain.MoveRecord
+	ratings map[string][2]int // gameID → player ratings going into the game
+	users   *UserRepo
+	saveMu  sync.Mutex // serialises SaveGame like the Postgres transaction does
+}
+
+func NewGameRepo(users *UserRepo) *GameRepo {
+	return &GameRepo{
+		games:   make(map[string]domain.GameResult),
+		boards:  make(map[string][][]int),
+		chats:   make(map[string][]domain.ChatMessage),
+		moves:   make(map[string][]domain.MoveRecord),
+		ratings: make(map[string][2]int),
+		users:   users,
+	}
+}
+
+// SaveGame saves a finished game and updates player stats.
+// Unrated (casual) games still count towards win/loss stats but leave ratings untouched;
+// games terminated by a moderator touch neither.
+func (r *GameRepo) SaveGame(gameID string, player1ID int64, player1Username string, player2ID *int64, player2Username string, winnerID *int64, winnerUsername string, reason string, totalMoves, durationSeconds int, createdAt, finishedAt time.Time, boardState [][]int, rated bool, chat []domain.ChatMessage, moves []domain.MoveRecord) error {
+	r.saveMu.Lock()
+	defer r.saveMu.Unlock()
+
+	p1Rating, err := r.users.rating(player1ID)
+	if err != nil {
+		return err
+	}
+
+	// Determine opponent rating (use default 1000 for bot games)
+	p2Rating := 1000
+	if player2ID != nil {
+		p2Rating, err = r.users.rating(*player2ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	p1Outcome, p2Outcome := domain.ScoreGame(player1ID, p1Rating, p2Rating, winnerID, reason, rated)
+	p1Change, p2Change := 0, 0
+	if reason != domain.ReasonTerminated {
+		r.users.applyResult(player1ID, p1Outcome)
+		p1Change = p1Outcome.NewRating - p1Rating
+		if player2ID != nil {
+			r.users.applyResult(*player2ID, p2Outcome)
+			p2Change = p2Outcome.NewRating - p2Rating
+		}
+	}
+
+	board := make([][]int, len(boardState))
+	for i := range boardState {
+		board[i] = append([]int(nil), boardState[i]...)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.games[gameID] = domain.GameResult{
+		GameID:          gameID,
+		Player1ID:       player1ID,
+		Player1Username: player1Username,
+		Player2ID:       copyID(player2ID),
+		Player2Username: player2Username,
+		WinnerID:        copyID(winnerID),
+		WinnerUsername:  winnerUsername,
+		Reason:          reason,
+		TotalMoves:      totalMoves,
+		DurationSeconds: durationSeconds,
+		CreatedAt:       createdAt,
+		FinishedAt:      finishedAt,
+		Rated:           rated,
+
+		Player1RatingChange: p1Change,
+		Player2RatingChange: p2Change,
+	}
+	r.boards[gameID] = board
+	r.chats[gameID] = append([]domain.ChatMessage(nil), chat...)
+	r.moves[gameID] = append([]domain.MoveRecord(nil), moves...)
+	r.ratings[gameID] = [2]int{p1Rating, p2Rating}
+	return nil
+}
+
+// GetGameByID returns nil, nil when the game does not exist
+func (r *GameRepo) GetGameByID(gameID string) (*domain.GameResult, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	result, ok := r.games[gameID]
+	if !ok {
+		return nil, nil
+	}
+	return &result, nil
+}
+
+// GetUserGameHistory returns all games for a user, most recently finished first
+func (r *GameRepo) GetUserGameHistory(userID int64) ([]domain.GameResult, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var games []domain.GameResult
+	for _, g := range r.games {
+		if g.Player1ID == userID || (g.Player2ID != nil && *g.Player2ID == userID) {
+			games = append(games, g)
+		}
+	}
+	sort.Slice(games, func(i, j int) bool {
+		return games[i].FinishedAt.After(games[j].FinishedAt)
+	})
+	return games, nil
+}
+
+// GetGameBoard returns the final board, or an empty board if the game is unknown
+func (r *GameRepo) GetGameBoard(gameID string) ([][]int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if board, ok := r.boards[gameID]; ok {
+		return board, nil
+	}
+	board := make([][]int, domain.Rows)
+	for i := range board {
+		board[i] = make([]int, domain.Columns)
+	}
+	return board, nil
+}
+
+// GetGameChat returns the saved chat transcript, or nil if there is none
+func (r *GameRepo) GetGameChat(gameID string) ([]domain.ChatMessage, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if len(r.chats[gameID]) == 0 {
+		return nil, nil
+	}
+	return append([]domain.ChatMessage(nil), r.chats[gameID]...), nil
+}
+
+// VoidGame reverses the rating changes a game applied and marks it voided.
+// Win/loss stats are left as they are.
+func (r *GameRepo) VoidGame(gameID string) error {
+	r.saveMu.Lock()
+	defer r.saveMu.Unlock()
+
+	r.mu.Lock()
+	result, ok := r.games[gameID]
+	if !ok || result.Voided {
+		r.mu.Unlock()
+		return fmt.Errorf("game %s not found or already voided", gameID)
+	}
+	result.Voided = true
+	r.games[gameID] = result
+	r.mu.Unlock()
+
+	r.users.adjustRating(result.Player1ID, -result.Player1RatingChange)
+	if result.Player2ID != nil {
+		r.users.adjustRating(*result.Player2ID, -result.Player2RatingChange)
+	}
+	return nil
+}
+
+// GetRatedGamesSince returns rated PvP games finished at or after since that
+// have not been voided, oldest first
+func (r *GameRepo) GetRatedGamesSince(since time.Time) ([]domain.GameResult, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var games []domain.GameResult
+	for _, g := range r.games {
+		if countsAsRatedPvP(g, since) {
+			games = append(games, g)
+		}
+	}
+	sort.Slice(games, func(i, j int) bool {
+		return games[i].FinishedAt.Before(games[j].FinishedAt)
+	})
+	return games, nil
+}
+
+// CountRatedOpponents counts the user's rated games per opponent since the given time
+func (r *GameRepo) CountRatedOpponents(userID int64, since time.Time) (map[int64]int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	counts := make(map[int64]int)
+	for _, g := range r.games {
+		if !countsAsRatedPvP(g, since) {
+			continue
+		}
+		switch userID {
+		case g.Player1ID:
+			counts[*g.Player2ID]++
+		case *g.Player2ID:
+			counts[g.Player1ID]++
+		}
+	}
+	return counts, nil
+}
+
+// DeleteBotGames removes the player's games against the bot. Win/loss stats
+// already applied are kept.
+func (r *GameRepo) DeleteBotGames(userID int64) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var deleted int64
+	for id, g := range r.games {
+		if g.Player1ID == userID && g.Player2ID == nil {
+			delete(r.games, id)
+			delete(r.boards, id)
+			delete(r.chats, id)
+			delete(r.moves, id)
+			delete(r.ratings, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+func countsAsRatedPvP(g domain.GameResult, since time.Time) bool {
+	return g.Rated && !g.Voided && g.Player2ID != nil && g.Reason != domain.ReasonTerminated && !g.FinishedAt.Before(since)
+}
+
+func copyID(id *int64) *int64 {
+	if id == nil {
+		return nil
+	}
+	v := *id
+	return &v
+}
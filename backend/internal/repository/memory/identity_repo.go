@@ -0,0 +1,84 @@
+package memory
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/iamasit07/connect4/backend/internal/domain"
+)
+
+// IdentityRepo is a thread-safe in-memory implementation of repository.IdentityRepository
+type IdentityRepo struct {
+	mu         sync.Mutex
+	identities []domain.UserIdentity
+	nextID     int64
+}
+
+func NewIdentityRepo() *IdentityRepo {
+	return &IdentityRepo{nextID: 1}
+}
+
+// LinkIdentity stores a new link, enforcing the same uniqueness rules as user_identities
+func (r *IdentityRepo) LinkIdentity(identity domain.UserIdentity) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.identities {
+		if existing.Provider != identity.Provider {
+			continue
+		}
+		if existing.Subject == identity.Subject {
+			return fmt.Errorf("failed to link identity: %s account already linked", identity.Provider)
+		}
+		if existing.UserID == identity.UserID {
+			return fmt.Errorf("failed to link identity: user %d already has a %s account", identity.UserID, identity.Provider)
+		}
+	}
+	identity.ID = r.nextID
+	r.nextID++
+	if identity.CreatedAt.IsZero() {
+		identity.CreatedAt = time.Now()
+	}
+	r.identities = append(r.identities, identity)
+	return nil
+}
+
+func (r *IdentityRepo) GetIdentity(provider, subject string) (*domain.UserIdentity, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, identity := range r.identities {
+		if identity.Provider == provider && identity.Subject == subject {
+			copied := identity
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+// ListIdentities returns the user's linked providers, oldest first
+func (r *IdentityRepo) ListIdentities(userID int64) ([]domain.UserIdentity, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []domain.UserIdentity
+	for _, identity := range r.identities {
+		if identity.UserID == userID {
+			out = append(out, identity)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out, nil
+}
+
+// UnlinkIdentity removes the user's link to provider, reporting whether one existed
+func (r *IdentityRepo) UnlinkIdentity(userID int64, provider string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, identity := range r.identities {
+		if identity.UserID == userID && identity.Provider == provider {
+			r.identities = append(r.identities[:i], r.identities[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
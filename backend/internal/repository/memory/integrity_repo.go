@@ -0,0 +1,134 @@
+package memory
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/iamasit07/connect4/backend/internal/domain"
+)
+
+// IntegrityRepo is a thread-safe in-memory implementation of repository.IntegrityRepository.
+// It shares a UserRepo to fill in usernames like the Postgres join does.
+type IntegrityRepo struct {
+	mu     sync.RWMutex
+	flags  []domain.IntegrityFlag
+	nextID int64
+	users  *UserRepo
+}
+
+func NewIntegrityRepo(users *UserRepo) *IntegrityRepo {
+	return &IntegrityRepo{nextID: 1, users: users}
+}
+
+// GetLatestFlag returns the newest flag of the given kind raised against userID (and opponentID)
+func (r *IntegrityRepo) GetLatestFlag(kind string, userID, opponentID int64) (*domain.IntegrityFlag, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for i := len(r.flags) - 1; i >= 0; i-- {
+		f := r.flags[i]
+		if f.Kind == kind && f.UserID == userID && f.OpponentID == opponentID {
+			return r.withNames(r.flags[i]), nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *IntegrityRepo) SaveFlag(flag *domain.IntegrityFlag) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	saved := *flag
+	saved.Signals = append([]string(nil), flag.Signals...)
+	saved.Evidence = copyEvidence(flag.Evidence)
+	if saved.ID == 0 {
+		saved.ID = r.nextID
+		r.nextID++
+		r.flags = append(r.flags, saved)
+		flag.ID = saved.ID
+		return nil
+	}
+	i := r.index(saved.ID)
+	if i < 0 {
+		return fmt.Errorf("integrity flag %d not found", saved.ID)
+	}
+	r.flags[i] = saved
+	return nil
+}
+
+func (r *IntegrityRepo) GetFlag(id int64) (*domain.IntegrityFlag, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if i := r.index(id); i >= 0 {
+		return r.withNames(r.flags[i]), nil
+	}
+	return nil, nil
+}
+
+// ListFlags returns up to limit flags with the given status ("" for all), highest score first
+func (r *IntegrityRepo) ListFlags(status string, limit int) ([]domain.IntegrityFlag, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	flags := make([]domain.IntegrityFlag, 0)
+	for _, f := range r.flags {
+		if status == "" || f.Status == status {
+			flags = append(flags, *r.withNames(f))
+		}
+	}
+	sort.SliceStable(flags, func(i, j int) bool {
+		if flags[i].Score != flags[j].Score {
+			return flags[i].Score > flags[j].Score
+		}
+		return flags[i].ID > flags[j].ID
+	})
+	if len(flags) > limit {
+		flags = flags[:limit]
+	}
+	return flags, nil
+}
+
+func (r *IntegrityRepo) ReviewFlag(id int64, status string, reviewerID int64, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	i := r.index(id)
+	if i < 0 {
+		return fmt.Errorf("integrity flag %d not found", id)
+	}
+	r.flags[i].Status = status
+	r.flags[i].ReviewedBy = &reviewerID
+	r.flags[i].UpdatedAt = at
+	return nil
+}
+
+// index returns the position of flag id, or -1 (caller must hold mu)
+func (r *IntegrityRepo) index(id int64) int {
+	for i := range r.flags {
+		if r.flags[i].ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func (r *IntegrityRepo) withNames(f domain.IntegrityFlag) *domain.IntegrityFlag {
+	f.Signals = append([]string(nil), f.Signals...)
+	f.Evidence = copyEvidence(f.Evidence)
+	if u, _ := r.users.GetUserByID(f.UserID); u != nil {
+		f.Username = u.Username
+	}
+	if u, _ := r.users.GetUserByID(f.OpponentID); u != nil {
+		f.OpponentUsername = u.Username
+	}
+	return &f
+}
+
+func copyEvidence(e *domain.EngineEvidence) *domain.EngineEvidence {
+	if e == nil {
+		return nil
+	}
+	c := *e
+	c.GameIDs = append([]string(nil), e.GameIDs...)
+	return &c
+}
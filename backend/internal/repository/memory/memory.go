@@ -0,0 +1,19 @@
+// Package memory provides thread-safe in-memory repositories. They back the
+// end-to-end tests and the `--storage=memory` dev mode; nothing is persisted.
+package memory
+
+import "github.com/iamasit07/connect4/backend/internal/repository"
+
+var (
+	_ repository.UserRepository         = (*UserRepo)(nil)
+	_ repository.GameRepository         = (*GameRepo)(nil)
+	_ repository.SessionRepository      = (*SessionRepo)(nil)
+	_ repository.ChatRepository         = (*ChatRepo)(nil)
+	_ repository.FriendRepository       = (*FriendRepo)(nil)
+	_ repository.AdminRepository        = (*AdminRepo)(nil)
+	_ repository.IntegrityRepository    = (*IntegrityRepo)(nil)
+	_ repository.AnalysisRepository     = (*AnalysisRepo)(nil)
+	_ repository.AccountTokenRepository = (*AccountTokenRepo)(nil)
+	_ repository.IdentityRepository     = (*IdentityRepo)(nil)
+	_ repository.APIKeyRepository       = (*APIKeyRepo)(nil)
+)
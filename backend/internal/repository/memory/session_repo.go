@@ -0,0 +1,249 @@
+package memory
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/iamasit07/connect4/backend/internal/domain"
+)
+
+// SessionRepo is a thread-safe in-memory implementation of repository.SessionRepository
+type SessionRepo struct {
+	mu            sync.RWMutex
+	sessions      map[string]*domain.UserSession
+	refreshTokens map[string]*domain.RefreshToken
+	nextID        int64
+}
+
+func NewSessionRepo() *SessionRepo {
+	return &SessionRepo{
+		sessions:      make(map[string]*domain.UserSession),
+		refreshTokens: make(map[string]*domain.RefreshToken),
+		nextID:        1,
+	}
+}
+
+func (r *SessionRepo) CreateSession(userID int64, sessionID, deviceInfo, ipAddress string, expiresAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.sessions[sessionID]; exists {
+		return fmt.Errorf("failed to create session: session %s already exists", sessionID)
+	}
+
+	now := time.Now()
+	r.sessions[sessionID] = &domain.UserSession{
+		ID:           r.nextID,
+		UserID:       userID,
+		SessionID:    sessionID,
+		DeviceInfo:   deviceInfo,
+		IPAddress:    ipAddress,
+		CreatedAt:    now,
+		ExpiresAt:    expiresAt,
+		LastActivity: now,
+		IsActive:     true,
+	}
+	r.nextID++
+	return nil
+}
+
+// GetSessionByID returns nil, nil when the session does not exist
+func (r *SessionRepo) GetSessionByID(sessionID string) (*domain.UserSession, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	s, ok := r.sessions[sessionID]
+	if !ok {
+		return nil, nil
+	}
+	copied := *s
+	return &copied, nil
+}
+
+// ListActiveSessions returns the user's active, unexpired sessions, most recently used first
+func (r *SessionRepo) ListActiveSessions(userID int64) ([]domain.UserSession, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	now := time.Now()
+	var sessions []domain.UserSession
+	for _, s := range r.sessions {
+		if s.UserID == userID && s.IsActive && s.ExpiresAt.After(now) {
+			sessions = append(sessions, *s)
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		if sessions[i].LastActivity.Equal(sessions[j].LastActivity) {
+			return sessions[i].ID > sessions[j].ID
+		}
+		return sessions[i].LastActivity.After(sessions[j].LastActivity)
+	})
+	return sessions, nil
+}
+
+func (r *SessionRepo) DeactivateAllUserSessions(userID int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, s := range r.sessions {
+		if s.UserID == userID {
+			s.IsActive = false
+		}
+	}
+	return nil
+}
+
+func (r *SessionRepo) DeactivateSession(sessionID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if s, ok := r.sessions[sessionID]; ok {
+		s.IsActive = false
+	}
+	return nil
+}
+
+func (r *SessionRepo) UpdateSessionActivity(sessionID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if s, ok := r.sessions[sessionID]; ok {
+		s.LastActivity = time.Now()
+	}
+	return nil
+}
+
+// GetUserSessionHistory returns the user's most recent sessions, newest first
+func (r *SessionRepo) GetUserSessionHistory(userID int64, limit int) ([]domain.UserSession, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var sessions []domain.UserSession
+	for _, s := range r.sessions {
+		if s.UserID == userID {
+			sessions = append(sessions, *s)
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		if sessions[i].CreatedAt.Equal(sessions[j].CreatedAt) {
+			return sessions[i].ID > sessions[j].ID
+		}
+		return sessions[i].CreatedAt.After(sessions[j].CreatedAt)
+	})
+	if limit > 0 && len(sessions) > limit {
+		sessions = sessions[:limit]
+	}
+	return sessions, nil
+}
+
+// GetUserIPAddresses returns the distinct non-empty IPs the user has logged in from
+func (r *SessionRepo) GetUserIPAddresses(userID int64) ([]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	seen := make(map[string]bool)
+	var ips []string
+	for _, s := range r.sessions {
+		if s.UserID == userID && s.IPAddress != "" && !seen[s.IPAddress] {
+			seen[s.IPAddress] = true
+			ips = append(ips, s.IPAddress)
+		}
+	}
+	sort.Strings(ips)
+	return ips, nil
+}
+
+// CleanupOldSessions deletes inactive sessions older than specified days
+func (r *SessionRepo) CleanupOldSessions(olderThanDays int) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cutoff := time.Now().AddDate(0, 0, -olderThanDays)
+	var removed int64
+	for id, s := range r.sessions {
+		if !s.IsActive && s.CreatedAt.Before(cutoff) {
+			delete(r.sessions, id)
+			removed++
+		}
+	}
+	return removed, nil
+}
+
+// --- Refresh Token Repository Methods ---
+
+func (r *SessionRepo) StoreRefreshToken(tokenID string, userID int64, sessionID string, expiresAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.refreshTokens[tokenID]; exists {
+		return fmt.Errorf("failed to store refresh token: token already exists")
+	}
+	r.refreshTokens[tokenID] = &domain.RefreshToken{
+		ID:        r.nextID,
+		TokenID:   tokenID,
+		UserID:    userID,
+		SessionID: sessionID,
+		ExpiresAt: expiresAt,
+		CreatedAt: time.Now(),
+	}
+	r.nextID++
+	return nil
+}
+
+// GetRefreshToken returns nil, nil when the token does not exist
+func (r *SessionRepo) GetRefreshToken(tokenID string) (*domain.RefreshToken, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	rt, ok := r.refreshTokens[tokenID]
+	if !ok {
+		return nil, nil
+	}
+	copied := *rt
+	return &copied, nil
+}
+
+func (r *SessionRepo) RevokeRefreshToken(tokenID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if rt, ok := r.refreshTokens[tokenID]; ok {
+		rt.Revoked = true
+	}
+	return nil
+}
+
+func (r *SessionRepo) RevokeAllUserRefreshTokens(userID int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, rt := range r.refreshTokens {
+		if rt.UserID == userID {
+			rt.Revoked = true
+		}
+	}
+	return nil
+}
+
+func (r *SessionRepo) RevokeSessionRefreshTokens(sessionID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, rt := range r.refreshTokens {
+		if rt.SessionID == sessionID {
+			rt.Revoked = true
+		}
+	}
+	return nil
+}
+
+// CleanupOldRefreshTokens deletes revoked tokens older than specified days and all expired tokens
+func (r *SessionRepo) CleanupOldRefreshTokens(olderThanDays int) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	cutoff := now.AddDate(0, 0, -olderThanDays)
+	var removed int64
+	for id, rt := range r.refreshTokens {
+		if (rt.Revoked && rt.CreatedAt.Before(cutoff)) || rt.ExpiresAt.Before(now) {
+			delete(r.refreshTokens, id)
+			removed++
+		}
+	}
+	return removed, nil
+}
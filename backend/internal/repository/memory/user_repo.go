@@ -0,0 +1,336 @@
+package memory
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/iamasit07/connect4/backend/internal/domain"
+)
+
+// UserRepo is a thread-safe in-memory implementation of repository.UserRepository.
+// It enforces the same uniqueness rules as the players table (username, email).
+type UserRepo struct {
+	mu         sync.RWMutex
+	users      map[int64]*domain.User
+	twoFactors map[int64]domain.TwoFactor
+	nextID     int64
+}
+
+func NewUserRepo() *UserRepo {
+	return &UserRepo{
+		users:      make(map[int64]*domain.User),
+		twoFactors: make(map[int64]domain.TwoFactor),
+		nextID:     1,
+	}
+}
+
+// CreateUser creates a new user with hashed password and optional email/avatar
+func (r *UserRepo) CreateUser(username, name, passwordHash string, email, avatarURL string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, u := range r.users {
+		if u.Username == username {
+			return 0, fmt.Errorf("failed to create user: username %q already exists", username)
+		}
+		if email != "" && u.Email.Valid && u.Email.String == email {
+			return 0, fmt.Errorf("failed to create user: email %q already exists", email)
+		}
+	}
+
+	user := &domain.User{
+		ID:           r.nextID,
+		Username:     username,
+		Name:         name,
+		AvatarURL:    avatarURL,
+		Email:        sql.NullString{String: email, Valid: email != ""},
+		PasswordHash: passwordHash,
+		Rating:       1000,
+		CreatedAt:    time.Now(),
+		Role:         domain.RoleUser,
+	}
+	r.users[user.ID] = user
+	r.nextID++
+	return user.ID, nil
+}
+
+// CreateGuest creates a guest player with no email or password
+func (r *UserRepo) CreateGuest(username string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, u := range r.users {
+		if u.Username == username {
+			return 0, fmt.Errorf("failed to create guest: username %q already exists", username)
+		}
+	}
+
+	user := &domain.User{
+		ID:        r.nextID,
+		Username:  username,
+		Name:      username,
+		Rating:    1000,
+		CreatedAt: time.Now(),
+		Role:      domain.RoleUser,
+		IsGuest:   true,
+	}
+	r.users[user.ID] = user
+	r.nextID++
+	return user.ID, nil
+}
+
+// UpgradeGuest turns a guest into a full account, keeping its ID (and so its games and stats)
+func (r *UserRepo) UpgradeGuest(userID int64, username, name, email, passwordHash string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	guest, ok := r.users[userID]
+	if !ok || !guest.IsGuest {
+		return fmt.Errorf("failed to upgrade guest: user %d is not a guest", userID)
+	}
+	for _, u := range r.users {
+		if u.ID == userID {
+			continue
+		}
+		if u.Username == username {
+			return fmt.Errorf("failed to upgrade guest: username %q already exists", username)
+		}
+		if u.Email.Valid && u.Email.String == email {
+			return fmt.Errorf("failed to upgrade guest: email %q already exists", email)
+		}
+	}
+
+	guest.Username = username
+	guest.Name = name
+	guest.Email = sql.NullString{String: email, Valid: true}
+	guest.PasswordHash = passwordHash
+	guest.IsGuest = false
+	return nil
+}
+
+// ListGuestIDs returns the guests created before the cutoff, oldest first
+func (r *UserRepo) ListGuestIDs(createdBefore time.Time) ([]int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var ids []int64
+	for _, u := range r.users {
+		if u.IsGuest && u.CreatedAt.Before(createdBefore) {
+			ids = append(ids, u.ID)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids, nil
+}
+
+// DeleteGuest deletes a guest, returning false when the user has been
+// upgraded or is already gone
+func (r *UserRepo) DeleteGuest(userID int64) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u, ok := r.users[userID]
+	if !ok || !u.IsGuest {
+		return false, nil
+	}
+	delete(r.users, userID)
+	delete(r.twoFactors, userID)
+	return true, nil
+}
+
+// find returns a copy of the first user matching the predicate (caller must hold mu)
+func (r *UserRepo) find(match func(*domain.User) bool) *domain.User {
+	for _, u := range r.users {
+		if match(u) {
+			copied := *u
+			return &copied
+		}
+	}
+	return nil
+}
+
+func (r *UserRepo) GetUserByID(userID int64) (*domain.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.find(func(u *domain.User) bool { return u.ID == userID }), nil
+}
+
+func (r *UserRepo) GetUserByUsername(username string) (*domain.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.find(func(u *domain.User) bool { return u.Username == username }), nil
+}
+
+func (r *UserRepo) GetUserByEmail(email string) (*domain.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.find(func(u *domain.User) bool { return u.Email.Valid && u.Email.String == email }), nil
+}
+
+// GetUserByIdentifier retrieves a user by username OR email
+func (r *UserRepo) GetUserByIdentifier(identifier string) (*domain.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.find(func(u *domain.User) bool {
+		return u.Username == identifier || (u.Email.Valid && u.Email.String == identifier)
+	}), nil
+}
+
+func (r *UserRepo) UpdateProfile(userID int64, name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if u, ok := r.users[userID]; ok {
+		u.Name = name
+	}
+	return nil
+}
+
+func (r *UserRepo) UpdateAvatar(userID int64, avatarURL string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if u, ok := r.users[userID]; ok {
+		u.AvatarURL = avatarURL
+	}
+	return nil
+}
+
+// GetLeaderboard ranks registered players by rating, then wins, then username (same order as Postgres)
+func (r *UserRepo) GetLeaderboard() ([]domain.PlayerStats, error) {
+	r.mu.RLock()
+	users := make([]domain.User, 0, len(r.users))
+	for _, u := range r.users {
+		if u.IsGuest {
+			continue
+		}
+		users = append(users, *u)
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(users, func(i, j int) bool {
+		if users[i].Rating != users[j].Rating {
+			return users[i].Rating > users[j].Rating
+		}
+		if users[i].GamesWon != users[j].GamesWon {
+			return users[i].GamesWon > users[j].GamesWon
+		}
+		return users[i].Username < users[j].Username
+	})
+
+	leaderboard := make([]domain.PlayerStats, 0, len(users))
+	for i, u := range users {
+		leaderboard = append(leaderboard, domain.PlayerStats{
+			Rank:     i + 1,
+			Username: u.Username,
+			Rating:   u.Rating,
+			Wins:     u.GamesWon,
+			Losses:   u.GamesPlayed - u.GamesWon - u.GamesDrawn,
+		})
+	}
+	return leaderboard, nil
+}
+
+func (r *UserRepo) SetEmailVerified(userID int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if u, ok := r.users[userID]; ok {
+		u.IsVerified = true
+	}
+	return nil
+}
+
+func (r *UserRepo) UpdatePassword(userID int64, passwordHash string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if u, ok := r.users[userID]; ok {
+		u.PasswordHash = passwordHash
+	}
+	return nil
+}
+
+func (r *UserRepo) GetTwoFactor(userID int64) (*domain.TwoFactor, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if _, ok := r.users[userID]; !ok {
+		return nil, nil
+	}
+	tf := r.twoFactors[userID]
+	tf.RecoveryCodes = append([]string(nil), tf.RecoveryCodes...)
+	return &tf, nil
+}
+
+func (r *UserRepo) SaveTwoFactor(userID int64, tf domain.TwoFactor) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	u, ok := r.users[userID]
+	if !ok {
+		return fmt.Errorf("failed to save two-factor state: user %d not found", userID)
+	}
+	tf.RecoveryCodes = append([]string(nil), tf.RecoveryCodes...)
+	r.twoFactors[userID] = tf
+	u.TOTPEnabled = tf.Enabled
+	return nil
+}
+
+func (r *UserRepo) SetUserRole(userID int64, role string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if u, ok := r.users[userID]; ok {
+		u.Role = role
+	}
+	return nil
+}
+
+// SetUserBan sets the ban flag and suspension end (nil clears the suspension)
+func (r *UserRepo) SetUserBan(userID int64, banned bool, suspendedUntil *time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if u, ok := r.users[userID]; ok {
+		u.Banned = banned
+		u.SuspendedUntil = sql.NullTime{}
+		if suspendedUntil != nil {
+			u.SuspendedUntil = sql.NullTime{Time: *suspendedUntil, Valid: true}
+		}
+	}
+	return nil
+}
+
+// rating returns a player's current rating (used by GameRepo when scoring games)
+func (r *UserRepo) rating(userID int64) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	u, ok := r.users[userID]
+	if !ok {
+		return 0, fmt.Errorf("failed to get player rating: user %d not found", userID)
+	}
+	return u.Rating, nil
+}
+
+// applyResult updates a player's stats after a finished game
+func (r *UserRepo) applyResult(userID int64, outcome domain.PlayerOutcome) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	u, ok := r.users[userID]
+	if !ok {
+		return
+	}
+	u.GamesPlayed++
+	switch outcome.Result {
+	case "won":
+		u.GamesWon++
+	case "draw":
+		u.GamesDrawn++
+	}
+	u.Rating = outcome.NewRating
+}
+
+// adjustRating adds delta to a player's rating (used by GameRepo when voiding games)
+func (r *UserRepo) adjustRating(userID int64, delta int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if u, ok := r.users[userID]; ok {
+		u.Rating += delta
+	}
+}
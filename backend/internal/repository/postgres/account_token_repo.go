@@ -0,0 +1,65 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/iamasit07/connect4/backend/internal/domain"
+)
+
+type AccountTokenRepo struct {
+	DB *sql.DB
+}
+
+func NewAccountTokenRepo(db *sql.DB) *AccountTokenRepo {
+	return &AccountTokenRepo{DB: db}
+}
+
+// CreateAccountToken stores a new token and drops the user's unused ones for the same purpose
+func (r *AccountTokenRepo) CreateAccountToken(token domain.AccountToken) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`DELETE FROM account_tokens WHERE user_id = $1 AND purpose = $2 AND used_at IS NULL;`, token.UserID, token.Purpose)
+	if err != nil {
+		return fmt.Errorf("failed to drop old account tokens: %v", err)
+	}
+
+	query := `
+	INSERT INTO account_tokens (user_id, purpose, token_hash, expires_at, created_at)
+	VALUES ($1, $2, $3, $4, $5);
+	`
+	_, err = tx.Exec(query, token.UserID, token.Purpose, token.TokenHash, token.ExpiresAt, token.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create account token: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %v", err)
+	}
+	return nil
+}
+
+// ConsumeAccountToken marks a live token used and returns it, or nil when unknown, used or expired
+func (r *AccountTokenRepo) ConsumeAccountToken(purpose, tokenHash string, now time.Time) (*domain.AccountToken, error) {
+	query := `
+	UPDATE account_tokens
+	SET used_at = $3
+	WHERE purpose = $1 AND token_hash = $2 AND used_at IS NULL AND expires_at > $3
+	RETURNING id, user_id, purpose, token_hash, expires_at, used_at, created_at;
+	`
+	var token domain.AccountToken
+	err := r.DB.QueryRow(query, purpose, tokenHash, now).Scan(
+		&token.ID, &token.UserID, &token.Purpose, &token.TokenHash, &token.ExpiresAt, &token.UsedAt, &token.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to consume account token: %v", err)
+	}
+	return &token, nil
+}
@@ -0,0 +1,60 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/iamasit07/connect4/backend/internal/domain"
+)
+
+type AdminRepo struct {
+	DB *sql.DB
+}
+
+func NewAdminRepo(db *sql.DB) *AdminRepo {
+	return &AdminRepo{DB: db}
+}
+
+// LogAdminAction appends an entry to the moderation audit log
+func (r *AdminRepo) LogAdminAction(action domain.AdminAction) error {
+	query := `
+	INSERT INTO admin_actions (admin_id, admin_username, action, target_user_id, target_game_id, reason, details, created_at)
+	VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6, $7, $8);
+	`
+	_, err := r.DB.Exec(query, action.AdminID, action.AdminUsername, action.Action, action.TargetUserID, action.TargetGameID, action.Reason, action.Details, action.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to log admin action: %v", err)
+	}
+	return nil
+}
+
+// ListAdminActions returns the most recent audit log entries, newest first
+func (r *AdminRepo) ListAdminActions(limit int) ([]domain.AdminAction, error) {
+	query := `
+	SELECT id, admin_id, admin_username, action, target_user_id, COALESCE(target_game_id, ''),
+	       COALESCE(reason, ''), COALESCE(details, ''), created_at
+	FROM admin_actions
+	ORDER BY created_at DESC, id DESC
+	LIMIT $1;
+	`
+	rows, err := r.DB.Query(query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query admin actions: %v", err)
+	}
+	defer rows.Close()
+
+	actions := make([]domain.AdminAction, 0)
+	for rows.Next() {
+		var a domain.AdminAction
+		var targetUserID sql.NullInt64
+		if err := rows.Scan(&a.ID, &a.AdminID, &a.AdminUsername, &a.Action, &targetUserID, &a.TargetGameID, &a.Reason, &a.Details, &a.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan admin action: %v", err)
+		}
+		if targetUserID.Valid {
+			id := targetUserID.Int64
+			a.TargetUserID = &id
+		}
+		actions = append(actions, a)
+	}
+	return actions, nil
+}
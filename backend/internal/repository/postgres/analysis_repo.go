@@ -0,0 +1,127 @@
+package postgres
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/iamasit07/connect4/backend/internal/domain"
+)
+
+type AnalysisRepo struct {
+	DB *sql.DB
+}
+
+func NewAnalysisRepo(db *sql.DB) *AnalysisRepo {
+	return &AnalysisRepo{DB: db}
+}
+
+// GetUnanalyzedGames returns up to limit games with a move list that haven't been analysed, oldest first.
+// Games saved before ratings were recorded fall back to the current rating less the game's change.
+func (r *AnalysisRepo) GetUnanalyzedGames(limit int) ([]domain.RecordedGame, error) {
+	query := `
+	SELECT g.game_id, COALESCE(g.player1_id, 0), g.player2_id,
+	       COALESCE(g.player1_rating, p1.rating - g.player1_rating_change, 0),
+	       COALESCE(g.player2_rating, p2.rating - g.player2_rating_change, 0),
+	       g.finished_at, g.move_history
+	FROM game g
+	LEFT JOIN players p1 ON p1.id = g.player1_id
+	LEFT JOIN players p2 ON p2.id = g.player2_id
+	WHERE g.analyzed_at IS NULL AND g.move_history IS NOT NULL
+	ORDER BY g.finished_at ASC
+	LIMIT $1;
+	`
+	rows, err := r.DB.Query(query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query unanalyzed games: %v", err)
+	}
+	defer rows.Close()
+
+	var games []domain.RecordedGame
+	for rows.Next() {
+		var g domain.RecordedGame
+		var player2ID sql.NullInt64
+		var movesJSON []byte
+		if err := rows.Scan(&g.GameID, &g.Player1ID, &player2ID, &g.Player1Rating, &g.Player2Rating, &g.FinishedAt, &movesJSON); err != nil {
+			return nil, fmt.Errorf("failed to scan game row: %v", err)
+		}
+		if player2ID.Valid {
+			id := player2ID.Int64
+			g.Player2ID = &id
+		}
+		if err := json.Unmarshal(movesJSON, &g.Moves); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal move history for %s: %v", g.GameID, err)
+		}
+		games = append(games, g)
+	}
+	return games, rows.Err()
+}
+
+// SaveGameAnalysis stores the per-player results and marks the game analysed in one transaction
+func (r *AnalysisRepo) SaveGameAnalysis(gameID string, analyses []domain.PlayerGameAnalysis) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+	INSERT INTO game_analysis (game_id, user_id, rating, moves, engine_matches, total_loss, think_mean_ms, think_stddev_ms, finished_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	ON CONFLICT (game_id, user_id) DO NOTHING;
+	`
+	for _, a := range analyses {
+		if _, err := tx.Exec(query, gameID, a.UserID, a.Rating, a.Moves, a.EngineMatches, a.TotalLoss, a.ThinkMeanMs, a.ThinkStdDevMs, a.FinishedAt); err != nil {
+			return fmt.Errorf("failed to insert game analysis: %v", err)
+		}
+	}
+	if _, err := tx.Exec(`UPDATE game SET analyzed_at = NOW() WHERE game_id = $1::text;`, gameID); err != nil {
+		return fmt.Errorf("failed to mark game analyzed: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %v", err)
+	}
+	return nil
+}
+
+// GetPlayerAnalyses returns the user's most recent analyses, newest game first
+func (r *AnalysisRepo) GetPlayerAnalyses(userID int64, limit int) ([]domain.PlayerGameAnalysis, error) {
+	query := `
+	SELECT game_id, user_id, rating, moves, engine_matches, total_loss, think_mean_ms, think_stddev_ms, finished_at
+	FROM game_analysis
+	WHERE user_id = $1
+	ORDER BY finished_at DESC
+	LIMIT $2;
+	`
+	rows, err := r.DB.Query(query, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query player analyses: %v", err)
+	}
+	defer rows.Close()
+
+	var analyses []domain.PlayerGameAnalysis
+	for rows.Next() {
+		var a domain.PlayerGameAnalysis
+		if err := rows.Scan(&a.GameID, &a.UserID, &a.Rating, &a.Moves, &a.EngineMatches, &a.TotalLoss, &a.ThinkMeanMs, &a.ThinkStdDevMs, &a.FinishedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan game analysis: %v", err)
+		}
+		analyses = append(analyses, a)
+	}
+	return analyses, rows.Err()
+}
+
+// GetBandStats totals the analyses of players rated minRating..maxRating, excluding one user
+func (r *AnalysisRepo) GetBandStats(minRating, maxRating int, excludeUserID int64) (domain.BandStats, error) {
+	query := `
+	SELECT COUNT(DISTINCT user_id), COALESCE(SUM(moves), 0), COALESCE(SUM(engine_matches), 0), COALESCE(SUM(total_loss), 0)
+	FROM game_analysis
+	WHERE rating BETWEEN $1 AND $2 AND user_id <> $3;
+	`
+	var stats domain.BandStats
+	err := r.DB.QueryRow(query, minRating, maxRating, excludeUserID).Scan(&stats.Players, &stats.Moves, &stats.EngineMatches, &stats.TotalLoss)
+	if err != nil {
+		return stats, fmt.Errorf("failed to get rating band stats: %v", err)
+	}
+	return stats, nil
+}
@@ -0,0 +1,101 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/iamasit07/connect4/backend/internal/domain"
+)
+
+type APIKeyRepo struct {
+	DB *sql.DB
+}
+
+func NewAPIKeyRepo(db *sql.DB) *APIKeyRepo {
+	return &APIKeyRepo{DB: db}
+}
+
+const apiKeyColumns = `id, user_id, name, prefix, key_hash, scopes, expires_at, last_used_at, created_at`
+
+func (r *APIKeyRepo) CreateAPIKey(key domain.APIKey) (int64, error) {
+	query := `
+	INSERT INTO api_keys (user_id, name, prefix, key_hash, scopes, expires_at, created_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7)
+	RETURNING id;
+	`
+	var id int64
+	err := r.DB.QueryRow(query, key.UserID, key.Name, key.Prefix, key.KeyHash, strings.Join(key.Scopes, ","), key.ExpiresAt, key.CreatedAt).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create api key: %v", err)
+	}
+	return id, nil
+}
+
+func (r *APIKeyRepo) GetAPIKeyByHash(keyHash string) (*domain.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE key_hash = $1;`
+	key, err := scanAPIKey(r.DB.QueryRow(query, keyHash))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get api key: %v", err)
+	}
+	return key, nil
+}
+
+// ListAPIKeys returns the user's keys, newest first
+func (r *APIKeyRepo) ListAPIKeys(userID int64) ([]domain.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE user_id = $1 ORDER BY id DESC;`
+	rows, err := r.DB.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list api keys: %v", err)
+	}
+	defer rows.Close()
+
+	var keys []domain.APIKey
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan api key: %v", err)
+		}
+		keys = append(keys, *key)
+	}
+	return keys, rows.Err()
+}
+
+// DeleteAPIKey removes one of the user's keys, reporting whether it existed
+func (r *APIKeyRepo) DeleteAPIKey(userID, keyID int64) (bool, error) {
+	result, err := r.DB.Exec(`DELETE FROM api_keys WHERE id = $1 AND user_id = $2;`, keyID, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete api key: %v", err)
+	}
+	n, _ := result.RowsAffected()
+	return n > 0, nil
+}
+
+func (r *APIKeyRepo) TouchAPIKey(keyID int64, usedAt time.Time) error {
+	_, err := r.DB.Exec(`UPDATE api_keys SET last_used_at = $2 WHERE id = $1;`, keyID, usedAt)
+	if err != nil {
+		return fmt.Errorf("failed to update api key last use: %v", err)
+	}
+	return nil
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanAPIKey(row rowScanner) (*domain.APIKey, error) {
+	var key domain.APIKey
+	var scopes string
+	err := row.Scan(&key.ID, &key.UserID, &key.Name, &key.Prefix, &key.KeyHash, &scopes, &key.ExpiresAt, &key.LastUsedAt, &key.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if scopes != "" {
+		key.Scopes = strings.Split(scopes, ",")
+	}
+	return &key, nil
+}
@@ -0,0 +1,31 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/iamasit07/connect4/backend/internal/domain"
+)
+
+type ChatRepo struct {
+	DB *sql.DB
+}
+
+func NewChatRepo(db *sql.DB) *ChatRepo {
+	return &ChatRepo{DB: db}
+}
+
+// SaveChatReport stores a chat report. Repeat reports of the same message by
+// the same user are ignored.
+func (r *ChatRepo) SaveChatReport(report domain.ChatReport) error {
+	query := `
+	INSERT INTO chat_reports (game_id, message_id, reporter_id, reported_user_id, message_text, reason, created_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7)
+	ON CONFLICT (game_id, message_id, reporter_id) DO NOTHING;
+	`
+	_, err := r.DB.Exec(query, report.GameID, report.MessageID, report.ReporterID, report.ReportedUserID, report.MessageText, report.Reason, report.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save chat report: %v", err)
+	}
+	return nil
+}
@@ -3,17 +3,32 @@ package postgres
 import (
 	"database/sql"
 	"fmt"
-	"log"
-	"os"
 	"time"
 
+	"github.com/XSAM/otelsql"
 	_ "github.com/jackc/pgx/v5/stdlib"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+
+	"github.com/iamasit07/connect4/backend/internal/logging"
 )
 
 var DB *sql.DB
 
+// Open opens a connection pool that records a span for every query. Repository
+// methods don't take a context, so each query starts its own trace.
+func Open(connStr string) (*sql.DB, error) {
+	return otelsql.Open("pgx", connStr,
+		otelsql.WithAttributes(semconv.DBSystemNamePostgreSQL),
+		otelsql.WithSpanOptions(otelsql.SpanOptions{
+			OmitConnResetSession: true,
+			OmitConnectorConnect: true,
+			OmitRows:             true,
+		}),
+	)
+}
+
 func InitDB(connStr string, maxOpenConns, maxIdleConns, connMaxLifetimeMin int) error {
-	db, err := sql.Open("pgx", connStr)
+	db, err := Open(connStr)
 	if err != nil {
 		return err
 	}
@@ -22,9 +37,7 @@ func InitDB(connStr string, maxOpenConns, maxIdleConns, connMaxLifetimeMin int)
 		return fmt.Errorf("unable to connect to database: %v", err)
 	}
 
-	schema, _ := os.ReadFile("db/schema.sql")
-	_, err = db.Exec(string(schema))
-	if err != nil {
+	if err := RunMigrations(db); err != nil {
 		return fmt.Errorf("failed to initialize database schema: %v", err)
 	}
 
@@ -33,7 +46,7 @@ func InitDB(connStr string, maxOpenConns, maxIdleConns, connMaxLifetimeMin int)
 	db.SetConnMaxLifetime(time.Duration(connMaxLifetimeMin) * time.Minute)
 
 	DB = db
-	log.Println("Database connected successfully")
+	logging.For("postgres").Info("Database connected successfully")
 	return nil
 }
 
@@ -0,0 +1,132 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/iamasit07/connect4/backend/internal/domain"
+)
+
+type FriendRepo struct {
+	DB *sql.DB
+}
+
+func NewFriendRepo(db *sql.DB) *FriendRepo {
+	return &FriendRepo{DB: db}
+}
+
+// GetFriendship returns the directed row from userID to friendID, or nil if there is none
+func (r *FriendRepo) GetFriendship(userID, friendID int64) (*domain.Friendship, error) {
+	query := `
+	SELECT user_id, friend_id, status, block_spectating, created_at, updated_at
+	FROM friendships
+	WHERE user_id = $1 AND friend_id = $2;
+	`
+
+	var f domain.Friendship
+	err := r.DB.QueryRow(query, userID, friendID).Scan(&f.UserID, &f.FriendID, &f.Status, &f.BlockSpectating, &f.CreatedAt, &f.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get friendship: %v", err)
+	}
+	return &f, nil
+}
+
+// SetFriendship inserts the directed row or updates its status
+func (r *FriendRepo) SetFriendship(userID, friendID int64, status string) error {
+	query := `
+	INSERT INTO friendships (user_id, friend_id, status)
+	VALUES ($1, $2, $3)
+	ON CONFLICT (user_id, friend_id) DO UPDATE SET
+		status = EXCLUDED.status,
+		block_spectating = FALSE,
+		updated_at = CURRENT_TIMESTAMP;
+	`
+	if _, err := r.DB.Exec(query, userID, friendID, status); err != nil {
+		return fmt.Errorf("failed to set friendship: %v", err)
+	}
+	return nil
+}
+
+// BlockUser inserts or updates a block from userID on targetID
+func (r *FriendRepo) BlockUser(userID, targetID int64, blockSpectating bool) error {
+	query := `
+	INSERT INTO friendships (user_id, friend_id, status, block_spectating)
+	VALUES ($1, $2, 'blocked', $3)
+	ON CONFLICT (user_id, friend_id) DO UPDATE SET
+		status = 'blocked',
+		block_spectating = EXCLUDED.block_spectating,
+		updated_at = CURRENT_TIMESTAMP;
+	`
+	if _, err := r.DB.Exec(query, userID, targetID, blockSpectating); err != nil {
+		return fmt.Errorf("failed to block user: %v", err)
+	}
+	return nil
+}
+
+// GetBlocks returns every block placed by or on the user
+func (r *FriendRepo) GetBlocks(userID int64) ([]domain.Friendship, error) {
+	query := `
+	SELECT user_id, friend_id, status, block_spectating, created_at, updated_at
+	FROM friendships
+	WHERE status = 'blocked' AND (user_id = $1 OR friend_id = $1);
+	`
+
+	rows, err := r.DB.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query blocks: %v", err)
+	}
+	defer rows.Close()
+
+	var blocks []domain.Friendship
+	for rows.Next() {
+		var f domain.Friendship
+		if err := rows.Scan(&f.UserID, &f.FriendID, &f.Status, &f.BlockSpectating, &f.CreatedAt, &f.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan block row: %v", err)
+		}
+		blocks = append(blocks, f)
+	}
+	return blocks, nil
+}
+
+func (r *FriendRepo) DeleteFriendship(userID, friendID int64) error {
+	query := `DELETE FROM friendships WHERE user_id = $1 AND friend_id = $2;`
+	if _, err := r.DB.Exec(query, userID, friendID); err != nil {
+		return fmt.Errorf("failed to delete friendship: %v", err)
+	}
+	return nil
+}
+
+// ListFriends returns the user's friends, pending requests in both directions
+// and the users they have blocked. Blocks placed on the user are not listed.
+func (r *FriendRepo) ListFriends(userID int64) ([]domain.Friend, error) {
+	query := `
+	SELECT p.id, p.username, COALESCE(p.avatar_url, ''), f.status, f.block_spectating, f.user_id = $1, f.updated_at
+	FROM friendships f
+	JOIN players p ON p.id = CASE WHEN f.user_id = $1 THEN f.friend_id ELSE f.user_id END
+	WHERE (f.user_id = $1 OR f.friend_id = $1)
+	  AND NOT (f.status = 'blocked' AND f.friend_id = $1)
+	ORDER BY p.username;
+	`
+
+	rows, err := r.DB.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query friends: %v", err)
+	}
+	defer rows.Close()
+
+	var friends []domain.Friend
+	for rows.Next() {
+		var f domain.Friend
+		var status string
+		var outgoing bool
+		if err := rows.Scan(&f.UserID, &f.Username, &f.AvatarURL, &status, &f.BlockSpectating, &outgoing, &f.Since); err != nil {
+			return nil, fmt.Errorf("failed to scan friend row: %v", err)
+		}
+		f.Relation = domain.FriendRelation(status, outgoing)
+		friends = append(friends, f)
+	}
+	return friends, nil
+}
@@ -17,24 +17,10 @@ func NewGameRepo(db *sql.DB) *GameRepo {
 	return &GameRepo{DB: db}
 }
 
-// GameResult represents the result of a finished game
-type GameResult struct {
-	GameID          string
-	Player1ID       int64
-	Player1Username string
-	Player2ID       *int64
-	Player2Username string
-	WinnerID        *int64
-	WinnerUsername  string
-	Reason          string
-	TotalMoves      int
-	DurationSeconds int
-	CreatedAt       time.Time
-	FinishedAt      time.Time
-}
-
-// SaveGame saves a finished game and updates player stats transactionally
-func (r *GameRepo) SaveGame(gameID string, player1ID int64, player1Username string, player2ID *int64, player2Username string, winnerID *int64, winnerUsername string, reason string, totalMoves, durationSeconds int, createdAt, finishedAt time.Time, boardState [][]int) error {
+// SaveGame saves a finished game and updates player stats transactionally.
+// Unrated (casual) games still count towards win/loss stats but leave ratings untouched;
+// games terminated by a moderator touch neither.
+func (r *GameRepo) SaveGame(gameID string, player1ID int64, player1Username string, player2ID *int64, player2Username string, winnerID *int64, winnerUsername string, reason string, totalMoves, durationSeconds int, createdAt, finishedAt time.Time, boardState [][]int, rated bool, chat []domain.ChatMessage, moves []domain.MoveRecord) error {
 	tx, err := r.DB.Begin()
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %v", err)
@@ -42,8 +28,6 @@ func (r *GameRepo) SaveGame(gameID string, player1ID int64, player1Username stri
 
 	defer tx.Rollback()
 
-	isDraw := (reason == "draw")
-
 	// Fetch current ratings for Elo calculation
 	p1Rating, err := r.getPlayerRatingTx(tx, player1ID)
 	if err != nil {
@@ -59,35 +43,23 @@ func (r *GameRepo) SaveGame(gameID string, player1ID int64, player1Username stri
 		}
 	}
 
-	// Calculate new Elo ratings
-	var p1NewRating, p2NewRating int
-	var p1Result, p2Result string
-	if isDraw {
-		p1Result = "draw"
-		p2Result = "draw"
-		p1NewRating = domain.CalculateElo(p1Rating, p2Rating, 0.5)
-		p2NewRating = domain.CalculateElo(p2Rating, p1Rating, 0.5)
-	} else if winnerID != nil && *winnerID == player1ID {
-		p1Result = "won"
-		p2Result = "lost"
-		p1NewRating = domain.CalculateElo(p1Rating, p2Rating, 1.0)
-		p2NewRating = domain.CalculateElo(p2Rating, p1Rating, 0.0)
-	} else {
-		p1Result = "lost"
-		p2Result = "won"
-		p1NewRating = domain.CalculateElo(p1Rating, p2Rating, 0.0)
-		p2NewRating = domain.CalculateElo(p2Rating, p1Rating, 1.0)
-	}
-
-	// Update player1 stats with Elo-calculated rating
-	if err := r.updatePlayerStatsTx(tx, player1ID, p1Result, p1NewRating); err != nil {
-		return err
-	}
+	// Calculate results and new Elo ratings (unchanged for unrated games)
+	p1Outcome, p2Outcome := domain.ScoreGame(player1ID, p1Rating, p2Rating, winnerID, reason, rated)
+	p1Change, p2Change := 0, 0
 
-	if player2ID != nil {
-		if err := r.updatePlayerStatsTx(tx, *player2ID, p2Result, p2NewRating); err != nil {
+	if reason != domain.ReasonTerminated {
+		// Update player1 stats with Elo-calculated rating
+		if err := r.updatePlayerStatsTx(tx, player1ID, p1Outcome.Result, p1Outcome.NewRating); err != nil {
 			return err
 		}
+		p1Change = p1Outcome.NewRating - p1Rating
+
+		if player2ID != nil {
+			if err := r.updatePlayerStatsTx(tx, *player2ID, p2Outcome.Result, p2Outcome.NewRating); err != nil {
+				return err
+			}
+			p2Change = p2Outcome.NewRating - p2Rating
+		}
 	}
 
 	boardJSON, err := json.Marshal(boardState)
@@ -95,9 +67,34 @@ func (r *GameRepo) SaveGame(gameID string, player1ID int64, player1Username stri
 		return fmt.Errorf("failed to marshal board state: %v", err)
 	}
 
+	var chatJSON *string
+	if len(chat) > 0 {
+		data, err := json.Marshal(chat)
+		if err != nil {
+			return fmt.Errorf("failed to marshal chat transcript: %v", err)
+		}
+		transcript := string(data)
+		chatJSON = &transcript
+	}
+
+	var p2PreRating *int
+	if player2ID != nil {
+		p2PreRating = &p2Rating
+	}
+
+	var movesJSON *string
+	if len(moves) > 0 {
+		data, err := json.Marshal(moves)
+		if err != nil {
+			return fmt.Errorf("failed to marshal move history: %v", err)
+		}
+		history := string(data)
+		movesJSON = &history
+	}
+
 	query := `
-	INSERT INTO game (game_id, player1_id, player1_username, player2_id, player2_username, winner_id, winner_username, reason, total_moves, duration_seconds, created_at, finished_at, board_state)
-	VALUES (CAST($1 as TEXT), $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+	INSERT INTO game (game_id, player1_id, player1_username, player2_id, player2_username, winner_id, winner_username, reason, total_moves, duration_seconds, created_at, finished_at, board_state, rated, chat_transcript, player1_rating_change, player2_rating_change, move_history, player1_rating, player2_rating)
+	VALUES (CAST($1 as TEXT), $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20)
 	ON CONFLICT (game_id) DO UPDATE SET
 		winner_id = EXCLUDED.winner_id,
 		winner_username = EXCLUDED.winner_username,
@@ -105,10 +102,17 @@ func (r *GameRepo) SaveGame(gameID string, player1ID int64, player1Username stri
 		total_moves = EXCLUDED.total_moves,
 		duration_seconds = EXCLUDED.duration_seconds,
 		finished_at = EXCLUDED.finished_at,
-		board_state = EXCLUDED.board_state;
+		board_state = EXCLUDED.board_state,
+		rated = EXCLUDED.rated,
+		chat_transcript = EXCLUDED.chat_transcript,
+		player1_rating_change = EXCLUDED.player1_rating_change,
+		player2_rating_change = EXCLUDED.player2_rating_change,
+		move_history = EXCLUDED.move_history,
+		player1_rating = EXCLUDED.player1_rating,
+		player2_rating = EXCLUDED.player2_rating;
 	`
 
-	_, err = tx.Exec(query, gameID, player1ID, player1Username, player2ID, player2Username, winnerID, winnerUsername, reason, totalMoves, durationSeconds, createdAt, finishedAt, string(boardJSON))
+	_, err = tx.Exec(query, gameID, player1ID, player1Username, player2ID, player2Username, winnerID, winnerUsername, reason, totalMoves, durationSeconds, createdAt, finishedAt, string(boardJSON), rated, chatJSON, p1Change, p2Change, movesJSON, p1Rating, p2PreRating)
 	if err != nil {
 		return fmt.Errorf("failed to upsert game record: %v", err)
 	}
@@ -154,16 +158,17 @@ func (r *GameRepo) getPlayerRatingTx(tx *sql.Tx, userID int64) (int, error) {
 }
 
 // GetGameByID retrieves game details from the database by gameID
-func (r *GameRepo) GetGameByID(gameID string) (*GameResult, error) {
+func (r *GameRepo) GetGameByID(gameID string) (*domain.GameResult, error) {
 	query := `
-	SELECT game_id, player1_id, player1_username, player2_id, player2_username, 
+	SELECT game_id, COALESCE(player1_id, 0), player1_username, player2_id, player2_username, 
 	       winner_id, winner_username, reason, total_moves, duration_seconds, 
-	       created_at, finished_at
+	       created_at, finished_at, rated,
+	       player1_rating_change, player2_rating_change, voided
 	FROM game 
 	WHERE game_id = $1::text;
 	`
 
-	var result GameResult
+	var result domain.GameResult
 	var player2ID, winnerID sql.NullInt64
 	var winnerUsername sql.NullString
 
@@ -180,6 +185,10 @@ func (r *GameRepo) GetGameByID(gameID string) (*GameResult, error) {
 		&result.DurationSeconds,
 		&result.CreatedAt,
 		&result.FinishedAt,
+		&result.Rated,
+		&result.Player1RatingChange,
+		&result.Player2RatingChange,
+		&result.Voided,
 	)
 
 	if err == sql.ErrNoRows {
@@ -205,11 +214,12 @@ func (r *GameRepo) GetGameByID(gameID string) (*GameResult, error) {
 }
 
 // GetUserGameHistory retrieves all games for a user (both as player1 and player2)
-func (r *GameRepo) GetUserGameHistory(userID int64) ([]GameResult, error) {
+func (r *GameRepo) GetUserGameHistory(userID int64) ([]domain.GameResult, error) {
 	query := `
-	SELECT game_id, player1_id, player1_username, player2_id, player2_username, 
+	SELECT game_id, COALESCE(player1_id, 0), player1_username, player2_id, player2_username, 
 	       winner_id, winner_username, reason, total_moves, duration_seconds, 
-	       created_at, finished_at
+	       created_at, finished_at, rated,
+	       player1_rating_change, player2_rating_change, voided
 	FROM game 
 	WHERE player1_id = $1 OR player2_id = $1
 	ORDER BY finished_at DESC;
@@ -221,9 +231,9 @@ func (r *GameRepo) GetUserGameHistory(userID int64) ([]GameResult, error) {
 	}
 	defer rows.Close()
 
-	var games []GameResult
+	var games []domain.GameResult
 	for rows.Next() {
-		var result GameResult
+		var result domain.GameResult
 		var player2ID, winnerID sql.NullInt64
 		var winnerUsername sql.NullString
 
@@ -240,6 +250,10 @@ func (r *GameRepo) GetUserGameHistory(userID int64) ([]GameResult, error) {
 			&result.DurationSeconds,
 			&result.CreatedAt,
 			&result.FinishedAt,
+			&result.Rated,
+			&result.Player1RatingChange,
+			&result.Player2RatingChange,
+			&result.Voided,
 		)
 
 		if err != nil {
@@ -263,6 +277,140 @@ func (r *GameRepo) GetUserGameHistory(userID int64) ([]GameResult, error) {
 	return games, nil
 }
 
+// VoidGame reverses the rating changes a game applied and marks it voided.
+// Win/loss stats are left as they are.
+func (r *GameRepo) VoidGame(gameID string) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	var player1ID int64
+	var player2ID sql.NullInt64
+	var p1Change, p2Change int
+	err = tx.QueryRow(`
+	SELECT COALESCE(player1_id, 0), player2_id, player1_rating_change, player2_rating_change
+	FROM game
+	WHERE game_id = $1::text AND voided = FALSE
+	FOR UPDATE;
+	`, gameID).Scan(&player1ID, &player2ID, &p1Change, &p2Change)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("game %s not found or already voided", gameID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load game for voiding: %v", err)
+	}
+
+	if _, err := tx.Exec(`UPDATE players SET rating = rating - $2 WHERE id = $1;`, player1ID, p1Change); err != nil {
+		return fmt.Errorf("failed to restore player rating: %v", err)
+	}
+	if player2ID.Valid {
+		if _, err := tx.Exec(`UPDATE players SET rating = rating - $2 WHERE id = $1;`, player2ID.Int64, p2Change); err != nil {
+			return fmt.Errorf("failed to restore player rating: %v", err)
+		}
+	}
+	if _, err := tx.Exec(`UPDATE game SET voided = TRUE WHERE game_id = $1::text;`, gameID); err != nil {
+		return fmt.Errorf("failed to mark game voided: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %v", err)
+	}
+	return nil
+}
+
+// GetRatedGamesSince returns non-voided rated PvP games finished since the given time, oldest first
+func (r *GameRepo) GetRatedGamesSince(since time.Time) ([]domain.GameResult, error) {
+	query := `
+	SELECT game_id, player1_id, player1_username, player2_id, player2_username,
+	       winner_id, winner_username, reason, total_moves, duration_seconds,
+	       created_at, finished_at
+	FROM game
+	WHERE rated = TRUE AND voided = FALSE AND player2_id IS NOT NULL
+	  AND reason <> $2 AND finished_at >= $1
+	ORDER BY finished_at ASC;
+	`
+	rows, err := r.DB.Query(query, since, domain.ReasonTerminated)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query rated games: %v", err)
+	}
+	defer rows.Close()
+
+	var games []domain.GameResult
+	for rows.Next() {
+		result := domain.GameResult{Rated: true}
+		var player2ID, winnerID sql.NullInt64
+		var winnerUsername sql.NullString
+		if err := rows.Scan(
+			&result.GameID,
+			&result.Player1ID,
+			&result.Player1Username,
+			&player2ID,
+			&result.Player2Username,
+			&winnerID,
+			&winnerUsername,
+			&result.Reason,
+			&result.TotalMoves,
+			&result.DurationSeconds,
+			&result.CreatedAt,
+			&result.FinishedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan game row: %v", err)
+		}
+		id := player2ID.Int64
+		result.Player2ID = &id
+		if winnerID.Valid {
+			id := winnerID.Int64
+			result.WinnerID = &id
+		}
+		result.WinnerUsername = winnerUsername.String
+		games = append(games, result)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate game rows: %v", err)
+	}
+	return games, nil
+}
+
+// CountRatedOpponents counts the user's non-voided rated games per opponent since the given time
+func (r *GameRepo) CountRatedOpponents(userID int64, since time.Time) (map[int64]int, error) {
+	query := `
+	SELECT CASE WHEN player1_id = $1 THEN player2_id ELSE player1_id END AS opponent_id, COUNT(*)
+	FROM game
+	WHERE (player1_id = $1 OR player2_id = $1)
+	  AND rated = TRUE AND voided = FALSE AND player2_id IS NOT NULL
+	  AND reason <> $3 AND finished_at >= $2
+	GROUP BY opponent_id;
+	`
+	rows, err := r.DB.Query(query, userID, since, domain.ReasonTerminated)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count rated opponents: %v", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[int64]int)
+	for rows.Next() {
+		var opponentID int64
+		var n int
+		if err := rows.Scan(&opponentID, &n); err != nil {
+			return nil, fmt.Errorf("failed to scan opponent count: %v", err)
+		}
+		counts[opponentID] = n
+	}
+	return counts, rows.Err()
+}
+
+// DeleteBotGames removes the player's games against the bot. Their analyses
+// go with them; win/loss stats already applied are kept.
+func (r *GameRepo) DeleteBotGames(userID int64) (int64, error) {
+	result, err := r.DB.Exec(`DELETE FROM game WHERE player1_id = $1 AND player2_id IS NULL;`, userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete bot games: %v", err)
+	}
+	return result.RowsAffected()
+}
+
 // GetGameBoard retrieves the board state for a game from the database
 func (r *GameRepo) GetGameBoard(gameID string) ([][]int, error) {
 	query := `SELECT board_state FROM game WHERE game_id = $1::text;`
@@ -296,3 +444,24 @@ func (r *GameRepo) GetGameBoard(gameID string) ([][]int, error) {
 
 	return board, nil
 }
+
+// GetGameChat retrieves the saved chat transcript for a game (nil if none)
+func (r *GameRepo) GetGameChat(gameID string) ([]domain.ChatMessage, error) {
+	query := `SELECT chat_transcript FROM game WHERE game_id = $1::text;`
+
+	var chatJSON []byte
+	err := r.DB.QueryRow(query, gameID).Scan(&chatJSON)
+	if err == sql.ErrNoRows || (err == nil && chatJSON == nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chat transcript: %v", err)
+	}
+
+	var chat []domain.ChatMessage
+	if err := json.Unmarshal(chatJSON, &chat); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal chat transcript: %v", err)
+	}
+
+	return chat, nil
+}
@@ -0,0 +1,84 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/iamasit07/connect4/backend/internal/domain"
+)
+
+type IdentityRepo struct {
+	DB *sql.DB
+}
+
+func NewIdentityRepo(db *sql.DB) *IdentityRepo {
+	return &IdentityRepo{DB: db}
+}
+
+// LinkIdentity stores a new link; the unique constraints reject a provider
+// account that is already linked or a second account from the same provider
+func (r *IdentityRepo) LinkIdentity(identity domain.UserIdentity) error {
+	query := `
+	INSERT INTO user_identities (user_id, provider, subject, email)
+	VALUES ($1, $2, $3, $4);
+	`
+	_, err := r.DB.Exec(query, identity.UserID, identity.Provider, identity.Subject, identity.Email)
+	if err != nil {
+		return fmt.Errorf("failed to link identity: %v", err)
+	}
+	return nil
+}
+
+func (r *IdentityRepo) GetIdentity(provider, subject string) (*domain.UserIdentity, error) {
+	query := `
+	SELECT id, user_id, provider, subject, COALESCE(email, ''), created_at
+	FROM user_identities
+	WHERE provider = $1 AND subject = $2;
+	`
+	var identity domain.UserIdentity
+	err := r.DB.QueryRow(query, provider, subject).Scan(
+		&identity.ID, &identity.UserID, &identity.Provider, &identity.Subject, &identity.Email, &identity.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get identity: %v", err)
+	}
+	return &identity, nil
+}
+
+// ListIdentities returns the user's linked providers, oldest first
+func (r *IdentityRepo) ListIdentities(userID int64) ([]domain.UserIdentity, error) {
+	query := `
+	SELECT id, user_id, provider, subject, COALESCE(email, ''), created_at
+	FROM user_identities
+	WHERE user_id = $1
+	ORDER BY id;
+	`
+	rows, err := r.DB.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list identities: %v", err)
+	}
+	defer rows.Close()
+
+	var identities []domain.UserIdentity
+	for rows.Next() {
+		var identity domain.UserIdentity
+		if err := rows.Scan(&identity.ID, &identity.UserID, &identity.Provider, &identity.Subject, &identity.Email, &identity.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan identity: %v", err)
+		}
+		identities = append(identities, identity)
+	}
+	return identities, rows.Err()
+}
+
+// UnlinkIdentity removes the user's link to provider, reporting whether one existed
+func (r *IdentityRepo) UnlinkIdentity(userID int64, provider string) (bool, error) {
+	result, err := r.DB.Exec(`DELETE FROM user_identities WHERE user_id = $1 AND provider = $2;`, userID, provider)
+	if err != nil {
+		return false, fmt.Errorf("failed to unlink identity: %v", err)
+	}
+	n, _ := result.RowsAffected()
+	return n > 0, nil
+}
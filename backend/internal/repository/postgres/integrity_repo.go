@@ -0,0 +1,146 @@
+package postgres
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/iamasit07/connect4/backend/internal/domain"
+)
+
+type IntegrityRepo struct {
+	DB *sql.DB
+}
+
+func NewIntegrityRepo(db *sql.DB) *IntegrityRepo {
+	return &IntegrityRepo{DB: db}
+}
+
+const flagColumns = `
+	f.id, f.kind, f.user_id, u.username, COALESCE(f.opponent_id, 0), COALESCE(o.username, ''), f.score, f.signals,
+	f.games, f.evidence, f.status, f.reviewed_by, f.created_at, f.updated_at
+	FROM integrity_flags f
+	JOIN players u ON u.id = f.user_id
+	LEFT JOIN players o ON o.id = f.opponent_id`
+
+// GetLatestFlag returns the newest flag of the given kind raised against userID (and opponentID)
+func (r *IntegrityRepo) GetLatestFlag(kind string, userID, opponentID int64) (*domain.IntegrityFlag, error) {
+	query := `SELECT` + flagColumns + `
+	WHERE f.kind = $1 AND f.user_id = $2 AND COALESCE(f.opponent_id, 0) = $3
+	ORDER BY f.created_at DESC, f.id DESC
+	LIMIT 1;`
+	flag, err := scanFlag(r.DB.QueryRow(query, kind, userID, opponentID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get integrity flag: %v", err)
+	}
+	return flag, nil
+}
+
+// SaveFlag inserts a new flag (ID 0) or refreshes an existing one
+func (r *IntegrityRepo) SaveFlag(flag *domain.IntegrityFlag) error {
+	signals := strings.Join(flag.Signals, ",")
+	var evidence *string
+	if flag.Evidence != nil {
+		data, err := json.Marshal(flag.Evidence)
+		if err != nil {
+			return fmt.Errorf("failed to marshal flag evidence: %v", err)
+		}
+		e := string(data)
+		evidence = &e
+	}
+	if flag.ID == 0 {
+		query := `
+		INSERT INTO integrity_flags (kind, user_id, opponent_id, score, signals, games, evidence, status, created_at, updated_at)
+		VALUES ($1, $2, NULLIF($3, 0), $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id;
+		`
+		err := r.DB.QueryRow(query, flag.Kind, flag.UserID, flag.OpponentID, flag.Score, signals, flag.Games, evidence, flag.Status, flag.CreatedAt, flag.UpdatedAt).Scan(&flag.ID)
+		if err != nil {
+			return fmt.Errorf("failed to insert integrity flag: %v", err)
+		}
+		return nil
+	}
+
+	query := `
+	UPDATE integrity_flags
+	SET score = $2, signals = $3, games = $4, evidence = $5, status = $6, updated_at = $7
+	WHERE id = $1;
+	`
+	if _, err := r.DB.Exec(query, flag.ID, flag.Score, signals, flag.Games, evidence, flag.Status, flag.UpdatedAt); err != nil {
+		return fmt.Errorf("failed to update integrity flag: %v", err)
+	}
+	return nil
+}
+
+func (r *IntegrityRepo) GetFlag(id int64) (*domain.IntegrityFlag, error) {
+	flag, err := scanFlag(r.DB.QueryRow(`SELECT`+flagColumns+` WHERE f.id = $1;`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get integrity flag: %v", err)
+	}
+	return flag, nil
+}
+
+// ListFlags returns up to limit flags with the given status ("" for all), highest score first
+func (r *IntegrityRepo) ListFlags(status string, limit int) ([]domain.IntegrityFlag, error) {
+	query := `SELECT` + flagColumns + `
+	WHERE $1 = '' OR f.status = $1
+	ORDER BY f.score DESC, f.id DESC
+	LIMIT $2;`
+	rows, err := r.DB.Query(query, status, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query integrity flags: %v", err)
+	}
+	defer rows.Close()
+
+	flags := make([]domain.IntegrityFlag, 0)
+	for rows.Next() {
+		flag, err := scanFlag(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan integrity flag: %v", err)
+		}
+		flags = append(flags, *flag)
+	}
+	return flags, rows.Err()
+}
+
+func (r *IntegrityRepo) ReviewFlag(id int64, status string, reviewerID int64, at time.Time) error {
+	query := `UPDATE integrity_flags SET status = $2, reviewed_by = $3, updated_at = $4 WHERE id = $1;`
+	if _, err := r.DB.Exec(query, id, status, reviewerID, at); err != nil {
+		return fmt.Errorf("failed to review integrity flag: %v", err)
+	}
+	return nil
+}
+
+func scanFlag(row interface{ Scan(dest ...any) error }) (*domain.IntegrityFlag, error) {
+	var f domain.IntegrityFlag
+	var signals string
+	var evidence []byte
+	var reviewedBy sql.NullInt64
+	if err := row.Scan(&f.ID, &f.Kind, &f.UserID, &f.Username, &f.OpponentID, &f.OpponentUsername, &f.Score, &signals,
+		&f.Games, &evidence, &f.Status, &reviewedBy, &f.CreatedAt, &f.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if len(evidence) > 0 {
+		f.Evidence = &domain.EngineEvidence{}
+		if err := json.Unmarshal(evidence, f.Evidence); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal flag evidence: %v", err)
+		}
+	}
+	f.Signals = []string{}
+	if signals != "" {
+		f.Signals = strings.Split(signals, ",")
+	}
+	if reviewedBy.Valid {
+		id := reviewedBy.Int64
+		f.ReviewedBy = &id
+	}
+	return &f, nil
+}
@@ -2,51 +2,317 @@ package postgres
 
 import (
 	"database/sql"
+	"embed"
 	"fmt"
-	"os"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/iamasit07/connect4/backend/internal/logging"
 )
 
-// RunMigrations executes the schema.sql file to initialize the database
+// migrationFiles holds the numbered SQL migrations compiled into the binary,
+// so the server no longer depends on the working directory to find them.
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migrationLockID is the pg_advisory_xact_lock key shared by every instance.
+// Transaction-scoped locks are used instead of session locks because the
+// connection may go through PgBouncer in transaction pooling mode.
+const migrationLockID = 7_240_412_001
+
+// migrationFilePattern matches "0001_initial_schema.up.sql" style names
+var migrationFilePattern = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// Migration is a single versioned schema change with its rollback
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus describes whether a known migration has been applied
+type MigrationStatus struct {
+	Version   int64
+	Name      string
+	Applied   bool
+	AppliedAt *time.Time
+}
+
+type Migrator struct {
+	DB         *sql.DB
+	Migrations []Migration
+}
+
+// NewMigrator loads the embedded migrations, sorted by version
+func NewMigrator(db *sql.DB) (*Migrator, error) {
+	migrations, err := loadMigrations(migrationFiles)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{DB: db, Migrations: migrations}, nil
+}
+
+// RunMigrations applies every pending migration (used on server startup)
 func RunMigrations(db *sql.DB) error {
-	// We check a few common locations for the schema file to make this robust
-	// regardless of where 'go run' or the binary is executed from.
-	possiblePaths := []string{
-		"script/migration/schema.sql",          // From backend root (go run ./cmd/api)
-		"../script/migration/schema.sql",       // From cmd/api
-		"../../script/migration/schema.sql",    // From internal/...
-		"backend/script/migration/schema.sql",  // From repo root
-	}
-
-	var schemaPath string
-	var content []byte
-	var err error
-
-	// Try to find the file
-	for _, path := range possiblePaths {
-		if _, err := os.Stat(path); err == nil {
-			schemaPath = path
+	m, err := NewMigrator(db)
+	if err != nil {
+		return err
+	}
+	applied, err := m.Up(0)
+	if err != nil {
+		return err
+	}
+	if applied > 0 {
+		logging.For("migrate").Info("Applied migrations", "count", applied)
+	}
+	return nil
+}
+
+func loadMigrations(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, "migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations directory: %v", err)
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		match := migrationFilePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration file name: %s", entry.Name())
+		}
+
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version in %s: %v", entry.Name(), err)
+		}
+
+		content, err := fs.ReadFile(fsys, "migrations/"+entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %v", entry.Name(), err)
+		}
+
+		m, exists := byVersion[version]
+		if !exists {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has conflicting names: %s and %s", version, m.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up step", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// ensureMigrationsTable creates the bookkeeping table under the advisory lock
+func (m *Migrator) ensureMigrationsTable() error {
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`SELECT pg_advisory_xact_lock($1)`, migrationLockID); err != nil {
+		return fmt.Errorf("failed to acquire migration lock: %v", err)
+	}
+
+	query := `
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		version BIGINT PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+	`
+	if _, err := tx.Exec(query); err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %v", err)
+	}
+
+	return tx.Commit()
+}
+
+// appliedVersions returns version → applied_at for every recorded migration
+func (m *Migrator) appliedVersions() (map[int64]time.Time, error) {
+	rows, err := m.DB.Query(`SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query schema_migrations: %v", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int64]time.Time)
+	for rows.Next() {
+		var version int64
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan schema_migrations row: %v", err)
+		}
+		applied[version] = appliedAt
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate schema_migrations rows: %v", err)
+	}
+	return applied, nil
+}
+
+// Up applies up to `steps` pending migrations in version order (steps <= 0 applies all).
+// It returns the number of migrations this call applied.
+func (m *Migrator) Up(steps int) (int, error) {
+	if err := m.ensureMigrationsTable(); err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, migration := range m.Migrations {
+		if steps > 0 && count >= steps {
 			break
 		}
+		applied, err := m.applyUp(migration)
+		if err != nil {
+			return count, err
+		}
+		if applied {
+			logging.For("migrate").Info("Applied migration", "version", migration.Version, "name", migration.Name)
+			count++
+		}
+	}
+	return count, nil
+}
+
+// applyUp runs a single migration in its own transaction. The version is
+// re-checked after taking the lock so a concurrent instance that got there
+// first is not repeated.
+func (m *Migrator) applyUp(migration Migration) (bool, error) {
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %v", err)
 	}
+	defer tx.Rollback()
 
-	// If relative paths failed, try to construct absolute path based on runtime/executable?
-	// For now, if schemaPath is empty, we default to the standard expectation and let it error out with context.
-	if schemaPath == "" {
-		schemaPath = "script/migration/schema.sql"
+	if _, err := tx.Exec(`SELECT pg_advisory_xact_lock($1)`, migrationLockID); err != nil {
+		return false, fmt.Errorf("failed to acquire migration lock: %v", err)
 	}
 
-	// Read schema file
-	content, err = os.ReadFile(schemaPath)
+	var exists bool
+	if err := tx.QueryRow(`SELECT EXISTS(SELECT 1 FROM schema_migrations WHERE version = $1)`, migration.Version).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check migration %d: %v", migration.Version, err)
+	}
+	if exists {
+		return false, nil
+	}
+
+	if _, err := tx.Exec(migration.Up); err != nil {
+		return false, fmt.Errorf("failed to apply migration %04d_%s: %v", migration.Version, migration.Name, err)
+	}
+
+	if _, err := tx.Exec(`INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`, migration.Version, migration.Name); err != nil {
+		return false, fmt.Errorf("failed to record migration %d: %v", migration.Version, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit migration %d: %v", migration.Version, err)
+	}
+	return true, nil
+}
+
+// Down rolls back the `steps` most recently applied migrations (newest first)
+func (m *Migrator) Down(steps int) (int, error) {
+	if err := m.ensureMigrationsTable(); err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for i := len(m.Migrations) - 1; i >= 0 && count < steps; i-- {
+		migration := m.Migrations[i]
+		reverted, err := m.applyDown(migration)
+		if err != nil {
+			return count, err
+		}
+		if reverted {
+			logging.For("migrate").Info("Rolled back migration", "version", migration.Version, "name", migration.Name)
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (m *Migrator) applyDown(migration Migration) (bool, error) {
+	tx, err := m.DB.Begin()
 	if err != nil {
-		wd, _ := os.Getwd()
-		return fmt.Errorf("failed to read migration file. Looking for '%s' (Current WD: %s). Error: %v", schemaPath, wd, err)
+		return false, fmt.Errorf("failed to begin transaction: %v", err)
 	}
+	defer tx.Rollback()
 
-	// Execute SQL
-	// Note: We use string(content) because Exec requires a string query
-	if _, err := db.Exec(string(content)); err != nil {
-		return fmt.Errorf("failed to execute schema.sql: %v", err)
+	if _, err := tx.Exec(`SELECT pg_advisory_xact_lock($1)`, migrationLockID); err != nil {
+		return false, fmt.Errorf("failed to acquire migration lock: %v", err)
 	}
 
-	return nil
-}
\ No newline at end of file
+	var exists bool
+	if err := tx.QueryRow(`SELECT EXISTS(SELECT 1 FROM schema_migrations WHERE version = $1)`, migration.Version).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check migration %d: %v", migration.Version, err)
+	}
+	if !exists {
+		return false, nil
+	}
+
+	if migration.Down == "" {
+		return false, fmt.Errorf("migration %04d_%s has no down step", migration.Version, migration.Name)
+	}
+
+	if _, err := tx.Exec(migration.Down); err != nil {
+		return false, fmt.Errorf("failed to roll back migration %04d_%s: %v", migration.Version, migration.Name, err)
+	}
+
+	if _, err := tx.Exec(`DELETE FROM schema_migrations WHERE version = $1`, migration.Version); err != nil {
+		return false, fmt.Errorf("failed to unrecord migration %d: %v", migration.Version, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit rollback of migration %d: %v", migration.Version, err)
+	}
+	return true, nil
+}
+
+// Status lists every known migration and whether it has been applied
+func (m *Migrator) Status() ([]MigrationStatus, error) {
+	if err := m.ensureMigrationsTable(); err != nil {
+		return nil, err
+	}
+
+	applied, err := m.appliedVersions()
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(m.Migrations))
+	for _, migration := range m.Migrations {
+		status := MigrationStatus{Version: migration.Version, Name: migration.Name}
+		if appliedAt, ok := applied[migration.Version]; ok {
+			status.Applied = true
+			status.AppliedAt = &appliedAt
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
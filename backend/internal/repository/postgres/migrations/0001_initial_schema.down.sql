@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS refresh_tokens;
+DROP TABLE IF EXISTS user_sessions;
+DROP TABLE IF EXISTS game;
+DROP TABLE IF EXISTS players;
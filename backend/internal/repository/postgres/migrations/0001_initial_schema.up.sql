@@ -81,4 +81,4 @@ CREATE INDEX IF NOT EXISTS idx_refresh_tokens_cleanup ON refresh_tokens(created_
 ALTER TABLE players ENABLE ROW LEVEL SECURITY;
 ALTER TABLE game ENABLE ROW LEVEL SECURITY;
 ALTER TABLE user_sessions ENABLE ROW LEVEL SECURITY;
-ALTER TABLE refresh_tokens ENABLE ROW LEVEL SECURITY;
\ No newline at end of file
+ALTER TABLE refresh_tokens ENABLE ROW LEVEL SECURITY;
@@ -0,0 +1,2 @@
+-- Casual vs rated games: unrated games leave player ratings untouched
+ALTER TABLE game ADD COLUMN IF NOT EXISTS rated BOOLEAN DEFAULT TRUE;
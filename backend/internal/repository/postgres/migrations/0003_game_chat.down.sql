@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS chat_reports;
+ALTER TABLE game DROP COLUMN IF EXISTS chat_transcript;
@@ -0,0 +1,19 @@
+-- In-game chat: transcript saved with the game, plus reports for moderators
+ALTER TABLE game ADD COLUMN IF NOT EXISTS chat_transcript JSONB;
+
+CREATE TABLE IF NOT EXISTS chat_reports (
+    id SERIAL PRIMARY KEY,
+    game_id TEXT NOT NULL,
+    message_id BIGINT NOT NULL,
+    reporter_id INT NOT NULL REFERENCES players(id) ON DELETE CASCADE,
+    reported_user_id INT NOT NULL REFERENCES players(id) ON DELETE CASCADE,
+    message_text TEXT NOT NULL,
+    reason TEXT DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (game_id, message_id, reporter_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_chat_reports_reported_user ON chat_reports(reported_user_id);
+CREATE INDEX IF NOT EXISTS idx_chat_reports_created_at ON chat_reports(created_at DESC);
+
+ALTER TABLE chat_reports ENABLE ROW LEVEL SECURITY;
@@ -0,0 +1,14 @@
+-- Friends and blocks. Rows are directed: user_id sent the request or placed the block.
+CREATE TABLE IF NOT EXISTS friendships (
+    user_id INT NOT NULL REFERENCES players(id) ON DELETE CASCADE,
+    friend_id INT NOT NULL REFERENCES players(id) ON DELETE CASCADE,
+    status TEXT NOT NULL CHECK (status IN ('pending', 'accepted', 'blocked')),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, friend_id),
+    CHECK (user_id <> friend_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_friendships_friend_id ON friendships(friend_id);
+
+ALTER TABLE friendships ENABLE ROW LEVEL SECURITY;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_friendships_blocked;
+ALTER TABLE friendships DROP COLUMN IF EXISTS block_spectating;
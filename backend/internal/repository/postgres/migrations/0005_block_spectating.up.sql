@@ -0,0 +1,4 @@
+-- Blockers can also hide their live games from the blocked user
+ALTER TABLE friendships ADD COLUMN IF NOT EXISTS block_spectating BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX IF NOT EXISTS idx_friendships_blocked ON friendships(friend_id) WHERE status = 'blocked';
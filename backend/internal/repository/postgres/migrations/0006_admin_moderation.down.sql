@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS admin_actions;
+ALTER TABLE game DROP COLUMN IF EXISTS voided;
+ALTER TABLE game DROP COLUMN IF EXISTS player2_rating_change;
+ALTER TABLE game DROP COLUMN IF EXISTS player1_rating_change;
+ALTER TABLE players DROP COLUMN IF EXISTS suspended_until;
+ALTER TABLE players DROP COLUMN IF EXISTS banned;
+ALTER TABLE players DROP COLUMN IF EXISTS role;
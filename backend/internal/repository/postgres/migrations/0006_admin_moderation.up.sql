@@ -0,0 +1,26 @@
+-- Admin moderation: roles, bans/suspensions, voidable ratings and an audit log
+ALTER TABLE players ADD COLUMN IF NOT EXISTS role TEXT NOT NULL DEFAULT 'user' CHECK (role IN ('user', 'admin'));
+ALTER TABLE players ADD COLUMN IF NOT EXISTS banned BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE players ADD COLUMN IF NOT EXISTS suspended_until TIMESTAMP;
+
+-- Rating change applied to each player, so an admin can reverse it later
+ALTER TABLE game ADD COLUMN IF NOT EXISTS player1_rating_change INT NOT NULL DEFAULT 0;
+ALTER TABLE game ADD COLUMN IF NOT EXISTS player2_rating_change INT NOT NULL DEFAULT 0;
+ALTER TABLE game ADD COLUMN IF NOT EXISTS voided BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE IF NOT EXISTS admin_actions (
+    id SERIAL PRIMARY KEY,
+    admin_id INT NOT NULL REFERENCES players(id) ON DELETE CASCADE,
+    admin_username TEXT NOT NULL,
+    action TEXT NOT NULL,
+    target_user_id INT REFERENCES players(id) ON DELETE SET NULL,
+    target_game_id TEXT,
+    reason TEXT DEFAULT '',
+    details TEXT DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_admin_actions_created_at ON admin_actions(created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_admin_actions_target_user ON admin_actions(target_user_id);
+
+ALTER TABLE admin_actions ENABLE ROW LEVEL SECURITY;
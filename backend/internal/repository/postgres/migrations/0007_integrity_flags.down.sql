@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_game_rated_finished;
+DROP TABLE IF EXISTS integrity_flags;
@@ -0,0 +1,21 @@
+-- Review queue for accounts the win-trading detector flags
+CREATE TABLE IF NOT EXISTS integrity_flags (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES players(id) ON DELETE CASCADE,
+    opponent_id INT NOT NULL REFERENCES players(id) ON DELETE CASCADE,
+    score INT NOT NULL,
+    signals TEXT NOT NULL DEFAULT '', -- comma-separated signal names
+    games INT NOT NULL DEFAULT 0,
+    status TEXT NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'dismissed', 'confirmed')),
+    reviewed_by INT REFERENCES players(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_integrity_flags_pair ON integrity_flags(user_id, opponent_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_integrity_flags_status ON integrity_flags(status, created_at DESC);
+
+-- Pairing scans filter rated games by finish time
+CREATE INDEX IF NOT EXISTS idx_game_rated_finished ON game(finished_at) WHERE rated = TRUE AND voided = FALSE;
+
+ALTER TABLE integrity_flags ENABLE ROW LEVEL SECURITY;
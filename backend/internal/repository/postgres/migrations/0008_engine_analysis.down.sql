@@ -0,0 +1,8 @@
+DELETE FROM integrity_flags WHERE kind = 'engine_assistance';
+ALTER TABLE integrity_flags ALTER COLUMN opponent_id SET NOT NULL;
+ALTER TABLE integrity_flags DROP COLUMN IF EXISTS evidence;
+ALTER TABLE integrity_flags DROP COLUMN IF EXISTS kind;
+DROP TABLE IF EXISTS game_analysis;
+DROP INDEX IF EXISTS idx_game_unanalyzed;
+ALTER TABLE game DROP COLUMN IF EXISTS analyzed_at;
+ALTER TABLE game DROP COLUMN IF EXISTS move_history;
@@ -0,0 +1,28 @@
+-- Move lists for replaying finished games, and per-player engine comparisons
+ALTER TABLE game ADD COLUMN IF NOT EXISTS move_history JSONB;
+ALTER TABLE game ADD COLUMN IF NOT EXISTS analyzed_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_game_unanalyzed ON game(finished_at) WHERE analyzed_at IS NULL AND move_history IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS game_analysis (
+    game_id TEXT NOT NULL REFERENCES game(game_id) ON DELETE CASCADE,
+    user_id INT NOT NULL REFERENCES players(id) ON DELETE CASCADE,
+    rating INT NOT NULL,
+    moves INT NOT NULL,
+    engine_matches INT NOT NULL,
+    total_loss INT NOT NULL,
+    think_mean_ms DOUBLE PRECISION NOT NULL DEFAULT 0,
+    think_stddev_ms DOUBLE PRECISION NOT NULL DEFAULT 0,
+    finished_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (game_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_game_analysis_user ON game_analysis(user_id, finished_at DESC);
+CREATE INDEX IF NOT EXISTS idx_game_analysis_rating ON game_analysis(rating);
+
+ALTER TABLE game_analysis ENABLE ROW LEVEL SECURITY;
+
+-- Engine flags share the review queue but have no opponent
+ALTER TABLE integrity_flags ADD COLUMN IF NOT EXISTS kind TEXT NOT NULL DEFAULT 'win_trading' CHECK (kind IN ('win_trading', 'engine_assistance'));
+ALTER TABLE integrity_flags ADD COLUMN IF NOT EXISTS evidence JSONB;
+ALTER TABLE integrity_flags ALTER COLUMN opponent_id DROP NOT NULL;
@@ -0,0 +1,2 @@
+-- Guest accounts: anonymous players who can upgrade to a full account later
+ALTER TABLE players ADD COLUMN IF NOT EXISTS is_guest BOOLEAN NOT NULL DEFAULT FALSE;
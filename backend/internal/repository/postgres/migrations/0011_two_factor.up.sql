@@ -0,0 +1,6 @@
+-- Optional TOTP two-factor authentication. The secret is AES-GCM encrypted by
+-- the application; recovery codes are stored as comma-separated keyed hashes.
+ALTER TABLE players ADD COLUMN IF NOT EXISTS totp_secret TEXT NOT NULL DEFAULT '';
+ALTER TABLE players ADD COLUMN IF NOT EXISTS totp_enabled BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE players ADD COLUMN IF NOT EXISTS totp_recovery_codes TEXT NOT NULL DEFAULT '';
+ALTER TABLE players ADD COLUMN IF NOT EXISTS totp_last_step BIGINT NOT NULL DEFAULT 0;
@@ -0,0 +1,8 @@
+ALTER TABLE players ADD COLUMN IF NOT EXISTS google_id TEXT UNIQUE;
+
+UPDATE players p
+SET google_id = i.subject
+FROM user_identities i
+WHERE i.user_id = p.id AND i.provider = 'google';
+
+DROP TABLE IF EXISTS user_identities;
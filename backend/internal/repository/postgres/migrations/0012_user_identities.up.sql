@@ -0,0 +1,20 @@
+-- External login providers linked to a player (Google, GitHub, Discord, OIDC issuers)
+CREATE TABLE IF NOT EXISTS user_identities (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES players(id) ON DELETE CASCADE,
+    provider TEXT NOT NULL,
+    subject TEXT NOT NULL,
+    email TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (provider, subject),
+    UNIQUE (user_id, provider)
+);
+
+ALTER TABLE user_identities ENABLE ROW LEVEL SECURITY;
+
+-- Google links used to live on players.google_id
+INSERT INTO user_identities (user_id, provider, subject, email)
+SELECT id, 'google', google_id, email FROM players WHERE google_id IS NOT NULL
+ON CONFLICT DO NOTHING;
+
+ALTER TABLE players DROP COLUMN IF EXISTS google_id;
@@ -0,0 +1,12 @@
+-- Keep only each user's most recently used session active before restoring the one-session index
+UPDATE user_sessions s
+SET is_active = FALSE
+WHERE is_active = TRUE
+  AND EXISTS (
+    SELECT 1 FROM user_sessions newer
+    WHERE newer.user_id = s.user_id
+      AND newer.is_active = TRUE
+      AND (newer.last_activity, newer.id) > (s.last_activity, s.id)
+  );
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_one_active_session ON user_sessions(user_id) WHERE is_active = TRUE;
@@ -0,0 +1,2 @@
+-- Users may now stay signed in on several devices; the limit is enforced in code (MAX_SESSIONS_PER_USER)
+DROP INDEX IF EXISTS idx_one_active_session;
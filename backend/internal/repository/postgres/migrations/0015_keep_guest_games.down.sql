@@ -0,0 +1,3 @@
+DELETE FROM game WHERE player1_id IS NULL;
+ALTER TABLE game DROP CONSTRAINT IF EXISTS game_player1_id_fkey;
+ALTER TABLE game ADD CONSTRAINT game_player1_id_fkey FOREIGN KEY (player1_id) REFERENCES players(id);
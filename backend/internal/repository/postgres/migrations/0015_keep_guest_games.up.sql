@@ -0,0 +1,4 @@
+-- Games against deleted guests stay in their opponents' history; the stored
+-- username stands in for the guest
+ALTER TABLE game DROP CONSTRAINT IF EXISTS game_player1_id_fkey;
+ALTER TABLE game ADD CONSTRAINT game_player1_id_fkey FOREIGN KEY (player1_id) REFERENCES players(id) ON DELETE SET NULL;
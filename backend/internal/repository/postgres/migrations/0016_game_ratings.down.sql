@@ -0,0 +1,2 @@
+ALTER TABLE game DROP COLUMN IF EXISTS player2_rating;
+ALTER TABLE game DROP COLUMN IF EXISTS player1_rating;
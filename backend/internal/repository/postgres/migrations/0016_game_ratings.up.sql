@@ -0,0 +1,4 @@
+-- Ratings going into each game, so engine analysis compares players against
+-- the band they were in when they played (NULL for bots and older games)
+ALTER TABLE game ADD COLUMN IF NOT EXISTS player1_rating INT;
+ALTER TABLE game ADD COLUMN IF NOT EXISTS player2_rating INT;
@@ -57,33 +57,43 @@ func (r *SessionRepo) GetSessionByID(sessionID string) (*domain.UserSession, err
 	return &session, nil
 }
 
-// GetActiveSessionByUserID retrieves the active session for a user
-func (r *SessionRepo) GetActiveSessionByUserID(userID int64) (*domain.UserSession, error) {
+// ListActiveSessions returns the user's active, unexpired sessions, most recently used first
+func (r *SessionRepo) ListActiveSessions(userID int64) ([]domain.UserSession, error) {
 	query := `
 	SELECT id, user_id, session_id, device_info, ip_address, created_at, expires_at, last_activity, is_active
 	FROM user_sessions
-	WHERE user_id = $1 AND is_active = TRUE
-	LIMIT 1;
+	WHERE user_id = $1 AND is_active = TRUE AND expires_at > NOW()
+	ORDER BY last_activity DESC, id DESC;
 	`
-	var session domain.UserSession
-	err := r.DB.QueryRow(query, userID).Scan(
-		&session.ID,
-		&session.UserID,
-		&session.SessionID,
-		&session.DeviceInfo,
-		&session.IPAddress,
-		&session.CreatedAt,
-		&session.ExpiresAt,
-		&session.LastActivity,
-		&session.IsActive,
-	)
-	if err == sql.ErrNoRows {
-		return nil, nil
-	}
+	rows, err := r.DB.Query(query, userID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get active session: %v", err)
+		return nil, fmt.Errorf("failed to query active sessions: %v", err)
 	}
-	return &session, nil
+	defer rows.Close()
+
+	var sessions []domain.UserSession
+	for rows.Next() {
+		var s domain.UserSession
+		if err := rows.Scan(
+			&s.ID,
+			&s.UserID,
+			&s.SessionID,
+			&s.DeviceInfo,
+			&s.IPAddress,
+			&s.CreatedAt,
+			&s.ExpiresAt,
+			&s.LastActivity,
+			&s.IsActive,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan session row: %v", err)
+		}
+		sessions = append(sessions, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate session rows: %v", err)
+	}
+
+	return sessions, nil
 }
 
 // DeactivateAllUserSessions marks all sessions for a user as inactive
@@ -148,6 +158,31 @@ func (r *SessionRepo) CleanupOldSessions(olderThanDays int) (int64, error) {
 	return rowsAffected, nil
 }
 
+// GetUserIPAddresses returns the distinct IPs the user has logged in from
+func (r *SessionRepo) GetUserIPAddresses(userID int64) ([]string, error) {
+	query := `
+	SELECT DISTINCT ip_address
+	FROM user_sessions
+	WHERE user_id = $1 AND ip_address IS NOT NULL AND ip_address <> ''
+	ORDER BY ip_address;
+	`
+	rows, err := r.DB.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query session IPs: %v", err)
+	}
+	defer rows.Close()
+
+	var ips []string
+	for rows.Next() {
+		var ip string
+		if err := rows.Scan(&ip); err != nil {
+			return nil, fmt.Errorf("failed to scan session IP: %v", err)
+		}
+		ips = append(ips, ip)
+	}
+	return ips, rows.Err()
+}
+
 // GetUserSessionHistory retrieves recent login sessions for a user
 func (r *SessionRepo) GetUserSessionHistory(userID int64, limit int) ([]domain.UserSession, error) {
 	query := `
@@ -257,6 +292,20 @@ func (r *SessionRepo) RevokeAllUserRefreshTokens(userID int64) error {
 	return nil
 }
 
+// RevokeSessionRefreshTokens revokes the refresh tokens issued to one session
+func (r *SessionRepo) RevokeSessionRefreshTokens(sessionID string) error {
+	query := `
+	UPDATE refresh_tokens
+	SET revoked = TRUE
+	WHERE session_id = $1 AND revoked = FALSE;
+	`
+	_, err := r.DB.Exec(query, sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke session refresh tokens: %v", err)
+	}
+	return nil
+}
+
 // CleanupOldRefreshTokens deletes revoked/expired refresh tokens
 func (r *SessionRepo) CleanupOldRefreshTokens(olderThanDays int) (int64, error) {
 	query := `
@@ -3,7 +3,10 @@ package postgres
 import (
 	"database/sql"
 	"fmt"
+	"strings"
 	"time"
+
+	"github.com/iamasit07/connect4/backend/internal/domain"
 )
 
 type UserRepo struct {
@@ -14,84 +17,99 @@ func NewUserRepo(db *sql.DB) *UserRepo {
 	return &UserRepo{DB: db}
 }
 
-type User struct {
-	ID           int64
-	Username     string
-	Name         string
-	AvatarURL    string
-	Email        sql.NullString
-	GoogleID     sql.NullString
-	IsVerified   bool
-	PasswordHash string
-	GamesPlayed  int
-	GamesWon     int
-	GamesDrawn   int
-	Rating       int
-	CreatedAt    time.Time
-}
-
-type PlayerStats struct {
-	Rank     int    `json:"rank"`
-	Username string `json:"username"`
-	Rating   int    `json:"rating"`
-	Wins     int    `json:"wins"`
-	Losses   int    `json:"losses"`
-}
-
-// UserResponse returns a consistent JSON-friendly map of user data
-func (u *User) UserResponse() map[string]interface{} {
-	email := ""
-	if u.Email.Valid {
-		email = u.Email.String
-	}
-	return map[string]interface{}{
-		"id":         u.ID,
-		"username":   u.Username,
-		"name":       u.Name,
-		"avatar_url": u.AvatarURL,
-		"email":      email,
-		"rating":     u.Rating,
-		"wins":       u.GamesWon,
-		"losses":     u.GamesPlayed - u.GamesWon - u.GamesDrawn,
-		"draws":      u.GamesDrawn,
-	}
-}
-
-// CreateUser creates a new user with hashed password and optional email/google_id/avatar
-func (r *UserRepo) CreateUser(username, name, passwordHash string, email, googleID, avatarURL string) (int64, error) {
-	var emailParam, googleIDParam interface{}
+// CreateUser creates a new user with hashed password and optional email/avatar
+func (r *UserRepo) CreateUser(username, name, passwordHash string, email, avatarURL string) (int64, error) {
+	var emailParam interface{}
 	emailParam = nil
 	if email != "" {
 		emailParam = email
 	}
-	googleIDParam = nil
-	if googleID != "" {
-		googleIDParam = googleID
-	}
 
 	query := `
-	INSERT INTO players (username, name, password_hash, email, google_id, avatar_url, games_played, games_won, games_drawn, rating)
-	VALUES ($1, $2, $3, $4, $5, $6, 0, 0, 0, 1000)
+	INSERT INTO players (username, name, password_hash, email, avatar_url, games_played, games_won, games_drawn, rating)
+	VALUES ($1, $2, $3, $4, $5, 0, 0, 0, 1000)
 	RETURNING id;
 	`
 	var userID int64
-	err := r.DB.QueryRow(query, username, name, passwordHash, emailParam, googleIDParam, avatarURL).Scan(&userID)
+	err := r.DB.QueryRow(query, username, name, passwordHash, emailParam, avatarURL).Scan(&userID)
 	if err != nil {
 		return 0, fmt.Errorf("failed to create user: %v", err)
 	}
 	return userID, nil
 }
 
+// CreateGuest creates a guest player with no email or password
+func (r *UserRepo) CreateGuest(username string) (int64, error) {
+	query := `
+	INSERT INTO players (username, name, password_hash, is_guest, games_played, games_won, games_drawn, rating)
+	VALUES ($1, $1, '', TRUE, 0, 0, 0, 1000)
+	RETURNING id;
+	`
+	var userID int64
+	err := r.DB.QueryRow(query, username).Scan(&userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create guest: %v", err)
+	}
+	return userID, nil
+}
+
+// UpgradeGuest turns a guest into a full account, keeping its ID (and so its games and stats)
+func (r *UserRepo) UpgradeGuest(userID int64, username, name, email, passwordHash string) error {
+	query := `
+	UPDATE players
+	SET username = $2, name = $3, email = $4, password_hash = $5, is_guest = FALSE
+	WHERE id = $1 AND is_guest;
+	`
+	result, err := r.DB.Exec(query, userID, username, name, email, passwordHash)
+	if err != nil {
+		return fmt.Errorf("failed to upgrade guest: %v", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("failed to upgrade guest: user %d is not a guest", userID)
+	}
+	return nil
+}
+
+// ListGuestIDs returns the guests created before the cutoff, oldest first
+func (r *UserRepo) ListGuestIDs(createdBefore time.Time) ([]int64, error) {
+	rows, err := r.DB.Query(`SELECT id FROM players WHERE is_guest AND created_at < $1 ORDER BY id;`, createdBefore)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query guests: %v", err)
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan guest: %v", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// DeleteGuest deletes a guest, cascading to their sessions, friendships and
+// other rows. Games they played keep their stored username. It returns false
+// when the user has been upgraded or is already gone.
+func (r *UserRepo) DeleteGuest(userID int64) (bool, error) {
+	result, err := r.DB.Exec(`DELETE FROM players WHERE id = $1 AND is_guest;`, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete guest: %v", err)
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
 // scanUser is a helper that scans a row into a User struct
-func scanUser(row interface{ Scan(dest ...any) error }) (*User, error) {
-	var user User
+func scanUser(row interface{ Scan(dest ...any) error }) (*domain.User, error) {
+	var user domain.User
 	err := row.Scan(
 		&user.ID,
 		&user.Username,
 		&user.Name,
 		&user.AvatarURL,
 		&user.Email,
-		&user.GoogleID,
 		&user.IsVerified,
 		&user.PasswordHash,
 		&user.GamesPlayed,
@@ -99,6 +117,11 @@ func scanUser(row interface{ Scan(dest ...any) error }) (*User, error) {
 		&user.GamesDrawn,
 		&user.Rating,
 		&user.CreatedAt,
+		&user.Role,
+		&user.Banned,
+		&user.SuspendedUntil,
+		&user.IsGuest,
+		&user.TOTPEnabled,
 	)
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -109,10 +132,10 @@ func scanUser(row interface{ Scan(dest ...any) error }) (*User, error) {
 	return &user, nil
 }
 
-const userSelectFields = `id, username, COALESCE(name, '') as name, COALESCE(avatar_url, '') as avatar_url, email, google_id, is_verified, password_hash, games_played, games_won, games_drawn, rating, created_at`
+const userSelectFields = `id, username, COALESCE(name, '') as name, COALESCE(avatar_url, '') as avatar_url, email, is_verified, password_hash, games_played, games_won, games_drawn, rating, created_at, role, banned, suspended_until, is_guest, totp_enabled`
 
 // GetUserByUsername retrieves a user by username
-func (r *UserRepo) GetUserByUsername(username string) (*User, error) {
+func (r *UserRepo) GetUserByUsername(username string) (*domain.User, error) {
 	query := `SELECT ` + userSelectFields + ` FROM players WHERE username = $1::text;`
 	user, err := scanUser(r.DB.QueryRow(query, username))
 	if err != nil {
@@ -122,7 +145,7 @@ func (r *UserRepo) GetUserByUsername(username string) (*User, error) {
 }
 
 // GetUserByEmail retrieves a user by email
-func (r *UserRepo) GetUserByEmail(email string) (*User, error) {
+func (r *UserRepo) GetUserByEmail(email string) (*domain.User, error) {
 	query := `SELECT ` + userSelectFields + ` FROM players WHERE email = $1::text;`
 	user, err := scanUser(r.DB.QueryRow(query, email))
 	if err != nil {
@@ -132,7 +155,7 @@ func (r *UserRepo) GetUserByEmail(email string) (*User, error) {
 }
 
 // GetUserByIdentifier retrieves a user by username OR email
-func (r *UserRepo) GetUserByIdentifier(identifier string) (*User, error) {
+func (r *UserRepo) GetUserByIdentifier(identifier string) (*domain.User, error) {
 	query := `SELECT ` + userSelectFields + ` FROM players WHERE username = $1::text OR email = $1::text;`
 	user, err := scanUser(r.DB.QueryRow(query, identifier))
 	if err != nil {
@@ -141,32 +164,8 @@ func (r *UserRepo) GetUserByIdentifier(identifier string) (*User, error) {
 	return user, nil
 }
 
-// UpdateUserGoogleID updates a user's Google ID based on their email
-func (r *UserRepo) UpdateUserGoogleID(email, googleID string) error {
-	query := `
-	UPDATE players
-	SET google_id = $2, is_verified = TRUE
-	WHERE email = $1;
-	`
-	_, err := r.DB.Exec(query, email, googleID)
-	if err != nil {
-		return fmt.Errorf("failed to update google id: %v", err)
-	}
-	return nil
-}
-
-// GetUserByGoogleID retrieves a user by Google ID
-func (r *UserRepo) GetUserByGoogleID(googleID string) (*User, error) {
-	query := `SELECT ` + userSelectFields + ` FROM players WHERE google_id = $1::text;`
-	user, err := scanUser(r.DB.QueryRow(query, googleID))
-	if err != nil {
-		return nil, fmt.Errorf("failed to get user: %v", err)
-	}
-	return user, nil
-}
-
 // GetUserByID retrieves a user by ID
-func (r *UserRepo) GetUserByID(userID int64) (*User, error) {
+func (r *UserRepo) GetUserByID(userID int64) (*domain.User, error) {
 	query := `SELECT ` + userSelectFields + ` FROM players WHERE id = $1;`
 	user, err := scanUser(r.DB.QueryRow(query, userID))
 	if err != nil {
@@ -195,7 +194,7 @@ func (r *UserRepo) UpdateAvatar(userID int64, avatarURL string) error {
 	return nil
 }
 
-func (r *UserRepo) GetLeaderboard() ([]PlayerStats, error) {
+func (r *UserRepo) GetLeaderboard() ([]domain.PlayerStats, error) {
 	query := `
 	SELECT 
 		ROW_NUMBER() OVER (ORDER BY rating DESC, games_won DESC, username ASC) AS rank,
@@ -204,6 +203,7 @@ func (r *UserRepo) GetLeaderboard() ([]PlayerStats, error) {
 		games_won,
 		games_played - games_won - games_drawn AS losses
 	FROM players
+	WHERE NOT is_guest
 	ORDER BY rating DESC, games_won DESC, username ASC;
 	`
 
@@ -213,9 +213,9 @@ func (r *UserRepo) GetLeaderboard() ([]PlayerStats, error) {
 	}
 	defer rows.Close()
 
-	leaderboard := make([]PlayerStats, 0)
+	leaderboard := make([]domain.PlayerStats, 0)
 	for rows.Next() {
-		var stats PlayerStats
+		var stats domain.PlayerStats
 		if err := rows.Scan(&stats.Rank, &stats.Username, &stats.Rating, &stats.Wins, &stats.Losses); err != nil {
 			return nil, fmt.Errorf("failed to scan leaderboard row: %v", err)
 		}
@@ -224,3 +224,75 @@ func (r *UserRepo) GetLeaderboard() ([]PlayerStats, error) {
 
 	return leaderboard, nil
 }
+
+// SetEmailVerified marks the user's email address as confirmed
+func (r *UserRepo) SetEmailVerified(userID int64) error {
+	query := `UPDATE players SET is_verified = TRUE WHERE id = $1;`
+	_, err := r.DB.Exec(query, userID)
+	if err != nil {
+		return fmt.Errorf("failed to mark email verified: %v", err)
+	}
+	return nil
+}
+
+// UpdatePassword replaces the user's password hash
+func (r *UserRepo) UpdatePassword(userID int64, passwordHash string) error {
+	query := `UPDATE players SET password_hash = $2 WHERE id = $1;`
+	_, err := r.DB.Exec(query, userID, passwordHash)
+	if err != nil {
+		return fmt.Errorf("failed to update password: %v", err)
+	}
+	return nil
+}
+
+// GetTwoFactor loads the user's TOTP state (nil when the user doesn't exist)
+func (r *UserRepo) GetTwoFactor(userID int64) (*domain.TwoFactor, error) {
+	query := `SELECT totp_secret, totp_enabled, totp_recovery_codes, totp_last_step FROM players WHERE id = $1;`
+	var tf domain.TwoFactor
+	var codes string
+	err := r.DB.QueryRow(query, userID).Scan(&tf.Secret, &tf.Enabled, &codes, &tf.LastStep)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get two-factor state: %v", err)
+	}
+	if codes != "" {
+		tf.RecoveryCodes = strings.Split(codes, ",")
+	}
+	return &tf, nil
+}
+
+// SaveTwoFactor replaces the user's TOTP state
+func (r *UserRepo) SaveTwoFactor(userID int64, tf domain.TwoFactor) error {
+	query := `
+	UPDATE players
+	SET totp_secret = $2, totp_enabled = $3, totp_recovery_codes = $4, totp_last_step = $5
+	WHERE id = $1;
+	`
+	_, err := r.DB.Exec(query, userID, tf.Secret, tf.Enabled, strings.Join(tf.RecoveryCodes, ","), tf.LastStep)
+	if err != nil {
+		return fmt.Errorf("failed to save two-factor state: %v", err)
+	}
+	return nil
+}
+
+// SetUserRole changes a user's role (domain.RoleUser or domain.RoleAdmin)
+func (r *UserRepo) SetUserRole(userID int64, role string) error {
+	query := `UPDATE players SET role = $2 WHERE id = $1;`
+	_, err := r.DB.Exec(query, userID, role)
+	if err != nil {
+		return fmt.Errorf("failed to update role: %v", err)
+	}
+	return nil
+}
+
+// SetUserBan sets the ban flag and suspension end (nil clears the suspension)
+func (r *UserRepo) SetUserBan(userID int64, banned bool, suspendedUntil *time.Time) error {
+	query := `UPDATE players SET banned = $2, suspended_until = $3 WHERE id = $1;`
+	_, err := r.DB.Exec(query, userID, banned, suspendedUntil)
+	if err != nil {
+		return fmt.Errorf("failed to update ban: %v", err)
+	}
+	return nil
+}
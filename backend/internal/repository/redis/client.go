@@ -2,10 +2,10 @@ package redis
 
 import (
 	"context"
-	"log"
 	"time"
 
 	"github.com/iamasit07/connect4/backend/internal/config"
+	"github.com/iamasit07/connect4/backend/internal/logging"
 	"github.com/redis/go-redis/v9"
 )
 
@@ -29,13 +29,13 @@ func InitRedis() error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 	if err := RedisClient.Ping(ctx).Err(); err != nil {
-		log.Printf("[REDIS] Warning: Could not connect to Redis: %v. Falling back to PostgreSQL only.", err)
+		logging.For("redis").Warn("Could not connect to Redis, falling back to PostgreSQL only", logging.Err(err))
 		redisEnabled = false
 		return nil
 	}
 
 	redisEnabled = true
-	log.Println("[REDIS] Connected successfully")
+	logging.For("redis").Info("Connected successfully")
 	return nil
 }
 
@@ -0,0 +1,131 @@
+// Package repository defines the storage interfaces used above the
+// persistence layer. The postgres package provides the production
+// implementations and the memory package thread-safe in-memory ones for
+// tests and local development.
+package repository
+
+import (
+	"time"
+
+	"github.com/iamasit07/connect4/backend/internal/domain"
+)
+
+// GameRepository stores finished games and the player stats they affect
+type GameRepository interface {
+	SaveGame(gameID string, player1ID int64, player1Username string, player2ID *int64, player2Username string, winnerID *int64, winnerUsername string, reason string, totalMoves, durationSeconds int, createdAt, finishedAt time.Time, boardState [][]int, rated bool, chat []domain.ChatMessage, moves []domain.MoveRecord) error
+	GetGameByID(gameID string) (*domain.GameResult, error)
+	GetUserGameHistory(userID int64) ([]domain.GameResult, error)
+	GetGameBoard(gameID string) ([][]int, error)
+	GetGameChat(gameID string) ([]domain.ChatMessage, error)
+	VoidGame(gameID string) error // reverses the game's rating changes once
+	GetRatedGamesSince(since time.Time) ([]domain.GameResult, error) // non-voided rated PvP games, oldest first
+	CountRatedOpponents(userID int64, since time.Time) (map[int64]int, error) // opponent ID → rated games since
+	DeleteBotGames(userID int64) (int64, error) // removes the player's games against the bot
+}
+
+// FriendRepository stores friendships and blocks. Rows are directed (see
+// domain.Friendship); lookups return (nil, nil) when no row matches.
+type FriendRepository interface {
+	GetFriendship(userID, friendID int64) (*domain.Friendship, error)
+	SetFriendship(userID, friendID int64, status string) error
+	BlockUser(userID, targetID int64, blockSpectating bool) error
+	DeleteFriendship(userID, friendID int64) error
+	ListFriends(userID int64) ([]domain.Friend, error)
+	GetBlocks(userID int64) ([]domain.Friendship, error) // blocks placed by or on userID
+}
+
+// ChatRepository stores reported chat messages for moderators
+type ChatRepository interface {
+	SaveChatReport(report domain.ChatReport) error
+}
+
+// UserRepository stores player accounts. Lookups return (nil, nil) when no user matches.
+type UserRepository interface {
+	CreateUser(username, name, passwordHash string, email, avatarURL string) (int64, error)
+	GetUserByID(userID int64) (*domain.User, error)
+	GetUserByUsername(username string) (*domain.User, error)
+	GetUserByEmail(email string) (*domain.User, error)
+	GetUserByIdentifier(identifier string) (*domain.User, error)
+	UpdateProfile(userID int64, name string) error
+	UpdateAvatar(userID int64, avatarURL string) error
+	GetLeaderboard() ([]domain.PlayerStats, error)
+	SetUserRole(userID int64, role string) error
+	SetUserBan(userID int64, banned bool, suspendedUntil *time.Time) error
+	CreateGuest(username string) (int64, error)
+	UpgradeGuest(userID int64, username, name, email, passwordHash string) error // fails unless the user is a guest
+	ListGuestIDs(createdBefore time.Time) ([]int64, error) // guests created before the cutoff, oldest first
+	DeleteGuest(userID int64) (bool, error) // false when the user is no longer a guest
+	SetEmailVerified(userID int64) error
+	UpdatePassword(userID int64, passwordHash string) error
+	GetTwoFactor(userID int64) (*domain.TwoFactor, error) // nil when the user doesn't exist
+	SaveTwoFactor(userID int64, tf domain.TwoFactor) error
+}
+
+// AccountTokenRepository stores single-use email verification and password reset tokens
+type AccountTokenRepository interface {
+	CreateAccountToken(token domain.AccountToken) error // drops the user's unused tokens for the same purpose
+	ConsumeAccountToken(purpose, tokenHash string, now time.Time) (*domain.AccountToken, error) // marks it used; nil when unknown, used or expired
+}
+
+// IdentityRepository stores the external login providers linked to each
+// player. GetIdentity returns (nil, nil) when nothing matches.
+type IdentityRepository interface {
+	LinkIdentity(identity domain.UserIdentity) error // fails when the provider account or the user's slot for that provider is taken
+	GetIdentity(provider, subject string) (*domain.UserIdentity, error)
+	ListIdentities(userID int64) ([]domain.UserIdentity, error) // oldest first
+	UnlinkIdentity(userID int64, provider string) (bool, error) // false when nothing was linked
+}
+
+// APIKeyRepository stores personal API keys by their keyed hash.
+// GetAPIKeyByHash returns (nil, nil) when nothing matches.
+type APIKeyRepository interface {
+	CreateAPIKey(key domain.APIKey) (int64, error)
+	GetAPIKeyByHash(keyHash string) (*domain.APIKey, error)
+	ListAPIKeys(userID int64) ([]domain.APIKey, error) // newest first
+	DeleteAPIKey(userID, keyID int64) (bool, error)    // false when the user has no such key
+	TouchAPIKey(keyID int64, usedAt time.Time) error   // records last use
+}
+
+// AdminRepository stores the moderation audit log
+type AdminRepository interface {
+	LogAdminAction(action domain.AdminAction) error
+	ListAdminActions(limit int) ([]domain.AdminAction, error) // newest first
+}
+
+// IntegrityRepository stores the win-trading review queue. GetLatestFlag and
+// GetFlag return (nil, nil) when nothing matches.
+type IntegrityRepository interface {
+	GetLatestFlag(kind string, userID, opponentID int64) (*domain.IntegrityFlag, error) // opponentID is 0 for engine flags
+	SaveFlag(flag *domain.IntegrityFlag) error // inserts when ID is 0, updates otherwise
+	GetFlag(id int64) (*domain.IntegrityFlag, error)
+	ListFlags(status string, limit int) ([]domain.IntegrityFlag, error) // highest score first; "" lists all
+	ReviewFlag(id int64, status string, reviewerID int64, at time.Time) error
+}
+
+// AnalysisRepository stores the engine analyser's per-game, per-player results
+type AnalysisRepository interface {
+	GetUnanalyzedGames(limit int) ([]domain.RecordedGame, error) // games with a move list not yet analysed, oldest first
+	SaveGameAnalysis(gameID string, analyses []domain.PlayerGameAnalysis) error // also marks the game analysed
+	GetPlayerAnalyses(userID int64, limit int) ([]domain.PlayerGameAnalysis, error) // newest game first
+	GetBandStats(minRating, maxRating int, excludeUserID int64) (domain.BandStats, error)
+}
+
+// SessionRepository stores login sessions and refresh tokens
+type SessionRepository interface {
+	CreateSession(userID int64, sessionID, deviceInfo, ipAddress string, expiresAt time.Time) error
+	GetSessionByID(sessionID string) (*domain.UserSession, error)
+	ListActiveSessions(userID int64) ([]domain.UserSession, error) // most recently used first
+	DeactivateAllUserSessions(userID int64) error
+	DeactivateSession(sessionID string) error
+	UpdateSessionActivity(sessionID string) error
+	GetUserSessionHistory(userID int64, limit int) ([]domain.UserSession, error)
+	CleanupOldSessions(olderThanDays int) (int64, error)
+	GetUserIPAddresses(userID int64) ([]string, error) // distinct IPs the user has logged in from
+	// Refresh token methods
+	StoreRefreshToken(tokenID string, userID int64, sessionID string, expiresAt time.Time) error
+	GetRefreshToken(tokenID string) (*domain.RefreshToken, error)
+	RevokeRefreshToken(tokenID string) error
+	RevokeAllUserRefreshTokens(userID int64) error
+	RevokeSessionRefreshTokens(sessionID string) error
+	CleanupOldRefreshTokens(olderThanDays int) (int64, error)
+}
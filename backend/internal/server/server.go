@@ -0,0 +1,321 @@
+// Package server wires repositories, services and transport handlers into a
+// single HTTP router. It is shared by cmd/api and the end-to-end tests so
+// both run exactly the same routes.
+package server
+
+import (
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/iamasit07/connect4/backend/internal/clock"
+	"github.com/iamasit07/connect4/backend/internal/config"
+	"github.com/iamasit07/connect4/backend/internal/domain"
+	"github.com/iamasit07/connect4/backend/internal/logging"
+	"github.com/iamasit07/connect4/backend/internal/mail"
+	"github.com/iamasit07/connect4/backend/internal/oauth"
+	"github.com/iamasit07/connect4/backend/internal/ratelimit"
+	"github.com/iamasit07/connect4/backend/internal/repository"
+	"github.com/iamasit07/connect4/backend/internal/service/account"
+	"github.com/iamasit07/connect4/backend/internal/service/admin"
+	"github.com/iamasit07/connect4/backend/internal/service/apikey"
+	"github.com/iamasit07/connect4/backend/internal/service/cleanup"
+	"github.com/iamasit07/connect4/backend/internal/service/friends"
+	"github.com/iamasit07/connect4/backend/internal/service/game"
+	"github.com/iamasit07/connect4/backend/internal/service/integrity"
+	"github.com/iamasit07/connect4/backend/internal/service/matchmaking"
+	"github.com/iamasit07/connect4/backend/internal/service/presence"
+	"github.com/iamasit07/connect4/backend/internal/service/session"
+	"github.com/iamasit07/connect4/backend/internal/service/twofactor"
+	transportHttp "github.com/iamasit07/connect4/backend/internal/transport/http"
+	"github.com/iamasit07/connect4/backend/internal/transport/http/middleware"
+	"github.com/iamasit07/connect4/backend/internal/transport/websocket"
+)
+
+// Stores groups the persistence layer (Postgres in production, memory in tests)
+type Stores struct {
+	Games      repository.GameRepository
+	Users      repository.UserRepository
+	Sessions   repository.SessionRepository
+	Chats      repository.ChatRepository
+	Friends    repository.FriendRepository
+	Admin      repository.AdminRepository
+	Flags      repository.IntegrityRepository
+	Analyses   repository.AnalysisRepository
+	Tokens     repository.AccountTokenRepository
+	Identities repository.IdentityRepository
+	APIKeys    repository.APIKeyRepository
+	RateLimits ratelimit.Store // nil keeps rate limit buckets in memory
+}
+
+type Server struct {
+	Router           *gin.Engine
+	SessionManager   *game.SessionManager
+	ConnManager      *websocket.ConnectionManager
+	MatchmakingQueue *matchmaking.MatchmakingQueue
+	AuthService      *session.AuthService
+	CleanupWorker    *cleanup.Worker
+	Integrity        *integrity.Detector
+	EngineAnalyzer   *integrity.EngineAnalyzer
+}
+
+// New builds services and handlers on top of the given stores and starts the
+// matchmaking listener. cache may be nil when Redis is disabled. clk drives
+// every game and matchmaking timer (clock.Real outside tests).
+func New(cfg *config.Config, stores Stores, cache session.CacheRepository, clk clock.Clock) *Server {
+	// Initialize Services (Business Logic Layer)
+	gameService := game.NewService(stores.Games)
+	sessionManager := game.NewSessionManager(stores.Games, clk, TimeoutsFromConfig(cfg))
+	if stores.Chats != nil {
+		sessionManager.SetChatReportStore(stores.Chats)
+	}
+
+	authService := session.NewAuthService(stores.Sessions, cache)
+	connManager := websocket.NewConnectionManager()
+
+	// Define timeout callback for matchmaking
+	onMatchmakingTimeout := func(userID int64) {
+		connManager.SendMessage(userID, domain.ServerMessage{Type: "queue_timeout"})
+	}
+	matchmakingQueue := matchmaking.NewMatchmakingQueue(cfg.MatchmakingTimeout, clk, onMatchmakingTimeout)
+
+	go matchmaking.MatchMakingListener(matchmakingQueue, sessionManager)
+
+	// Friends, presence and challenges
+	friendsService := friends.NewService(stores.Friends, stores.Users)
+	matchmakingQueue.SetBlockChecker(friendsService)
+	sessionManager.SetBlockChecker(friendsService)
+
+	// Win-trading detection and the rated pairing limit
+	detector := integrity.NewDetector(stores.Games, stores.Sessions, stores.Flags, clk, integrity.DefaultConfig(cfg.IntegrityWindow, cfg.RatedPairDailyLimit))
+	matchmakingQueue.SetPairingThrottle(detector)
+	sessionManager.SetPairingThrottle(detector)
+	engineAnalyzer := integrity.NewEngineAnalyzer(stores.Analyses, stores.Users, stores.Flags, clk, integrity.DefaultEngineConfig(cfg.EngineAnalysisDepth))
+
+	presenceService := presence.NewService(connManager, matchmakingQueue, sessionManager)
+	presenceService.SetChangeCallback(func(userID int64, status presence.Status) {
+		friendIDs, err := friendsService.FriendIDs(userID)
+		if err != nil {
+			logging.For("presence").Error("Failed to load friends", logging.UserID(userID), logging.Err(err))
+			return
+		}
+		for _, friendID := range friendIDs {
+			connManager.SendMessage(friendID, domain.ServerMessage{Type: "presence_update", UserID: userID, Presence: string(status)})
+		}
+	})
+	onChallengeExpired := func(ch *friends.Challenge) {
+		for _, id := range []int64{ch.FromID, ch.ToID} {
+			connManager.SendMessage(id, domain.ServerMessage{Type: "challenge_expired", ChallengeID: ch.ID})
+		}
+	}
+	challenges := friends.NewChallenges(clk, cfg.ChallengeTimeout, onChallengeExpired)
+
+	// Initialize HTTP Handlers (API Layer)
+	authHandler := transportHttp.NewAuthHandler(stores.Users, stores.Sessions, connManager, cache, authService, sessionManager)
+	historyHandler := transportHttp.NewHistoryHandler(stores.Games)
+	oauthHandler := transportHttp.NewOAuthHandler(stores.Users, stores.Sessions, stores.Identities, oauth.NewRegistry(cfg.OAuthConfig), connManager, authService)
+	sessionsHandler := transportHttp.NewSessionsHandler(authService, connManager)
+	friendsHandler := transportHttp.NewFriendsHandler(friendsService, presenceService, connManager)
+	wsHandler := websocket.NewHandler(connManager, matchmakingQueue, sessionManager, gameService, authService, presenceService, friendsService, challenges)
+	watchHandler := transportHttp.NewWatchHandler(sessionManager)
+	adminService := admin.NewService(stores.Users, stores.Games, stores.Admin, authService, connManager, sessionManager, clk)
+	adminService.SetIntegrity(stores.Flags, detector, engineAnalyzer)
+	adminHandler := transportHttp.NewAdminHandler(adminService)
+	accountService := account.NewService(stores.Users, stores.Tokens, MailerFromConfig(cfg), authService, connManager, clk, account.Config{
+		BaseURL:   cfg.FrontendURL,
+		VerifyTTL: cfg.EmailVerifyTTL,
+		ResetTTL:  cfg.PasswordResetTTL,
+	})
+	authHandler.SetVerifier(accountService)
+	oauthHandler.SetVerifier(accountService)
+	accountHandler := transportHttp.NewAccountHandler(accountService)
+	twoFactorService := twofactor.NewService(stores.Users, clk, "Connect 4")
+	authHandler.SetTwoFactor(twoFactorService)
+	twoFactorHandler := transportHttp.NewTwoFactorHandler(twoFactorService, authService)
+	apiKeyService := apikey.NewService(stores.APIKeys, stores.Users, clk)
+	wsHandler.SetAPIKeys(apiKeyService)
+	apiKeysHandler := transportHttp.NewAPIKeysHandler(apiKeyService, connManager)
+
+	// Rate limits and login lockout
+	rateLimitStore := stores.RateLimits
+	if rateLimitStore == nil {
+		rateLimitStore = ratelimit.NewMemoryStore()
+	}
+	limiter := ratelimit.NewLimiter(rateLimitStore, clk)
+	authHandler.SetLockout(ratelimit.NewLockout(limiter, cfg.LoginLockout, "lockout"), ratelimit.NewLockout(limiter, cfg.LoginAccountLockout, "account-lockout"))
+	wsHandler.SetRateLimits(limiter, cfg.RateLimits)
+
+	// Setup Gin Router
+	router := gin.New()
+	router.Use(middleware.Tracing(), middleware.RequestID(), gin.Recovery())
+	router.Use(middleware.SecurityHeadersMiddleware())
+	router.Use(middleware.CORSMiddleware())
+	router.Use(middleware.RateLimit(limiter, cfg.RateLimits))
+
+	// Auth middleware for protected routes. API keys only reach the routes
+	// listed here, and only with the scope given; the WebSocket needs "play".
+	authMW := middleware.AuthMiddleware(authService, apiKeyService, middleware.APIKeyRoutes{
+		"GET /api/auth/me":     domain.ScopeRead,
+		"GET /api/friends":     domain.ScopeRead,
+		"GET /api/watch":       domain.ScopeRead,
+		"GET /api/history":     domain.ScopeHistory,
+		"GET /api/history/:id": domain.ScopeHistory,
+	})
+
+	// Public Auth Routes
+	router.POST("/api/auth/register", authHandler.Register)
+	router.POST("/api/auth/login", authHandler.Login)
+	router.POST("/api/auth/login/2fa", authHandler.LoginTwoFactor)
+	router.POST("/api/auth/refresh", authHandler.RefreshToken)
+	router.POST("/api/auth/guest", authHandler.Guest)
+	router.POST("/api/auth/verify-email", accountHandler.VerifyEmail)
+	router.POST("/api/auth/forgot-password", accountHandler.ForgotPassword)
+	router.POST("/api/auth/reset-password", accountHandler.ResetPassword)
+	router.GET("/api/leaderboard", authHandler.Leaderboard)
+
+	// OAuth Routes (public)
+	router.GET("/api/auth/google/login", oauthHandler.GoogleLogin)
+	router.GET("/api/auth/google/callback", oauthHandler.GoogleCallback)
+	router.POST("/api/auth/google/complete", oauthHandler.CompleteSignup)
+	router.GET("/api/auth/providers", oauthHandler.ListProviders)
+	router.GET("/api/auth/oauth/:provider/login", oauthHandler.Login)
+	router.GET("/api/auth/oauth/:provider/callback", oauthHandler.Callback)
+	router.POST("/api/auth/oauth/complete", oauthHandler.CompleteSignup)
+
+	// Protected Routes
+	protected := router.Group("/")
+	protected.Use(authMW)
+	{
+		protected.POST("/api/auth/logout", authHandler.Logout)
+		protected.GET("/api/auth/me", authHandler.Me)
+		protected.POST("/api/auth/guest/upgrade", authHandler.UpgradeGuest)
+		protected.POST("/api/auth/verify-email/resend", accountHandler.ResendVerification)
+		protected.POST("/api/auth/2fa/enroll", twoFactorHandler.Enroll)
+		protected.POST("/api/auth/2fa/confirm", twoFactorHandler.Confirm)
+		protected.POST("/api/auth/2fa/disable", twoFactorHandler.Disable)
+		protected.POST("/api/auth/2fa/recovery-codes", twoFactorHandler.RegenerateRecoveryCodes)
+		protected.GET("/api/auth/identities", oauthHandler.ListIdentities)
+		protected.POST("/api/auth/identities/:provider", oauthHandler.StartLink)
+		protected.DELETE("/api/auth/identities/:provider", oauthHandler.Unlink)
+		protected.PUT("/api/auth/profile", authHandler.UpdateProfile)
+		protected.POST("/api/auth/avatar", authHandler.UploadAvatar)
+		protected.DELETE("/api/auth/avatar/remove", authHandler.RemoveAvatar)
+
+		// Game History Routes
+		protected.GET("/api/history", historyHandler.GetHistory)
+		protected.GET("/api/history/:id", historyHandler.GetGameDetails)
+		protected.GET("/api/sessions", authHandler.GetSessionHistory)
+		protected.GET("/api/sessions/active", sessionsHandler.ListActive)
+		protected.DELETE("/api/sessions/:id", sessionsHandler.Revoke)
+
+		// API Key Routes
+		protected.GET("/api/keys", apiKeysHandler.List)
+		protected.POST("/api/keys", apiKeysHandler.Create)
+		protected.DELETE("/api/keys/:id", apiKeysHandler.Revoke)
+
+		// Watch / Spectator Routes
+		protected.GET("/api/watch", watchHandler.GetLiveGames)
+
+		// Friends Routes
+		protected.GET("/api/friends", friendsHandler.ListFriends)
+		protected.POST("/api/friends/requests", friendsHandler.SendRequest)
+		protected.POST("/api/friends/requests/:id/accept", friendsHandler.AcceptRequest)
+		protected.POST("/api/friends/requests/:id/decline", friendsHandler.DeclineRequest)
+		protected.DELETE("/api/friends/:id", friendsHandler.RemoveFriend)
+		protected.POST("/api/friends/:id/block", friendsHandler.Block)
+		protected.DELETE("/api/friends/:id/block", friendsHandler.Unblock)
+	}
+
+	// Admin Routes (moderation, every action is audit-logged)
+	admins := router.Group("/api/admin")
+	admins.Use(authMW, middleware.RequireRole(stores.Users, domain.RoleAdmin))
+	{
+		admins.GET("/actions", adminHandler.ListActions)
+		admins.POST("/users/:id/ban", adminHandler.Ban)
+		admins.POST("/users/:id/suspend", adminHandler.Suspend)
+		admins.DELETE("/users/:id/ban", adminHandler.Unban)
+		admins.PUT("/users/:id/role", adminHandler.SetRole)
+		admins.POST("/games/:id/terminate", adminHandler.TerminateGame)
+		admins.POST("/games/:id/void", adminHandler.VoidGame)
+		admins.GET("/flags", adminHandler.ListFlags)
+		admins.POST("/flags/scan", adminHandler.ScanFlags)
+		admins.POST("/flags/analyze", adminHandler.AnalyzeGames)
+		admins.GET("/flags/analyze", adminHandler.AnalysisStatus)
+		admins.POST("/flags/:id/review", adminHandler.ReviewFlag)
+	}
+
+	// WebSocket Route (auth handled inside the WS handler itself)
+	router.GET("/ws", wsHandler.HandleWebSocket)
+
+	// Serve uploaded files (avatars)
+	router.Static("/uploads", "./uploads")
+
+	serveFrontend(router)
+
+	return &Server{
+		Router:           router,
+		SessionManager:   sessionManager,
+		ConnManager:      connManager,
+		MatchmakingQueue: matchmakingQueue,
+		AuthService:      authService,
+		CleanupWorker:    cleanup.NewWorker(sessionManager, stores.Sessions, stores.Users, stores.Games, clk, time.Duration(cfg.GuestSessionTTLHours)*time.Hour),
+		Integrity:        detector,
+		EngineAnalyzer:   engineAnalyzer,
+	}
+}
+
+// TimeoutsFromConfig maps the configured timer durations onto game.Timeouts
+func TimeoutsFromConfig(cfg *config.Config) game.Timeouts {
+	return game.Timeouts{
+		Turn:       cfg.TurnTimeout,
+		Disconnect: cfg.DisconnectTimeout,
+		PostGame:   cfg.PostGameTimeout,
+		Rematch:    cfg.RematchTimeout,
+		DrawOffer:  cfg.DrawOfferTimeout,
+	}
+}
+
+// MailerFromConfig sends through SMTP when SMTP_HOST is set, otherwise writes
+// messages to MAIL_DIR (or the log) for local development
+func MailerFromConfig(cfg *config.Config) mail.Mailer {
+	if cfg.SMTPHost != "" {
+		return mail.NewSMTPMailer(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.MailFrom)
+	}
+	return mail.NewFileMailer(cfg.MailDir, cfg.MailFrom)
+}
+
+// serveFrontend serves the static React build (SPA fallback) when present
+func serveFrontend(router *gin.Engine) {
+	if _, err := os.Stat("./static"); err != nil {
+		return
+	}
+
+	router.Static("/assets", "./static/assets")
+
+	// Serve known static file extensions directly
+	router.GET("/", func(c *gin.Context) {
+		c.File("./static/index.html")
+	})
+
+	// SPA fallback: serve index.html for all unmatched routes
+	router.NoRoute(func(c *gin.Context) {
+		path := "./static" + c.Request.URL.Path
+
+		// Serve actual static files if they exist
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			c.File(path)
+			return
+		}
+
+		// For asset requests that don't exist, return 404
+		if strings.HasPrefix(c.Request.URL.Path, "/assets/") || strings.HasSuffix(c.Request.URL.Path, ".css") || strings.HasSuffix(c.Request.URL.Path, ".js") {
+			c.Status(http.StatusNotFound)
+			return
+		}
+
+		// SPA fallback
+		c.File("./static/index.html")
+	})
+}
@@ -0,0 +1,197 @@
+// Package account implements email verification and password reset. Both use
+// single-use tokens that are mailed to the user; only a keyed hash is stored.
+package account
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/iamasit07/connect4/backend/internal/clock"
+	"github.com/iamasit07/connect4/backend/internal/domain"
+	"github.com/iamasit07/connect4/backend/internal/logging"
+	"github.com/iamasit07/connect4/backend/internal/mail"
+	"github.com/iamasit07/connect4/backend/internal/repository"
+	"github.com/iamasit07/connect4/backend/pkg/auth"
+)
+
+var (
+	ErrUserNotFound    = errors.New("user not found")
+	ErrNoEmail         = errors.New("account has no email address")
+	ErrAlreadyVerified = errors.New("email is already verified")
+	ErrInvalidToken    = errors.New("invalid or expired token")
+)
+
+// SessionRevoker ends a user's logins (implemented by session.AuthService)
+type SessionRevoker interface {
+	InvalidateAllUserSessions(userID int64) error
+	RevokeAllUserRefreshTokens(userID int64) error
+}
+
+// Disconnector closes a user's WebSocket (implemented by websocket.ConnectionManager)
+type Disconnector interface {
+	DisconnectUser(userID int64, reason string)
+}
+
+// Config sets where links point and how long tokens stay valid
+type Config struct {
+	BaseURL   string // frontend origin; links go to /verify-email and /reset-password
+	VerifyTTL time.Duration
+	ResetTTL  time.Duration
+}
+
+type Service struct {
+	users    repository.UserRepository
+	tokens   repository.AccountTokenRepository
+	mailer   mail.Mailer
+	sessions SessionRevoker
+	conns    Disconnector
+	clock    clock.Clock
+	cfg      Config
+}
+
+func NewService(users repository.UserRepository, tokens repository.AccountTokenRepository, mailer mail.Mailer, sessions SessionRevoker, conns Disconnector, clk clock.Clock, cfg Config) *Service {
+	return &Service{users: users, tokens: tokens, mailer: mailer, sessions: sessions, conns: conns, clock: clk, cfg: cfg}
+}
+
+// SendVerification mails the user a fresh verification link, replacing any earlier one
+func (s *Service) SendVerification(userID int64) error {
+	user, err := s.users.GetUserByID(userID)
+	if err != nil {
+		return fmt.Errorf("failed to load user: %v", err)
+	}
+	if user == nil {
+		return ErrUserNotFound
+	}
+	if !user.Email.Valid {
+		return ErrNoEmail
+	}
+	if user.IsVerified {
+		return ErrAlreadyVerified
+	}
+
+	token, err := s.issue(userID, domain.TokenVerifyEmail, s.cfg.VerifyTTL)
+	if err != nil {
+		return err
+	}
+	s.send(mail.Message{
+		To:      user.Email.String,
+		Subject: "Verify your Connect 4 email",
+		Body: fmt.Sprintf("Hi %s,\n\nConfirm your email address by opening this link:\n\n%s\n\nThe link expires in %s.\n",
+			user.Username, s.link("/verify-email", token), s.cfg.VerifyTTL),
+	})
+	return nil
+}
+
+// VerifyEmail consumes a verification token and marks its owner's email verified
+func (s *Service) VerifyEmail(token string) error {
+	t, err := s.consume(domain.TokenVerifyEmail, token)
+	if err != nil {
+		return err
+	}
+	if err := s.users.SetEmailVerified(t.UserID); err != nil {
+		return fmt.Errorf("failed to verify email: %v", err)
+	}
+	logging.For("auth").Info("Email verified", logging.UserID(t.UserID))
+	return nil
+}
+
+// ForgotPassword mails a reset link when the email belongs to a registered
+// account. Unknown emails succeed silently so callers can't probe for accounts.
+func (s *Service) ForgotPassword(email string) error {
+	user, err := s.users.GetUserByEmail(email)
+	if err != nil {
+		return fmt.Errorf("failed to load user: %v", err)
+	}
+	if user == nil || user.IsGuest {
+		return nil
+	}
+
+	token, err := s.issue(user.ID, domain.TokenResetPassword, s.cfg.ResetTTL)
+	if err != nil {
+		return err
+	}
+	s.send(mail.Message{
+		To:      email,
+		Subject: "Reset your Connect 4 password",
+		Body: fmt.Sprintf("Hi %s,\n\nSomeone asked to reset your password. If it was you, open this link:\n\n%s\n\nThe link expires in %s. If you didn't ask, you can ignore this email.\n",
+			user.Username, s.link("/reset-password", token), s.cfg.ResetTTL),
+	})
+	return nil
+}
+
+// ResetPassword consumes a reset token, sets the new password and signs the
+// user out everywhere. The caller validates password strength.
+func (s *Service) ResetPassword(token, password string) error {
+	t, err := s.consume(domain.TokenResetPassword, token)
+	if err != nil {
+		return err
+	}
+
+	hash, err := auth.HashPassword(password)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %v", err)
+	}
+	if err := s.users.UpdatePassword(t.UserID, hash); err != nil {
+		return err
+	}
+	// The link arrived by email, so the address is confirmed too
+	if err := s.users.SetEmailVerified(t.UserID); err != nil {
+		logging.For("auth").Error("Failed to mark email verified", logging.UserID(t.UserID), logging.Err(err))
+	}
+
+	if err := s.sessions.InvalidateAllUserSessions(t.UserID); err != nil {
+		logging.For("auth").Error("Failed to invalidate sessions", logging.UserID(t.UserID), logging.Err(err))
+	}
+	if err := s.sessions.RevokeAllUserRefreshTokens(t.UserID); err != nil {
+		logging.For("auth").Error("Failed to revoke refresh tokens", logging.UserID(t.UserID), logging.Err(err))
+	}
+	s.conns.DisconnectUser(t.UserID, "Your password was changed")
+	logging.For("auth").Info("Password reset", logging.UserID(t.UserID))
+	return nil
+}
+
+// issue creates and stores a new token, returning the plain value to mail out
+func (s *Service) issue(userID int64, purpose string, ttl time.Duration) (string, error) {
+	token := auth.GenerateToken()
+	now := s.clock.Now()
+	err := s.tokens.CreateAccountToken(domain.AccountToken{
+		UserID:    userID,
+		Purpose:   purpose,
+		TokenHash: auth.HashAccountToken(purpose, token),
+		ExpiresAt: now.Add(ttl),
+		CreatedAt: now,
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+func (s *Service) consume(purpose, token string) (*domain.AccountToken, error) {
+	if token == "" {
+		return nil, ErrInvalidToken
+	}
+	t, err := s.tokens.ConsumeAccountToken(purpose, auth.HashAccountToken(purpose, token), s.clock.Now())
+	if err != nil {
+		return nil, err
+	}
+	if t == nil {
+		return nil, ErrInvalidToken
+	}
+	return t, nil
+}
+
+func (s *Service) link(path, token string) string {
+	return s.cfg.BaseURL + path + "?token=" + url.QueryEscape(token)
+}
+
+// send delivers in the background so a slow mail server doesn't hold up the request
+func (s *Service) send(msg mail.Message) {
+	go func() {
+		if err := s.mailer.Send(msg); err != nil {
+			logging.For("mail").Error("Failed to send email", "subject", msg.Subject, logging.Err(err))
+		}
+	}()
+}
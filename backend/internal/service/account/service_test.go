@@ -0,0 +1,245 @@
+package account
+
+import (
+	"errors"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/iamasit07/connect4/backend/internal/clock"
+	"github.com/iamasit07/connect4/backend/internal/config"
+	"github.com/iamasit07/connect4/backend/internal/domain"
+	"github.com/iamasit07/connect4/backend/internal/mail"
+	"github.com/iamasit07/connect4/backend/internal/repository/memory"
+	"github.com/iamasit07/connect4/backend/internal/service/session"
+	"github.com/iamasit07/connect4/backend/internal/testutil"
+	"github.com/iamasit07/connect4/backend/pkg/auth"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	verifyTTL   = 24 * time.Hour
+	resetTTL    = time.Hour
+	newPassword = "N3w-Passw0rd!"
+)
+
+var linkToken = regexp.MustCompile(`token=(\S+)`)
+
+// outbox collects mail sent in the background
+type outbox chan mail.Message
+
+func (o outbox) Send(msg mail.Message) error {
+	o <- msg
+	return nil
+}
+
+// sockets records the users whose WebSockets were closed
+type sockets []int64
+
+func (s *sockets) DisconnectUser(userID int64, reason string) {
+	*s = append(*s, userID)
+}
+
+// testEnv is the service over in-memory stores, with real session revocation
+type testEnv struct {
+	svc      *Service
+	users    *memory.UserRepo
+	sessions *session.AuthService
+	clock    *clock.Fake
+	mail     outbox
+	closed   *sockets
+	userIDs  []int64 // alice, bob
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+	testutil.SetConfig(t, config.Config{JWTSecret: "test-secret", PasswordHashCost: bcrypt.MinCost, RefreshTokenTTLDays: 1, AccessTokenTTLMinutes: 15})
+	e := &testEnv{users: memory.NewUserRepo(), clock: clock.NewFake(time.Now()), mail: make(outbox, 4), closed: &sockets{}}
+	e.userIDs = testutil.Users(t, e.users, "alice", "bob")
+	e.sessions = session.NewAuthService(memory.NewSessionRepo(), nil)
+	e.svc = NewService(e.users, memory.NewAccountTokenRepo(), e.mail, e.sessions, e.closed, e.clock, Config{
+		BaseURL:   "https://connect4.test",
+		VerifyTTL: verifyTTL,
+		ResetTTL:  resetTTL,
+	})
+	return e
+}
+
+// issue mails alice a token for purpose ("verify" or "reset") and returns it
+func (e *testEnv) issue(t *testing.T, purpose string) string {
+	t.Helper()
+	var err error
+	if purpose == "verify" {
+		err = e.svc.SendVerification(e.userIDs[0])
+	} else {
+		err = e.svc.ForgotPassword("alice@example.com")
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case msg := <-e.mail:
+		m := linkToken.FindStringSubmatch(msg.Body)
+		if m == nil {
+			t.Fatalf("no token in %q", msg.Body)
+		}
+		token, err := url.QueryUnescape(m[1])
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	case <-time.After(time.Second):
+		t.Fatal("no email sent")
+		return ""
+	}
+}
+
+// use spends a token on purpose's action
+func (e *testEnv) use(purpose, token string) error {
+	if purpose == "verify" {
+		return e.svc.VerifyEmail(token)
+	}
+	return e.svc.ResetPassword(token, newPassword)
+}
+
+// signIn opens a session for the user and returns its refresh token
+func (e *testEnv) signIn(t *testing.T, userID int64, sessionID string) string {
+	t.Helper()
+	if err := e.sessions.SetSession(&domain.UserSession{UserID: userID, SessionID: sessionID, ExpiresAt: time.Now().Add(time.Hour), IsActive: true}); err != nil {
+		t.Fatal(err)
+	}
+	_, refresh, err := e.sessions.GenerateTokenPair(userID, "", sessionID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return refresh
+}
+
+func TestTokens(t *testing.T) {
+	// Each case mails a token, takes the steps in before, then spends it
+	tests := []struct {
+		name    string
+		issued  string // purpose the token was mailed for
+		used    string // purpose it is spent on
+		before  string // "use", "wait", "almost" (wait until just before expiry) or "reissue"
+		wantErr error
+	}{
+		{"verify", "verify", "verify", "", nil},
+		{"reset", "reset", "reset", "", nil},
+		{"verify reused", "verify", "verify", "use", ErrInvalidToken},
+		{"reset reused", "reset", "reset", "use", ErrInvalidToken},
+		{"verify just before expiry", "verify", "verify", "almost", nil},
+		{"reset just before expiry", "reset", "reset", "almost", nil},
+		{"verify expired", "verify", "verify", "wait", ErrInvalidToken},
+		{"reset expired", "reset", "reset", "wait", ErrInvalidToken},
+		{"verify replaced by a newer link", "verify", "verify", "reissue", ErrInvalidToken},
+		{"reset replaced by a newer link", "reset", "reset", "reissue", ErrInvalidToken},
+		{"verify token used to reset", "verify", "reset", "", ErrInvalidToken},
+		{"reset token used to verify", "reset", "verify", "", ErrInvalidToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestEnv(t)
+			token := e.issue(t, tt.issued)
+			ttl := verifyTTL
+			if tt.issued == "reset" {
+				ttl = resetTTL
+			}
+			switch tt.before {
+			case "use":
+				if err := e.use(tt.issued, token); err != nil {
+					t.Fatal(err)
+				}
+			case "wait":
+				e.clock.Advance(ttl)
+			case "almost":
+				e.clock.Advance(ttl - time.Second)
+			case "reissue":
+				e.issue(t, tt.issued)
+			}
+			// Snapshot the account to check that a rejected token changes nothing
+			before, _ := e.users.GetUserByID(e.userIDs[0])
+
+			if err := e.use(tt.used, token); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("use = %v, want %v", err, tt.wantErr)
+			}
+
+			user, _ := e.users.GetUserByID(e.userIDs[0])
+			if tt.wantErr != nil {
+				if user.IsVerified != before.IsVerified || user.PasswordHash != before.PasswordHash {
+					t.Error("a rejected token still changed the account")
+				}
+				return
+			}
+			if !user.IsVerified {
+				t.Error("email not verified")
+			}
+			if changed := auth.CheckPasswordHash(newPassword, user.PasswordHash); changed != (tt.used == "reset") {
+				t.Errorf("password changed = %v, want %v", changed, tt.used == "reset")
+			}
+		})
+	}
+}
+
+func TestResetPasswordRevokesEverySession(t *testing.T) {
+	e := newTestEnv(t)
+	alice, bob := e.userIDs[0], e.userIDs[1]
+	refresh := map[string]string{
+		"alice-laptop": e.signIn(t, alice, "alice-laptop"),
+		"alice-phone":  e.signIn(t, alice, "alice-phone"),
+		"bob-laptop":   e.signIn(t, bob, "bob-laptop"),
+	}
+
+	if err := e.use("reset", e.issue(t, "reset")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		sessionID string
+		userID    int64
+		wantAlive bool
+	}{
+		{"alice-laptop", alice, false},
+		{"alice-phone", alice, false},
+		{"bob-laptop", bob, true},
+	}
+	for _, tt := range tests {
+		s, err := e.sessions.GetSession(tt.sessionID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if alive := s != nil && s.IsActive; alive != tt.wantAlive {
+			t.Errorf("%s active = %v, want %v", tt.sessionID, alive, tt.wantAlive)
+		}
+		if _, _, err := e.sessions.ValidateAndRefresh(refresh[tt.sessionID]); (err == nil) != tt.wantAlive {
+			t.Errorf("%s refresh = %v, want it to work: %v", tt.sessionID, err, tt.wantAlive)
+		}
+	}
+	if len(*e.closed) != 1 || (*e.closed)[0] != alice {
+		t.Errorf("closed sockets of %v, want only alice's", *e.closed)
+	}
+}
+
+func TestInvalidTokens(t *testing.T) {
+	e := newTestEnv(t)
+	e.issue(t, "verify")
+	for _, token := range []string{"", "not-a-token"} {
+		if err := e.svc.VerifyEmail(token); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("VerifyEmail(%q) = %v, want ErrInvalidToken", token, err)
+		}
+	}
+}
+
+func TestForgotPasswordUnknownEmail(t *testing.T) {
+	e := newTestEnv(t)
+	if err := e.svc.ForgotPassword("nobody@example.com"); err != nil {
+		t.Fatalf("ForgotPassword = %v, want silent success", err)
+	}
+	select {
+	case msg := <-e.mail:
+		t.Errorf("mail sent for an unknown address: %+v", msg)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
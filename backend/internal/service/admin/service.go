@@ -0,0 +1,267 @@
+// Package admin implements the moderation actions behind the admin API. Every
+// action is recorded in the audit log.
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/iamasit07/connect4/backend/internal/clock"
+	"github.com/iamasit07/connect4/backend/internal/domain"
+	"github.com/iamasit07/connect4/backend/internal/repository"
+)
+
+var (
+	ErrUserNotFound  = errors.New("user not found")
+	ErrGameNotFound  = errors.New("game not found")
+	ErrGameNotLive   = errors.New("game is not live")
+	ErrSelf          = errors.New("you cannot moderate your own account")
+	ErrInvalidRole   = errors.New("invalid role")
+	ErrInvalidPeriod = errors.New("suspension must be at least one hour")
+	ErrNotRated      = errors.New("game was not rated")
+	ErrAlreadyVoided = errors.New("game is already voided")
+	ErrFlagNotFound  = errors.New("flag not found")
+	ErrInvalidStatus = errors.New("invalid flag status")
+	ErrAnalysisBusy  = errors.New("engine analysis is already running")
+)
+
+// SessionRevoker ends a user's logins (implemented by session.AuthService)
+type SessionRevoker interface {
+	InvalidateAllUserSessions(userID int64) error
+	RevokeAllUserRefreshTokens(userID int64) error
+}
+
+// Disconnector closes a user's WebSocket (implemented by websocket.ConnectionManager)
+type Disconnector interface {
+	DisconnectUser(userID int64, reason string)
+}
+
+// GameTerminator ends live games (implemented by game.SessionManager)
+type GameTerminator interface {
+	TerminateGame(gameID string) error
+}
+
+// Scanner runs the win-trading detector on demand (implemented by integrity.Detector)
+type Scanner interface {
+	Scan() (int, error)
+}
+
+// Analyzer runs the engine-assistance analyser in the background (implemented by integrity.EngineAnalyzer)
+type Analyzer interface {
+	Trigger() (domain.EngineAnalysisRun, bool)
+	Status() domain.EngineAnalysisRun
+}
+
+// Actor is the admin performing an action
+type Actor struct {
+	ID       int64
+	Username string
+}
+
+type Service struct {
+	users    repository.UserRepository
+	games    repository.GameRepository
+	audit    repository.AdminRepository
+	sessions SessionRevoker
+	conns    Disconnector
+	live     GameTerminator
+	clock    clock.Clock
+	flags    repository.IntegrityRepository
+	scanner  Scanner
+	analyzer Analyzer
+}
+
+func NewService(users repository.UserRepository, games repository.GameRepository, audit repository.AdminRepository, sessions SessionRevoker, conns Disconnector, live GameTerminator, clk clock.Clock) *Service {
+	return &Service{users: users, games: games, audit: audit, sessions: sessions, conns: conns, live: live, clock: clk}
+}
+
+// SetIntegrity enables the review queue fed by the win-trading detector and engine analyser
+func (s *Service) SetIntegrity(flags repository.IntegrityRepository, scanner Scanner, analyzer Analyzer) {
+	s.flags = flags
+	s.scanner = scanner
+	s.analyzer = analyzer
+}
+
+// Ban bans a user indefinitely and signs them out everywhere
+func (s *Service) Ban(admin Actor, userID int64, reason string) error {
+	if err := s.restrict(admin, userID, true, nil, "Your account has been banned"); err != nil {
+		return err
+	}
+	return s.record(admin, domain.AdminActionBan, &userID, "", reason, "")
+}
+
+// Suspend blocks a user from logging in for d and signs them out everywhere
+func (s *Service) Suspend(admin Actor, userID int64, d time.Duration, reason string) (time.Time, error) {
+	if d < time.Hour {
+		return time.Time{}, ErrInvalidPeriod
+	}
+	until := s.clock.Now().Add(d).UTC()
+	if err := s.restrict(admin, userID, false, &until, "Your account has been suspended"); err != nil {
+		return time.Time{}, err
+	}
+	return until, s.record(admin, domain.AdminActionSuspend, &userID, "", reason, "until "+until.Format(time.RFC3339))
+}
+
+// Unban lifts a ban or suspension
+func (s *Service) Unban(admin Actor, userID int64, reason string) error {
+	if _, err := s.user(userID); err != nil {
+		return err
+	}
+	if err := s.users.SetUserBan(userID, false, nil); err != nil {
+		return err
+	}
+	return s.record(admin, domain.AdminActionUnban, &userID, "", reason, "")
+}
+
+// SetRole promotes or demotes a user
+func (s *Service) SetRole(admin Actor, userID int64, role, reason string) error {
+	if role != domain.RoleUser && role != domain.RoleAdmin {
+		return ErrInvalidRole
+	}
+	if userID == admin.ID {
+		return ErrSelf
+	}
+	if _, err := s.user(userID); err != nil {
+		return err
+	}
+	if err := s.users.SetUserRole(userID, role); err != nil {
+		return err
+	}
+	return s.record(admin, domain.AdminActionSetRole, &userID, "", reason, role)
+}
+
+// TerminateGame force-ends a live game without a result
+func (s *Service) TerminateGame(admin Actor, gameID, reason string) error {
+	if err := s.live.TerminateGame(gameID); err != nil {
+		return fmt.Errorf("%w: %v", ErrGameNotLive, err)
+	}
+	return s.record(admin, domain.AdminActionTerminateGame, nil, gameID, reason, "")
+}
+
+// VoidGame reverses the rating changes of a finished rated game
+func (s *Service) VoidGame(admin Actor, gameID, reason string) error {
+	game, err := s.games.GetGameByID(gameID)
+	if err != nil {
+		return err
+	}
+	if game == nil {
+		return ErrGameNotFound
+	}
+	if game.Voided {
+		return ErrAlreadyVoided
+	}
+	if !game.Rated {
+		return ErrNotRated
+	}
+	if err := s.games.VoidGame(gameID); err != nil {
+		return err
+	}
+	details := fmt.Sprintf("%s %+d, %s %+d", game.Player1Username, -game.Player1RatingChange, game.Player2Username, -game.Player2RatingChange)
+	return s.record(admin, domain.AdminActionVoidGame, nil, gameID, reason, details)
+}
+
+// Actions returns the most recent audit log entries, newest first
+func (s *Service) Actions(limit int) ([]domain.AdminAction, error) {
+	return s.audit.ListAdminActions(limit)
+}
+
+// Flags returns up to limit review-queue entries with the given status ("" for all)
+func (s *Service) Flags(status string, limit int) ([]domain.IntegrityFlag, error) {
+	if status != "" && status != domain.FlagOpen && status != domain.FlagDismissed && status != domain.FlagConfirmed {
+		return nil, ErrInvalidStatus
+	}
+	return s.flags.ListFlags(status, limit)
+}
+
+// ScanFlags runs the win-trading detector now and returns how many flags it raised
+func (s *Service) ScanFlags() (int, error) {
+	return s.scanner.Scan()
+}
+
+// AnalyzeGames starts the engine analyser over unanalysed games in the
+// background. It fails with ErrAnalysisBusy while a run is in progress.
+func (s *Service) AnalyzeGames() (domain.EngineAnalysisRun, error) {
+	run, started := s.analyzer.Trigger()
+	if !started {
+		return run, ErrAnalysisBusy
+	}
+	return run, nil
+}
+
+// AnalysisStatus returns the latest engine-analysis run
+func (s *Service) AnalysisStatus() domain.EngineAnalysisRun {
+	return s.analyzer.Status()
+}
+
+// ReviewFlag closes a flag as dismissed or confirmed. Confirming doesn't
+// punish anyone by itself; the admin follows up with a ban or void.
+func (s *Service) ReviewFlag(admin Actor, flagID int64, status, reason string) error {
+	if status != domain.FlagDismissed && status != domain.FlagConfirmed {
+		return ErrInvalidStatus
+	}
+	flag, err := s.flags.GetFlag(flagID)
+	if err != nil {
+		return err
+	}
+	if flag == nil {
+		return ErrFlagNotFound
+	}
+	if err := s.flags.ReviewFlag(flagID, status, admin.ID, s.clock.Now()); err != nil {
+		return err
+	}
+	details := fmt.Sprintf("%s flag %d %s", flag.Kind, flagID, status)
+	if flag.OpponentUsername != "" {
+		details += " (vs " + flag.OpponentUsername + ")"
+	}
+	return s.record(admin, domain.AdminActionReviewFlag, &flag.UserID, "", reason, details)
+}
+
+// restrict bans or suspends the user, then revokes their sessions and closes their socket
+func (s *Service) restrict(admin Actor, userID int64, banned bool, until *time.Time, message string) error {
+	if userID == admin.ID {
+		return ErrSelf
+	}
+	if _, err := s.user(userID); err != nil {
+		return err
+	}
+	if err := s.users.SetUserBan(userID, banned, until); err != nil {
+		return err
+	}
+	if err := s.sessions.InvalidateAllUserSessions(userID); err != nil {
+		return err
+	}
+	if err := s.sessions.RevokeAllUserRefreshTokens(userID); err != nil {
+		return err
+	}
+	s.conns.DisconnectUser(userID, message)
+	return nil
+}
+
+func (s *Service) user(userID int64) (*domain.User, error) {
+	user, err := s.users.GetUserByID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up user: %v", err)
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	return user, nil
+}
+
+func (s *Service) record(admin Actor, action string, userID *int64, gameID, reason, details string) error {
+	err := s.audit.LogAdminAction(domain.AdminAction{
+		AdminID:       admin.ID,
+		AdminUsername: admin.Username,
+		Action:        action,
+		TargetUserID:  userID,
+		TargetGameID:  gameID,
+		Reason:        reason,
+		Details:       details,
+		CreatedAt:     s.clock.Now(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record admin action: %v", err)
+	}
+	return nil
+}
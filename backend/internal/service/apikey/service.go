@@ -0,0 +1,176 @@
+// Package apikey implements personal API keys for scripted access. Keys are
+// scoped, may expire, and are stored only as a keyed hash.
+package apikey
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/iamasit07/connect4/backend/internal/clock"
+	"github.com/iamasit07/connect4/backend/internal/domain"
+	"github.com/iamasit07/connect4/backend/internal/logging"
+	"github.com/iamasit07/connect4/backend/internal/repository"
+	"github.com/iamasit07/connect4/backend/pkg/auth"
+)
+
+const (
+	// maxKeysPerUser caps how many keys one account can hold
+	maxKeysPerUser = 10
+
+	// hashPurpose keys the stored key hashes
+	hashPurpose = "api_key"
+
+	// touchInterval limits how often last_used_at is written for a busy key
+	touchInterval = time.Minute
+
+	// prefixLength is how much of a key is kept in the clear to identify it
+	prefixLength = len(auth.APIKeyPrefix) + 8
+)
+
+var (
+	ErrUserNotFound = errors.New("user not found")
+	ErrGuestAccount = errors.New("guest accounts can't create API keys")
+	ErrInvalidName  = errors.New("name must be 1-64 characters")
+	ErrInvalidScope = errors.New("scopes must be one or more of read, history, play")
+	ErrInvalidTTL   = errors.New("expiry can't be negative")
+	ErrTooManyKeys  = errors.New("API key limit reached")
+	ErrKeyNotFound  = errors.New("API key not found")
+	ErrInvalidKey   = errors.New("invalid or expired API key")
+)
+
+type Service struct {
+	keys  repository.APIKeyRepository
+	users repository.UserRepository
+	clock clock.Clock
+}
+
+func NewService(keys repository.APIKeyRepository, users repository.UserRepository, clk clock.Clock) *Service {
+	return &Service{keys: keys, users: users, clock: clk}
+}
+
+// Create issues a new key. ttl of zero means it never expires. The plain key
+// is returned once and can't be recovered later.
+func (s *Service) Create(userID int64, name string, scopes []string, ttl time.Duration) (string, *domain.APIKey, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > 64 {
+		return "", nil, ErrInvalidName
+	}
+	scopes, ok := normalizeScopes(scopes)
+	if !ok {
+		return "", nil, ErrInvalidScope
+	}
+	if ttl < 0 {
+		return "", nil, ErrInvalidTTL
+	}
+
+	user, err := s.users.GetUserByID(userID)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to load user: %v", err)
+	}
+	if user == nil {
+		return "", nil, ErrUserNotFound
+	}
+	if user.IsGuest {
+		return "", nil, ErrGuestAccount
+	}
+
+	existing, err := s.keys.ListAPIKeys(userID)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(existing) >= maxKeysPerUser {
+		return "", nil, ErrTooManyKeys
+	}
+
+	plain := auth.GenerateAPIKey()
+	now := s.clock.Now()
+	key := domain.APIKey{
+		UserID:    userID,
+		Name:      name,
+		Prefix:    plain[:prefixLength],
+		KeyHash:   auth.HashAccountToken(hashPurpose, plain),
+		Scopes:    scopes,
+		CreatedAt: now,
+	}
+	if ttl > 0 {
+		expiresAt := now.Add(ttl)
+		key.ExpiresAt = &expiresAt
+	}
+	key.ID, err = s.keys.CreateAPIKey(key)
+	if err != nil {
+		return "", nil, err
+	}
+	return plain, &key, nil
+}
+
+// List returns the user's keys, newest first
+func (s *Service) List(userID int64) ([]domain.APIKey, error) {
+	keys, err := s.keys.ListAPIKeys(userID)
+	if err != nil {
+		return nil, err
+	}
+	if keys == nil {
+		keys = []domain.APIKey{}
+	}
+	return keys, nil
+}
+
+// Revoke deletes one of the user's keys
+func (s *Service) Revoke(userID, keyID int64) error {
+	deleted, err := s.keys.DeleteAPIKey(userID, keyID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrKeyNotFound
+	}
+	return nil
+}
+
+// Authenticate resolves a presented key to the key and its owner. Expired
+// keys and keys of banned or suspended users are rejected.
+func (s *Service) Authenticate(plain string) (*domain.APIKey, *domain.User, error) {
+	key, err := s.keys.GetAPIKeyByHash(auth.HashAccountToken(hashPurpose, plain))
+	if err != nil {
+		return nil, nil, err
+	}
+	now := s.clock.Now()
+	if key == nil || (key.ExpiresAt != nil && !now.Before(*key.ExpiresAt)) {
+		return nil, nil, ErrInvalidKey
+	}
+
+	user, err := s.users.GetUserByID(key.UserID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load user: %v", err)
+	}
+	if user == nil || user.IsRestricted(now) {
+		return nil, nil, ErrInvalidKey
+	}
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= touchInterval {
+		if err := s.keys.TouchAPIKey(key.ID, now); err != nil {
+			logging.For("auth").Warn("Failed to record use of API key", "api_key_id", key.ID, logging.UserID(key.UserID), logging.Err(err))
+		}
+		key.LastUsedAt = &now
+	}
+	return key, user, nil
+}
+
+// normalizeScopes drops duplicates and orders scopes as domain.APIKeyScopes
+// does. It fails on an empty list or an unknown scope.
+func normalizeScopes(scopes []string) ([]string, bool) {
+	wanted := make(map[string]bool)
+	for _, scope := range scopes {
+		wanted[scope] = true
+	}
+	var out []string
+	for _, scope := range domain.APIKeyScopes {
+		if wanted[scope] {
+			out = append(out, scope)
+			delete(wanted, scope)
+		}
+	}
+	return out, len(out) > 0 && len(wanted) == 0
+}
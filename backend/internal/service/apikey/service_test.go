@@ -0,0 +1,145 @@
+package apikey
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/iamasit07/connect4/backend/internal/clock"
+	"github.com/iamasit07/connect4/backend/internal/config"
+	"github.com/iamasit07/connect4/backend/internal/domain"
+	"github.com/iamasit07/connect4/backend/internal/repository/memory"
+	"github.com/iamasit07/connect4/backend/internal/testutil"
+)
+
+// newService returns a service with one user, alice
+func newService(t *testing.T) (*Service, *memory.UserRepo, *clock.Fake, int64) {
+	t.Helper()
+	testutil.SetConfig(t, config.Config{JWTSecret: "test-secret"})
+	users := memory.NewUserRepo()
+	clk := clock.NewFake(time.Unix(1_700_000_000, 0))
+	return NewService(memory.NewAPIKeyRepo(), users, clk), users, clk, testutil.Users(t, users, "alice")[0]
+}
+
+// restrict bans the user outright, or suspends them for d when d > 0
+func restrict(t *testing.T, users *memory.UserRepo, clk *clock.Fake, userID int64, d time.Duration) {
+	t.Helper()
+	var until *time.Time
+	if d > 0 {
+		end := clk.Now().Add(d)
+		until = &end
+	}
+	if err := users.SetUserBan(userID, d == 0, until); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	tests := []struct {
+		name    string
+		ttl     time.Duration
+		action  string // ban, suspend (for an hour) or revoke, before waiting
+		wait    time.Duration
+		wantErr error
+	}{
+		{name: "fresh key", ttl: time.Hour},
+		{name: "no expiry", wait: 365 * 24 * time.Hour},
+		{name: "just before expiry", ttl: time.Hour, wait: time.Hour - time.Second},
+		{name: "at expiry", ttl: time.Hour, wait: time.Hour, wantErr: ErrInvalidKey},
+		{name: "owner banned", action: "ban", wantErr: ErrInvalidKey},
+		{name: "owner suspended", action: "suspend", wantErr: ErrInvalidKey},
+		{name: "suspension over", action: "suspend", wait: time.Hour},
+		{name: "revoked", action: "revoke", wantErr: ErrInvalidKey},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, users, clk, userID := newService(t)
+			plain, key, err := svc.Create(userID, "bot", []string{domain.ScopePlay}, tt.ttl)
+			if err != nil {
+				t.Fatal(err)
+			}
+			switch tt.action {
+			case "ban":
+				restrict(t, users, clk, userID, 0)
+			case "suspend":
+				restrict(t, users, clk, userID, time.Hour)
+			case "revoke":
+				if err := svc.Revoke(userID, key.ID); err != nil {
+					t.Fatal(err)
+				}
+			}
+			clk.Advance(tt.wait)
+
+			got, user, err := svc.Authenticate(plain)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Authenticate = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && (got.ID != key.ID || user.ID != userID) {
+				t.Errorf("Authenticate = key %d of user %d, want key %d of user %d", got.ID, user.ID, key.ID, userID)
+			}
+		})
+	}
+}
+
+func TestKeyStopsWorkingWhileOwnerSuspended(t *testing.T) {
+	svc, users, clk, userID := newService(t)
+	plain, _, err := svc.Create(userID, "bot", []string{domain.ScopePlay}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Steps run in order against the same key
+	steps := []struct {
+		name    string
+		suspend time.Duration
+		wait    time.Duration
+		wantErr error
+	}{
+		{name: "before the suspension"},
+		{name: "suspended for a day", suspend: 24 * time.Hour, wantErr: ErrInvalidKey},
+		{name: "a second before it ends", wait: 24*time.Hour - time.Second, wantErr: ErrInvalidKey},
+		{name: "once it ends", wait: time.Second},
+	}
+	for _, step := range steps {
+		if step.suspend > 0 {
+			restrict(t, users, clk, userID, step.suspend)
+		}
+		clk.Advance(step.wait)
+		if _, _, err := svc.Authenticate(plain); !errors.Is(err, step.wantErr) {
+			t.Errorf("%s: Authenticate = %v, want %v", step.name, err, step.wantErr)
+		}
+	}
+}
+
+func TestAuthenticateUnknownKey(t *testing.T) {
+	svc, _, _, userID := newService(t)
+	plain, _, err := svc.Create(userID, "bot", []string{domain.ScopeRead}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := svc.Authenticate(plain + "x"); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("Authenticate(altered key) = %v, want ErrInvalidKey", err)
+	}
+}
+
+func TestCreateRejects(t *testing.T) {
+	tests := []struct {
+		name    string
+		keyName string
+		scopes  []string
+		ttl     time.Duration
+		wantErr error
+	}{
+		{"negative expiry", "bot", []string{domain.ScopeRead}, -time.Hour, ErrInvalidTTL},
+		{"no scopes", "bot", nil, 0, ErrInvalidScope},
+		{"unknown scope", "bot", []string{"admin"}, 0, ErrInvalidScope},
+		{"blank name", "  ", []string{domain.ScopeRead}, 0, ErrInvalidName},
+	}
+	for _, tt := range tests {
+		svc, _, _, userID := newService(t)
+		if _, _, err := svc.Create(userID, tt.keyName, tt.scopes, tt.ttl); !errors.Is(err, tt.wantErr) {
+			t.Errorf("%s: Create = %v, want %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package bot
+
+import (
+	"math"
+
+	"github.com/iamasit07/connect4/backend/internal/domain"
+)
+
+// ScoreMoves scores every legal move for player with a full-window minimax
+// search of the given depth. Unlike CalculateBestMoveMinimax, which narrows
+// the window as it goes, every score is exact, so a played move can be
+// compared with the best one. Used to replay finished games for cheat detection.
+func ScoreMoves(board [][]domain.PlayerID, player domain.PlayerID, depth int) map[int]int {
+	opponent := getOpponent(player)
+	scores := make(map[int]int)
+	for _, col := range domain.GetValidMoves(board) {
+		testBoard, row, _ := domain.SimulateMove(board, col, player)
+		if _, won := domain.CheckWin(testBoard, row, col, player); won {
+			scores[col] = MINIMAX_WIN
+			continue
+		}
+		scores[col] = minimax(testBoard, depth-1, math.MinInt32, math.MaxInt32, false, player, opponent)
+	}
+	return scores
+}
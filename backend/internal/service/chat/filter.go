@@ -0,0 +1,51 @@
+// Package chat holds the moderation rules for in-game chat: text filtering
+// and per-user rate limiting. Delivery lives in the game package.
+package chat
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxMessageLength is the longest chat message accepted, in characters
+const MaxMessageLength = 200
+
+var (
+	ErrEmptyMessage   = errors.New("message is empty")
+	ErrMessageTooLong = errors.New("message is too long")
+	ErrLinkNotAllowed = errors.New("links are not allowed in chat")
+)
+
+// linkPattern catches URLs, bare domains ("example.com/...") and invite-style links
+var linkPattern = regexp.MustCompile(`(?i)(https?://|www\.|\b[a-z0-9-]+\.(com|net|org|io|gg|me|ru|xyz|co|app|dev|link|ly)\b)`)
+
+// blockedWords are masked with asterisks wherever they appear as whole words
+var blockedWords = []string{
+	"fuck", "fucking", "shit", "bitch", "bastard", "asshole", "dick", "cunt", "whore", "slut", "retard", "faggot", "nigger",
+}
+
+var profanityPattern = regexp.MustCompile(`(?i)\b(` + strings.Join(blockedWords, "|") + `)\b`)
+
+// controlChars strips characters that could break client rendering
+var controlChars = regexp.MustCompile(`[\x00-\x1f\x7f]`)
+
+// Sanitize validates a chat message and masks profanity. Messages with links
+// are rejected outright rather than masked.
+func Sanitize(text string) (string, error) {
+	text = strings.TrimSpace(controlChars.ReplaceAllString(text, " "))
+	if text == "" {
+		return "", ErrEmptyMessage
+	}
+	if utf8.RuneCountInString(text) > MaxMessageLength {
+		return "", ErrMessageTooLong
+	}
+	if linkPattern.MatchString(text) {
+		return "", ErrLinkNotAllowed
+	}
+
+	return profanityPattern.ReplaceAllStringFunc(text, func(word string) string {
+		return strings.Repeat("*", utf8.RuneCountInString(word))
+	}), nil
+}
@@ -0,0 +1,35 @@
+package chat
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSanitize(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr error
+	}{
+		{in: "  good game  ", want: "good game"},
+		{in: "Shit happens", want: "**** happens"},
+		{in: "shitake mushrooms", want: "shitake mushrooms"},
+		{in: "line\nbreak", want: "line break"},
+		{in: "   ", wantErr: ErrEmptyMessage},
+		{in: strings.Repeat("x", MaxMessageLength+1), wantErr: ErrMessageTooLong},
+		{in: "join discord.gg/abc", wantErr: ErrLinkNotAllowed},
+		{in: "see http://foo", wantErr: ErrLinkNotAllowed},
+		{in: "www.example", wantErr: ErrLinkNotAllowed},
+	}
+
+	for _, tt := range tests {
+		got, err := Sanitize(tt.in)
+		if err != tt.wantErr {
+			t.Errorf("Sanitize(%q) error = %v, want %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Sanitize(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
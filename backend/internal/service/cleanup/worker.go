@@ -1,20 +1,29 @@
 package cleanup
 
 import (
-	"log"
 	"time"
 
-	"github.com/iamasit07/connect4/backend/internal/repository/postgres"
+	"github.com/iamasit07/connect4/backend/internal/clock"
+	"github.com/iamasit07/connect4/backend/internal/logging"
+	"github.com/iamasit07/connect4/backend/internal/repository"
 	"github.com/iamasit07/connect4/backend/internal/service/game"
 )
 
+// guestGrace is how long a guest is kept after their session expires, so a
+// game still being played over an open socket has been saved
+const guestGrace = time.Hour
+
 type Worker struct {
 	SessionManager *game.SessionManager
-	SessionRepository *postgres.SessionRepo
+	SessionRepository repository.SessionRepository
+	Users    repository.UserRepository
+	Games    repository.GameRepository
+	Clock    clock.Clock
+	GuestTTL time.Duration
 }
 
-func NewWorker(sm *game.SessionManager, sr *postgres.SessionRepo) *Worker {
-	return &Worker{SessionManager: sm, SessionRepository: sr}
+func NewWorker(sm *game.SessionManager, sr repository.SessionRepository, users repository.UserRepository, games repository.GameRepository, clk clock.Clock, guestTTL time.Duration) *Worker {
+	return &Worker{SessionManager: sm, SessionRepository: sr, Users: users, Games: games, Clock: clk, GuestTTL: guestTTL}
 }
 
 // Start initiates the background ticker
@@ -28,22 +37,62 @@ func (w *Worker) Start() {
 			w.runCleanup()
 		}
 	}()
-	log.Println("[CLEANUP] Background worker started")
+	logging.For("cleanup").Info("Background worker started")
 }
 
 // runCleanup executes the actual cleanup logic
 func (w *Worker) runCleanup() {
-	log.Println("[CLEANUP] Starting scheduled cleanup task...")
+	logging.For("cleanup").Debug("Starting scheduled cleanup task")
 
 	w.SessionManager.CleanupOldSessions()
 
 	daysToKeep := 30 // Delete sessions older than 30 days
 	deletedCount, err := w.SessionRepository.CleanupOldSessions(daysToKeep)
 	if err != nil {
-		log.Printf("[CLEANUP] Error cleaning up DB sessions: %v", err)
+		logging.For("cleanup").Error("Error cleaning up DB sessions", logging.Err(err))
 	} else {
 		if deletedCount > 0 {
-			log.Printf("[CLEANUP] Removed %d expired sessions from database", deletedCount)
+			logging.For("cleanup").Info("Removed expired sessions from database", "count", deletedCount)
+		}
+	}
+
+	guests, err := w.deleteExpiredGuests()
+	if err != nil {
+		logging.For("cleanup").Error("Error deleting expired guests", logging.Err(err))
+	}
+	if guests > 0 {
+		logging.For("cleanup").Info("Deleted expired guest accounts", "count", guests)
+	}
+}
+
+// deleteExpiredGuests deletes guests that were never upgraded and whose
+// session has expired, along with their bot games. Casual games against
+// registered players stay in those players' history, matching their stats.
+func (w *Worker) deleteExpiredGuests() (int, error) {
+	ids, err := w.Users.ListGuestIDs(w.Clock.Now().Add(-w.GuestTTL - guestGrace))
+	if err != nil {
+		return 0, err
+	}
+
+	deleted := 0
+	for _, id := range ids {
+		sessions, err := w.SessionRepository.ListActiveSessions(id)
+		if err != nil {
+			return deleted, err
+		}
+		if len(sessions) > 0 {
+			continue
+		}
+		if _, err := w.Games.DeleteBotGames(id); err != nil {
+			return deleted, err
+		}
+		ok, err := w.Users.DeleteGuest(id)
+		if err != nil {
+			return deleted, err
+		}
+		if ok {
+			deleted++
 		}
 	}
+	return deleted, nil
 }
\ No newline at end of file
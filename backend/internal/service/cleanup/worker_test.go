@@ -0,0 +1,128 @@
+package cleanup
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/iamasit07/connect4/backend/internal/clock"
+	"github.com/iamasit07/connect4/backend/internal/repository/memory"
+)
+
+const testGuestTTL = 24 * time.Hour
+
+type fixture struct {
+	users    *memory.UserRepo
+	games    *memory.GameRepo
+	sessions *memory.SessionRepo
+	clock    *clock.Fake
+	worker   *Worker
+}
+
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+	users := memory.NewUserRepo()
+	f := &fixture{users: users, games: memory.NewGameRepo(users), sessions: memory.NewSessionRepo(), clock: clock.NewFake(time.Now())}
+	f.worker = NewWorker(nil, f.sessions, f.users, f.games, f.clock, testGuestTTL)
+	return f
+}
+
+func (f *fixture) guest(t *testing.T, name string) int64 {
+	t.Helper()
+	id, err := f.users.CreateGuest(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+// saveGame records a casual game player1 won; player2 nil is a bot game
+func (f *fixture) saveGame(t *testing.T, gameID string, player1 int64, player2 *int64) {
+	t.Helper()
+	now := f.clock.Now()
+	err := f.games.SaveGame(gameID, player1, "p1", player2, "p2", &player1, "p1", "connect_four", 7, 60, now, now, nil, false, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDeleteExpiredGuests(t *testing.T) {
+	f := newFixture(t)
+	member, err := f.users.CreateUser("member", "Member", "hash", "member@example.com", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expired := f.guest(t, "guest_expired")
+	online := f.guest(t, "guest_online")
+	upgraded := f.guest(t, "guest_upgraded")
+	if err := f.users.UpgradeGuest(upgraded, "upgraded", "Upgraded", "up@example.com", "hash"); err != nil {
+		t.Fatal(err)
+	}
+	// A session still valid (by the real clock the repository uses)
+	if err := f.sessions.CreateSession(online, "s1", "", "", time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	f.saveGame(t, "pvp", expired, &member)
+	f.saveGame(t, "bot", expired, nil)
+	f.saveGame(t, "kept", upgraded, &member)
+
+	f.clock.Advance(testGuestTTL + guestGrace + time.Minute)
+	deleted, err := f.worker.deleteExpiredGuests()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deleted != 1 {
+		t.Errorf("deleted %d guests, want 1", deleted)
+	}
+
+	tests := []struct {
+		name   string
+		userID int64
+		exists bool
+	}{
+		{"expired guest", expired, false},
+		{"guest with a live session", online, true},
+		{"upgraded guest", upgraded, true},
+		{"registered user", member, true},
+	}
+	for _, tt := range tests {
+		user, err := f.users.GetUserByID(tt.userID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if (user != nil) != tt.exists {
+			t.Errorf("%s: exists = %v, want %v", tt.name, user != nil, tt.exists)
+		}
+	}
+
+	history, err := f.games.GetUserGameHistory(member)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var gameIDs []string
+	for _, g := range history {
+		gameIDs = append(gameIDs, g.GameID)
+	}
+	slices.Sort(gameIDs)
+	if !slices.Equal(gameIDs, []string{"kept", "pvp"}) {
+		t.Errorf("member history = %v, want the games against both guests kept", gameIDs)
+	}
+	if g, _ := f.games.GetGameByID("bot"); g != nil {
+		t.Errorf("bot game %+v survived its guest", g)
+	}
+}
+
+func TestDeleteExpiredGuestsWaitsForGrace(t *testing.T) {
+	f := newFixture(t)
+	guest := f.guest(t, "guest_recent")
+
+	// The session has expired, but a game may still be finishing
+	f.clock.Advance(testGuestTTL + guestGrace/2)
+	deleted, err := f.worker.deleteExpiredGuests()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user, _ := f.users.GetUserByID(guest); deleted != 0 || user == nil {
+		t.Errorf("deleted %d guests within the grace period, want 0", deleted)
+	}
+}
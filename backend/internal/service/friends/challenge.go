@@ -0,0 +1,104 @@
+package friends
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/iamasit07/connect4/backend/internal/clock"
+	"github.com/iamasit07/connect4/backend/pkg/uid"
+)
+
+var (
+	ErrChallengePending  = errors.New("you already challenged this player")
+	ErrChallengeNotFound = errors.New("challenge not found or expired")
+)
+
+// Challenge is an open invitation from one friend to another to start a game
+type Challenge struct {
+	ID           string
+	FromID       int64
+	FromUsername string
+	ToID         int64
+	ToUsername   string
+	Rated        bool
+
+	timer clock.Timer
+}
+
+// Challenges tracks open challenges until they are answered or expire
+type Challenges struct {
+	mu       sync.Mutex
+	pending  map[string]*Challenge
+	clock    clock.Clock
+	timeout  time.Duration
+	onExpire func(*Challenge)
+}
+
+// NewChallenges creates a registry whose challenges expire after timeout, calling onExpire
+func NewChallenges(clk clock.Clock, timeout time.Duration, onExpire func(*Challenge)) *Challenges {
+	return &Challenges{
+		pending:  make(map[string]*Challenge),
+		clock:    clk,
+		timeout:  timeout,
+		onExpire: onExpire,
+	}
+}
+
+// Create opens a challenge. Only one open challenge per sender/recipient pair is allowed.
+func (c *Challenges) Create(fromID int64, fromUsername string, toID int64, toUsername string, rated bool) (*Challenge, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, ch := range c.pending {
+		if ch.FromID == fromID && ch.ToID == toID {
+			return nil, ErrChallengePending
+		}
+	}
+
+	ch := &Challenge{
+		ID:           uid.GenerateGameID(),
+		FromID:       fromID,
+		FromUsername: fromUsername,
+		ToID:         toID,
+		ToUsername:   toUsername,
+		Rated:        rated,
+	}
+	ch.timer = c.clock.AfterFunc(c.timeout, func() {
+		c.expire(ch.ID)
+	})
+	c.pending[ch.ID] = ch
+	return ch, nil
+}
+
+// Take removes and returns the challenge with id if it was sent to toID
+func (c *Challenges) Take(id string, toID int64) (*Challenge, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ch, ok := c.pending[id]
+	if !ok || ch.ToID != toID {
+		return nil, ErrChallengeNotFound
+	}
+	ch.timer.Stop()
+	delete(c.pending, id)
+	return ch, nil
+}
+
+// Timeout is how long a challenge stays open
+func (c *Challenges) Timeout() time.Duration {
+	return c.timeout
+}
+
+func (c *Challenges) expire(id string) {
+	c.mu.Lock()
+	ch, ok := c.pending[id]
+	if ok {
+		delete(c.pending, id)
+	}
+	c.mu.Unlock()
+
+	if ok && c.onExpire != nil {
+		c.onExpire(ch)
+	}
+}
@@ -0,0 +1,259 @@
+// Package friends implements friend requests, blocks and direct challenges
+// between friends.
+package friends
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/iamasit07/connect4/backend/internal/domain"
+	"github.com/iamasit07/connect4/backend/internal/repository"
+)
+
+var (
+	ErrUserNotFound     = errors.New("user not found")
+	ErrSelf             = errors.New("you cannot friend yourself")
+	ErrBlocked          = errors.New("this user is not accepting requests from you")
+	ErrAlreadyFriends   = errors.New("you are already friends")
+	ErrAlreadyRequested = errors.New("friend request already sent")
+	ErrNoRequest        = errors.New("no pending request from this user")
+	ErrNotFriends       = errors.New("you are not friends with this user")
+)
+
+type Service struct {
+	repo  repository.FriendRepository
+	users repository.UserRepository
+}
+
+func NewService(repo repository.FriendRepository, users repository.UserRepository) *Service {
+	return &Service{repo: repo, users: users}
+}
+
+// SendRequest sends a friend request to username. If they had already asked
+// the user, the request is accepted instead. Returns the target and the new relation.
+func (s *Service) SendRequest(userID int64, username string) (*domain.User, string, error) {
+	target, err := s.users.GetUserByUsername(username)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to look up user: %v", err)
+	}
+	if target == nil {
+		return nil, "", ErrUserNotFound
+	}
+	if target.ID == userID {
+		return nil, "", ErrSelf
+	}
+
+	mine, theirs, err := s.pair(userID, target.ID)
+	if err != nil {
+		return nil, "", err
+	}
+	if isStatus(mine, domain.FriendshipBlocked) || isStatus(theirs, domain.FriendshipBlocked) {
+		return nil, "", ErrBlocked
+	}
+	if isStatus(mine, domain.FriendshipAccepted) || isStatus(theirs, domain.FriendshipAccepted) {
+		return nil, "", ErrAlreadyFriends
+	}
+	if isStatus(mine, domain.FriendshipPending) {
+		return nil, "", ErrAlreadyRequested
+	}
+
+	if isStatus(theirs, domain.FriendshipPending) {
+		if err := s.repo.SetFriendship(target.ID, userID, domain.FriendshipAccepted); err != nil {
+			return nil, "", err
+		}
+		return target, domain.FriendAccepted, nil
+	}
+
+	if err := s.repo.SetFriendship(userID, target.ID, domain.FriendshipPending); err != nil {
+		return nil, "", err
+	}
+	return target, domain.FriendOutgoing, nil
+}
+
+// Accept accepts a pending request from requesterID
+func (s *Service) Accept(userID, requesterID int64) error {
+	req, err := s.repo.GetFriendship(requesterID, userID)
+	if err != nil {
+		return err
+	}
+	if !isStatus(req, domain.FriendshipPending) {
+		return ErrNoRequest
+	}
+	return s.repo.SetFriendship(requesterID, userID, domain.FriendshipAccepted)
+}
+
+// Decline rejects a pending request from requesterID
+func (s *Service) Decline(userID, requesterID int64) error {
+	req, err := s.repo.GetFriendship(requesterID, userID)
+	if err != nil {
+		return err
+	}
+	if !isStatus(req, domain.FriendshipPending) {
+		return ErrNoRequest
+	}
+	return s.repo.DeleteFriendship(requesterID, userID)
+}
+
+// Remove ends a friendship or withdraws a request the user sent. Blocks are left alone.
+func (s *Service) Remove(userID, friendID int64) error {
+	mine, theirs, err := s.pair(userID, friendID)
+	if err != nil {
+		return err
+	}
+
+	removed := false
+	if mine != nil && mine.Status != domain.FriendshipBlocked {
+		if err := s.repo.DeleteFriendship(userID, friendID); err != nil {
+			return err
+		}
+		removed = true
+	}
+	if isStatus(theirs, domain.FriendshipAccepted) {
+		if err := s.repo.DeleteFriendship(friendID, userID); err != nil {
+			return err
+		}
+		removed = true
+	}
+	if !removed {
+		return ErrNotFriends
+	}
+	return nil
+}
+
+// Block blocks targetID, dropping any friendship or request between the two.
+// With blockSpectating the target also can't watch the user's games.
+func (s *Service) Block(userID, targetID int64, blockSpectating bool) error {
+	if userID == targetID {
+		return ErrSelf
+	}
+	target, err := s.users.GetUserByID(targetID)
+	if err != nil {
+		return fmt.Errorf("failed to look up user: %v", err)
+	}
+	if target == nil {
+		return ErrUserNotFound
+	}
+
+	theirs, err := s.repo.GetFriendship(targetID, userID)
+	if err != nil {
+		return err
+	}
+	if theirs != nil && theirs.Status != domain.FriendshipBlocked {
+		if err := s.repo.DeleteFriendship(targetID, userID); err != nil {
+			return err
+		}
+	}
+	return s.repo.BlockUser(userID, targetID, blockSpectating)
+}
+
+// Unblock lifts a block the user placed on targetID
+func (s *Service) Unblock(userID, targetID int64) error {
+	mine, err := s.repo.GetFriendship(userID, targetID)
+	if err != nil {
+		return err
+	}
+	if !isStatus(mine, domain.FriendshipBlocked) {
+		return nil
+	}
+	return s.repo.DeleteFriendship(userID, targetID)
+}
+
+// List returns the user's friends list (without presence)
+func (s *Service) List(userID int64) ([]domain.Friend, error) {
+	friends, err := s.repo.ListFriends(userID)
+	if err != nil {
+		return nil, err
+	}
+	if friends == nil {
+		friends = []domain.Friend{}
+	}
+	return friends, nil
+}
+
+// FriendIDs returns the IDs of the user's accepted friends
+func (s *Service) FriendIDs(userID int64) ([]int64, error) {
+	friends, err := s.repo.ListFriends(userID)
+	if err != nil {
+		return nil, err
+	}
+	var ids []int64
+	for _, f := range friends {
+		if f.Relation == domain.FriendAccepted {
+			ids = append(ids, f.UserID)
+		}
+	}
+	return ids, nil
+}
+
+// AreFriends reports whether the two users are accepted friends
+func (s *Service) AreFriends(a, b int64) (bool, error) {
+	ab, ba, err := s.pair(a, b)
+	if err != nil {
+		return false, err
+	}
+	return isStatus(ab, domain.FriendshipAccepted) || isStatus(ba, domain.FriendshipAccepted), nil
+}
+
+// BlockedIDs returns the users the user has blocked or been blocked by.
+// Blocks apply both ways for matchmaking and chat.
+func (s *Service) BlockedIDs(userID int64) (map[int64]bool, error) {
+	blocks, err := s.repo.GetBlocks(userID)
+	if err != nil {
+		return nil, err
+	}
+	ids := make(map[int64]bool, len(blocks))
+	for _, b := range blocks {
+		if b.UserID == userID {
+			ids[b.FriendID] = true
+		} else {
+			ids[b.UserID] = true
+		}
+	}
+	return ids, nil
+}
+
+// IsBlocked reports whether either user has blocked the other
+func (s *Service) IsBlocked(a, b int64) (bool, error) {
+	ids, err := s.BlockedIDs(a)
+	if err != nil {
+		return false, err
+	}
+	return ids[b], nil
+}
+
+// CanWatch reports whether spectatorID may watch a game between playerIDs:
+// false if any player blocked them with blockSpectating
+func (s *Service) CanWatch(spectatorID int64, playerIDs ...int64) (bool, error) {
+	blocks, err := s.repo.GetBlocks(spectatorID)
+	if err != nil {
+		return false, err
+	}
+	for _, b := range blocks {
+		if b.FriendID != spectatorID || !b.BlockSpectating {
+			continue
+		}
+		for _, id := range playerIDs {
+			if b.UserID == id {
+				return false, nil
+			}
+		}
+	}
+	return true, nil
+}
+
+// pair loads the rows in both directions between userID and otherID
+func (s *Service) pair(userID, otherID int64) (mine, theirs *domain.Friendship, err error) {
+	mine, err = s.repo.GetFriendship(userID, otherID)
+	if err != nil {
+		return nil, nil, err
+	}
+	theirs, err = s.repo.GetFriendship(otherID, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return mine, theirs, nil
+}
+
+func isStatus(f *domain.Friendship, status string) bool {
+	return f != nil && f.Status == status
+}
@@ -0,0 +1,116 @@
+package friends
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/iamasit07/connect4/backend/internal/repository/memory"
+	"github.com/iamasit07/connect4/backend/internal/testutil"
+)
+
+// step is one action a player takes towards the other
+type step struct {
+	byB    bool   // taken by b towards a; otherwise by a towards b
+	action string // "request", "accept", "block", "block_spectating" or "unblock"
+}
+
+func (st step) run(s *Service, a, b int64) error {
+	from, to, toName := a, b, "b"
+	if st.byB {
+		from, to, toName = b, a, "a"
+	}
+	switch st.action {
+	case "request":
+		_, _, err := s.SendRequest(from, toName)
+		return err
+	case "accept":
+		return s.Accept(from, to)
+	case "block":
+		return s.Block(from, to, false)
+	case "block_spectating":
+		return s.Block(from, to, true)
+	case "unblock":
+		return s.Unblock(from, to)
+	}
+	return errors.New("unknown action " + st.action)
+}
+
+func TestBlockIsSymmetric(t *testing.T) {
+	tests := []struct {
+		name         string
+		steps        []step
+		wantBlocked  bool // whether each side sees a block towards the other
+		wantBWatches bool // whether b may watch a's games
+	}{
+		{"a blocks b", []step{{false, "block"}}, true, true},
+		{"a blocks b from spectating", []step{{false, "block_spectating"}}, true, false},
+		{"only the blocker can unblock", []step{{false, "block_spectating"}, {true, "unblock"}}, true, false},
+		{"a unblocks b", []step{{false, "block_spectating"}, {false, "unblock"}}, false, true},
+		{"mutual blocks need both lifted", []step{{false, "block"}, {true, "block"}, {false, "unblock"}}, true, true},
+		{"unblocking doesn't restore a friendship", []step{{false, "request"}, {true, "accept"}, {true, "block"}, {true, "unblock"}}, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users := memory.NewUserRepo()
+			ids := testutil.Users(t, users, "a", "b")
+			a, b := ids[0], ids[1]
+			s := NewService(memory.NewFriendRepo(users), users)
+			for _, st := range tt.steps {
+				if err := st.run(s, a, b); err != nil {
+					t.Fatalf("%+v: %v", st, err)
+				}
+			}
+
+			for _, pair := range [][2]int64{{a, b}, {b, a}} {
+				if blocked, err := s.IsBlocked(pair[0], pair[1]); err != nil || blocked != tt.wantBlocked {
+					t.Errorf("IsBlocked(%d, %d) = %v, %v, want %v", pair[0], pair[1], blocked, err, tt.wantBlocked)
+				}
+				if ids, err := s.BlockedIDs(pair[0]); err != nil || ids[pair[1]] != tt.wantBlocked {
+					t.Errorf("BlockedIDs(%d) = %v, %v, want %d listed: %v", pair[0], ids, err, pair[1], tt.wantBlocked)
+				}
+			}
+			if friends, _ := s.AreFriends(a, b); friends {
+				t.Error("AreFriends = true, want any friendship dropped by the block")
+			}
+			if watch, _ := s.CanWatch(b, a); watch != tt.wantBWatches {
+				t.Errorf("CanWatch(b, a) = %v, want %v", watch, tt.wantBWatches)
+			}
+			if watch, _ := s.CanWatch(a, b); !watch {
+				t.Error("CanWatch(a, b) = false, want spectating blocks to be one-way")
+			}
+			if _, _, err := s.SendRequest(b, "a"); errors.Is(err, ErrBlocked) != tt.wantBlocked {
+				t.Errorf("SendRequest(b, a) = %v, want blocked %v", err, tt.wantBlocked)
+			}
+		})
+	}
+}
+
+func TestBlockDropsRequests(t *testing.T) {
+	// a blocks and then unblocks b; no request survives in either direction
+	tests := []struct {
+		name    string
+		request step
+	}{
+		{"blocking the requester", step{true, "request"}},
+		{"blocking after requesting", step{false, "request"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users := memory.NewUserRepo()
+			ids := testutil.Users(t, users, "a", "b")
+			s := NewService(memory.NewFriendRepo(users), users)
+			for _, st := range []step{tt.request, {false, "block"}, {false, "unblock"}} {
+				if err := st.run(s, ids[0], ids[1]); err != nil {
+					t.Fatalf("%+v: %v", st, err)
+				}
+			}
+
+			for _, id := range ids {
+				if list, err := s.List(id); err != nil || len(list) != 0 {
+					t.Errorf("List(%d) = %+v, %v, want the request gone", id, list, err)
+				}
+			}
+		})
+	}
+}
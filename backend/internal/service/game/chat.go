@@ -0,0 +1,197 @@
+package game
+
+import (
+	"fmt"
+
+	"github.com/iamasit07/connect4/backend/internal/domain"
+	"github.com/iamasit07/connect4/backend/internal/logging"
+	"github.com/iamasit07/connect4/backend/internal/service/chat"
+)
+
+// maxChatHistory caps the chat kept per game; older lines are dropped from
+// history and from the saved transcript
+const maxChatHistory = 200
+
+// HandleChatMessage posts a chat line from a player (players channel) or a
+// spectator (spectators channel) and delivers it to everyone allowed to see it
+func (gs *GameSession) HandleChatMessage(userID int64, username, text string) error {
+	blocked := gs.sessionManager.blockedIDs(userID)
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+
+	channel, ok := gs.chatChannelFor(userID)
+	if !ok {
+		return fmt.Errorf("you are not in this game")
+	}
+
+	clean, err := chat.Sanitize(text)
+	if err != nil {
+		return err
+	}
+
+	gs.nextChatID++
+	msg := domain.ChatMessage{
+		ID:             gs.nextChatID,
+		GameID:         gs.GameID,
+		Channel:        channel,
+		SenderID:       userID,
+		SenderUsername: username,
+		Text:           clean,
+		CreatedAt:      gs.clock.Now(),
+	}
+
+	gs.Chat = append(gs.Chat, msg)
+	if len(gs.Chat) > maxChatHistory {
+		gs.Chat = append([]domain.ChatMessage(nil), gs.Chat[len(gs.Chat)-maxChatHistory:]...)
+	}
+
+	gs.broadcastEvent(domain.GameEvent{
+		Type:       domain.EventInfo,
+		Recipients: gs.chatRecipients(msg, blocked),
+		Payload: domain.ServerMessage{
+			Type:   "chat_message",
+			GameID: gs.GameID,
+			Chat:   &msg,
+		},
+	})
+
+	return nil
+}
+
+// HandleMute hides (or, with mute false, shows again) chat from targetID for userID only
+func (gs *GameSession) HandleMute(userID, targetID int64, mute bool) error {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+
+	if _, ok := gs.chatChannelFor(userID); !ok {
+		return fmt.Errorf("you are not in this game")
+	}
+	if targetID == userID {
+		return fmt.Errorf("cannot mute yourself")
+	}
+
+	msgType := "user_unmuted"
+	if mute {
+		if _, ok := gs.chatChannelFor(targetID); !ok {
+			return fmt.Errorf("user is not in this game")
+		}
+		if gs.Mutes[userID] == nil {
+			gs.Mutes[userID] = make(map[int64]bool)
+		}
+		gs.Mutes[userID][targetID] = true
+		msgType = "user_muted"
+	} else {
+		delete(gs.Mutes[userID], targetID)
+	}
+
+	gs.sendEvent(userID, domain.ServerMessage{
+		Type:   msgType,
+		GameID: gs.GameID,
+		UserID: targetID,
+	})
+	return nil
+}
+
+// HandleReport records a report against a chat message the reporter could see
+func (gs *GameSession) HandleReport(userID, messageID int64, reason string) error {
+	gs.mu.Lock()
+
+	if _, ok := gs.chatChannelFor(userID); !ok {
+		gs.mu.Unlock()
+		return fmt.Errorf("you are not in this game")
+	}
+
+	var reported *domain.ChatMessage
+	for i := range gs.Chat {
+		if gs.Chat[i].ID == messageID && gs.canSeeChannel(userID, gs.Chat[i].Channel) {
+			reported = &gs.Chat[i]
+			break
+		}
+	}
+	if reported == nil {
+		gs.mu.Unlock()
+		return fmt.Errorf("message not found")
+	}
+	if reported.SenderID == userID {
+		gs.mu.Unlock()
+		return fmt.Errorf("cannot report your own message")
+	}
+
+	if r := []rune(reason); len(r) > chat.MaxMessageLength {
+		reason = string(r[:chat.MaxMessageLength])
+	}
+	report := domain.ChatReport{
+		GameID:         gs.GameID,
+		MessageID:      reported.ID,
+		ReporterID:     userID,
+		ReportedUserID: reported.SenderID,
+		MessageText:    reported.Text,
+		Reason:         reason,
+		CreatedAt:      gs.clock.Now(),
+	}
+
+	gs.mu.Unlock()
+
+	var store ChatReportStore
+	if gs.sessionManager != nil {
+		gs.sessionManager.mu.RLock()
+		store = gs.sessionManager.chatReports
+		gs.sessionManager.mu.RUnlock()
+	}
+	if store == nil {
+		logging.For("chat").Info("Report on message (no report store configured)", logging.GameID(gs.GameID), logging.UserID(userID), "message_id", messageID)
+	} else if err := store.SaveChatReport(report); err != nil {
+		logging.For("chat").Error("Error saving report", logging.GameID(gs.GameID), logging.UserID(userID), logging.Err(err))
+		return fmt.Errorf("failed to save report")
+	}
+
+	gs.sendEvent(userID, domain.ServerMessage{
+		Type:   "report_received",
+		GameID: gs.GameID,
+	})
+	return nil
+}
+
+// chatChannelFor returns the channel the user writes to; ok is false for outsiders
+func (gs *GameSession) chatChannelFor(userID int64) (string, bool) {
+	if _, isPlayer := gs.PlayerMapping[userID]; isPlayer {
+		return domain.ChatChannelPlayers, true
+	}
+	if gs.Spectators[userID] {
+		return domain.ChatChannelSpectators, true
+	}
+	return "", false
+}
+
+// canSeeChannel reports whether the user may read a channel. Players never
+// see the spectators channel so spectators can't coach them.
+func (gs *GameSession) canSeeChannel(userID int64, channel string) bool {
+	writes, ok := gs.chatChannelFor(userID)
+	if !ok {
+		return false
+	}
+	return channel == domain.ChatChannelPlayers || writes == domain.ChatChannelSpectators
+}
+
+// chatRecipients returns who receives msg: everyone who can see its channel
+// except users who muted the sender or are blocked either way (senderBlocks)
+func (gs *GameSession) chatRecipients(msg domain.ChatMessage, senderBlocks map[int64]bool) []int64 {
+	var recipients []int64
+	for _, id := range gs.getAllParticipants() {
+		if gs.canSeeChannel(id, msg.Channel) && !gs.Mutes[id][msg.SenderID] && !senderBlocks[id] {
+			recipients = append(recipients, id)
+		}
+	}
+	return recipients
+}
+
+// chatHistoryFor returns the chat lines userID can see, minus muted and blocked senders
+func (gs *GameSession) chatHistoryFor(userID int64, blocked map[int64]bool) []domain.ChatMessage {
+	var history []domain.ChatMessage
+	for _, msg := range gs.Chat {
+		if gs.canSeeChannel(userID, msg.Channel) && !gs.Mutes[userID][msg.SenderID] && !blocked[msg.SenderID] {
+			history = append(history, msg)
+		}
+	}
+	return history
+}
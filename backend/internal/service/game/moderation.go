@@ -0,0 +1,64 @@
+package game
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/iamasit07/connect4/backend/internal/domain"
+)
+
+// TerminateGame ends a live game on a moderator's behalf. The game finishes
+// with no winner and is saved with domain.ReasonTerminated, so neither
+// player's stats or rating change.
+func (sm *SessionManager) TerminateGame(gameID string) error {
+	gs, exists := sm.GetSessionByGameID(gameID)
+	if !exists {
+		return fmt.Errorf("game not found")
+	}
+	return gs.terminate()
+}
+
+func (gs *GameSession) terminate() error {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+
+	if gs.Game.IsFinished() {
+		return fmt.Errorf("game is already over")
+	}
+
+	if gs.TurnTimer != nil {
+		gs.TurnTimer.Stop()
+	}
+	if gs.DisconnectTimer != nil {
+		gs.DisconnectTimer.Stop()
+	}
+	if gs.DrawOfferTimer != nil {
+		gs.DrawOfferTimer.Stop()
+	}
+
+	gs.Game.Status = domain.StatusDraw
+	gs.FinishedAt = gs.clock.Now()
+	gs.Reason = domain.ReasonTerminated
+	duration := int(gs.FinishedAt.Sub(gs.CreatedAt).Seconds())
+	allowRematch := false
+
+	gs.broadcastEvent(domain.GameEvent{
+		Type:       domain.EventGameOver,
+		Recipients: gs.getAllParticipants(),
+		Payload: domain.ServerMessage{
+			Type:         "game_over",
+			Reason:       gs.Reason,
+			Message:      "This game was ended by a moderator",
+			Board:        gs.Game.Board,
+			AllowRematch: &allowRematch,
+		},
+	})
+
+	gs.log.Info("Game terminated by a moderator")
+	gs.saveGameAsync(context.Background(), gs.GameID, gs.Player1ID, gs.Player1Username,
+		gs.Player2ID, gs.Player2Username, nil, "",
+		gs.Reason, gs.Game.MoveCount, duration, gs.CreatedAt, gs.FinishedAt, convertBoardToInts(gs.Game.Board))
+
+	gs.StartPostGameTimer()
+	return nil
+}
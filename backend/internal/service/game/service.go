@@ -3,13 +3,17 @@ package game
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"sync"
 	"time"
 
+	"github.com/iamasit07/connect4/backend/internal/clock"
 	"github.com/iamasit07/connect4/backend/internal/domain"
+	"github.com/iamasit07/connect4/backend/internal/logging"
 	"github.com/iamasit07/connect4/backend/internal/service/bot"
+	"github.com/iamasit07/connect4/backend/internal/tracing"
 	"github.com/iamasit07/connect4/backend/pkg/uid"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type GameSession struct {
@@ -25,18 +29,31 @@ type GameSession struct {
 	CreatedAt           time.Time
 	FinishedAt          time.Time
 	BotDifficulty       string      // "easy", "medium", "hard"
-	PostGameTimer       *time.Timer // 30-second window for rematch after game ends
+	Rated               bool        // whether the result affects player ratings
+	PostGameTimer       clock.Timer // Timeouts.PostGame window for rematch after game ends
 	RematchRequester    *int64      // userID of player who requested rematch
-	RematchRequestTimer *time.Timer // 10-second window to accept rematch request
-	TurnTimer           *time.Timer // 30-minute turn timer
-	DisconnectTimer     *time.Timer      // Shared grace period timer
+	RematchRequestTimer clock.Timer // Timeouts.Rematch window to accept rematch request
+	TurnTimer           clock.Timer // Timeouts.Turn turn timer
+	DisconnectTimer     clock.Timer      // Shared grace period timer (Timeouts.Disconnect)
 	DisconnectTime      time.Time        // When the disconnect timer started
 	DisconnectedPlayers map[int64]bool   // Set of currently disconnected player IDs
-	GracePeriodTimer    *time.Timer      // Short timer (3s) to debounce disconnect events
+	GracePeriodTimer    clock.Timer      // Short timer (3s) to debounce disconnect events
+	DrawOfferer         *int64           // userID of player who offered a draw
+	DrawOfferTimer      clock.Timer      // Timeouts.DrawOffer window to answer a draw offer
+	DrawOffers          map[int64]int    // userID → number of draw offers made this game
+	LastDrawOfferMove   map[int64]int    // userID → move count at their last draw offer
+	Chat                []domain.ChatMessage     // Chat history for both channels, oldest first
+	Mutes               map[int64]map[int64]bool // userID → senders they have muted in this game
+	nextChatID          int64
+	Moves               []domain.MoveRecord // Every move played, for replay and engine analysis
+	turnStartedAt       time.Time           // When the player to move got the turn
 
 	mu             sync.Mutex
+	log            *slog.Logger // tagged with the game ID
 	repo           GameRepository
 	sessionManager *SessionManager
+	clock          clock.Clock
+	timeouts       Timeouts
 
 	// Lifecycle management
 	Ctx    context.Context
@@ -46,8 +63,49 @@ type GameSession struct {
 	Events chan domain.GameEvent
 }
 
+const (
+	maxDrawOffersPerGame = 3                      // Per-player cap on draw offers in a single game
+	drawOfferMoveGap     = 2                      // Moves that must be played before the same player offers again
+	botMoveDelay         = 500 * time.Millisecond // Pause before the bot replies so moves don't appear instantly
+)
+
+// Timeouts controls every timed window in a game session
+type Timeouts struct {
+	Turn       time.Duration // Time a player has to move before losing on time
+	Disconnect time.Duration // Grace period for a disconnected player to reconnect
+	PostGame   time.Duration // Window after the game ends during which a rematch can be requested
+	Rematch    time.Duration // Time the opponent has to answer a rematch request
+	DrawOffer  time.Duration // Time the opponent has to answer a draw offer
+}
+
+// DefaultTimeouts returns the production timer durations
+func DefaultTimeouts() Timeouts {
+	return Timeouts{
+		Turn:       15 * time.Minute,
+		Disconnect: 60 * time.Second,
+		PostGame:   30 * time.Second,
+		Rematch:    10 * time.Second,
+		DrawOffer:  15 * time.Second,
+	}
+}
+
 type GameRepository interface {
-	SaveGame(gameID string, player1ID int64, player1Username string, player2ID *int64, player2Username string, winnerID *int64, winnerUsername string, reason string, totalMoves, durationSeconds int, createdAt, finishedAt time.Time, boardState [][]int) error
+	SaveGame(gameID string, player1ID int64, player1Username string, player2ID *int64, player2Username string, winnerID *int64, winnerUsername string, reason string, totalMoves, durationSeconds int, createdAt, finishedAt time.Time, boardState [][]int, rated bool, chat []domain.ChatMessage, moves []domain.MoveRecord) error
+}
+
+// ChatReportStore persists reported chat messages for moderators
+type ChatReportStore interface {
+	SaveChatReport(report domain.ChatReport) error
+}
+
+// BlockChecker reports the users someone has blocked or been blocked by
+type BlockChecker interface {
+	BlockedIDs(userID int64) (map[int64]bool, error)
+}
+
+// PairingThrottle reports opponents a player has met too often in rated games
+type PairingThrottle interface {
+	ThrottledOpponents(userID int64) (map[int64]bool, error)
 }
 
 // SessionManager manages active game sessions
@@ -57,27 +115,94 @@ type SessionManager struct {
 	mu               sync.RWMutex
 	repo             GameRepository
 	onSessionCreated func(*GameSession)
+	clock            clock.Clock
+	timeouts         Timeouts
+	chatReports      ChatReportStore
+	blocks           BlockChecker
+	throttle         PairingThrottle
 }
 
-func NewSessionManager(repo GameRepository) *SessionManager {
+// NewSessionManager creates a manager whose sessions schedule timers on clk
+func NewSessionManager(repo GameRepository, clk clock.Clock, timeouts Timeouts) *SessionManager {
 	return &SessionManager{
 		Session:    make(map[string]*GameSession),
 		UserToGame: make(map[int64]string),
 		repo:       repo,
+		clock:      clk,
+		timeouts:   timeouts,
 	}
 }
 
+// SetChatReportStore sets where reported chat messages are stored. Reports are
+// only logged when no store is set.
+func (sm *SessionManager) SetChatReportStore(store ChatReportStore) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	sm.chatReports = store
+}
+
+// SetBlockChecker hides chat between users who have blocked each other
+func (sm *SessionManager) SetBlockChecker(blocks BlockChecker) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	sm.blocks = blocks
+}
+
+// blockedIDs loads the user's blocks, returning nil when none are configured
+// or the store fails. Must not be called with a session lock held.
+func (sm *SessionManager) blockedIDs(userID int64) map[int64]bool {
+	if sm == nil {
+		return nil
+	}
+	sm.mu.RLock()
+	blocks := sm.blocks
+	sm.mu.RUnlock()
+	if blocks == nil {
+		return nil
+	}
+	ids, err := blocks.BlockedIDs(userID)
+	if err != nil {
+		logging.For("chat").Error("Failed to load blocks", logging.UserID(userID), logging.Err(err))
+		return nil
+	}
+	return ids
+}
+
+// SetPairingThrottle caps rated games between the same two players, however they start
+func (sm *SessionManager) SetPairingThrottle(throttle PairingThrottle) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	sm.throttle = throttle
+}
+
+// RatedPairLimitReached reports whether a and b have used up their rated games
+// against each other for the day. Store failures are logged and count as not reached.
+func (sm *SessionManager) RatedPairLimitReached(a, b int64) bool {
+	sm.mu.RLock()
+	throttle := sm.throttle
+	sm.mu.RUnlock()
+	if throttle == nil {
+		return false
+	}
+	ids, err := throttle.ThrottledOpponents(a)
+	if err != nil {
+		logging.For("game").Error("Failed to load pairing limits", logging.UserID(a), logging.Err(err))
+		return false
+	}
+	return ids[b]
+}
+
 func (sm *SessionManager) SetSessionCreatedCallback(cb func(*GameSession)) {
 	sm.mu.Lock()
 	defer sm.mu.Unlock()
 	sm.onSessionCreated = cb
 }
 
-func (sm *SessionManager) CreateSession(player1ID int64, player1Username string, player2ID *int64, player2Username string, botDifficulty string) *GameSession {
+func (sm *SessionManager) CreateSession(player1ID int64, player1Username string, player2ID *int64, player2Username string, botDifficulty string, rated bool) *GameSession {
 	sm.mu.Lock()
 	defer sm.mu.Unlock()
 
-	session := NewGameSession(player1ID, player1Username, player2ID, player2Username, botDifficulty, sm.repo, sm)
+	session := NewGameSession(player1ID, player1Username, player2ID, player2Username, botDifficulty, rated, sm.repo, sm)
 	gameID := session.GameID
 	sm.Session[gameID] = session
 	sm.UserToGame[player1ID] = gameID
@@ -98,6 +223,7 @@ func (sm *SessionManager) CreateSession(player1ID int64, player1Username string,
 		YourPlayer:  int(domain.Player1),
 		CurrentTurn: int(session.Game.CurrentPlayer),
 		Board:       session.Game.Board,
+		Rated:       &rated,
 	})
 
 	if player2ID != nil {
@@ -108,6 +234,7 @@ func (sm *SessionManager) CreateSession(player1ID int64, player1Username string,
 			YourPlayer:  int(domain.Player2),
 			CurrentTurn: int(session.Game.CurrentPlayer),
 			Board:       session.Game.Board,
+			Rated:       &rated,
 		})
 	}
 
@@ -198,6 +325,7 @@ type ActiveGameInfo struct {
 	MoveCount      int    `json:"moveCount"`
 	SpectatorCount int    `json:"spectatorCount"`
 	StartedAt      string `json:"startedAt"`
+	Rated          bool   `json:"rated"`
 }
 
 // GetActiveGames returns a list of all active (non-finished) PvP game sessions
@@ -218,6 +346,7 @@ func (sm *SessionManager) GetActiveGames() []ActiveGameInfo {
 			MoveCount:      session.Game.MoveCount,
 			SpectatorCount: len(session.Spectators),
 			StartedAt:      session.CreatedAt.Format("2006-01-02T15:04:05Z"),
+			Rated:          session.Rated,
 		})
 	}
 	return games
@@ -237,6 +366,17 @@ func (sm *SessionManager) IsSpectator(userID int64, gameID string) bool {
 	return session.Spectators[userID]
 }
 
+// IsPlaying reports whether the user is a player in an unfinished game
+func (sm *SessionManager) IsPlaying(userID int64) bool {
+	session, exists := sm.GetSessionByUserID(userID)
+	if !exists {
+		return false
+	}
+	session.mu.Lock()
+	defer session.mu.Unlock()
+	return !session.Game.IsFinished()
+}
+
 // IsSpectatorAnywhere checks whether a user is spectating any game
 func (sm *SessionManager) IsSpectatorAnywhere(userID int64) bool {
 	sm.mu.RLock()
@@ -285,7 +425,7 @@ func (sm *SessionManager) ForceCleanupForUser(userID int64) {
 	sm.RemoveSession(gameID)
 }
 
-func NewGameSession(player1ID int64, player1Username string, player2ID *int64, player2Username string, botDifficulty string, repo GameRepository, sm *SessionManager) *GameSession {
+func NewGameSession(player1ID int64, player1Username string, player2ID *int64, player2Username string, botDifficulty string, rated bool, repo GameRepository, sm *SessionManager) *GameSession {
 	gameID := uid.GenerateGameID()
 	newGame := (&domain.Game{}).NewGame()
 
@@ -297,7 +437,12 @@ func NewGameSession(player1ID int64, player1Username string, player2ID *int64, p
 
 	ctx, cancel := context.WithCancel(context.Background())
 
-
+	clk := clock.Real
+	timeouts := DefaultTimeouts()
+	if sm != nil {
+		clk = sm.clock
+		timeouts = sm.timeouts
+	}
 
 	gs := &GameSession{
 		GameID:          gameID,
@@ -309,16 +454,25 @@ func NewGameSession(player1ID int64, player1Username string, player2ID *int64, p
 		PlayerMapping:   mapping,
 		Spectators:      make(map[int64]bool),
 		BotDifficulty:   botDifficulty,
-		CreatedAt:       time.Now(),
+		Rated:           rated,
+		CreatedAt:       clk.Now(),
 		mu:              sync.Mutex{},
+		log:             logging.For("game").With(logging.GameID(gameID)),
 		repo:            repo,
 		sessionManager:  sm,
+		clock:           clk,
+		timeouts:        timeouts,
 		DisconnectedPlayers: make(map[int64]bool),
+		DrawOffers:          make(map[int64]int),
+		LastDrawOfferMove:   make(map[int64]int),
+		Mutes:               make(map[int64]map[int64]bool),
 		Events: make(chan domain.GameEvent, 100),
 		Ctx:    ctx,
 		cancel: cancel,
 
 	}
+	gs.turnStartedAt = gs.CreatedAt
+	gs.log.Info("Game started", "player1_id", player1ID, "player2_id", player2ID, "bot", botDifficulty, "rated", rated)
 
 	gs.startTurnTimer()
 	return gs
@@ -329,7 +483,7 @@ func (sm *SessionManager) CleanupOldSessions() {
 	defer sm.mu.Unlock()
 
 	count := 0
-	now := time.Now()
+	now := sm.clock.Now()
 
 	for gameID, session := range sm.Session {
 		if session.Game.IsFinished() {
@@ -356,7 +510,7 @@ func (sm *SessionManager) CleanupOldSessions() {
 	}
 
 	if count > 0 {
-		log.Printf("[SESSION] Memory cleanup: Removed %d stale game sessions", count)
+		logging.For("game").Info("Memory cleanup: removed stale game sessions", "count", count)
 	}
 }
 
@@ -366,7 +520,7 @@ func (gs *GameSession) sendEvent(userID int64, payload interface{}) {
 	case gs.Events <- domain.GameEvent{
 		Type:       domain.EventInfo,
 		Recipients: []int64{userID},
-		Payload:    payload,
+		Payload:    snapshotPayload(payload),
 	}:
 	case <-gs.Ctx.Done():
 		// Session cancelled, stop sending
@@ -375,12 +529,24 @@ func (gs *GameSession) sendEvent(userID int64, payload interface{}) {
 
 // Helper to broadcast and event to specific users
 func (gs *GameSession) broadcastEvent(event domain.GameEvent) {
+	event.Payload = snapshotPayload(event.Payload)
 	select {
 	case gs.Events <- event:
 	case <-gs.Ctx.Done():
 	}
 }
 
+// snapshotPayload copies the board out of a message. Events are serialised on
+// other goroutines after gs.mu is released, so they must not alias the live board.
+func snapshotPayload(payload interface{}) interface{} {
+	msg, ok := payload.(domain.ServerMessage)
+	if !ok || msg.Board == nil {
+		return payload
+	}
+	msg.Board = domain.CopyBoard(msg.Board)
+	return msg
+}
+
 // Helper to get all participants (players + spectators)
 func (gs *GameSession) getAllParticipants() []int64 {
 	participants := []int64{gs.Player1ID}
@@ -393,8 +559,14 @@ func (gs *GameSession) getAllParticipants() []int64 {
 	return participants
 }
 
-func (gs *GameSession) HandleMove(userID int64, column int) error {
-	gs.mu.Lock()
+// HandleMove plays userID's disc in column. The span separates waiting for
+// the session lock from the move itself.
+func (gs *GameSession) HandleMove(ctx context.Context, userID int64, column int) (err error) {
+	ctx, span := tracing.Start(ctx, "GameSession.HandleMove",
+		tracing.KeyGameID.String(gs.GameID), tracing.KeyUserID.Int64(userID), attribute.Int("column", column))
+	defer func() { tracing.End(span, err) }()
+
+	gs.lock(ctx)
 	defer gs.mu.Unlock()
 
 	playerID, exists := gs.GetPlayerID(userID)
@@ -410,6 +582,7 @@ func (gs *GameSession) HandleMove(userID int64, column int) error {
 	if err != nil {
 		return err
 	}
+	gs.recordMove(playerID, column)
 
 	recipients := gs.getAllParticipants()
 
@@ -432,7 +605,7 @@ func (gs *GameSession) HandleMove(userID int64, column int) error {
 			},
 		})
 
-		gs.FinishedAt = time.Now()
+		gs.FinishedAt = gs.clock.Now()
 		winnerUsername := gs.GetUsername(gs.Game.Winner)
 		winnerID := userID
 		gs.Reason = "connect_four"
@@ -454,7 +627,7 @@ func (gs *GameSession) HandleMove(userID int64, column int) error {
 			},
 		})
 
-		gs.saveGameAsync(gs.GameID, gs.Player1ID, gs.Player1Username,
+		gs.saveGameAsync(ctx, gs.GameID, gs.Player1ID, gs.Player1Username,
 			gs.Player2ID, gs.Player2Username, &winnerID, winnerUsername,
 			gs.Reason, gs.Game.MoveCount, duration, gs.CreatedAt, gs.FinishedAt, convertBoardToInts(gs.Game.Board))
 
@@ -481,7 +654,7 @@ func (gs *GameSession) HandleMove(userID int64, column int) error {
 			},
 		})
 
-		gs.FinishedAt = time.Now()
+		gs.FinishedAt = gs.clock.Now()
 		gs.Reason = "draw"
 		duration := int(gs.FinishedAt.Sub(gs.CreatedAt).Seconds())
 		allowRematch := true
@@ -499,7 +672,7 @@ func (gs *GameSession) HandleMove(userID int64, column int) error {
 			},
 		})
 
-		gs.saveGameAsync(gs.GameID, gs.Player1ID, gs.Player1Username,
+		gs.saveGameAsync(ctx, gs.GameID, gs.Player1ID, gs.Player1Username,
 			gs.Player2ID, gs.Player2Username, nil, "draw",
 			gs.Reason, gs.Game.MoveCount, duration, gs.CreatedAt, gs.FinishedAt, convertBoardToInts(gs.Game.Board))
 
@@ -527,9 +700,9 @@ func (gs *GameSession) HandleMove(userID int64, column int) error {
 	if gs.IsBot() && gs.Game.CurrentPlayer == domain.Player2 {
 		go func() {
 			select {
-			case <-time.After(500 * time.Millisecond):
-				if err := gs.HandleBotMove(); err != nil {
-					log.Printf("[BOT] Error handling bot move: %v", err)
+			case <-gs.clock.After(botMoveDelay):
+				if err := gs.HandleBotMove(ctx); err != nil {
+					gs.log.Error("Error handling bot move", logging.Err(err))
 				}
 			case <-gs.Ctx.Done():
 				// Game cancelled during sleep
@@ -541,9 +714,13 @@ func (gs *GameSession) HandleMove(userID int64, column int) error {
 	return nil
 }
 
-func (gs *GameSession) HandleBotMove() error {
+// HandleBotMove plays the bot's reply; ctx is the span of the move it answers
+func (gs *GameSession) HandleBotMove(ctx context.Context) (err error) {
+	ctx, span := tracing.Start(ctx, "GameSession.HandleBotMove", tracing.KeyGameID.String(gs.GameID))
+	defer func() { tracing.End(span, err) }()
+
 	// Acquire lock since this is entry point from goroutine
-	gs.mu.Lock()
+	gs.lock(ctx)
 	defer gs.mu.Unlock()
 
 	// Check if session is still valid
@@ -561,11 +738,14 @@ func (gs *GameSession) HandleBotMove() error {
 		difficulty = "medium"
 	}
 
+	_, botSpan := tracing.Start(ctx, "bot.CalculateBestMove", attribute.String("difficulty", difficulty))
 	botColumn := bot.CalculateBestMove(gs.Game.Board, domain.Player2, difficulty)
+	botSpan.End()
 	botRow, err := gs.Game.MakeMove(domain.Player2, botColumn)
 	if err != nil {
 		return err
 	}
+	gs.recordMove(domain.Player2, botColumn)
 
 	recipients := gs.getAllParticipants()
 
@@ -587,7 +767,7 @@ func (gs *GameSession) HandleBotMove() error {
 			},
 		})
 
-		gs.FinishedAt = time.Now()
+		gs.FinishedAt = gs.clock.Now()
 		gs.Reason = "connect_four"
 		duration := int(gs.FinishedAt.Sub(gs.CreatedAt).Seconds())
 		allowRematch := true
@@ -605,7 +785,7 @@ func (gs *GameSession) HandleBotMove() error {
 			},
 		})
 
-		gs.saveGameAsync(gs.GameID, gs.Player1ID, gs.Player1Username,
+		gs.saveGameAsync(ctx, gs.GameID, gs.Player1ID, gs.Player1Username,
 			nil, gs.Player2Username, nil, gs.Player2Username,
 			gs.Reason, gs.Game.MoveCount, duration, gs.CreatedAt, gs.FinishedAt, convertBoardToInts(gs.Game.Board))
 
@@ -631,7 +811,7 @@ func (gs *GameSession) HandleBotMove() error {
 			},
 		})
 
-		gs.FinishedAt = time.Now()
+		gs.FinishedAt = gs.clock.Now()
 		gs.Reason = "draw"
 		duration := int(gs.FinishedAt.Sub(gs.CreatedAt).Seconds())
 		allowRematch := true
@@ -648,7 +828,7 @@ func (gs *GameSession) HandleBotMove() error {
 			},
 		})
 
-		gs.saveGameAsync(gs.GameID, gs.Player1ID, gs.Player1Username,
+		gs.saveGameAsync(ctx, gs.GameID, gs.Player1ID, gs.Player1Username,
 			nil, gs.Player2Username, nil, "draw",
 			gs.Reason, gs.Game.MoveCount, duration, gs.CreatedAt, gs.FinishedAt, convertBoardToInts(gs.Game.Board))
 
@@ -693,14 +873,14 @@ func (gs *GameSession) HandleDisconnect(userID int64, sessionManager *SessionMan
 
 	username := gs.GetUsernameByUserID(userID)
 	gs.DisconnectedPlayers[userID] = true
-	log.Printf("[DISCONNECT] Player %s (ID: %d) disconnected from game %s", username, userID, gs.GameID)
+	gs.log.Info("Player disconnected", logging.UserID(userID), "username", username)
 
 	if gs.DisconnectTimer != nil {
 		gs.mu.Unlock()
 		return nil
 	}
 
-	gs.DisconnectTime = time.Now()
+	gs.DisconnectTime = gs.clock.Now()
 	
 	opponentID := gs.GetOpponentID(userID)
 	if opponentID != nil {
@@ -710,13 +890,13 @@ func (gs *GameSession) HandleDisconnect(userID int64, sessionManager *SessionMan
 			Payload: domain.ServerMessage{
 				Type:              "opponent_disconnected",
 				Message:           "Opponent disconnected, waiting for reconnect...",
-				DisconnectTimeout: 60,
+				DisconnectTimeout: int(gs.timeouts.Disconnect.Seconds()),
 			},
 		})
 	}
 
-	// Start 60-second grace period timer
-	gs.DisconnectTimer = time.AfterFunc(60*time.Second, func() {
+	// Start grace period timer
+	gs.DisconnectTimer = gs.clock.AfterFunc(gs.timeouts.Disconnect, func() {
 		gs.mu.Lock()
 		defer gs.mu.Unlock()
 
@@ -732,7 +912,7 @@ func (gs *GameSession) HandleDisconnect(userID int64, sessionManager *SessionMan
 		
 		gs.Game.Status = domain.StatusWon
 		gs.Reason = "abandonment"
-		gs.FinishedAt = time.Now()
+		gs.FinishedAt = gs.clock.Now()
 		duration := int(gs.FinishedAt.Sub(gs.CreatedAt).Seconds())
 		
 		recipients := gs.getAllParticipants()
@@ -750,7 +930,7 @@ func (gs *GameSession) HandleDisconnect(userID int64, sessionManager *SessionMan
 			},
 		})
 
-		gs.saveGameAsync(gs.GameID, gs.Player1ID, gs.Player1Username,
+		gs.saveGameAsync(context.Background(), gs.GameID, gs.Player1ID, gs.Player1Username,
 			gs.Player2ID, gs.Player2Username, winnerID, winnerName,
 			gs.Reason, gs.Game.MoveCount, duration, gs.CreatedAt, gs.FinishedAt, convertBoardToInts(gs.Game.Board))
 	})
@@ -760,6 +940,7 @@ func (gs *GameSession) HandleDisconnect(userID int64, sessionManager *SessionMan
 }
 
 func (gs *GameSession) HandleReconnect(userID int64) error {
+	blocked := gs.sessionManager.blockedIDs(userID)
 	gs.mu.Lock()
 	defer gs.mu.Unlock()
 
@@ -777,7 +958,7 @@ func (gs *GameSession) HandleReconnect(userID int64) error {
 		if allConnected && gs.DisconnectTimer != nil {
 			gs.DisconnectTimer.Stop()
 			gs.DisconnectTimer = nil
-			log.Printf("[RECONNECT] Disconnect timer stopped for game %s", gs.GameID)
+			gs.log.Info("Disconnect timer stopped; all players reconnected")
 			
 			opponentID := gs.GetOpponentID(userID)
 			if opponentID != nil && !gs.Game.IsFinished() {
@@ -828,6 +1009,11 @@ func (gs *GameSession) HandleReconnect(userID int64) error {
 		rematchRequester = gs.GetUsernameByUserID(*gs.RematchRequester)
 	}
 
+	var drawOfferer string
+	if gs.DrawOfferer != nil && !gs.Game.IsFinished() {
+		drawOfferer = gs.GetUsernameByUserID(*gs.DrawOfferer)
+	}
+
 	// Send current state to reconnected user
 	gs.sendEvent(userID, domain.ServerMessage{
 		Type:             "game_state", 
@@ -840,6 +1026,9 @@ func (gs *GameSession) HandleReconnect(userID int64) error {
 		Reason:           reason,
 		AllowRematch:     allowRematch,
 		RematchRequester: rematchRequester,
+		DrawOfferer:      drawOfferer,
+		Rated:            &gs.Rated,
+		ChatHistory:      gs.chatHistoryFor(userID, blocked),
 	})
 
 	return nil
@@ -853,6 +1042,10 @@ func (gs *GameSession) HandleRematchRequest(userID int64, sessionManager *Sessio
 		return fmt.Errorf("game is not finished")
 	}
 
+	if gs.Reason == domain.ReasonTerminated {
+		return fmt.Errorf("rematch is not available for this game")
+	}
+
 	if gs.RematchRequester != nil {
 		return fmt.Errorf("rematch already requested")
 	}
@@ -866,7 +1059,7 @@ func (gs *GameSession) HandleRematchRequest(userID int64, sessionManager *Sessio
 		if gs.IsBot() {
 			// Instant rematch for bot
 			gs.mu.Unlock() 
-			sessionManager.CreateRematchSession(gs.Player1ID, gs.Player1Username, nil, "", gs.BotDifficulty)
+			sessionManager.CreateRematchSession(gs.Player1ID, gs.Player1Username, nil, "", gs.BotDifficulty, gs.Rated)
 			gs.mu.Lock()
 			return nil
 		}
@@ -880,7 +1073,7 @@ func (gs *GameSession) HandleRematchRequest(userID int64, sessionManager *Sessio
 			Type: "rematch_requested",
 			Message: fmt.Sprintf("%s wants a rematch!", requesterName),
 			RematchRequester: requesterName,
-			RematchTimeout: 10,
+			RematchTimeout: int(gs.timeouts.Rematch.Seconds()),
 		},
 	})
 	
@@ -924,6 +1117,7 @@ func (gs *GameSession) HandleRematchResponse(userID int64, accept bool, sessionM
 	p2ID := gs.Player2ID
 	p2Name := gs.Player2Username
 	botDiff := gs.BotDifficulty
+	rated := gs.Rated
 	oldGameID := gs.GameID
 
 	// Send rematch_accepted via old session (still valid at this point)
@@ -939,8 +1133,127 @@ func (gs *GameSession) HandleRematchResponse(userID int64, accept bool, sessionM
 	// Clean up old session and create new one
 	gs.mu.Unlock()
 	sessionManager.RemoveSession(oldGameID)
-	sessionManager.CreateRematchSession(p1ID, p1Name, p2ID, p2Name, botDiff)
+	sessionManager.CreateRematchSession(p1ID, p1Name, p2ID, p2Name, botDiff, rated)
+	gs.mu.Lock()
+	return nil
+}
+
+// HandleDrawOffer lets a player propose ending an active PvP game as a draw
+func (gs *GameSession) HandleDrawOffer(userID int64) error {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+
+	if _, exists := gs.GetPlayerID(userID); !exists {
+		return fmt.Errorf("player not found in game")
+	}
+
+	if gs.Game.IsFinished() {
+		return fmt.Errorf("game is already finished")
+	}
+
+	if gs.IsBot() {
+		return fmt.Errorf("cannot offer a draw to a bot")
+	}
+
+	if gs.DrawOfferer != nil {
+		return fmt.Errorf("draw already offered")
+	}
+
+	// Anti-spam: cap offers per game and require moves to be played between offers
+	if gs.DrawOffers[userID] >= maxDrawOffersPerGame {
+		return fmt.Errorf("draw offer limit reached for this game")
+	}
+	if lastMove, offered := gs.LastDrawOfferMove[userID]; offered && gs.Game.MoveCount-lastMove < drawOfferMoveGap {
+		return fmt.Errorf("wait for more moves before offering another draw")
+	}
+
+	gs.DrawOfferer = &userID
+	gs.DrawOffers[userID]++
+	gs.LastDrawOfferMove[userID] = gs.Game.MoveCount
+	offererName := gs.GetUsernameByUserID(userID)
+
+	gs.broadcastEvent(domain.GameEvent{
+		Type:       domain.EventInfo,
+		Recipients: gs.getAllParticipants(),
+		Payload: domain.ServerMessage{
+			Type:        "draw_offered",
+			Message:     fmt.Sprintf("%s offers a draw", offererName),
+			DrawOfferer: offererName,
+			DrawTimeout: int(gs.timeouts.DrawOffer.Seconds()),
+		},
+	})
+
+	gs.startDrawOfferTimer()
+	return nil
+}
+
+// HandleDrawResponse accepts or declines the opponent's pending draw offer
+func (gs *GameSession) HandleDrawResponse(userID int64, accept bool) error {
 	gs.mu.Lock()
+	defer gs.mu.Unlock()
+
+	if gs.DrawOfferer == nil {
+		return fmt.Errorf("no draw offered")
+	}
+
+	if *gs.DrawOfferer == userID {
+		return fmt.Errorf("cannot respond to own offer")
+	}
+
+	if _, exists := gs.GetPlayerID(userID); !exists {
+		return fmt.Errorf("player not found in game")
+	}
+
+	gs.DrawOfferer = nil
+	if gs.DrawOfferTimer != nil {
+		gs.DrawOfferTimer.Stop()
+		gs.DrawOfferTimer = nil
+	}
+
+	if gs.Game.IsFinished() {
+		return fmt.Errorf("game is already finished")
+	}
+
+	if !accept {
+		gs.broadcastEvent(domain.GameEvent{
+			Type:       domain.EventInfo,
+			Recipients: gs.getAllParticipants(),
+			Payload: domain.ServerMessage{
+				Type:    "draw_declined",
+				Message: "Draw offer declined",
+			},
+		})
+		return nil
+	}
+
+	// Accepted — end the game as a draw by agreement
+	if gs.TurnTimer != nil {
+		gs.TurnTimer.Stop()
+	}
+
+	gs.Game.Status = domain.StatusDraw
+	gs.FinishedAt = gs.clock.Now()
+	gs.Reason = "agreement"
+	duration := int(gs.FinishedAt.Sub(gs.CreatedAt).Seconds())
+	allowRematch := true
+
+	gs.broadcastEvent(domain.GameEvent{
+		Type:       domain.EventGameOver,
+		Recipients: gs.getAllParticipants(),
+		Payload: domain.ServerMessage{
+			Type:         "game_over",
+			Winner:       "draw",
+			Reason:       gs.Reason,
+			Board:        gs.Game.Board,
+			AllowRematch: &allowRematch,
+		},
+	})
+
+	gs.saveGameAsync(context.Background(), gs.GameID, gs.Player1ID, gs.Player1Username,
+		gs.Player2ID, gs.Player2Username, nil, "draw",
+		gs.Reason, gs.Game.MoveCount, duration, gs.CreatedAt, gs.FinishedAt, convertBoardToInts(gs.Game.Board))
+
+	gs.StartPostGameTimer()
 	return nil
 }
 
@@ -953,7 +1266,7 @@ func (gs *GameSession) TerminateSessionWithReason(userID int64, reason string) {
 		return
 	}
 
-	gs.FinishedAt = time.Now()
+	gs.FinishedAt = gs.clock.Now()
 	gs.Reason = reason
 	gs.Game.Status = domain.StatusWon // For abandonment
 	
@@ -977,7 +1290,7 @@ func (gs *GameSession) TerminateSessionWithReason(userID int64, reason string) {
 	})
 	
 	duration := int(gs.FinishedAt.Sub(gs.CreatedAt).Seconds())
-	gs.saveGameAsync(gs.GameID, gs.Player1ID, gs.Player1Username, gs.Player2ID, gs.Player2Username,
+	gs.saveGameAsync(context.Background(), gs.GameID, gs.Player1ID, gs.Player1Username, gs.Player2ID, gs.Player2Username,
 		winnerID, winnerName, reason, gs.Game.MoveCount, duration, gs.CreatedAt, gs.FinishedAt, convertBoardToInts(gs.Game.Board))
 }
 
@@ -1002,7 +1315,11 @@ func (gs *GameSession) GetOpponentID(userID int64) *int64 {
 	return &gs.Player1ID
 }
 func (gs *GameSession) IsBot() bool { return gs.Player2ID == nil }
+func (gs *GameSession) IsPlayer(userID int64) bool {
+	return userID == gs.Player1ID || (gs.Player2ID != nil && *gs.Player2ID == userID)
+}
 func (gs *GameSession) AddSpectator(userID int64) {
+	blocked := gs.sessionManager.blockedIDs(userID)
 	gs.mu.Lock()
 	defer gs.mu.Unlock()
 	gs.Spectators[userID] = true
@@ -1015,6 +1332,7 @@ func (gs *GameSession) AddSpectator(userID int64) {
 		Player2:     gs.Player2Username,
 		CurrentTurn: int(gs.Game.CurrentPlayer),
 		Board:       gs.Game.Board,
+		ChatHistory: gs.chatHistoryFor(userID, blocked),
 	})
 }
 func (gs *GameSession) RemoveSpectator(userID int64) {
@@ -1022,14 +1340,40 @@ func (gs *GameSession) RemoveSpectator(userID int64) {
 	defer gs.mu.Unlock()
 	delete(gs.Spectators, userID)
 }
-func (gs *GameSession) saveGameAsync(gameID string, p1ID int64, p1User string,
+// lock takes mu, recording the wait as its own span
+func (gs *GameSession) lock(ctx context.Context) {
+	_, span := tracing.Start(ctx, "GameSession.lock")
+	gs.mu.Lock()
+	span.End()
+}
+
+// recordMove appends a move to the history with its thinking time (caller must hold mu)
+func (gs *GameSession) recordMove(player domain.PlayerID, column int) {
+	now := gs.clock.Now()
+	gs.Moves = append(gs.Moves, domain.MoveRecord{
+		Column:  column,
+		Player:  player,
+		ThinkMs: now.Sub(gs.turnStartedAt).Milliseconds(),
+	})
+	gs.turnStartedAt = now
+}
+
+// saveGameAsync stores the finished game in the background, traced as a
+// child of ctx's span
+func (gs *GameSession) saveGameAsync(ctx context.Context, gameID string, p1ID int64, p1User string,
 	p2ID *int64, p2User string, winnerID *int64, winnerUser string,
 	reason string, moves, duration int, created, finished time.Time, boardState [][]int) {
+	rated := gs.Rated
+	transcript := append([]domain.ChatMessage(nil), gs.Chat...)
+	history := append([]domain.MoveRecord(nil), gs.Moves...)
+	gs.log.Info("Game finished", "reason", reason, "winner", winnerUser, "moves", moves, "rated", rated)
 	go func() {
+		_, span := tracing.Start(ctx, "GameSession.saveGameAsync", tracing.KeyGameID.String(gameID))
 		err := gs.repo.SaveGame(gameID, p1ID, p1User, p2ID, p2User,
-			winnerID, winnerUser, reason, moves, duration, created, finished, boardState)
+			winnerID, winnerUser, reason, moves, duration, created, finished, boardState, rated, transcript, history)
+		tracing.End(span, err)
 		if err != nil {
-			log.Printf("[GAME] Error saving game %s: %v", gameID, err)
+			gs.log.Error("Error saving game", logging.Err(err))
 		}
 	}()
 }
@@ -1037,7 +1381,7 @@ func (gs *GameSession) startTurnTimer() {
 	if gs.TurnTimer != nil {
 		gs.TurnTimer.Stop()
 	}
-	gs.TurnTimer = time.AfterFunc(15*time.Minute, func() {
+	gs.TurnTimer = gs.clock.AfterFunc(gs.timeouts.Turn, func() {
 		gs.mu.Lock()
 		defer gs.mu.Unlock()
 		if gs.Game.IsFinished() { return }
@@ -1069,13 +1413,13 @@ func (gs *GameSession) startTurnTimer() {
 }
 func (gs *GameSession) StartPostGameTimer() {
 	if gs.PostGameTimer != nil { gs.PostGameTimer.Stop() }
-	gs.PostGameTimer = time.AfterFunc(30*time.Second, func() {
+	gs.PostGameTimer = gs.clock.AfterFunc(gs.timeouts.PostGame, func() {
 		// Cleanup
 	})
 }
 func (gs *GameSession) startRematchTimer() {
 	if gs.RematchRequestTimer != nil { gs.RematchRequestTimer.Stop() }
-	gs.RematchRequestTimer = time.AfterFunc(10*time.Second, func() {
+	gs.RematchRequestTimer = gs.clock.AfterFunc(gs.timeouts.Rematch, func() {
 		gs.mu.Lock()
 		defer gs.mu.Unlock()
 		if gs.RematchRequester == nil { return }
@@ -1097,6 +1441,25 @@ func (gs *GameSession) startRematchTimer() {
 		})
 	})
 }
+func (gs *GameSession) startDrawOfferTimer() {
+	if gs.DrawOfferTimer != nil { gs.DrawOfferTimer.Stop() }
+	gs.DrawOfferTimer = gs.clock.AfterFunc(gs.timeouts.DrawOffer, func() {
+		gs.mu.Lock()
+		defer gs.mu.Unlock()
+		if gs.DrawOfferer == nil || gs.Game.IsFinished() { return }
+		gs.DrawOfferer = nil
+		gs.DrawOfferTimer = nil
+
+		gs.broadcastEvent(domain.GameEvent{
+			Type:       domain.EventInfo,
+			Recipients: gs.getAllParticipants(),
+			Payload: domain.ServerMessage{
+				Type:    "draw_timeout",
+				Message: "Draw offer expired",
+			},
+		})
+	})
+}
 func (gs *GameSession) CancelRematchRequest() {
 	if gs.RematchRequester != nil {
 		gs.RematchRequester = nil
@@ -1126,8 +1489,10 @@ func (gs *GameSession) cleanupTimers() {
 	if gs.DisconnectTimer != nil { gs.DisconnectTimer.Stop() }
 	if gs.PostGameTimer != nil { gs.PostGameTimer.Stop() }
 	if gs.RematchRequestTimer != nil { gs.RematchRequestTimer.Stop() }
+	if gs.DrawOfferTimer != nil { gs.DrawOfferTimer.Stop() }
 }
 func (gs *GameSession) HandleGetState(userID int64) {
+	blocked := gs.sessionManager.blockedIDs(userID)
 	gs.mu.Lock()
 	defer gs.mu.Unlock()
 	
@@ -1167,6 +1532,11 @@ func (gs *GameSession) HandleGetState(userID int64) {
 		rematchRequester = gs.GetUsernameByUserID(*gs.RematchRequester)
 	}
 
+	var drawOfferer string
+	if gs.DrawOfferer != nil && !gs.Game.IsFinished() {
+		drawOfferer = gs.GetUsernameByUserID(*gs.DrawOfferer)
+	}
+
 	gs.sendEvent(userID, domain.ServerMessage{
 		Type:             "game_state",
 		GameID:           gs.GameID,
@@ -1178,13 +1548,21 @@ func (gs *GameSession) HandleGetState(userID int64) {
 		Reason:           reason,
 		AllowRematch:     allowRematch,
 		RematchRequester: rematchRequester,
+		DrawOfferer:      drawOfferer,
+		Rated:            &gs.Rated,
+		ChatHistory:      gs.chatHistoryFor(userID, blocked),
 	})
 }
 
 // Add CreateRematchSession to SessionManager
-func (sm *SessionManager) CreateRematchSession(p1ID int64, p1User string, p2ID *int64, p2User string, botDiff string) *GameSession {
-	// Logic to start new game
-	session := sm.CreateSession(p1ID, p1User, p2ID, p2User, botDiff)
+func (sm *SessionManager) CreateRematchSession(p1ID int64, p1User string, p2ID *int64, p2User string, botDiff string, rated bool) *GameSession {
+	// Logic to start new game (rematches keep the original game mode, unless
+	// the pair has reached its daily rated limit)
+	if rated && p2ID != nil && sm.RatedPairLimitReached(p1ID, *p2ID) {
+		logging.For("game").Info("Rematch downgraded to casual at the rated pair limit", "player1_id", p1ID, "player2_id", *p2ID)
+		rated = false
+	}
+	session := sm.CreateSession(p1ID, p1User, p2ID, p2User, botDiff, rated)
 	return session
 }
 
@@ -0,0 +1,186 @@
+// Package integrity looks for rating manipulation: pairs of accounts that keep
+// meeting in rated games and trading quick, one-sided results. Suspicious
+// pairings are flagged into a review queue for admins.
+package integrity
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/iamasit07/connect4/backend/internal/clock"
+	"github.com/iamasit07/connect4/backend/internal/domain"
+	"github.com/iamasit07/connect4/backend/internal/logging"
+	"github.com/iamasit07/connect4/backend/internal/repository"
+)
+
+// Config tunes what counts as suspicious
+type Config struct {
+	Window              time.Duration // how far back a scan looks
+	MinPairGames        int           // rated games between two players before they are scored
+	QuickGameMoves      int           // games ending by surrender within this many moves count as quick
+	Threshold           int           // score (0-100) at which a pairing is flagged
+	RatedPairDailyLimit int           // rated games the same two players may play per 24h (0 = unlimited)
+}
+
+// DefaultConfig returns the scoring defaults with the given window and pairing limit
+func DefaultConfig(window time.Duration, ratedPairDailyLimit int) Config {
+	return Config{
+		Window:              window,
+		MinPairGames:        5,
+		QuickGameMoves:      10,
+		Threshold:           60,
+		RatedPairDailyLimit: ratedPairDailyLimit,
+	}
+}
+
+type Detector struct {
+	games    repository.GameRepository
+	sessions repository.SessionRepository
+	flags    repository.IntegrityRepository
+	clock    clock.Clock
+	cfg      Config
+}
+
+func NewDetector(games repository.GameRepository, sessions repository.SessionRepository, flags repository.IntegrityRepository, clk clock.Clock, cfg Config) *Detector {
+	return &Detector{games: games, sessions: sessions, flags: flags, clock: clk, cfg: cfg}
+}
+
+// Start scans immediately and then every interval. A non-positive interval disables scanning.
+func (d *Detector) Start(interval time.Duration) {
+	runEvery("Win-trading scan", interval, d.runScan)
+}
+
+// runEvery runs job now and then on a ticker, unless interval is non-positive
+func runEvery(name string, interval time.Duration, job func()) {
+	if interval <= 0 {
+		logging.For("integrity").Info("Job disabled", "job", name)
+		return
+	}
+	go job()
+
+	ticker := time.NewTicker(interval)
+	go func() {
+		for range ticker.C {
+			job()
+		}
+	}()
+	logging.For("integrity").Info("Job scheduled", "job", name, "interval", interval)
+}
+
+func (d *Detector) runScan() {
+	flagged, err := d.Scan()
+	if err != nil {
+		logging.For("integrity").Error("Scan failed", logging.Err(err))
+		return
+	}
+	if flagged > 0 {
+		logging.For("integrity").Info("Flagged suspicious pairings", "count", flagged)
+	}
+}
+
+// Scan scores every pairing in the window and flags those over the threshold.
+// It returns how many flags were raised or refreshed.
+func (d *Detector) Scan() (int, error) {
+	now := d.clock.Now()
+	games, err := d.games.GetRatedGamesSince(now.Add(-d.cfg.Window))
+	if err != nil {
+		return 0, err
+	}
+
+	flagged := 0
+	for _, p := range groupByPair(games) {
+		if len(p.games) < d.cfg.MinPairGames {
+			continue
+		}
+		sharedIP, err := d.shareIP(p.a, p.b)
+		if err != nil {
+			return flagged, err
+		}
+		s := score(p, d.cfg, sharedIP)
+		if s.score < d.cfg.Threshold {
+			continue
+		}
+		raised, err := d.flag(p, s, now)
+		if err != nil {
+			return flagged, err
+		}
+		if raised {
+			flagged++
+		}
+	}
+	return flagged, nil
+}
+
+// ThrottledOpponents returns the opponents userID has already met
+// RatedPairDailyLimit times in rated games over the last 24 hours
+func (d *Detector) ThrottledOpponents(userID int64) (map[int64]bool, error) {
+	if d.cfg.RatedPairDailyLimit <= 0 {
+		return nil, nil
+	}
+	counts, err := d.games.CountRatedOpponents(userID, d.clock.Now().Add(-24*time.Hour))
+	if err != nil {
+		return nil, err
+	}
+	throttled := make(map[int64]bool)
+	for opponentID, n := range counts {
+		if n >= d.cfg.RatedPairDailyLimit {
+			throttled[opponentID] = true
+		}
+	}
+	return throttled, nil
+}
+
+// flag opens a flag for the pairing or refreshes the open one. A reviewed
+// pairing is only flagged again once the pair has played since the review.
+func (d *Detector) flag(p *pair, s result, now time.Time) (bool, error) {
+	opponent := p.a
+	if s.beneficiary == p.a {
+		opponent = p.b
+	}
+
+	flag, err := d.flags.GetLatestFlag(domain.FlagKindWinTrading, s.beneficiary, opponent)
+	if err != nil {
+		return false, err
+	}
+	if flag != nil && flag.Status != domain.FlagOpen {
+		if !p.playedSince(flag.UpdatedAt) {
+			return false, nil
+		}
+		flag = nil
+	}
+	if flag == nil {
+		flag = &domain.IntegrityFlag{Kind: domain.FlagKindWinTrading, UserID: s.beneficiary, OpponentID: opponent, Status: domain.FlagOpen, CreatedAt: now}
+	}
+	flag.Score = s.score
+	flag.Signals = s.signals
+	flag.Games = len(p.games)
+	flag.UpdatedAt = now
+	if err := d.flags.SaveFlag(flag); err != nil {
+		return false, fmt.Errorf("failed to save integrity flag: %v", err)
+	}
+	return true, nil
+}
+
+func (d *Detector) shareIP(a, b int64) (bool, error) {
+	ipsA, err := d.sessions.GetUserIPAddresses(a)
+	if err != nil {
+		return false, err
+	}
+	if len(ipsA) == 0 {
+		return false, nil
+	}
+	ipsB, err := d.sessions.GetUserIPAddresses(b)
+	if err != nil {
+		return false, err
+	}
+	seen := make(map[string]bool, len(ipsA))
+	for _, ip := range ipsA {
+		seen[ip] = true
+	}
+	for _, ip := range ipsB {
+		if seen[ip] {
+			return true, nil
+		}
+	}
+	return false, nil
+}
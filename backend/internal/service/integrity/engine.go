@@ -0,0 +1,389 @@
+package integrity
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/iamasit07/connect4/backend/internal/clock"
+	"github.com/iamasit07/connect4/backend/internal/domain"
+	"github.com/iamasit07/connect4/backend/internal/logging"
+	"github.com/iamasit07/connect4/backend/internal/repository"
+	"github.com/iamasit07/connect4/backend/internal/service/bot"
+)
+
+// ErrAnalysisRunning is returned when an analysis is started while another is in progress
+var ErrAnalysisRunning = errors.New("engine analysis is already running")
+
+// EngineConfig tunes the engine-assistance analyser
+type EngineConfig struct {
+	Depth            int     // search depth for bot.ScoreMoves; at 7 this is the hard bot's own search
+	OpeningMoves     int     // each player's first moves are book moves and aren't compared
+	LossCap          int     // per-move cap on score loss, so one blunder into a forced loss doesn't dominate
+	RecentGames      int     // analysed games per player that are scored
+	MinMoves         int     // compared moves a player needs before they are scored
+	BandWidth        int     // rating band size for baselines
+	MinBandPlayers   int     // other players a band needs before it replaces the default baseline
+	DefaultMatchRate float64 // baseline engine-match rate for thin bands
+	DefaultAvgLoss   float64 // baseline average loss for thin bands
+	MatchFloor       float64 // match rate a player must reach whatever their band
+	MatchMargin      float64 // how far above the band's match rate a player must be
+	LossRatio        float64 // average loss at or below this share of the band's counts as low
+	ConsistentCV     float64 // move-time variation (stddev / mean) at or below this looks machine-like
+	Threshold        int     // score (0-100) at which a player is flagged
+	BatchSize        int     // games loaded per query
+}
+
+// DefaultEngineConfig returns the scoring defaults with the given solver depth
+func DefaultEngineConfig(depth int) EngineConfig {
+	return EngineConfig{
+		Depth:            depth,
+		OpeningMoves:     2,
+		LossCap:          1000,
+		RecentGames:      20,
+		MinMoves:         20,
+		BandWidth:        200,
+		MinBandPlayers:   5,
+		DefaultMatchRate: 0.6,
+		DefaultAvgLoss:   150,
+		MatchFloor:       0.85,
+		MatchMargin:      0.15,
+		LossRatio:        0.5,
+		ConsistentCV:     0.35,
+		Threshold:        75,
+		BatchSize:        50,
+	}
+}
+
+// EngineAnalyzer replays finished games through the bot's minimax search and
+// flags players whose moves match it far more often than their rating band.
+// The search is depth-limited rather than a perfect solver, so the match rate
+// measures agreement with the hard bot: someone copying a stronger engine
+// only stands out where the two agree. Only one analysis runs at a time.
+type EngineAnalyzer struct {
+	analyses repository.AnalysisRepository
+	users    repository.UserRepository
+	flags    repository.IntegrityRepository
+	clock    clock.Clock
+	cfg      EngineConfig
+
+	mu   sync.Mutex
+	last domain.EngineAnalysisRun
+}
+
+func NewEngineAnalyzer(analyses repository.AnalysisRepository, users repository.UserRepository, flags repository.IntegrityRepository, clk clock.Clock, cfg EngineConfig) *EngineAnalyzer {
+	return &EngineAnalyzer{analyses: analyses, users: users, flags: flags, clock: clk, cfg: cfg}
+}
+
+// Start analyses immediately and then every interval. A non-positive interval disables it.
+func (a *EngineAnalyzer) Start(interval time.Duration) {
+	runEvery("Engine analysis", interval, func() {
+		flagged, err := a.Analyze()
+		if errors.Is(err, ErrAnalysisRunning) {
+			logging.For("integrity").Info("Engine analysis still running, skipping this run")
+			return
+		}
+		if err != nil {
+			logging.For("integrity").Error("Engine analysis failed", logging.Err(err))
+			return
+		}
+		if flagged > 0 {
+			logging.For("integrity").Info("Flagged players for engine assistance", "count", flagged)
+		}
+	})
+}
+
+// Analyze replays every game not analysed yet, then rescores the players
+// involved. It returns how many flags were raised or refreshed, or
+// ErrAnalysisRunning if another analysis hasn't finished.
+func (a *EngineAnalyzer) Analyze() (int, error) {
+	run, ok := a.begin()
+	if !ok {
+		return 0, ErrAnalysisRunning
+	}
+	flagged, err := a.analyze()
+	a.finish(run.ID, flagged, err)
+	return flagged, err
+}
+
+// Trigger starts an analysis in the background and returns its run. It
+// returns the run in progress and false if one hasn't finished.
+func (a *EngineAnalyzer) Trigger() (domain.EngineAnalysisRun, bool) {
+	run, ok := a.begin()
+	if !ok {
+		return run, false
+	}
+	go func() {
+		flagged, err := a.analyze()
+		if err != nil {
+			logging.For("integrity").Error("Engine analysis failed", logging.Err(err))
+		}
+		a.finish(run.ID, flagged, err)
+	}()
+	return run, true
+}
+
+// Status returns the latest run, which is still going if Running is set
+func (a *EngineAnalyzer) Status() domain.EngineAnalysisRun {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.last
+}
+
+// begin records a new run, unless one is already in progress
+func (a *EngineAnalyzer) begin() (domain.EngineAnalysisRun, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.last.Running {
+		return a.last, false
+	}
+	a.last = domain.EngineAnalysisRun{ID: a.last.ID + 1, Running: true, StartedAt: a.clock.Now()}
+	return a.last, true
+}
+
+func (a *EngineAnalyzer) finish(id, flagged int, err error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	now := a.clock.Now()
+	a.last = domain.EngineAnalysisRun{ID: id, Flagged: flagged, StartedAt: a.last.StartedAt, FinishedAt: &now}
+	if err != nil {
+		a.last.Error = err.Error()
+	}
+}
+
+func (a *EngineAnalyzer) analyze() (int, error) {
+	touched := make(map[int64]bool)
+	for {
+		games, err := a.analyses.GetUnanalyzedGames(a.cfg.BatchSize)
+		if err != nil {
+			return 0, err
+		}
+		for _, g := range games {
+			results, err := a.analyzeGame(g)
+			if err != nil {
+				return 0, err
+			}
+			if err := a.analyses.SaveGameAnalysis(g.GameID, results); err != nil {
+				return 0, err
+			}
+			for _, r := range results {
+				touched[r.UserID] = true
+			}
+		}
+		if len(games) < a.cfg.BatchSize {
+			break
+		}
+	}
+
+	flagged := 0
+	for userID := range touched {
+		raised, err := a.evaluate(userID)
+		if err != nil {
+			return flagged, err
+		}
+		if raised {
+			flagged++
+		}
+	}
+	return flagged, nil
+}
+
+// moveStats accumulates one player's comparison with the solver in one game
+type moveStats struct {
+	played, compared, matches, loss int
+	thinkMs                         []float64
+}
+
+// analyzeGame replays a game between two people and compares each move with
+// the solver. Bot games are skipped: the baselines are for human opponents.
+func (a *EngineAnalyzer) analyzeGame(g domain.RecordedGame) ([]domain.PlayerGameAnalysis, error) {
+	if g.Player2ID == nil {
+		return nil, nil
+	}
+	userIDs := map[domain.PlayerID]int64{domain.Player1: g.Player1ID, domain.Player2: *g.Player2ID}
+	ratings := map[domain.PlayerID]int{domain.Player1: g.Player1Rating, domain.Player2: g.Player2Rating}
+	stats := map[domain.PlayerID]*moveStats{domain.Player1: {}, domain.Player2: {}}
+
+	board := domain.NewBoard()
+	for _, m := range g.Moves {
+		s, ok := stats[m.Player]
+		if !ok || !domain.IsValidMove(board, m.Column) {
+			logging.For("integrity").Warn("Invalid move history, skipping the rest", logging.GameID(g.GameID))
+			break
+		}
+		if s.played >= a.cfg.OpeningMoves {
+			s.thinkMs = append(s.thinkMs, float64(m.ThinkMs))
+			a.compare(board, m, s)
+		}
+		s.played++
+		domain.DropDisk(board, m.Column, m.Player)
+	}
+
+	var results []domain.PlayerGameAnalysis
+	for player, userID := range userIDs {
+		s := stats[player]
+		if s.compared == 0 {
+			continue
+		}
+		// Players deleted since (expired guests) have nothing to flag
+		user, err := a.users.GetUserByID(userID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to look up user %d: %v", userID, err)
+		}
+		if user == nil {
+			continue
+		}
+		mean, stddev := meanStdDev(s.thinkMs)
+		results = append(results, domain.PlayerGameAnalysis{
+			GameID:        g.GameID,
+			UserID:        userID,
+			Rating:        ratings[player],
+			Moves:         s.compared,
+			EngineMatches: s.matches,
+			TotalLoss:     s.loss,
+			ThinkMeanMs:   mean,
+			ThinkStdDevMs: stddev,
+			FinishedAt:    g.FinishedAt,
+		})
+	}
+	return results, nil
+}
+
+// compare scores the position before move m. Positions where every move
+// scores the same (forced or already decided) say nothing and are skipped.
+func (a *EngineAnalyzer) compare(board [][]domain.PlayerID, m domain.MoveRecord, s *moveStats) {
+	scores := bot.ScoreMoves(board, m.Player, a.cfg.Depth)
+	best, worst := math.MinInt, math.MaxInt
+	for _, score := range scores {
+		best = max(best, score)
+		worst = min(worst, score)
+	}
+	if best == worst {
+		return
+	}
+	s.compared++
+	if scores[m.Column] == best {
+		s.matches++
+	}
+	s.loss += min(best-scores[m.Column], a.cfg.LossCap)
+}
+
+// evaluate scores the player's recent analysed games against their rating
+// band and flags them when over the threshold. A reviewed flag is only raised
+// again once a game played after the review has been analysed.
+func (a *EngineAnalyzer) evaluate(userID int64) (bool, error) {
+	analyses, err := a.analyses.GetPlayerAnalyses(userID, a.cfg.RecentGames)
+	if err != nil || len(analyses) == 0 {
+		return false, err
+	}
+	evidence := summarize(analyses)
+	if evidence.Moves < a.cfg.MinMoves {
+		return false, nil
+	}
+
+	low := analyses[0].Rating / a.cfg.BandWidth * a.cfg.BandWidth
+	band, err := a.analyses.GetBandStats(low, low+a.cfg.BandWidth-1, userID)
+	if err != nil {
+		return false, err
+	}
+	evidence.BandMatchRate, evidence.BandAvgLoss = a.cfg.DefaultMatchRate, a.cfg.DefaultAvgLoss
+	if band.Players >= a.cfg.MinBandPlayers && band.Moves > 0 {
+		evidence.BandMatchRate = float64(band.EngineMatches) / float64(band.Moves)
+		evidence.BandAvgLoss = float64(band.TotalLoss) / float64(band.Moves)
+	}
+
+	score, signals := engineScore(evidence, a.cfg)
+	if score < a.cfg.Threshold {
+		return false, nil
+	}
+
+	now := a.clock.Now()
+	flag, err := a.flags.GetLatestFlag(domain.FlagKindEngine, userID, 0)
+	if err != nil {
+		return false, err
+	}
+	if flag != nil && flag.Status != domain.FlagOpen {
+		if !analyses[0].FinishedAt.After(flag.UpdatedAt) {
+			return false, nil
+		}
+		flag = nil
+	}
+	if flag == nil {
+		flag = &domain.IntegrityFlag{Kind: domain.FlagKindEngine, UserID: userID, Status: domain.FlagOpen, CreatedAt: now}
+	}
+	flag.Score = score
+	flag.Signals = signals
+	flag.Games = evidence.Games
+	flag.Evidence = &evidence
+	flag.UpdatedAt = now
+	if err := a.flags.SaveFlag(flag); err != nil {
+		return false, fmt.Errorf("failed to save integrity flag: %v", err)
+	}
+	return true, nil
+}
+
+// summarize totals a player's analyses. ThinkTimeCV is the move-weighted
+// average of each game's stddev/mean, over games with recorded think times.
+func summarize(analyses []domain.PlayerGameAnalysis) domain.EngineEvidence {
+	e := domain.EngineEvidence{Games: len(analyses), GameIDs: make([]string, 0, len(analyses))}
+	matches, loss, timedMoves := 0, 0, 0
+	cv := 0.0
+	for _, a := range analyses {
+		e.Moves += a.Moves
+		matches += a.EngineMatches
+		loss += a.TotalLoss
+		e.GameIDs = append(e.GameIDs, a.GameID)
+		if a.ThinkMeanMs > 0 {
+			cv += a.ThinkStdDevMs / a.ThinkMeanMs * float64(a.Moves)
+			timedMoves += a.Moves
+		}
+	}
+	if e.Moves > 0 {
+		e.MatchRate = float64(matches) / float64(e.Moves)
+		e.AvgLoss = float64(loss) / float64(e.Moves)
+	}
+	e.ThinkTimeCV = -1
+	if timedMoves > 0 {
+		e.ThinkTimeCV = cv / float64(timedMoves)
+	}
+	return e
+}
+
+// engineScore weighs the evidence into a 0-100 score: matching the solver
+// well above the band (+50), losing much less per move than the band (+25)
+// and unusually even move times (+25). Without the match signal a player
+// can't reach the default threshold.
+func engineScore(e domain.EngineEvidence, cfg EngineConfig) (int, []string) {
+	score, signals := 0, []string{}
+	if e.MatchRate >= cfg.MatchFloor && e.MatchRate >= e.BandMatchRate+cfg.MatchMargin {
+		score += 50
+		signals = append(signals, domain.SignalEngineMatch)
+	}
+	if e.AvgLoss <= e.BandAvgLoss*cfg.LossRatio {
+		score += 25
+		signals = append(signals, domain.SignalLowMoveLoss)
+	}
+	if e.ThinkTimeCV >= 0 && e.ThinkTimeCV <= cfg.ConsistentCV {
+		score += 25
+		signals = append(signals, domain.SignalConsistentTiming)
+	}
+	return score, signals
+}
+
+func meanStdDev(values []float64) (float64, float64) {
+	if len(values) == 0 {
+		return 0, 0
+	}
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(len(values))
+	var sq float64
+	for _, v := range values {
+		sq += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(sq / float64(len(values)))
+}
@@ -0,0 +1,122 @@
+package integrity
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/iamasit07/connect4/backend/internal/clock"
+	"github.com/iamasit07/connect4/backend/internal/domain"
+	"github.com/iamasit07/connect4/backend/internal/repository"
+	"github.com/iamasit07/connect4/backend/internal/repository/memory"
+)
+
+// blockingAnalyses holds GetUnanalyzedGames until release is closed
+type blockingAnalyses struct {
+	repository.AnalysisRepository
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (b *blockingAnalyses) GetUnanalyzedGames(int) ([]domain.RecordedGame, error) {
+	b.entered <- struct{}{}
+	<-b.release
+	return nil, nil
+}
+
+func TestEngineAnalyzerRunsOneAtATime(t *testing.T) {
+	repo := &blockingAnalyses{entered: make(chan struct{}, 1), release: make(chan struct{})}
+	a := NewEngineAnalyzer(repo, nil, nil, clock.NewFake(time.Unix(0, 0)), DefaultEngineConfig(1))
+
+	run, ok := a.Trigger()
+	if !ok || run.ID != 1 || !run.Running {
+		t.Fatalf("Trigger() = %+v, %v, want run 1 started", run, ok)
+	}
+	<-repo.entered
+
+	if busy, ok := a.Trigger(); ok || busy.ID != 1 {
+		t.Errorf("second Trigger() = %+v, %v, want run 1 still in progress", busy, ok)
+	}
+	if _, err := a.Analyze(); !errors.Is(err, ErrAnalysisRunning) {
+		t.Errorf("Analyze() during a run = %v, want ErrAnalysisRunning", err)
+	}
+
+	close(repo.release)
+	deadline := time.Now().Add(5 * time.Second)
+	for a.Status().Running {
+		if time.Now().After(deadline) {
+			t.Fatal("analysis never finished")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if status := a.Status(); status.ID != 1 || status.FinishedAt == nil || status.Error != "" {
+		t.Errorf("Status() = %+v, want run 1 finished cleanly", status)
+	}
+
+	if flagged, err := a.Analyze(); err != nil || flagged != 0 {
+		t.Errorf("Analyze() after the run = %d, %v, want 0, nil", flagged, err)
+	}
+	if status := a.Status(); status.ID != 2 || status.Running {
+		t.Errorf("Status() = %+v, want run 2 finished", status)
+	}
+}
+
+func TestEngineAnalyzerUsesRatingsAtGameTime(t *testing.T) {
+	users := memory.NewUserRepo()
+	games := memory.NewGameRepo(users)
+	analyses := memory.NewAnalysisRepo(games)
+	a := NewEngineAnalyzer(analyses, users, memory.NewIntegrityRepo(users), clock.NewFake(time.Unix(0, 0)), DefaultEngineConfig(4))
+
+	p1, err := users.CreateUser("alice", "Alice", "hash", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p2, err := users.CreateUser("bob", "Bob", "hash", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Player 1 stacks column 0 and wins every game, gaining rating each time
+	var moves []domain.MoveRecord
+	for i, col := range []int{0, 1, 0, 1, 0, 1, 0} {
+		moves = append(moves, domain.MoveRecord{Column: col, Player: domain.PlayerID(i%2 + 1), ThinkMs: 1000})
+	}
+	now := time.Unix(0, 0)
+	ratingBefore := make(map[string]int)
+	save := func(gameID string, player2 *int64) {
+		t.Helper()
+		user, _ := users.GetUserByID(p1)
+		ratingBefore[gameID] = user.Rating
+		if err := games.SaveGame(gameID, p1, "alice", player2, "bob", &p1, "alice", "connect_four", len(moves), 60, now, now, nil, true, nil, moves); err != nil {
+			t.Fatal(err)
+		}
+	}
+	save("bot", nil)
+	save("first", &p2)
+	save("second", &p2)
+	if ratingBefore["first"] == ratingBefore["second"] {
+		t.Fatalf("rating unchanged at %d by a rated win", ratingBefore["first"])
+	}
+
+	if _, err := a.Analyze(); err != nil {
+		t.Fatal(err)
+	}
+	results, err := analyses.GetPlayerAnalyses(p1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	byGame := make(map[string]domain.PlayerGameAnalysis)
+	for _, r := range results {
+		byGame[r.GameID] = r
+	}
+	if _, ok := byGame["bot"]; ok {
+		t.Error("bot game was analysed")
+	}
+	for _, gameID := range []string{"first", "second"} {
+		if r, ok := byGame[gameID]; !ok || r.Rating != ratingBefore[gameID] {
+			t.Errorf("%s game analysis = %+v, want rating %d going into the game", gameID, r, ratingBefore[gameID])
+		}
+	}
+	if games, err := analyses.GetUnanalyzedGames(10); err != nil || len(games) != 0 {
+		t.Errorf("unanalysed games = %v, %v, want the bot game marked analysed too", games, err)
+	}
+}
@@ -0,0 +1,101 @@
+package integrity
+
+import (
+	"time"
+
+	"github.com/iamasit07/connect4/backend/internal/domain"
+)
+
+// pair is every rated game between two players (a < b) inside the scan window
+type pair struct {
+	a, b  int64
+	games []domain.GameResult
+}
+
+func groupByPair(games []domain.GameResult) map[[2]int64]*pair {
+	pairs := make(map[[2]int64]*pair)
+	for _, g := range games {
+		if g.Player2ID == nil {
+			continue
+		}
+		a, b := g.Player1ID, *g.Player2ID
+		if a > b {
+			a, b = b, a
+		}
+		key := [2]int64{a, b}
+		if pairs[key] == nil {
+			pairs[key] = &pair{a: a, b: b}
+		}
+		pairs[key].games = append(pairs[key].games, g)
+	}
+	return pairs
+}
+
+func (p *pair) playedSince(t time.Time) bool {
+	for _, g := range p.games {
+		if g.FinishedAt.After(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// result is a pairing's suspicion score and the player who gained from it
+type result struct {
+	score       int
+	signals     []string
+	beneficiary int64
+}
+
+// score weighs the pairing's signals into a 0-100 suspicion score:
+// repeated pairing (+30, +10 more at twice the minimum), mostly quick
+// surrenders (+30), one player winning at least 80% of decisive games (+20)
+// and a shared login IP (+30)
+func score(p *pair, cfg Config, sharedIP bool) result {
+	res := result{signals: []string{}}
+	if len(p.games) >= cfg.MinPairGames {
+		res.score += 30
+		if len(p.games) >= 2*cfg.MinPairGames {
+			res.score += 10
+		}
+		res.signals = append(res.signals, domain.SignalRepeatedPairing)
+	}
+
+	quick, decisive := 0, 0
+	wins := map[int64]int{}
+	for _, g := range p.games {
+		if isSurrender(g.Reason) && g.TotalMoves <= cfg.QuickGameMoves {
+			quick++
+		}
+		if g.WinnerID != nil {
+			decisive++
+			wins[*g.WinnerID]++
+		}
+	}
+	if quick*2 >= len(p.games) {
+		res.score += 30
+		res.signals = append(res.signals, domain.SignalQuickSurrenders)
+	}
+
+	res.beneficiary = p.a
+	if wins[p.b] > wins[p.a] {
+		res.beneficiary = p.b
+	}
+	if decisive > 0 && wins[res.beneficiary]*5 >= decisive*4 {
+		res.score += 20
+		res.signals = append(res.signals, domain.SignalLopsided)
+	}
+
+	if sharedIP {
+		res.score += 30
+		res.signals = append(res.signals, domain.SignalSharedIP)
+	}
+	res.score = min(res.score, 100)
+	return res
+}
+
+// isSurrender reports whether a game was given up (resigned, abandoned or
+// timed out) rather than played out
+func isSurrender(reason string) bool {
+	return reason == "surrender" || reason == "abandonment" || reason == "timeout"
+}
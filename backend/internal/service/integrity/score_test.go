@@ -0,0 +1,97 @@
+package integrity
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/iamasit07/connect4/backend/internal/domain"
+)
+
+// games builds n rated games between players 1 and 2 that winner wins by reason
+func games(n int, winner int64, reason string, moves int) []domain.GameResult {
+	p2, w := int64(2), winner
+	out := make([]domain.GameResult, n)
+	for i := range out {
+		out[i] = domain.GameResult{Player1ID: 1, Player2ID: &p2, WinnerID: &w, Reason: reason, TotalMoves: moves, Rated: true}
+	}
+	return out
+}
+
+func TestScore(t *testing.T) {
+	cfg := DefaultConfig(0, 0)
+	tests := []struct {
+		name        string
+		games       []domain.GameResult
+		sharedIP    bool
+		wantScore   int
+		wantSignals []string
+		beneficiary int64
+	}{
+		{
+			name:        "few games",
+			games:       games(4, 2, "abandonment", 2),
+			wantScore:   50,
+			wantSignals: []string{domain.SignalQuickSurrenders, domain.SignalLopsided},
+			beneficiary: 2,
+		},
+		{
+			name:        "played out games",
+			games:       append(games(3, 1, "connect_four", 20), games(3, 2, "connect_four", 20)...),
+			wantScore:   30,
+			wantSignals: []string{domain.SignalRepeatedPairing},
+			beneficiary: 1,
+		},
+		{
+			name:        "win trading",
+			games:       games(5, 2, "surrender", 4),
+			sharedIP:    true,
+			wantScore:   100,
+			wantSignals: []string{domain.SignalRepeatedPairing, domain.SignalQuickSurrenders, domain.SignalLopsided, domain.SignalSharedIP},
+			beneficiary: 2,
+		},
+		{
+			name:        "long losing streak",
+			games:       games(10, 1, "connect_four", 30),
+			wantScore:   60,
+			wantSignals: []string{domain.SignalRepeatedPairing, domain.SignalLopsided},
+			beneficiary: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		p := groupByPair(tt.games)[[2]int64{1, 2}]
+		got := score(p, cfg, tt.sharedIP)
+		if got.score != tt.wantScore || !slices.Equal(got.signals, tt.wantSignals) || got.beneficiary != tt.beneficiary {
+			t.Errorf("%s: score = %d %v (beneficiary %d), want %d %v (beneficiary %d)",
+				tt.name, got.score, got.signals, got.beneficiary, tt.wantScore, tt.wantSignals, tt.beneficiary)
+		}
+	}
+}
+
+func TestEngineScore(t *testing.T) {
+	cfg := DefaultEngineConfig(0)
+	band := domain.EngineEvidence{BandMatchRate: 0.6, BandAvgLoss: 150}
+	tests := []struct {
+		name        string
+		matchRate   float64
+		avgLoss     float64
+		thinkCV     float64
+		wantScore   int
+		wantSignals []string
+	}{
+		{"human", 0.65, 140, 0.9, 0, []string{}},
+		{"strong human", 0.8, 60, 0.8, 25, []string{domain.SignalLowMoveLoss}},
+		{"engine at a human pace", 0.95, 20, 0.7, 75, []string{domain.SignalEngineMatch, domain.SignalLowMoveLoss}},
+		{"engine", 1, 0, 0.1, 100, []string{domain.SignalEngineMatch, domain.SignalLowMoveLoss, domain.SignalConsistentTiming}},
+		{"no timings", 0.6, 150, -1, 0, []string{}},
+	}
+
+	for _, tt := range tests {
+		e := band
+		e.MatchRate, e.AvgLoss, e.ThinkTimeCV = tt.matchRate, tt.avgLoss, tt.thinkCV
+		score, signals := engineScore(e, cfg)
+		if score != tt.wantScore || !slices.Equal(signals, tt.wantSignals) {
+			t.Errorf("%s: engineScore = %d %v, want %d %v", tt.name, score, signals, tt.wantScore, tt.wantSignals)
+		}
+	}
+}
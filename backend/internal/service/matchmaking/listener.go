@@ -1,8 +1,7 @@
 package matchmaking
 
 import (
-	"log"
-
+	"github.com/iamasit07/connect4/backend/internal/logging"
 	"github.com/iamasit07/connect4/backend/internal/service/game"
 )
 
@@ -15,9 +14,9 @@ func MatchMakingListener(queue *MatchmakingQueue, sm *game.SessionManager) {
 		player2ID := match.Player2ID
 		player2Username := match.Player2Username
 
-		session := sm.CreateSession(player1ID, player1Username, player2ID, player2Username, match.BotDifficulty)
+		session := sm.CreateSession(player1ID, player1Username, player2ID, player2Username, match.BotDifficulty, match.Rated)
 
-		log.Printf("[MATCHMAKING] Match started: %s vs %s (game: %s)",
-			player1Username, player2Username, session.GameID)
+		queue.log.Info("Match started", logging.GameID(session.GameID),
+			"player1_id", player1ID, "player2_id", player2ID, "rated", match.Rated)
 	}
 }
\ No newline at end of file
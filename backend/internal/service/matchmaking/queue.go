@@ -1,11 +1,13 @@
 package matchmaking
 
 import (
+	"log/slog"
 	"sync"
 	"time"
 
-	"github.com/iamasit07/connect4/backend/internal/config"
+	"github.com/iamasit07/connect4/backend/internal/clock"
 	"github.com/iamasit07/connect4/backend/internal/domain"
+	"github.com/iamasit07/connect4/backend/internal/logging"
 )
 
 type Match struct {
@@ -14,37 +16,117 @@ type Match struct {
 	Player2ID       *int64 // nil for BOT
 	Player2Username string
 	BotDifficulty   string // "easy", "medium", "hard" - only used for bot games
+	Rated           bool   // whether the result affects player ratings
+}
+
+// BlockChecker reports the users a player must never be paired with
+type BlockChecker interface {
+	BlockedIDs(userID int64) (map[int64]bool, error)
+}
+
+// PairingThrottle reports opponents a player has met too often in rated games
+type PairingThrottle interface {
+	ThrottledOpponents(userID int64) (map[int64]bool, error)
 }
 
 type MatchmakingQueue struct {
-	WaitingPlayers map[int64]string         // userID → username
+	WaitingPlayers map[int64]string         // rated queue: userID → username
+	CasualPlayers  map[int64]string         // casual queue: userID → username
 	Difficulties   map[int64]string         // userID → bot difficulty
 	Mux            *sync.Mutex
 	MatchChannel   chan Match
-	Timer          *map[int64]*time.Timer
+	Timer          *map[int64]clock.Timer
 	OnTimeout      func(userID int64)
+	Timeout        time.Duration // how long a player waits for an opponent before OnTimeout fires
+	clock          clock.Clock
+	blocks         BlockChecker
+	throttle       PairingThrottle
+	log            *slog.Logger
 }
 
-func NewMatchmakingQueue(onTimeout func(userID int64)) *MatchmakingQueue {
-	timerMap := make(map[int64]*time.Timer)
+// NewMatchmakingQueue creates a queue whose wait timeouts are scheduled on clk
+func NewMatchmakingQueue(timeout time.Duration, clk clock.Clock, onTimeout func(userID int64)) *MatchmakingQueue {
+	timerMap := make(map[int64]clock.Timer)
 	waitingPlayers := make(map[int64]string) // userID → username
+	casualPlayers := make(map[int64]string)  // userID → username
 	difficulties := make(map[int64]string)   // userID → difficulty
 	queue := &MatchmakingQueue{
 		WaitingPlayers: waitingPlayers,
+		CasualPlayers:  casualPlayers,
 		Difficulties:   difficulties,
 		MatchChannel:   make(chan Match, 100),
 		Mux:            &sync.Mutex{},
 		Timer:          &timerMap,
 		OnTimeout:      onTimeout,
+		Timeout:        timeout,
+		clock:          clk,
+		log:            logging.For("matchmaking"),
 	}
 	return queue
 }
 
-func (m *MatchmakingQueue) AddPlayerToQueue(userID int64, username string, difficulty string) error {
+// SetBlockChecker makes pairing skip opponents either player has blocked
+func (m *MatchmakingQueue) SetBlockChecker(blocks BlockChecker) {
+	m.blocks = blocks
+}
+
+// SetPairingThrottle stops the rated queue pairing the same two players too often
+func (m *MatchmakingQueue) SetPairingThrottle(throttle PairingThrottle) {
+	m.throttle = throttle
+}
+
+// excludedOpponents returns who the user must not be paired with in this queue
+func (m *MatchmakingQueue) excludedOpponents(userID int64, rated bool) map[int64]bool {
+	excluded := make(map[int64]bool)
+	if m.blocks != nil {
+		ids, err := m.blocks.BlockedIDs(userID)
+		if err != nil {
+			m.log.Error("Failed to load blocks", logging.UserID(userID), logging.Err(err))
+		}
+		for id := range ids {
+			excluded[id] = true
+		}
+	}
+	if m.throttle != nil && rated {
+		ids, err := m.throttle.ThrottledOpponents(userID)
+		if err != nil {
+			m.log.Error("Failed to load pairing limits", logging.UserID(userID), logging.Err(err))
+		}
+		for id := range ids {
+			excluded[id] = true
+		}
+	}
+	return excluded
+}
+
+// queueFor returns the waiting pool for the requested game mode (caller must hold Mux)
+func (m *MatchmakingQueue) queueFor(rated bool) map[int64]string {
+	if rated {
+		return m.WaitingPlayers
+	}
+	return m.CasualPlayers
+}
+
+// isWaiting reports whether the user sits in either queue (caller must hold Mux)
+func (m *MatchmakingQueue) isWaiting(userID int64) bool {
+	if _, exists := m.WaitingPlayers[userID]; exists {
+		return true
+	}
+	_, exists := m.CasualPlayers[userID]
+	return exists
+}
+
+func (m *MatchmakingQueue) AddPlayerToQueue(userID int64, username string, difficulty string, rated bool) error {
+	// Load exclusions before taking the lock so a slow store doesn't stall the queue
+	var excluded map[int64]bool
+	if difficulty == "" {
+		excluded = m.excludedOpponents(userID, rated)
+	}
+
 	m.Mux.Lock()
 	defer m.Mux.Unlock()
 
-	if _, exists := m.WaitingPlayers[userID]; exists {
+	if m.isWaiting(userID) {
 		return nil
 	}
 
@@ -57,29 +139,35 @@ func (m *MatchmakingQueue) AddPlayerToQueue(userID int64, username string, diffi
 			Player2ID:       nil,
 			Player2Username: domain.GetBotName(difficulty),
 			BotDifficulty:   difficulty,
+			Rated:           rated,
 		}
 		m.MatchChannel <- match
 		return nil
 	}
 
-	// No difficulty = online matchmaking
-	if len(m.WaitingPlayers) == 0 {
-		m.WaitingPlayers[userID] = username
+	// No difficulty = online matchmaking within the chosen (rated/casual) queue
+	queue := m.queueFor(rated)
+	var opponentID int64
+	var opponentUsername string
+	found := false
+	for uid, name := range queue {
+		if excluded[uid] {
+			continue
+		}
+		opponentID, opponentUsername, found = uid, name, true
+		break
+	}
+
+	if !found {
+		queue[userID] = username
 		m.Difficulties[userID] = difficulty
-		timer := time.AfterFunc(config.AppConfig.MatchmakingTimeout, func() {
+		timer := m.clock.AfterFunc(m.Timeout, func() {
 			m.HandleTimeout(userID)
 		})
 		(*m.Timer)[userID] = timer
+		m.log.Debug("Player queued", logging.UserID(userID), "rated", rated)
 	} else {
-		var opponentID int64
-		var opponentUsername string
-		for uid, name := range m.WaitingPlayers {
-			opponentID = uid
-			opponentUsername = name
-			break
-		}
-
-		delete(m.WaitingPlayers, opponentID)
+		delete(queue, opponentID)
 		delete(m.Difficulties, opponentID)
 		m.stopAndDeleteTimer(opponentID)
 
@@ -89,6 +177,7 @@ func (m *MatchmakingQueue) AddPlayerToQueue(userID int64, username string, diffi
 			Player2ID:       &userID,
 			Player2Username: username,
 			BotDifficulty:   "", // PvP game, no difficulty needed
+			Rated:           rated,
 		}
 
 		m.MatchChannel <- match
@@ -100,20 +189,25 @@ func (m *MatchmakingQueue) HandleTimeout(userID int64) {
 	m.Mux.Lock()
 	defer m.Mux.Unlock()
 
-	_, exists := m.WaitingPlayers[userID]
-	if !exists {
+	if !m.isWaiting(userID) {
 		return
 	}
 
-	delete(m.WaitingPlayers, userID)
-	delete(m.Difficulties, userID)
-	m.stopAndDeleteTimer(userID)
+	m.removeLocked(userID)
+	m.log.Debug("Queue wait timed out", logging.UserID(userID))
 	
 	if m.OnTimeout != nil {
 		go m.OnTimeout(userID)
 	}
 }
 
+// IsInQueue reports whether the user is waiting for a PvP opponent
+func (m *MatchmakingQueue) IsInQueue(userID int64) bool {
+	m.Mux.Lock()
+	defer m.Mux.Unlock()
+	return m.isWaiting(userID)
+}
+
 func (m *MatchmakingQueue) GetMatchChannel() chan Match {
 	return m.MatchChannel
 }
@@ -122,7 +216,13 @@ func (m *MatchmakingQueue) RemovePlayer(userID int64) {
 	m.Mux.Lock()
 	defer m.Mux.Unlock()
 
+	m.removeLocked(userID)
+}
+
+// removeLocked drops the user from both queues and stops their timer (caller must hold Mux)
+func (m *MatchmakingQueue) removeLocked(userID int64) {
 	delete(m.WaitingPlayers, userID)
+	delete(m.CasualPlayers, userID)
 	delete(m.Difficulties, userID)
 	m.stopAndDeleteTimer(userID)
 }
@@ -0,0 +1,95 @@
+// Package presence derives a user's online status from their connection,
+// matchmaking and game state, and reports changes so friends can be notified.
+package presence
+
+import "sync"
+
+type Status string
+
+const (
+	Offline    Status = "offline"
+	Online     Status = "online"
+	InQueue    Status = "in_queue"
+	Playing    Status = "playing"
+	Spectating Status = "spectating"
+)
+
+type ConnectionChecker interface {
+	IsOnline(userID int64) bool
+}
+
+type QueueChecker interface {
+	IsInQueue(userID int64) bool
+}
+
+type GameChecker interface {
+	IsPlaying(userID int64) bool
+	IsSpectatorAnywhere(userID int64) bool
+}
+
+// Service computes presence on demand and remembers the last status it
+// reported for each user, so Refresh only fires on real changes
+type Service struct {
+	conns    ConnectionChecker
+	queue    QueueChecker
+	games    GameChecker
+	mu       sync.Mutex
+	last     map[int64]Status
+	onChange func(userID int64, status Status)
+}
+
+func NewService(conns ConnectionChecker, queue QueueChecker, games GameChecker) *Service {
+	return &Service{
+		conns: conns,
+		queue: queue,
+		games: games,
+		last:  make(map[int64]Status),
+	}
+}
+
+// SetChangeCallback sets the function called (synchronously) when a refreshed user's status changes
+func (s *Service) SetChangeCallback(cb func(userID int64, status Status)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onChange = cb
+}
+
+// Get returns the user's current status
+func (s *Service) Get(userID int64) Status {
+	switch {
+	case !s.conns.IsOnline(userID):
+		return Offline
+	case s.games.IsPlaying(userID):
+		return Playing
+	case s.queue.IsInQueue(userID):
+		return InQueue
+	case s.games.IsSpectatorAnywhere(userID):
+		return Spectating
+	default:
+		return Online
+	}
+}
+
+// Refresh recomputes the status of each user and reports the ones that changed
+func (s *Service) Refresh(userIDs ...int64) {
+	for _, userID := range userIDs {
+		status := s.Get(userID)
+
+		s.mu.Lock()
+		prev, known := s.last[userID]
+		if !known {
+			prev = Offline
+		}
+		if status == Offline {
+			delete(s.last, userID)
+		} else {
+			s.last[userID] = status
+		}
+		cb := s.onChange
+		s.mu.Unlock()
+
+		if status != prev && cb != nil {
+			cb(userID, status)
+		}
+	}
+}
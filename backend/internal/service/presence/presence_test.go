@@ -0,0 +1,85 @@
+package presence
+
+import (
+	"slices"
+	"testing"
+)
+
+// state is one user's connection, queue and game flags; it implements all three checkers
+type state struct {
+	online, queued, playing, spectating bool
+}
+
+func (s *state) IsOnline(int64) bool            { return s.online }
+func (s *state) IsInQueue(int64) bool           { return s.queued }
+func (s *state) IsPlaying(int64) bool           { return s.playing }
+func (s *state) IsSpectatorAnywhere(int64) bool { return s.spectating }
+
+func TestGet(t *testing.T) {
+	tests := []struct {
+		name  string
+		state state
+		want  Status
+	}{
+		{"disconnected", state{}, Offline},
+		{"disconnected while a game is still live", state{playing: true, queued: true}, Offline},
+		{"connected", state{online: true}, Online},
+		{"in queue", state{online: true, queued: true}, InQueue},
+		{"playing beats queueing", state{online: true, queued: true, playing: true}, Playing},
+		{"queueing beats watching", state{online: true, queued: true, spectating: true}, InQueue},
+		{"spectating", state{online: true, spectating: true}, Spectating},
+	}
+	for _, tt := range tests {
+		s := tt.state
+		if got := NewService(&s, &s, &s).Get(1); got != tt.want {
+			t.Errorf("%s: Get = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRefreshReportsTransitions(t *testing.T) {
+	var s state
+	svc := NewService(&s, &s, &s)
+	var reported []Status
+	svc.SetChangeCallback(func(userID int64, status Status) { reported = append(reported, status) })
+
+	// Each step changes the user's state, refreshes and lists what was reported
+	steps := []struct {
+		name   string
+		change func()
+		want   []Status
+	}{
+		{"still offline", func() {}, nil},
+		{"connects", func() { s.online = true }, []Status{Online}},
+		{"refresh without a change", func() {}, nil},
+		{"joins the queue", func() { s.queued = true }, []Status{InQueue}},
+		{"gets matched", func() { s.queued, s.playing = false, true }, []Status{Playing}},
+		{"game ends", func() { s.playing = false }, []Status{Online}},
+		{"watches a game", func() { s.spectating = true }, []Status{Spectating}},
+		{"disconnects", func() { s.online = false }, []Status{Offline}},
+		{"offline again", func() {}, nil},
+		{"reconnects while still watching", func() { s.online = true }, []Status{Spectating}},
+	}
+	for _, step := range steps {
+		reported = nil
+		step.change()
+		svc.Refresh(1)
+		if !slices.Equal(reported, step.want) {
+			t.Errorf("%s: reported %v, want %v", step.name, reported, step.want)
+		}
+	}
+}
+
+func TestRefreshTracksUsersSeparately(t *testing.T) {
+	var s state
+	svc := NewService(&s, &s, &s)
+	var reported []int64
+	svc.SetChangeCallback(func(userID int64, status Status) { reported = append(reported, userID) })
+
+	s.online = true
+	svc.Refresh(1)
+	svc.Refresh(1, 2)
+	if !slices.Equal(reported, []int64{1, 2}) {
+		t.Errorf("reported users %v, want 1 then 2 once each", reported)
+	}
+}
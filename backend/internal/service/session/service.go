@@ -5,11 +5,11 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
 	"time"
 
 	"github.com/iamasit07/connect4/backend/internal/config"
 	"github.com/iamasit07/connect4/backend/internal/domain"
+	"github.com/iamasit07/connect4/backend/internal/logging"
 	"github.com/iamasit07/connect4/backend/pkg/auth"
 )
 
@@ -21,7 +21,7 @@ const sessionTTL = 30 * 24 * time.Hour // 30 days
 type SessionRepository interface {
 	CreateSession(userID int64, sessionID, deviceInfo, ipAddress string, expiresAt time.Time) error
 	GetSessionByID(sessionID string) (*domain.UserSession, error)
-	GetActiveSessionByUserID(userID int64) (*domain.UserSession, error)
+	ListActiveSessions(userID int64) ([]domain.UserSession, error)
 	DeactivateAllUserSessions(userID int64) error
 	DeactivateSession(sessionID string) error
 	UpdateSessionActivity(sessionID string) error
@@ -31,6 +31,7 @@ type SessionRepository interface {
 	GetRefreshToken(tokenID string) (*domain.RefreshToken, error)
 	RevokeRefreshToken(tokenID string) error
 	RevokeAllUserRefreshTokens(userID int64) error
+	RevokeSessionRefreshTokens(sessionID string) error
 }
 
 type CacheRepository interface {
@@ -119,7 +120,7 @@ func (s *AuthService) GenerateTokenPair(userID int64, username, sessionID string
 		data, err := json.Marshal(rtData)
 		if err == nil {
 			if cacheErr := s.cache.Set(ctx, key, data, refreshTTL); cacheErr != nil {
-				log.Printf("[SESSION] Warning: Failed to cache refresh token: %v", cacheErr)
+				logging.For("session").Warn("Failed to cache refresh token", logging.UserID(userID), logging.SessionID(sessionID), logging.Err(cacheErr))
 			}
 		}
 	}
@@ -156,7 +157,7 @@ func (s *AuthService) ValidateAndRefresh(refreshTokenString string) (newAccessTo
 	}
 
 	if err := s.RevokeRefreshToken(claims.TokenID); err != nil {
-		log.Printf("[SESSION] Warning: Failed to revoke old refresh token: %v", err)
+		logging.For("session").Warn("Failed to revoke old refresh token", logging.UserID(claims.UserID), logging.SessionID(claims.SessionID), logging.Err(err))
 	}
 
 	newAccessToken, newRefreshToken, err = s.GenerateTokenPair(rt.UserID, "", claims.SessionID)
@@ -201,7 +202,7 @@ func (s *AuthService) ValidateAndRefreshWithUsername(refreshTokenString string,
 	}
 
 	if err := s.RevokeRefreshToken(claims.TokenID); err != nil {
-		log.Printf("[SESSION] Warning: Failed to revoke old refresh token: %v", err)
+		logging.For("session").Warn("Failed to revoke old refresh token", logging.UserID(claims.UserID), logging.SessionID(claims.SessionID), logging.Err(err))
 	}
 
 	newAccessToken, newRefreshToken, err = s.GenerateTokenPair(rt.UserID, username, claims.SessionID)
@@ -235,7 +236,7 @@ func (s *AuthService) RevokeRefreshToken(tokenID string) error {
 		ctx := context.Background()
 		key := refreshTokenKeyPrefix + tokenID
 		if err := s.cache.Del(ctx, key); err != nil {
-			log.Printf("[SESSION] Warning: Failed to delete refresh token from cache: %v", err)
+			logging.For("session").Warn("Failed to delete refresh token from cache", logging.Err(err))
 		}
 	}
 	return nil
@@ -259,7 +260,7 @@ func (s *AuthService) SetSession(session *domain.UserSession) error {
 	if s.cache != nil {
 		err = s.setSessionInCache(session)
 		if err != nil {
-			log.Printf("[SESSION] Warning: Failed to store session in cache: %v", err)
+			logging.For("session").Warn("Failed to store session in cache", logging.UserID(session.UserID), logging.SessionID(session.SessionID), logging.Err(err))
 		}
 	}
 	return nil
@@ -289,14 +290,15 @@ func (s *AuthService) GetSession(sessionID string) (*domain.UserSession, error)
 	if session != nil && s.cache != nil {
 		err = s.setSessionInCache(session)
 		if err != nil {
-			log.Printf("[SESSION] Warning: Failed to populate cache: %v", err)
+			logging.For("session").Warn("Failed to populate cache", logging.SessionID(sessionID), logging.Err(err))
 		}
 	}
 	return session, nil
 }
 
-func (s *AuthService) GetActiveSession(userID int64) (*domain.UserSession, error) {
-	return s.repo.GetActiveSessionByUserID(userID)
+// ListActiveSessions returns the sessions the user is signed in with, most recently used first
+func (s *AuthService) ListActiveSessions(userID int64) ([]domain.UserSession, error) {
+	return s.repo.ListActiveSessions(userID)
 }
 
 func (s *AuthService) getSessionFromCache(sessionID string) (*domain.UserSession, error) {
@@ -328,20 +330,55 @@ func (s *AuthService) InvalidateSession(sessionID string) error {
 	return s.BlocklistSession(sessionID, 1*time.Hour)
 }
 
-// InvalidateAllUserSessions deactivates all sessions and blocklists the active one
+// RevokeSession signs one device out: the session is invalidated and its refresh tokens revoked
+func (s *AuthService) RevokeSession(sessionID string) error {
+	if err := s.InvalidateSession(sessionID); err != nil {
+		return err
+	}
+	if err := s.repo.RevokeSessionRefreshTokens(sessionID); err != nil {
+		return fmt.Errorf("failed to revoke session refresh tokens: %v", err)
+	}
+	return nil
+}
+
+// MakeRoomForSession signs out the user's least recently used sessions so one
+// more fits within MaxSessionsPerUser. It returns the sessions it ended.
+func (s *AuthService) MakeRoomForSession(userID int64) ([]domain.UserSession, error) {
+	active, err := s.repo.ListActiveSessions(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list active sessions: %v", err)
+	}
+	limit := config.AppConfig.MaxSessionsPerUser
+	if limit < 1 {
+		limit = 1
+	}
+	if len(active) < limit {
+		return nil, nil
+	}
+
+	evicted := active[limit-1:]
+	for _, session := range evicted {
+		if err := s.RevokeSession(session.SessionID); err != nil {
+			return nil, err
+		}
+	}
+	return evicted, nil
+}
+
+// InvalidateAllUserSessions deactivates all sessions and blocklists the active ones
 func (s *AuthService) InvalidateAllUserSessions(userID int64) error {
-	activeSession, _ := s.repo.GetActiveSessionByUserID(userID)
-	
+	active, _ := s.repo.ListActiveSessions(userID)
+
 	err := s.repo.DeactivateAllUserSessions(userID)
 	if err != nil {
 		return fmt.Errorf("failed to deactivate user sessions in database: %v", err)
 	}
 
-	if activeSession != nil && activeSession.IsActive {
-		s.BlocklistSession(activeSession.SessionID, 1*time.Hour)
+	for _, session := range active {
+		s.BlocklistSession(session.SessionID, 1*time.Hour)
 		if s.cache != nil {
 			ctx := context.Background()
-			key := sessionKeyPrefix + activeSession.SessionID
+			key := sessionKeyPrefix + session.SessionID
 			s.cache.Del(ctx, key)
 		}
 	}
@@ -358,7 +395,7 @@ func (s *AuthService) UpdateSessionActivity(sessionID string) error {
 		if err == nil && session != nil {
 			err = s.setSessionInCache(session)
 			if err != nil {
-				log.Printf("[SESSION] Warning: Failed to update session in cache: %v", err)
+				logging.For("session").Warn("Failed to update session in cache", logging.SessionID(sessionID), logging.Err(err))
 			}
 		}
 	}
@@ -0,0 +1,226 @@
+// Package twofactor implements optional TOTP two-factor authentication with
+// single-use recovery codes.
+package twofactor
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/iamasit07/connect4/backend/internal/clock"
+	"github.com/iamasit07/connect4/backend/internal/domain"
+	"github.com/iamasit07/connect4/backend/internal/repository"
+	"github.com/iamasit07/connect4/backend/pkg/auth"
+)
+
+// recoveryCodeCount is how many recovery codes each enrollment or regeneration hands out
+const recoveryCodeCount = 10
+
+// recoveryPurpose keys the stored recovery code hashes
+const recoveryPurpose = "totp_recovery"
+
+var (
+	ErrUserNotFound   = errors.New("user not found")
+	ErrGuestAccount   = errors.New("guest accounts can't enable two-factor authentication")
+	ErrAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrNotEnabled     = errors.New("two-factor authentication is not enabled")
+	ErrNotEnrolled    = errors.New("start two-factor enrollment first")
+	ErrInvalidCode    = errors.New("invalid code")
+)
+
+// Enrollment is what the user needs to set up an authenticator app
+type Enrollment struct {
+	Secret          string   `json:"secret"`
+	ProvisioningURI string   `json:"provisioning_uri"`
+	RecoveryCodes   []string `json:"recovery_codes"`
+}
+
+type Service struct {
+	users  repository.UserRepository
+	clock  clock.Clock
+	issuer string
+	mu     sync.Mutex // serialises read-modify-write of a user's state, so a code is accepted once
+}
+
+// NewService creates the service; issuer is the name shown in authenticator apps
+func NewService(users repository.UserRepository, clk clock.Clock, issuer string) *Service {
+	return &Service{users: users, clock: clk, issuer: issuer}
+}
+
+// Enroll starts (or restarts) enrollment with a new secret and recovery codes.
+// Two-factor stays off until Confirm receives a valid code.
+func (s *Service) Enroll(userID int64) (*Enrollment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, err := s.users.GetUserByID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load user: %v", err)
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	if user.IsGuest {
+		return nil, ErrGuestAccount
+	}
+	if user.TOTPEnabled {
+		return nil, ErrAlreadyEnabled
+	}
+
+	secret := auth.GenerateTOTPSecret()
+	encrypted, err := auth.EncryptSecret(secret)
+	if err != nil {
+		return nil, err
+	}
+	codes, hashes := newRecoveryCodes()
+	if err := s.users.SaveTwoFactor(userID, domain.TwoFactor{Secret: encrypted, RecoveryCodes: hashes}); err != nil {
+		return nil, err
+	}
+	return &Enrollment{
+		Secret:          secret,
+		ProvisioningURI: auth.TOTPProvisioningURI(s.issuer, user.Username, secret),
+		RecoveryCodes:   codes,
+	}, nil
+}
+
+// Confirm turns two-factor on once the user proves their app produces valid codes
+func (s *Service) Confirm(userID int64, code string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tf, err := s.load(userID)
+	if err != nil {
+		return err
+	}
+	if tf.Enabled {
+		return ErrAlreadyEnabled
+	}
+	if tf.Secret == "" {
+		return ErrNotEnrolled
+	}
+	if err := s.checkTOTP(tf, code); err != nil {
+		return err
+	}
+	tf.Enabled = true
+	return s.users.SaveTwoFactor(userID, *tf)
+}
+
+// Verify accepts a current TOTP code or an unused recovery code, which is then spent
+func (s *Service) Verify(userID int64, code string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tf, err := s.load(userID)
+	if err != nil {
+		return err
+	}
+	if !tf.Enabled {
+		return ErrNotEnabled
+	}
+	if err := s.check(tf, code); err != nil {
+		return err
+	}
+	return s.users.SaveTwoFactor(userID, *tf)
+}
+
+// Disable turns two-factor off after checking a code
+func (s *Service) Disable(userID int64, code string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tf, err := s.load(userID)
+	if err != nil {
+		return err
+	}
+	if !tf.Enabled {
+		return ErrNotEnabled
+	}
+	if err := s.check(tf, code); err != nil {
+		return err
+	}
+	return s.users.SaveTwoFactor(userID, domain.TwoFactor{})
+}
+
+// RegenerateRecoveryCodes replaces every recovery code after checking a code
+func (s *Service) RegenerateRecoveryCodes(userID int64, code string) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tf, err := s.load(userID)
+	if err != nil {
+		return nil, err
+	}
+	if !tf.Enabled {
+		return nil, ErrNotEnabled
+	}
+	if err := s.check(tf, code); err != nil {
+		return nil, err
+	}
+	codes, hashes := newRecoveryCodes()
+	tf.RecoveryCodes = hashes
+	if err := s.users.SaveTwoFactor(userID, *tf); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+func (s *Service) load(userID int64) (*domain.TwoFactor, error) {
+	tf, err := s.users.GetTwoFactor(userID)
+	if err != nil {
+		return nil, err
+	}
+	if tf == nil {
+		return nil, ErrUserNotFound
+	}
+	return tf, nil
+}
+
+// check accepts a TOTP code or a recovery code, updating tf (caller saves it)
+func (s *Service) check(tf *domain.TwoFactor, code string) error {
+	code = normalize(code)
+	if len(code) != 6 {
+		return s.spendRecoveryCode(tf, code)
+	}
+	return s.checkTOTP(tf, code)
+}
+
+// checkTOTP accepts a TOTP code newer than the last one used and records its step
+func (s *Service) checkTOTP(tf *domain.TwoFactor, code string) error {
+	secret, err := auth.DecryptSecret(tf.Secret)
+	if err != nil {
+		return err
+	}
+	step, ok := auth.ValidateTOTP(secret, normalize(code), s.clock.Now(), tf.LastStep)
+	if !ok {
+		return ErrInvalidCode
+	}
+	tf.LastStep = step
+	return nil
+}
+
+func (s *Service) spendRecoveryCode(tf *domain.TwoFactor, code string) error {
+	hash := auth.HashAccountToken(recoveryPurpose, code)
+	for i, stored := range tf.RecoveryCodes {
+		if stored == hash {
+			tf.RecoveryCodes = append(tf.RecoveryCodes[:i], tf.RecoveryCodes[i+1:]...)
+			return nil
+		}
+	}
+	return ErrInvalidCode
+}
+
+// newRecoveryCodes returns codes formatted for the user and their stored hashes
+func newRecoveryCodes() (codes, hashes []string) {
+	for i := 0; i < recoveryCodeCount; i++ {
+		raw := auth.GenerateToken()[:10]
+		codes = append(codes, raw[:5]+"-"+raw[5:])
+		hashes = append(hashes, auth.HashAccountToken(recoveryPurpose, raw))
+	}
+	return codes, hashes
+}
+
+// normalize strips the spaces and dashes users type or paste along with codes
+func normalize(code string) string {
+	return strings.ToLower(strings.NewReplacer(" ", "", "-", "").Replace(strings.TrimSpace(code)))
+}
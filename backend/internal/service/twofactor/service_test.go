@@ -0,0 +1,193 @@
+package twofactor
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/iamasit07/connect4/backend/internal/clock"
+	"github.com/iamasit07/connect4/backend/internal/config"
+	"github.com/iamasit07/connect4/backend/internal/repository/memory"
+	"github.com/iamasit07/connect4/backend/internal/testutil"
+	"github.com/iamasit07/connect4/backend/pkg/auth"
+)
+
+const testKey = "test-totp-key"
+
+// newService returns a service with one user, alice, at the start of a 30s step
+func newService(t *testing.T) (*Service, *clock.Fake, int64) {
+	t.Helper()
+	testutil.SetConfig(t, config.Config{JWTSecret: "test-secret", TOTPEncryptionKey: testKey})
+	users := memory.NewUserRepo()
+	clk := clock.NewFake(time.Unix(1_700_000_010, 0))
+	return NewService(users, clk, "Connect 4"), clk, testutil.Users(t, users, "alice")[0]
+}
+
+// enable enrolls the user and confirms with the current code
+func enable(t *testing.T, s *Service, clk *clock.Fake, userID int64) *Enrollment {
+	t.Helper()
+	enrollment, err := s.Enroll(userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Confirm(userID, totp(t, enrollment.Secret, clk.Now())); err != nil {
+		t.Fatal(err)
+	}
+	return enrollment
+}
+
+func totp(t *testing.T, secret string, at time.Time) string {
+	t.Helper()
+	code, err := auth.TOTPCode(secret, at)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code
+}
+
+func TestTOTPStepReplay(t *testing.T) {
+	s, clk, userID := newService(t)
+	enrollment := enable(t, s, clk, userID)
+	confirmed := clk.Now()
+
+	// Steps run in order against one account
+	steps := []struct {
+		name    string
+		advance time.Duration
+		codeAt  time.Duration // offset from the current time the code is for
+		wantErr error
+	}{
+		{"confirmation code replayed", 0, 0, ErrInvalidCode},
+		{"next step", 30 * time.Second, 0, nil},
+		{"same step again", 0, 0, ErrInvalidCode},
+		{"previous step within skew", 0, -30 * time.Second, ErrInvalidCode},
+		{"skipped step within skew", 60 * time.Second, -30 * time.Second, nil},
+		{"current step after the skipped one", 0, 0, nil},
+		{"skipped step once the current is used", 0, -30 * time.Second, ErrInvalidCode},
+		{"outside skew", 30 * time.Second, -60 * time.Second, ErrInvalidCode},
+		{"future step", 0, 60 * time.Second, ErrInvalidCode},
+	}
+	for _, step := range steps {
+		clk.Advance(step.advance)
+		code := totp(t, enrollment.Secret, clk.Now().Add(step.codeAt))
+		if err := s.Verify(userID, code); !errors.Is(err, step.wantErr) {
+			t.Errorf("%s (%s after confirming): Verify = %v, want %v", step.name, clk.Now().Sub(confirmed), err, step.wantErr)
+		}
+	}
+}
+
+func TestRecoveryCodesAreSingleUse(t *testing.T) {
+	s, clk, userID := newService(t)
+	codes := enable(t, s, clk, userID).RecoveryCodes
+
+	steps := []struct {
+		name    string
+		code    string
+		wantErr error
+	}{
+		{"as printed", codes[0], nil},
+		{"reused", codes[0], ErrInvalidCode},
+		{"retyped without the dash in upper case", strings.ToUpper(strings.ReplaceAll(codes[1], "-", "")), nil},
+		{"reused retyped as printed", codes[1], ErrInvalidCode},
+		{"with spaces", " " + codes[2] + " ", nil},
+		{"unknown", "zzzzz-zzzzz", ErrInvalidCode},
+	}
+	for _, step := range steps {
+		if err := s.Verify(userID, step.code); !errors.Is(err, step.wantErr) {
+			t.Errorf("%s: Verify(%q) = %v, want %v", step.name, step.code, err, step.wantErr)
+		}
+	}
+
+	// Spending a code to regenerate also retires every old one
+	fresh, err := s.RegenerateRecoveryCodes(userID, codes[3])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Verify(userID, codes[4]); !errors.Is(err, ErrInvalidCode) {
+		t.Errorf("old code after regenerating: Verify = %v, want ErrInvalidCode", err)
+	}
+	if err := s.Verify(userID, fresh[0]); err != nil {
+		t.Errorf("new code after regenerating: Verify = %v", err)
+	}
+}
+
+func TestDisableWithRecoveryCode(t *testing.T) {
+	s, clk, userID := newService(t)
+	enrollment := enable(t, s, clk, userID)
+	if err := s.Disable(userID, enrollment.RecoveryCodes[0]); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Verify(userID, enrollment.RecoveryCodes[1]); !errors.Is(err, ErrNotEnabled) {
+		t.Errorf("Verify after disabling = %v, want ErrNotEnabled", err)
+	}
+}
+
+func TestTOTPStepReplayAcrossSessions(t *testing.T) {
+	s, clk, userID := newService(t)
+	enrollment := enable(t, s, clk, userID)
+
+	// Two sign-ins racing with the same code: only one may get through
+	for i := 0; i < 20; i++ {
+		clk.Advance(30 * time.Second)
+		code := totp(t, enrollment.Secret, clk.Now())
+		errs := make([]error, 2)
+		var wg sync.WaitGroup
+		for session := range errs {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs[session] = s.Verify(userID, code)
+			}()
+		}
+		wg.Wait()
+		if (errs[0] == nil) == (errs[1] == nil) || !errors.Is(errors.Join(errs...), ErrInvalidCode) {
+			t.Fatalf("step %d: Verify from two sessions = %v, want exactly one ErrInvalidCode", i, errs)
+		}
+	}
+}
+
+func TestMissingEncryptionKeyFailsClosed(t *testing.T) {
+	tests := []struct {
+		name    string
+		enabled bool   // two-factor turned on while the key was set
+		key     string // key in use for the call
+		call    func(s *Service, userID int64, code string) error
+	}{
+		{"enroll", false, "", func(s *Service, userID int64, _ string) error {
+			_, err := s.Enroll(userID)
+			return err
+		}},
+		{"verify after the key is removed", true, "", (*Service).Verify},
+		{"disable after the key is removed", true, "", (*Service).Disable},
+		{"verify after the key is changed", true, "another-key", (*Service).Verify},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, clk, userID := newService(t)
+			var enrollment *Enrollment
+			var code string
+			if tt.enabled {
+				enrollment = enable(t, s, clk, userID)
+				clk.Advance(30 * time.Second)
+				code = totp(t, enrollment.Secret, clk.Now())
+			}
+			testutil.SetConfig(t, config.Config{JWTSecret: "test-secret", TOTPEncryptionKey: tt.key})
+
+			err := tt.call(s, userID, code)
+			if err == nil || errors.Is(err, ErrInvalidCode) {
+				t.Fatalf("got %v, want a configuration error", err)
+			}
+			if tt.key == "" && !errors.Is(err, auth.ErrNoSecretKey) {
+				t.Errorf("got %v, want ErrNoSecretKey", err)
+			}
+			if tt.enabled {
+				// Two-factor stays on, and recovery codes need no key
+				if err := s.Verify(userID, enrollment.RecoveryCodes[0]); err != nil {
+					t.Errorf("recovery code: Verify = %v", err)
+				}
+			}
+		})
+	}
+}
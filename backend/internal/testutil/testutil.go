@@ -0,0 +1,31 @@
+// Package testutil holds helpers shared by the service unit tests
+package testutil
+
+import (
+	"testing"
+
+	"github.com/iamasit07/connect4/backend/internal/config"
+	"github.com/iamasit07/connect4/backend/internal/repository/memory"
+)
+
+// SetConfig installs cfg as config.AppConfig until the test ends
+func SetConfig(t testing.TB, cfg config.Config) {
+	t.Helper()
+	prev := config.AppConfig
+	config.AppConfig = &cfg
+	t.Cleanup(func() { config.AppConfig = prev })
+}
+
+// Users registers a user for each name and returns their IDs in the same order
+func Users(t testing.TB, repo *memory.UserRepo, names ...string) []int64 {
+	t.Helper()
+	ids := make([]int64, len(names))
+	for i, name := range names {
+		id, err := repo.CreateUser(name, name, "hash", name+"@example.com", "")
+		if err != nil {
+			t.Fatalf("create user %s: %v", name, err)
+		}
+		ids[i] = id
+	}
+	return ids
+}
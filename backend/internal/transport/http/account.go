@@ -0,0 +1,102 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/iamasit07/connect4/backend/internal/logging"
+	"github.com/iamasit07/connect4/backend/internal/service/account"
+	"github.com/iamasit07/connect4/backend/pkg/auth"
+)
+
+// AccountHandler serves email verification and password reset
+type AccountHandler struct {
+	Accounts *account.Service
+}
+
+func NewAccountHandler(as *account.Service) *AccountHandler {
+	return &AccountHandler{Accounts: as}
+}
+
+type accountTokenRequest struct {
+	Token    string `json:"token"`
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+// VerifyEmail confirms the address a verification link was sent to
+func (h *AccountHandler) VerifyEmail(c *gin.Context) {
+	var req accountTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	if err := h.Accounts.VerifyEmail(strings.TrimSpace(req.Token)); err != nil {
+		h.respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Email verified"})
+}
+
+// ResendVerification mails the caller a new verification link
+func (h *AccountHandler) ResendVerification(c *gin.Context) {
+	if err := h.Accounts.SendVerification(c.GetInt64("user_id")); err != nil {
+		h.respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"message": "Verification email sent"})
+}
+
+// ForgotPassword mails a reset link. The response is the same whether or not
+// the email is registered.
+func (h *AccountHandler) ForgotPassword(c *gin.Context) {
+	var req accountTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	req.Email = strings.TrimSpace(req.Email)
+	if req.Email == "" || !strings.Contains(req.Email, "@") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid email format"})
+		return
+	}
+	if err := h.Accounts.ForgotPassword(req.Email); err != nil {
+		h.respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"message": "If that email is registered, a reset link is on its way"})
+}
+
+// ResetPassword sets a new password from a reset link and signs the user out everywhere
+func (h *AccountHandler) ResetPassword(c *gin.Context) {
+	var req accountTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	if err := auth.ValidatePasswordStrength(req.Password); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.Accounts.ResetPassword(strings.TrimSpace(req.Token), req.Password); err != nil {
+		h.respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Password updated. Please log in again."})
+}
+
+func (h *AccountHandler) respondError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, account.ErrInvalidToken), errors.Is(err, account.ErrNoEmail):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, account.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, account.ErrAlreadyVerified):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		requestLog(c, "auth").Error("Account request failed", logging.Err(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+	}
+}
@@ -0,0 +1,235 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/iamasit07/connect4/backend/internal/logging"
+	"github.com/iamasit07/connect4/backend/internal/service/admin"
+)
+
+// maxAdminActions caps how many audit log entries one request can fetch
+const maxAdminActions = 500
+
+type AdminHandler struct {
+	Admin *admin.Service
+}
+
+func NewAdminHandler(as *admin.Service) *AdminHandler {
+	return &AdminHandler{Admin: as}
+}
+
+// moderationRequest is the optional JSON body shared by the admin endpoints
+type moderationRequest struct {
+	Reason string `json:"reason"`
+	Hours  int    `json:"hours"`
+	Role   string `json:"role"`
+	Status string `json:"status"`
+}
+
+// ListActions returns the audit log, newest first (?limit=, default 50)
+func (h *AdminHandler) ListActions(c *gin.Context) {
+	limit, ok := limitQuery(c)
+	if !ok {
+		return
+	}
+
+	actions, err := h.Admin.Actions(limit)
+	if err != nil {
+		requestLog(c, "admin").Error("Error listing actions", logging.Err(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch admin actions"})
+		return
+	}
+	c.JSON(http.StatusOK, actions)
+}
+
+func (h *AdminHandler) Ban(c *gin.Context) {
+	userID, req, ok := h.userRequest(c)
+	if !ok {
+		return
+	}
+	if err := h.Admin.Ban(actor(c), userID, req.Reason); err != nil {
+		h.respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "User banned"})
+}
+
+func (h *AdminHandler) Suspend(c *gin.Context) {
+	userID, req, ok := h.userRequest(c)
+	if !ok {
+		return
+	}
+	until, err := h.Admin.Suspend(actor(c), userID, time.Duration(req.Hours)*time.Hour, req.Reason)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "User suspended", "suspendedUntil": until})
+}
+
+// Unban lifts a ban or suspension
+func (h *AdminHandler) Unban(c *gin.Context) {
+	userID, req, ok := h.userRequest(c)
+	if !ok {
+		return
+	}
+	if err := h.Admin.Unban(actor(c), userID, req.Reason); err != nil {
+		h.respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "User unbanned"})
+}
+
+func (h *AdminHandler) SetRole(c *gin.Context) {
+	userID, req, ok := h.userRequest(c)
+	if !ok {
+		return
+	}
+	if err := h.Admin.SetRole(actor(c), userID, req.Role, req.Reason); err != nil {
+		h.respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"role": req.Role})
+}
+
+// TerminateGame force-ends a live game with no result
+func (h *AdminHandler) TerminateGame(c *gin.Context) {
+	req, ok := bindModeration(c)
+	if !ok {
+		return
+	}
+	if err := h.Admin.TerminateGame(actor(c), c.Param("id"), req.Reason); err != nil {
+		h.respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Game terminated"})
+}
+
+// VoidGame reverses a finished game's rating changes
+func (h *AdminHandler) VoidGame(c *gin.Context) {
+	req, ok := bindModeration(c)
+	if !ok {
+		return
+	}
+	if err := h.Admin.VoidGame(actor(c), c.Param("id"), req.Reason); err != nil {
+		h.respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Game voided"})
+}
+
+// ListFlags returns the win-trading review queue, highest score first (?status=open&limit=)
+func (h *AdminHandler) ListFlags(c *gin.Context) {
+	limit, ok := limitQuery(c)
+	if !ok {
+		return
+	}
+
+	flags, err := h.Admin.Flags(c.Query("status"), limit)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, flags)
+}
+
+// ScanFlags runs the win-trading detector immediately
+func (h *AdminHandler) ScanFlags(c *gin.Context) {
+	flagged, err := h.Admin.ScanFlags()
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"flagged": flagged})
+}
+
+// AnalyzeGames starts replaying unanalysed games through the solver and
+// returns 202 with the run; poll AnalysisStatus for the result
+func (h *AdminHandler) AnalyzeGames(c *gin.Context) {
+	run, err := h.Admin.AnalyzeGames()
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusAccepted, run)
+}
+
+// AnalysisStatus returns the latest engine-analysis run
+func (h *AdminHandler) AnalysisStatus(c *gin.Context) {
+	c.JSON(http.StatusOK, h.Admin.AnalysisStatus())
+}
+
+// ReviewFlag dismisses or confirms a flag ({"status": "dismissed"|"confirmed", "reason": ...})
+func (h *AdminHandler) ReviewFlag(c *gin.Context) {
+	flagID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || flagID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid flag ID"})
+		return
+	}
+	req, ok := bindModeration(c)
+	if !ok {
+		return
+	}
+	if err := h.Admin.ReviewFlag(actor(c), flagID, req.Status, req.Reason); err != nil {
+		h.respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": req.Status})
+}
+
+func (h *AdminHandler) userRequest(c *gin.Context) (int64, moderationRequest, bool) {
+	userID, ok := idParam(c)
+	if !ok {
+		return 0, moderationRequest{}, false
+	}
+	req, ok := bindModeration(c)
+	return userID, req, ok
+}
+
+func (h *AdminHandler) respondError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, admin.ErrUserNotFound), errors.Is(err, admin.ErrGameNotFound), errors.Is(err, admin.ErrFlagNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, admin.ErrSelf), errors.Is(err, admin.ErrInvalidRole), errors.Is(err, admin.ErrInvalidPeriod), errors.Is(err, admin.ErrInvalidStatus):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, admin.ErrGameNotLive), errors.Is(err, admin.ErrNotRated), errors.Is(err, admin.ErrAlreadyVoided), errors.Is(err, admin.ErrAnalysisBusy):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		requestLog(c, "admin").Error("Moderation request failed", logging.Err(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+	}
+}
+
+// bindModeration parses the optional JSON body, writing a 400 when it is malformed
+func bindModeration(c *gin.Context) (moderationRequest, bool) {
+	var req moderationRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			return req, false
+		}
+	}
+	return req, true
+}
+
+// limitQuery parses ?limit= (default 50, capped at maxAdminActions), writing a 400 when invalid
+func limitQuery(c *gin.Context) (int, bool) {
+	raw := c.Query("limit")
+	if raw == "" {
+		return 50, true
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+		return 0, false
+	}
+	return min(n, maxAdminActions), true
+}
+
+func actor(c *gin.Context) admin.Actor {
+	return admin.Actor{ID: c.GetInt64("user_id"), Username: c.GetString("username")}
+}
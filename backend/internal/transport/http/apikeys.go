@@ -0,0 +1,90 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/iamasit07/connect4/backend/internal/domain"
+	"github.com/iamasit07/connect4/backend/internal/logging"
+	"github.com/iamasit07/connect4/backend/internal/service/apikey"
+)
+
+// APIKeysHandler lets users manage their personal API keys
+type APIKeysHandler struct {
+	Keys    *apikey.Service
+	Devices DeviceTracker
+}
+
+func NewAPIKeysHandler(keys *apikey.Service, devices DeviceTracker) *APIKeysHandler {
+	return &APIKeysHandler{Keys: keys, Devices: devices}
+}
+
+type createAPIKeyRequest struct {
+	Name          string   `json:"name"`
+	Scopes        []string `json:"scopes"`
+	ExpiresInDays int      `json:"expires_in_days"` // 0 never expires
+}
+
+// List returns the caller's keys without the secrets
+func (h *APIKeysHandler) List(c *gin.Context) {
+	keys, err := h.Keys.List(c.GetInt64("user_id"))
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, keys)
+}
+
+// Create issues a key. The response is the only time the key is shown.
+func (h *APIKeysHandler) Create(c *gin.Context) {
+	var req createAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	userID := c.GetInt64("user_id")
+	plain, key, err := h.Keys.Create(userID, req.Name, req.Scopes, time.Duration(req.ExpiresInDays)*24*time.Hour)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+	requestLog(c, "auth").Info("Created API key", "api_key_id", key.ID, "scopes", key.Scopes)
+	c.JSON(http.StatusCreated, gin.H{"key": plain, "api_key": key})
+}
+
+// Revoke deletes one of the caller's keys; it stops working at once and its
+// WebSocket is closed
+func (h *APIKeysHandler) Revoke(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid key ID"})
+		return
+	}
+	userID := c.GetInt64("user_id")
+	if err := h.Keys.Revoke(userID, id); err != nil {
+		h.respondError(c, err)
+		return
+	}
+	h.Devices.DisconnectSession(userID, (&domain.APIKey{ID: id}).SessionID(), "API key revoked")
+	requestLog(c, "auth").Info("Revoked API key", "api_key_id", id)
+	c.JSON(http.StatusOK, gin.H{"message": "API key revoked"})
+}
+
+func (h *APIKeysHandler) respondError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, apikey.ErrInvalidName), errors.Is(err, apikey.ErrInvalidScope), errors.Is(err, apikey.ErrInvalidTTL):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, apikey.ErrGuestAccount):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, apikey.ErrUserNotFound), errors.Is(err, apikey.ErrKeyNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, apikey.ErrTooManyKeys):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		requestLog(c, "auth").Error("API key request failed", logging.Err(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+	}
+}
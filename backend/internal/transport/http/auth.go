@@ -4,7 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -13,9 +13,13 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/iamasit07/connect4/backend/internal/repository/postgres"
+	"github.com/iamasit07/connect4/backend/internal/config"
+	"github.com/iamasit07/connect4/backend/internal/domain"
+	"github.com/iamasit07/connect4/backend/internal/logging"
+	"github.com/iamasit07/connect4/backend/internal/repository"
 	"github.com/iamasit07/connect4/backend/internal/service/game"
 	"github.com/iamasit07/connect4/backend/internal/service/session"
+	"github.com/iamasit07/connect4/backend/internal/transport/http/middleware"
 	"github.com/iamasit07/connect4/backend/pkg/auth"
 	"github.com/iamasit07/connect4/backend/pkg/httputil"
 	"github.com/iamasit07/connect4/backend/pkg/useragent"
@@ -30,22 +34,44 @@ var htmlTagPattern = regexp.MustCompile(`<[^>]*>`)
 
 type Disconnector interface {
 	DisconnectUser(userID int64, reason string)
+	DisconnectSession(userID int64, sessionID, reason string)
 }
 
 type SessionInvalidator interface {
 	InvalidateSession(sessionID string) error
 }
 
+// VerificationSender mails email verification links (implemented by account.Service)
+type VerificationSender interface {
+	SendVerification(userID int64) error
+}
+
 type AuthHandler struct {
-	UserRepo       *postgres.UserRepo
-	SessionRepo    *postgres.SessionRepo
+	UserRepo       repository.UserRepository
+	SessionRepo    repository.SessionRepository
 	ConnManager    Disconnector
 	Cache          session.CacheRepository
 	AuthService    *session.AuthService
 	SessionManager *game.SessionManager
+	Verifier       VerificationSender
+	TwoFactor      TwoFactorVerifier
+	Lockout        LoginLockout // strict; passwords are keyed by account and client IP
+	AccountLockout LoginLockout // looser, keyed by account alone
+}
+
+// LoginLockout locks an account after repeated failed logins (implemented by ratelimit.Lockout)
+type LoginLockout interface {
+	Locked(key string) (time.Duration, bool)
+	Fail(key string)
+	Reset(key string)
 }
 
-func NewAuthHandler(userRepo *postgres.UserRepo, sessionRepo *postgres.SessionRepo, cm Disconnector, cache session.CacheRepository, authSvc *session.AuthService, sm *game.SessionManager) *AuthHandler {
+// TwoFactorVerifier checks a TOTP or recovery code (implemented by twofactor.Service)
+type TwoFactorVerifier interface {
+	Verify(userID int64, code string) error
+}
+
+func NewAuthHandler(userRepo repository.UserRepository, sessionRepo repository.SessionRepository, cm Disconnector, cache session.CacheRepository, authSvc *session.AuthService, sm *game.SessionManager) *AuthHandler {
 	return &AuthHandler{
 		UserRepo:       userRepo,
 		SessionRepo:    sessionRepo,
@@ -56,6 +82,34 @@ func NewAuthHandler(userRepo *postgres.UserRepo, sessionRepo *postgres.SessionRe
 	}
 }
 
+// SetVerifier makes new accounts receive a verification email
+func (h *AuthHandler) SetVerifier(v VerificationSender) {
+	h.Verifier = v
+}
+
+// SetLockout locks logins out after repeated failed password or two-factor
+// attempts. perClient counts an account's failures from one client IP and
+// perAccount those from everywhere.
+func (h *AuthHandler) SetLockout(perClient, perAccount LoginLockout) {
+	h.Lockout = perClient
+	h.AccountLockout = perAccount
+}
+
+// SetTwoFactor enables the second login step for accounts with two-factor on
+func (h *AuthHandler) SetTwoFactor(tf TwoFactorVerifier) {
+	h.TwoFactor = tf
+}
+
+// sendVerification mails a verification link when a verifier is configured
+func (h *AuthHandler) sendVerification(userID int64) {
+	if h.Verifier == nil {
+		return
+	}
+	if err := h.Verifier.SendVerification(userID); err != nil {
+		logging.For("auth").Error("Failed to send verification email", logging.UserID(userID), logging.Err(err))
+	}
+}
+
 func (h *AuthHandler) Register(c *gin.Context) {
 	var req struct {
 		Username string `json:"username"`
@@ -85,8 +139,8 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		return
 	}
 
-	if strings.ToUpper(req.Username) == "BOT" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Username 'BOT' is reserved"})
+	if msg := reservedUsernameError(req.Username); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
 		return
 	}
 
@@ -120,16 +174,17 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		return
 	}
 
-	userID, err := h.UserRepo.CreateUser(req.Username, req.Name, hashedPwd, req.Email, "", "")
+	userID, err := h.UserRepo.CreateUser(req.Username, req.Name, hashedPwd, req.Email, "")
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
 		return
 	}
+	h.sendVerification(userID)
 
 	// Create Session
 	sessionID := auth.GenerateToken()
 	deviceInfo := useragent.ExtractDeviceInfo(c.Request)
-	ipAddress := useragent.ExtractIPAddress(c.Request)
+	ipAddress := config.AppConfig.ClientIP(c.Request)
 	expiresAt := time.Now().Add(30 * 24 * time.Hour)
 
 	err = h.SessionRepo.CreateSession(userID, sessionID, deviceInfo, ipAddress, expiresAt)
@@ -173,35 +228,133 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
+	// Unknown accounts count too, so a lockout doesn't reveal which exist.
+	// The strict lockout is per client so a stranger can't lock the owner out;
+	// the per-account one catches guessing spread over many addresses.
+	accountKey := "login:" + strings.ToLower(strings.TrimSpace(req.Username))
+	clientKey := accountKey + ":" + config.AppConfig.ClientIP(c.Request)
+	if lockedOut(c, h.Lockout, clientKey) || lockedOut(c, h.AccountLockout, accountKey) {
+		return
+	}
+	failed := func() {
+		loginFailed(h.Lockout, clientKey)
+		loginFailed(h.AccountLockout, accountKey)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+	}
+
 	user, err := h.UserRepo.GetUserByIdentifier(req.Username)
 	if err != nil || user == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+		failed()
 		return
 	}
 
 	if !auth.CheckPasswordHash(req.Password, user.PasswordHash) {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+		failed()
 		return
 	}
+	loginSucceeded(h.Lockout, clientKey)
 
-	// Deactivate old sessions and revoke old refresh tokens
-	err = h.SessionRepo.DeactivateAllUserSessions(user.ID)
+	if user.IsRestricted(time.Now()) {
+		c.JSON(http.StatusForbidden, gin.H{"error": restrictionMessage(user)})
+		return
+	}
+
+	if user.TOTPEnabled {
+		// Password is right; the token pair waits for the second step
+		challenge, err := auth.GenerateTwoFactorChallenge(user.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate tokens"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"two_factor_required": true, "challenge": challenge})
+		return
+	}
+
+	h.startSession(c, user)
+}
+
+// LoginTwoFactor completes a login for an account with two-factor enabled. It
+// takes the challenge from Login plus a TOTP or recovery code.
+func (h *AuthHandler) LoginTwoFactor(c *gin.Context) {
+	var req struct {
+		Challenge string `json:"challenge"`
+		Code      string `json:"code"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	claims, err := auth.ValidateTwoFactorChallenge(req.Challenge)
 	if err != nil {
-		// Log error but proceed
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Login expired, please sign in again"})
+		return
+	}
+	user, err := h.UserRepo.GetUserByID(claims.UserID)
+	if err != nil || user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+		return
+	}
+	if user.IsRestricted(time.Now()) {
+		c.JSON(http.StatusForbidden, gin.H{"error": restrictionMessage(user)})
+		return
+	}
+	if h.TwoFactor == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+	// Only someone with the password gets here, so the strict lockout can be per account
+	lockoutKey := fmt.Sprintf("2fa:%d", user.ID)
+	if lockedOut(c, h.Lockout, lockoutKey) {
+		return
+	}
+	if err := h.TwoFactor.Verify(user.ID, req.Code); err != nil {
+		requestLog(c, "auth").Info("Two-factor check failed", logging.UserID(user.ID), logging.Err(err))
+		loginFailed(h.Lockout, lockoutKey)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid code"})
+		return
 	}
+	loginSucceeded(h.Lockout, lockoutKey)
 
-	h.AuthService.RevokeAllUserRefreshTokens(user.ID)
+	h.startSession(c, user)
+}
 
-	if h.ConnManager != nil {
-		h.ConnManager.DisconnectUser(user.ID, "Logged in from another device")
+// lockedOut answers 429 when key has failed too often recently
+func lockedOut(c *gin.Context, l LoginLockout, key string) bool {
+	if l == nil {
+		return false
+	}
+	wait, locked := l.Locked(key)
+	if locked {
+		requestLog(c, "auth").Warn("Login locked out", "key", key)
+		middleware.TooManyRequests(c, wait, "Too many failed attempts, try again later")
+	}
+	return locked
+}
+
+func loginFailed(l LoginLockout, key string) {
+	if l != nil {
+		l.Fail(key)
+	}
+}
+
+func loginSucceeded(l LoginLockout, key string) {
+	if l != nil {
+		l.Reset(key)
 	}
+}
+
+// startSession issues a fresh session and token pair, signing out the user's
+// least recently used devices if they are at the session limit
+func (h *AuthHandler) startSession(c *gin.Context, user *domain.User) {
+	makeRoomForSession(h.AuthService, h.ConnManager, user.ID, "Logged in from another device")
 
 	sessionID := auth.GenerateToken()
 	deviceInfo := useragent.ExtractDeviceInfo(c.Request)
-	ipAddress := useragent.ExtractIPAddress(c.Request)
+	ipAddress := config.AppConfig.ClientIP(c.Request)
 	expiresAt := time.Now().Add(30 * 24 * time.Hour)
 
-	err = h.SessionRepo.CreateSession(user.ID, sessionID, deviceInfo, ipAddress, expiresAt)
+	err := h.SessionRepo.CreateSession(user.ID, sessionID, deviceInfo, ipAddress, expiresAt)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create session"})
 		return
@@ -221,13 +374,214 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	})
 }
 
+// makeRoomForSession applies MaxSessionsPerUser before userID signs in on
+// another device, closing the sockets of the sessions it signs out. Failures
+// are logged and don't stop the login.
+func makeRoomForSession(authSvc *session.AuthService, conns Disconnector, userID int64, reason string) {
+	evicted, err := authSvc.MakeRoomForSession(userID)
+	if err != nil {
+		logging.For("auth").Error("Failed to apply session limit", logging.UserID(userID), logging.Err(err))
+		return
+	}
+	if conns == nil {
+		return
+	}
+	for _, s := range evicted {
+		conns.DisconnectSession(userID, s.SessionID, reason)
+	}
+}
+
+// Guest creates a temporary guest account so visitors can play casual and bot
+// games without registering. Guests get a single access token, no refresh token.
+func (h *AuthHandler) Guest(c *gin.Context) {
+	var userID int64
+	var username string
+	var err error
+	for attempt := 0; attempt < 3; attempt++ {
+		username = domain.GuestUsernamePrefix + auth.GenerateToken()[:8]
+		if userID, err = h.UserRepo.CreateGuest(username); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		requestLog(c, "auth").Error("Failed to create guest", logging.Err(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create guest"})
+		return
+	}
+
+	sessionID := auth.GenerateToken()
+	deviceInfo := useragent.ExtractDeviceInfo(c.Request)
+	ipAddress := config.AppConfig.ClientIP(c.Request)
+	expiresAt := time.Now().Add(time.Duration(config.AppConfig.GuestSessionTTLHours) * time.Hour)
+
+	err = h.SessionRepo.CreateSession(userID, sessionID, deviceInfo, ipAddress, expiresAt)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create session"})
+		return
+	}
+
+	accessToken, err := auth.GenerateGuestAccessToken(userID, username, sessionID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate tokens"})
+		return
+	}
+
+	httputil.SetGuestCookie(c.Writer, accessToken)
+	c.JSON(http.StatusCreated, gin.H{
+		"token":      accessToken,
+		"expires_at": expiresAt.UTC().Format(time.RFC3339),
+		"user": gin.H{
+			"id":         userID,
+			"username":   username,
+			"name":       username,
+			"avatar_url": "",
+			"email":      "",
+			"rating":     1000,
+			"wins":       0,
+			"losses":     0,
+			"draws":      0,
+			"is_guest":   true,
+		},
+	})
+}
+
+// UpgradeGuest turns the calling guest into a registered account. The user ID
+// stays the same, so game history and stats carry over.
+func (h *AuthHandler) UpgradeGuest(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var req struct {
+		Username string `json:"username"`
+		Name     string `json:"name"`
+		Password string `json:"password"`
+		Email    string `json:"email"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	user, err := h.UserRepo.GetUserByID(userID)
+	if err != nil || user == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if !user.IsGuest {
+		c.JSON(http.StatusConflict, gin.H{"error": "Account is already registered"})
+		return
+	}
+
+	req.Username = strings.TrimSpace(req.Username)
+	if len(req.Username) < 3 || len(req.Username) > 50 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Username must be between 3 and 50 characters"})
+		return
+	}
+	if !safeInputPattern.MatchString(req.Username) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Username contains invalid characters"})
+		return
+	}
+	if msg := reservedUsernameError(req.Username); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	req.Email = strings.TrimSpace(req.Email)
+	if req.Email == "" || !strings.Contains(req.Email, "@") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid email format"})
+		return
+	}
+
+	if err := auth.ValidatePasswordStrength(req.Password); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if htmlTagPattern.MatchString(req.Name) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Name contains invalid characters"})
+		return
+	}
+	if req.Name == "" {
+		req.Name = req.Username
+	}
+
+	for _, identifier := range []string{req.Username, req.Email} {
+		if existing, _ := h.UserRepo.GetUserByIdentifier(identifier); existing != nil {
+			c.JSON(http.StatusConflict, gin.H{"error": "Username or email already taken"})
+			return
+		}
+	}
+
+	hashedPwd, err := auth.HashPassword(req.Password)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	if err := h.UserRepo.UpgradeGuest(userID, req.Username, req.Name, req.Email, hashedPwd); err != nil {
+		requestLog(c, "auth").Error("Failed to upgrade guest", logging.Err(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upgrade account"})
+		return
+	}
+	requestLog(c, "auth").Info("Guest upgraded", "from", user.Username, "to", req.Username)
+	h.sendVerification(userID)
+
+	// Replace the guest session with a regular one; the socket reconnects with the new token
+	if err := h.AuthService.InvalidateSession(c.GetString("session_id")); err != nil {
+		requestLog(c, "auth").Warn("Failed to invalidate guest session", logging.Err(err))
+	}
+	if h.ConnManager != nil {
+		h.ConnManager.DisconnectUser(userID, "Account upgraded")
+	}
+	if h.Cache != nil {
+		h.Cache.Del(c.Request.Context(), fmt.Sprintf("user_profile:%d", userID))
+	}
+
+	sessionID := auth.GenerateToken()
+	deviceInfo := useragent.ExtractDeviceInfo(c.Request)
+	ipAddress := config.AppConfig.ClientIP(c.Request)
+	expiresAt := time.Now().Add(30 * 24 * time.Hour)
+
+	err = h.SessionRepo.CreateSession(userID, sessionID, deviceInfo, ipAddress, expiresAt)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create session"})
+		return
+	}
+
+	accessToken, refreshToken, err := h.AuthService.GenerateTokenPair(userID, req.Username, sessionID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate tokens"})
+		return
+	}
+
+	user, err = h.UserRepo.GetUserByID(userID)
+	if err != nil || user == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	httputil.SetTokenPairCookies(c.Writer, accessToken, refreshToken)
+	c.JSON(http.StatusOK, gin.H{
+		"token": accessToken,
+		"user":  user.UserResponse(),
+	})
+}
+
 func (h *AuthHandler) Logout(c *gin.Context) {
 	// Invalidate session server-side (DB + Redis cache)
 	sessionID, exists := c.Get("session_id")
 	if exists {
 		if sid, ok := sessionID.(string); ok && sid != "" {
 			if err := h.AuthService.InvalidateSession(sid); err != nil {
-				log.Printf("[AUTH] Failed to invalidate session %s on logout: %v", sid, err)
+				requestLog(c, "auth").Warn("Failed to invalidate session on logout", logging.Err(err))
+			}
+			// Other devices stay signed in; only this one's socket closes
+			if h.ConnManager != nil {
+				h.ConnManager.DisconnectSession(c.GetInt64("user_id"), sid, "Logged out")
 			}
 		}
 	}
@@ -265,7 +619,7 @@ func (h *AuthHandler) RefreshToken(c *gin.Context) {
 		},
 	)
 	if err != nil {
-		log.Printf("[AUTH] Refresh token validation failed: %v", err)
+		requestLog(c, "auth").Info("Refresh token validation failed", logging.Err(err))
 		httputil.ClearAllAuthCookies(c.Writer)
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid refresh token"})
 		return
@@ -287,7 +641,7 @@ func (h *AuthHandler) Me(c *gin.Context) {
 	// 1. Get Token (needed for response)
 	token, err := httputil.GetTokenFromRequest(c.Request)
 	if err != nil {
-		log.Printf("[AUTH] /me: Failed to get token for user %d: %v", userID, err)
+		requestLog(c, "auth").Warn("/me: failed to get token", logging.Err(err))
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Token not found"})
 		return
 	}
@@ -310,7 +664,7 @@ func (h *AuthHandler) Me(c *gin.Context) {
 		// 3. Fallback to Database
 		user, err := h.UserRepo.GetUserByID(userID)
 		if err != nil || user == nil {
-			log.Printf("[AUTH] /me: GetUserByID failed for user %d: %v", userID, err)
+			requestLog(c, "auth").Warn("/me: GetUserByID failed", logging.Err(err))
 			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
 			return
 		}
@@ -426,7 +780,7 @@ func (h *AuthHandler) UploadAvatar(c *gin.Context) {
 	// Create uploads directory
 	uploadDir := "./uploads/avatars"
 	if err := os.MkdirAll(uploadDir, 0755); err != nil {
-		log.Printf("[AVATAR] Failed to create upload dir: %v", err)
+		requestLog(c, "avatar").Error("Failed to create upload dir", logging.Err(err))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
 		return
 	}
@@ -438,14 +792,14 @@ func (h *AuthHandler) UploadAvatar(c *gin.Context) {
 	// Save file
 	dst, err := os.Create(savePath)
 	if err != nil {
-		log.Printf("[AVATAR] Failed to create file: %v", err)
+		requestLog(c, "avatar").Error("Failed to create file", logging.Err(err))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
 		return
 	}
 	defer dst.Close()
 
 	if _, err := io.Copy(dst, file); err != nil {
-		log.Printf("[AVATAR] Failed to save file: %v", err)
+		requestLog(c, "avatar").Error("Failed to save file", logging.Err(err))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
 		return
 	}
@@ -460,7 +814,7 @@ func (h *AuthHandler) UploadAvatar(c *gin.Context) {
 	// Save URL to database
 	avatarURL := "/uploads/avatars/" + filename
 	if err := h.UserRepo.UpdateAvatar(userID, avatarURL); err != nil {
-		log.Printf("[AVATAR] Failed to update avatar in DB: %v", err)
+		requestLog(c, "avatar").Error("Failed to update avatar in DB", logging.Err(err))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update avatar"})
 		return
 	}
@@ -529,3 +883,28 @@ func (h *AuthHandler) GetSessionHistory(c *gin.Context) {
 	// 3. Return JSON
 	c.JSON(http.StatusOK, sessions)
 }
+
+// reservedUsernameError explains why a username can't be registered, or returns ""
+func reservedUsernameError(username string) string {
+	if strings.ToUpper(username) == "BOT" {
+		return "Username 'BOT' is reserved"
+	}
+	if strings.HasPrefix(strings.ToLower(username), domain.GuestUsernamePrefix) {
+		return "Usernames starting with '" + domain.GuestUsernamePrefix + "' are reserved for guests"
+	}
+	return ""
+}
+
+// restrictionMessage explains why a banned or suspended user can't log in
+func restrictionMessage(user *domain.User) string {
+	if user.Banned {
+		return "Your account has been banned"
+	}
+	return "Your account is suspended until " + user.SuspendedUntil.Time.UTC().Format(time.RFC1123)
+}
+
+// requestLog returns the request's logger (with its ID, and the caller once
+// authenticated) tagged with a component
+func requestLog(c *gin.Context, component string) *slog.Logger {
+	return logging.ForContext(c.Request.Context(), component)
+}
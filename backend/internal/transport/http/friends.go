@@ -0,0 +1,190 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/iamasit07/connect4/backend/internal/domain"
+	"github.com/iamasit07/connect4/backend/internal/logging"
+	"github.com/iamasit07/connect4/backend/internal/service/friends"
+	"github.com/iamasit07/connect4/backend/internal/service/presence"
+)
+
+// Notifier pushes a message to a connected user (no-op when offline)
+type Notifier interface {
+	SendMessage(userID int64, message domain.ServerMessage) error
+}
+
+type FriendsHandler struct {
+	Friends  *friends.Service
+	Presence *presence.Service
+	Notifier Notifier
+}
+
+func NewFriendsHandler(fs *friends.Service, ps *presence.Service, notifier Notifier) *FriendsHandler {
+	return &FriendsHandler{Friends: fs, Presence: ps, Notifier: notifier}
+}
+
+// ListFriends returns friends (with presence), pending requests and blocked users
+func (h *FriendsHandler) ListFriends(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+
+	list, err := h.Friends.List(userID)
+	if err != nil {
+		requestLog(c, "friends").Error("Error listing friends", logging.Err(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch friends"})
+		return
+	}
+
+	for i := range list {
+		if list[i].Relation == domain.FriendAccepted {
+			list[i].Presence = string(h.Presence.Get(list[i].UserID))
+		}
+	}
+
+	c.JSON(http.StatusOK, list)
+}
+
+func (h *FriendsHandler) SendRequest(c *gin.Context) {
+	var req struct {
+		Username string `json:"username"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || strings.TrimSpace(req.Username) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Username is required"})
+		return
+	}
+
+	userID := c.GetInt64("user_id")
+	target, relation, err := h.Friends.SendRequest(userID, strings.TrimSpace(req.Username))
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	event := "friend_request"
+	if relation == domain.FriendAccepted {
+		event = "friend_accepted"
+	}
+	h.Notifier.SendMessage(target.ID, domain.ServerMessage{
+		Type:     event,
+		UserID:   userID,
+		Username: c.GetString("username"),
+	})
+
+	c.JSON(http.StatusOK, gin.H{"userId": target.ID, "username": target.Username, "relation": relation})
+}
+
+func (h *FriendsHandler) AcceptRequest(c *gin.Context) {
+	requesterID, ok := idParam(c)
+	if !ok {
+		return
+	}
+
+	userID := c.GetInt64("user_id")
+	if err := h.Friends.Accept(userID, requesterID); err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	h.Notifier.SendMessage(requesterID, domain.ServerMessage{
+		Type:     "friend_accepted",
+		UserID:   userID,
+		Username: c.GetString("username"),
+	})
+	c.JSON(http.StatusOK, gin.H{"relation": domain.FriendAccepted})
+}
+
+func (h *FriendsHandler) DeclineRequest(c *gin.Context) {
+	requesterID, ok := idParam(c)
+	if !ok {
+		return
+	}
+
+	if err := h.Friends.Decline(c.GetInt64("user_id"), requesterID); err != nil {
+		h.respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Request declined"})
+}
+
+// RemoveFriend ends a friendship or withdraws an outgoing request
+func (h *FriendsHandler) RemoveFriend(c *gin.Context) {
+	friendID, ok := idParam(c)
+	if !ok {
+		return
+	}
+
+	if err := h.Friends.Remove(c.GetInt64("user_id"), friendID); err != nil {
+		h.respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Friend removed"})
+}
+
+func (h *FriendsHandler) Block(c *gin.Context) {
+	targetID, ok := idParam(c)
+	if !ok {
+		return
+	}
+
+	// Optional body: {"blockSpectating": true} also hides your live games from them
+	var req struct {
+		BlockSpectating bool `json:"blockSpectating"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			return
+		}
+	}
+
+	if err := h.Friends.Block(c.GetInt64("user_id"), targetID, req.BlockSpectating); err != nil {
+		h.respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"relation": domain.FriendBlocked})
+}
+
+func (h *FriendsHandler) Unblock(c *gin.Context) {
+	targetID, ok := idParam(c)
+	if !ok {
+		return
+	}
+
+	if err := h.Friends.Unblock(c.GetInt64("user_id"), targetID); err != nil {
+		h.respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "User unblocked"})
+}
+
+func (h *FriendsHandler) respondError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, friends.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, friends.ErrNoRequest), errors.Is(err, friends.ErrNotFriends):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, friends.ErrSelf):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, friends.ErrBlocked):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, friends.ErrAlreadyFriends), errors.Is(err, friends.ErrAlreadyRequested):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		requestLog(c, "friends").Error("Friends request failed", logging.Err(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+	}
+}
+
+// idParam parses the :id path parameter, writing a 400 when it is invalid
+func idParam(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return 0, false
+	}
+	return id, true
+}
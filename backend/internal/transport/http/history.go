@@ -1,18 +1,19 @@
 package http
 
 import (
-	"log"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
-	"github.com/iamasit07/connect4/backend/internal/repository/postgres"
+	"github.com/iamasit07/connect4/backend/internal/domain"
+	"github.com/iamasit07/connect4/backend/internal/logging"
+	"github.com/iamasit07/connect4/backend/internal/repository"
 )
 
 type HistoryHandler struct {
-	GameRepo *postgres.GameRepo
+	GameRepo repository.GameRepository
 }
 
-func NewHistoryHandler(gameRepo *postgres.GameRepo) *HistoryHandler {
+func NewHistoryHandler(gameRepo repository.GameRepository) *HistoryHandler {
 	return &HistoryHandler{GameRepo: gameRepo}
 }
 
@@ -23,19 +24,20 @@ type historyResponse struct {
 	EndReason        string `json:"endReason"`
 	CreatedAt        string `json:"createdAt"`
 	MovesCount       int    `json:"movesCount"`
+	Rated            bool   `json:"rated"`
 }
 
 func (h *HistoryHandler) GetHistory(c *gin.Context) {
 	userID := c.GetInt64("user_id")
 	if userID == 0 {
-		log.Printf("[HISTORY] Unauthorized: user_id is missing or zero")
+		requestLog(c, "history").Warn("Unauthorized: user_id is missing or zero")
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
 		return
 	}
 
 	rawHistory, err := h.GameRepo.GetUserGameHistory(userID)
 	if err != nil {
-		log.Printf("[HISTORY] Error fetching history for user %d: %v", userID, err)
+		requestLog(c, "history").Error("Error fetching history", logging.Err(err))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch history"})
 		return
 	}
@@ -51,7 +53,7 @@ func (h *HistoryHandler) GetHistory(c *gin.Context) {
 		}
 
 		var result string
-		if game.Reason == "draw" {
+		if domain.IsDrawReason(game.Reason) {
 			result = "draw"
 		} else if game.WinnerID != nil && *game.WinnerID == userID {
 			result = "win"
@@ -66,6 +68,7 @@ func (h *HistoryHandler) GetHistory(c *gin.Context) {
 			EndReason:        game.Reason,
 			CreatedAt:        game.CreatedAt.Format("2006-01-02T15:04:05Z"),
 			MovesCount:       game.TotalMoves,
+			Rated:            game.Rated,
 		})
 	}
 
@@ -93,12 +96,24 @@ func (h *HistoryHandler) GetGameDetails(c *gin.Context) {
 		return
 	}
 
+	// Chat transcripts are private to the two players
+	var chat []domain.ChatMessage
+	userID := c.GetInt64("user_id")
+	if game != nil && (game.Player1ID == userID || (game.Player2ID != nil && *game.Player2ID == userID)) {
+		chat, err = h.GameRepo.GetGameChat(gameID)
+		if err != nil {
+			requestLog(c, "history").Error("Error fetching chat", logging.GameID(gameID), logging.Err(err))
+		}
+	}
+
 	response := struct {
-		*postgres.GameResult
-		Board [][]int `json:"board_state"`
+		*domain.GameResult
+		Board [][]int              `json:"board_state"`
+		Chat  []domain.ChatMessage `json:"chat,omitempty"`
 	}{
 		GameResult: game,
 		Board:      board,
+		Chat:       chat,
 	}
 
 	c.JSON(http.StatusOK, response)
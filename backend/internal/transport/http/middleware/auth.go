@@ -1,11 +1,12 @@
 package middleware
 
 import (
-	"log"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/iamasit07/connect4/backend/internal/domain"
+	"github.com/iamasit07/connect4/backend/internal/logging"
 	"github.com/iamasit07/connect4/backend/pkg/auth"
 	"github.com/iamasit07/connect4/backend/pkg/httputil"
 )
@@ -16,8 +17,18 @@ type SessionValidator interface {
 	UpdateSessionActivity(sessionID string) error
 }
 
-// AuthMiddleware validates JWT token and session via the AuthService (Redis → Postgres fallback)
-func AuthMiddleware(sv SessionValidator) gin.HandlerFunc {
+// APIKeyAuthenticator resolves personal API keys (implemented by apikey.Service)
+type APIKeyAuthenticator interface {
+	Authenticate(key string) (*domain.APIKey, *domain.User, error)
+}
+
+// APIKeyRoutes maps "METHOD /route/pattern" to the scope an API key needs
+// there. Routes that aren't listed only accept a signed-in session.
+type APIKeyRoutes map[string]string
+
+// AuthMiddleware validates JWT token and session via the AuthService (Redis → Postgres fallback).
+// A bearer API key is accepted instead on the routes listed in routes.
+func AuthMiddleware(sv SessionValidator, keys APIKeyAuthenticator, routes APIKeyRoutes) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// 1. Extract Token (Cookie or Header)
 		tokenString, err := httputil.GetTokenFromRequest(c.Request)
@@ -25,20 +36,26 @@ func AuthMiddleware(sv SessionValidator) gin.HandlerFunc {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
 			return
 		}
+		if auth.IsAPIKey(tokenString) {
+			authenticateAPIKey(c, keys, routes, tokenString)
+			return
+		}
 
 		// 2. Validate JWT + Session (checks signature, is_active, and expires_at)
 		claims, err := sv.ValidateToken(tokenString)
 		if err != nil {
-			log.Printf("[AUTH] Token/session validation failed for %s: %v", c.Request.URL.Path, err)
+			logging.ForContext(c.Request.Context(), "auth").Info("Token/session validation failed", "path", c.Request.URL.Path, logging.Err(err))
 			httputil.ClearAuthCookie(c.Writer)
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
 			return
 		}
 
 		// 3. Update Last Activity (async, best-effort)
+		ctx := logging.With(c.Request.Context(), logging.UserID(claims.UserID), logging.SessionID(claims.SessionID))
+		c.Request = c.Request.WithContext(ctx)
 		go func(sid string) {
 			if err := sv.UpdateSessionActivity(sid); err != nil {
-				log.Printf("[AUTH] Failed to update session activity for %s: %v", sid, err)
+				logging.ForContext(ctx, "auth").Warn("Failed to update session activity", logging.Err(err))
 			}
 		}(claims.SessionID)
 
@@ -50,3 +67,52 @@ func AuthMiddleware(sv SessionValidator) gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// authenticateAPIKey lets a request through on an API key that has the scope the route needs
+func authenticateAPIKey(c *gin.Context, keys APIKeyAuthenticator, routes APIKeyRoutes, plain string) {
+	key, user, err := keys.Authenticate(plain)
+	if err != nil {
+		logging.ForContext(c.Request.Context(), "auth").Info("API key rejected", "path", c.Request.URL.Path, logging.Err(err))
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	scope, allowed := routes[c.Request.Method+" "+c.FullPath()]
+	if !allowed {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "API keys can't be used for this endpoint"})
+		return
+	}
+	if !key.HasScope(scope) {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "API key is missing the " + scope + " scope"})
+		return
+	}
+
+	c.Set("user_id", user.ID)
+	c.Set("username", user.Username)
+	c.Set("api_key_id", key.ID)
+	c.Request = c.Request.WithContext(logging.With(c.Request.Context(), logging.UserID(user.ID), "api_key_id", key.ID))
+	c.Next()
+}
+
+// UserLookup loads the caller's account (implemented by repository.UserRepository)
+type UserLookup interface {
+	GetUserByID(userID int64) (*domain.User, error)
+}
+
+// RequireRole only lets users with the given role through. It must run after
+// AuthMiddleware. The role is read on every request so a demotion applies at once.
+func RequireRole(users UserLookup, role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, err := users.GetUserByID(c.GetInt64("user_id"))
+		if err != nil {
+			logging.ForContext(c.Request.Context(), "auth").Error("Role lookup failed", logging.Err(err))
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+		if user == nil || user.Role != role {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Forbidden"})
+			return
+		}
+		c.Next()
+	}
+}
@@ -1,11 +1,11 @@
 package middleware
 
 import (
-	"log"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"github.com/iamasit07/connect4/backend/internal/config"
+	"github.com/iamasit07/connect4/backend/internal/logging"
 )
 
 // SecurityHeadersMiddleware adds security headers to all HTTP responses.
@@ -28,7 +28,7 @@ func CORSMiddleware() gin.HandlerFunc {
 		// If no origin header (like from curl or same-origin), allow the request
 		if origin == "" {
 			c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-			c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization")
+			c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Request-ID, traceparent, tracestate")
 			c.Header("Access-Control-Allow-Credentials", "true")
 
 			if c.Request.Method == http.MethodOptions {
@@ -51,15 +51,16 @@ func CORSMiddleware() gin.HandlerFunc {
 
 		// If origin not allowed, log and reject
 		if !allowed {
-			log.Printf("[CORS] Origin '%s' not in allowed list: %v", origin, config.AppConfig.AllowedOrigins)
+			logging.ForContext(c.Request.Context(), "cors").Warn("Origin not in allowed list", "origin", origin)
 			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Origin not allowed"})
 			return
 		}
 
 		// Set CORS headers for allowed origins
 		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Request-ID, traceparent, tracestate")
 		c.Header("Access-Control-Allow-Credentials", "true")
+		c.Header("Access-Control-Expose-Headers", "Retry-After, X-Request-ID")
 
 		// Handle preflight OPTIONS requests
 		if c.Request.Method == http.MethodOptions {
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/iamasit07/connect4/backend/internal/config"
+	"github.com/iamasit07/connect4/backend/internal/ratelimit"
+)
+
+// RateLimiter spends tokens from per-key buckets (implemented by ratelimit.Limiter)
+type RateLimiter interface {
+	Allow(key string, p ratelimit.Policy) (bool, time.Duration)
+}
+
+// RateLimit limits each client IP on the routes that have a policy, keyed
+// "METHOD /route/pattern". Limited requests get 429 with a Retry-After header.
+func RateLimit(limiter RateLimiter, policies map[string]ratelimit.Policy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := c.Request.Method + " " + c.FullPath()
+		policy, ok := policies[route]
+		if !ok {
+			c.Next()
+			return
+		}
+
+		if allowed, wait := limiter.Allow(route+":"+config.AppConfig.ClientIP(c.Request), policy); !allowed {
+			TooManyRequests(c, wait, "Too many requests, please slow down")
+			return
+		}
+		c.Next()
+	}
+}
+
+// TooManyRequests aborts with 429 and tells the client when to try again
+func TooManyRequests(c *gin.Context, wait time.Duration, message string) {
+	c.Header("Retry-After", strconv.Itoa(ratelimit.RetryAfterSeconds(wait)))
+	c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": message})
+}
@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/iamasit07/connect4/backend/internal/config"
+	"github.com/iamasit07/connect4/backend/internal/logging"
+	"github.com/iamasit07/connect4/backend/internal/tracing"
+	"github.com/iamasit07/connect4/backend/pkg/uid"
+)
+
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds IDs taken from callers so they can't flood the logs
+const maxRequestIDLength = 64
+
+// RequestID tags each request with an ID, taken from X-Request-ID when the
+// caller (or a proxy) sent a sane one, and echoes it back. The request context
+// carries a logger with the ID (and the trace ID when the request is traced),
+// and one line is logged per request.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID(id) {
+			id = uid.GenerateRequestID()
+		}
+		c.Header(RequestIDHeader, id)
+		c.Set("request_id", id)
+		logger := slog.Default().With(logging.RequestID(id))
+		if traceID := tracing.TraceID(c.Request.Context()); traceID != "" {
+			logger = logger.With(logging.TraceID(traceID))
+		}
+		c.Request = c.Request.WithContext(logging.NewContext(c.Request.Context(), logger))
+
+		ip := config.AppConfig.ClientIP(c.Request)
+		start := time.Now()
+		c.Next()
+
+		level := slog.LevelInfo
+		if c.Writer.Status() >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		route := c.FullPath()
+		if route == "" {
+			route = c.Request.URL.Path
+		}
+		// The auth middleware may have added the user to the context's logger
+		logging.FromContext(c.Request.Context()).LogAttrs(c.Request.Context(), level, "request",
+			slog.String(logging.KeyComponent, "http"),
+			slog.String("method", c.Request.Method),
+			slog.String("route", route),
+			slog.Int("status", c.Writer.Status()),
+			slog.Duration("duration", time.Since(start)),
+			slog.String("ip", ip),
+		)
+	}
+}
+
+// validRequestID accepts IDs of letters, digits, '-', '_' and '.'
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -2,15 +2,19 @@ package http
 
 import (
 	"context"
+	"crypto/subtle"
 	"fmt"
-	"log"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/iamasit07/connect4/backend/internal/config"
-	"github.com/iamasit07/connect4/backend/internal/repository/postgres"
+	"github.com/iamasit07/connect4/backend/internal/domain"
+	"github.com/iamasit07/connect4/backend/internal/logging"
+	"github.com/iamasit07/connect4/backend/internal/oauth"
+	"github.com/iamasit07/connect4/backend/internal/repository"
 	"github.com/iamasit07/connect4/backend/internal/service/session"
 	"github.com/iamasit07/connect4/backend/pkg/auth"
 	"github.com/iamasit07/connect4/backend/pkg/httputil"
@@ -18,75 +22,190 @@ import (
 )
 
 type OAuthHandler struct {
-	UserRepo    *postgres.UserRepo
-	SessionRepo *postgres.SessionRepo
-	Config      *config.OAuthConfig
+	UserRepo    repository.UserRepository
+	SessionRepo repository.SessionRepository
+	Identities  repository.IdentityRepository
+	Providers   *oauth.Registry
 	ConnManager Disconnector // Reusing the interface from auth.go
 	AuthService *session.AuthService
+	Verifier    VerificationSender
 }
 
-// NewOAuthHandler now requires SessionRepo, Disconnector (ConnManager), and AuthService
-func NewOAuthHandler(userRepo *postgres.UserRepo, sessionRepo *postgres.SessionRepo, cfg *config.OAuthConfig, cm Disconnector, authSvc *session.AuthService) *OAuthHandler {
+// NewOAuthHandler requires the identity store and provider registry alongside SessionRepo, Disconnector (ConnManager), and AuthService
+func NewOAuthHandler(userRepo repository.UserRepository, sessionRepo repository.SessionRepository, identities repository.IdentityRepository, providers *oauth.Registry, cm Disconnector, authSvc *session.AuthService) *OAuthHandler {
 	return &OAuthHandler{
 		UserRepo:    userRepo,
 		SessionRepo: sessionRepo,
-		Config:      cfg,
+		Identities:  identities,
+		Providers:   providers,
 		ConnManager: cm,
 		AuthService: authSvc,
 	}
 }
 
+// SetVerifier makes accounts created with an unverified provider email receive a verification email
+func (h *OAuthHandler) SetVerifier(v VerificationSender) {
+	h.Verifier = v
+}
+
+// ListProviders returns the login providers the frontend should offer
+func (h *OAuthHandler) ListProviders(c *gin.Context) {
+	providers := []gin.H{}
+	for _, p := range h.Providers.List() {
+		providers = append(providers, gin.H{"name": p.Name, "display_name": p.DisplayName})
+	}
+	c.JSON(http.StatusOK, providers)
+}
+
+// Login redirects the user to the provider named in the route
+func (h *OAuthHandler) Login(c *gin.Context) {
+	h.login(c, c.Param("provider"))
+}
+
 // GoogleLogin redirects the user to Google
 func (h *OAuthHandler) GoogleLogin(c *gin.Context) {
-	url := h.Config.GoogleLoginConfig.AuthCodeURL("state")
-	c.Redirect(http.StatusTemporaryRedirect, url)
+	h.login(c, config.OAuthKindGoogle)
+}
+
+func (h *OAuthHandler) login(c *gin.Context, name string) {
+	provider := h.Providers.Get(name)
+	if provider == nil {
+		c.Redirect(http.StatusTemporaryRedirect, config.AppConfig.FrontendURL+"/login?error=unknown_provider")
+		return
+	}
+	authURL, err := h.startFlow(c, provider, 0)
+	if err != nil {
+		requestLog(c, "oauth").Error("Failed to start login", logging.Err(err))
+		c.Redirect(http.StatusTemporaryRedirect, config.AppConfig.FrontendURL+"/login?error=provider_unavailable")
+		return
+	}
+	c.Redirect(http.StatusTemporaryRedirect, authURL)
+}
+
+// startFlow returns the provider's consent URL for a fresh random state and
+// PKCE verifier, which it keeps in a signed cookie for the callback to check.
+// linkUserID is set when a signed-in user is linking the provider.
+func (h *OAuthHandler) startFlow(c *gin.Context, provider *oauth.Provider, linkUserID int64) (string, error) {
+	state := auth.GenerateToken()
+	verifier := oauth.NewVerifier()
+	authURL, err := provider.AuthCodeURL(c.Request.Context(), state, verifier)
+	if err != nil {
+		return "", err
+	}
+	cookie, err := auth.GenerateOAuthState(auth.OAuthStateClaims{
+		State:      state,
+		Verifier:   verifier,
+		Provider:   provider.Name,
+		LinkUserID: linkUserID,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to sign oauth state: %v", err)
+	}
+	httputil.SetOAuthCookie(c.Writer, httputil.OAuthStateCookieName, cookie, int((10 * time.Minute).Seconds()))
+	return authURL, nil
+}
+
+// checkState consumes the flow cookie and returns its claims if it was issued
+// for this provider and matches the callback's state parameter
+func (h *OAuthHandler) checkState(c *gin.Context, name string) (*auth.OAuthStateClaims, bool) {
+	cookie, err := c.Cookie(httputil.OAuthStateCookieName)
+	httputil.ClearOAuthCookie(c.Writer, httputil.OAuthStateCookieName)
+	if err != nil {
+		return nil, false
+	}
+	claims, err := auth.ValidateOAuthState(cookie)
+	if err != nil || claims.Provider != name {
+		return nil, false
+	}
+	state := c.Query("state")
+	if state == "" || subtle.ConstantTimeCompare([]byte(state), []byte(claims.State)) != 1 {
+		return nil, false
+	}
+	return claims, true
+}
+
+// Callback handles the response from the provider named in the route
+func (h *OAuthHandler) Callback(c *gin.Context) {
+	h.callback(c, c.Param("provider"))
 }
 
 // GoogleCallback handles the response from Google
 func (h *OAuthHandler) GoogleCallback(c *gin.Context) {
-	code := c.Query("code")
-	token, err := h.Config.GoogleLoginConfig.Exchange(context.Background(), code)
+	h.callback(c, config.OAuthKindGoogle)
+}
+
+func (h *OAuthHandler) callback(c *gin.Context, name string) {
+	provider := h.Providers.Get(name)
+	if provider == nil {
+		c.Redirect(http.StatusTemporaryRedirect, config.AppConfig.FrontendURL+"/login?error=unknown_provider")
+		return
+	}
+
+	// The callback must come back to the browser that started the flow
+	flow, ok := h.checkState(c, name)
+	if !ok {
+		requestLog(c, "oauth").Warn("Callback with missing or mismatched state", "provider", name)
+		c.Redirect(http.StatusTemporaryRedirect, config.AppConfig.FrontendURL+"/login?error=invalid_state")
+		return
+	}
+	// A link user means a signed-in user is adding this provider to their account
+	linking := flow.LinkUserID != 0
+
+	userInfo, err := provider.Exchange(context.Background(), c.Query("code"), flow.Verifier)
 	if err != nil {
-		log.Printf("[OAUTH] Failed to exchange token: %v", err)
+		requestLog(c, "oauth").Warn("Login failed", "provider", name, logging.Err(err))
+		if linking {
+			c.Redirect(http.StatusTemporaryRedirect, config.AppConfig.FrontendURL+"/settings?error=auth_failed")
+			return
+		}
 		c.Redirect(http.StatusTemporaryRedirect, config.AppConfig.FrontendURL+"/login?error=auth_failed")
 		return
 	}
 
-	userInfo, err := config.GetGoogleUserInfo(token.AccessToken)
-	if err != nil {
-		log.Printf("[OAUTH] Failed to get user info: %v", err)
-		c.Redirect(http.StatusTemporaryRedirect, config.AppConfig.FrontendURL+"/login?error=user_info_failed")
+	if linking {
+		h.finishLink(c, flow.LinkUserID, name, userInfo)
 		return
 	}
 
-	user, err := h.UserRepo.GetUserByEmail(userInfo.Email)
+	user, err := h.findUser(name, userInfo)
+	if err != nil {
+		requestLog(c, "oauth").Error("Failed to find user", "provider", name, logging.Err(err))
+		c.Redirect(http.StatusTemporaryRedirect, config.AppConfig.FrontendURL+"/login?error=server_error")
+		return
+	}
 
 	if user != nil {
 		// --- CASE A: EXISTING USER (LOGIN) ---
 
-		// Auto-link Google ID if missing (The logic you liked)
-		if !user.GoogleID.Valid {
-			if err := h.UserRepo.UpdateUserGoogleID(userInfo.Email, userInfo.ID); err != nil {
-				log.Printf("[OAUTH] Failed to link Google ID: %v", err)
-			}
+		if user.IsRestricted(time.Now()) {
+			c.Redirect(http.StatusTemporaryRedirect, config.AppConfig.FrontendURL+"/login?error=account_restricted")
+			return
 		}
 
-		// Security: Invalidate old sessions and refresh tokens
-		h.SessionRepo.DeactivateAllUserSessions(user.ID)
-		h.AuthService.RevokeAllUserRefreshTokens(user.ID)
-		if h.ConnManager != nil {
-			h.ConnManager.DisconnectUser(user.ID, "Logged in from another device via Google")
+		// Two-factor accounts finish signing in on the frontend's code prompt
+		if user.TOTPEnabled {
+			challenge, err := auth.GenerateTwoFactorChallenge(user.ID)
+			if err != nil {
+				requestLog(c, "oauth").Error("Failed to generate two-factor challenge", logging.UserID(user.ID), logging.Err(err))
+				c.Redirect(http.StatusTemporaryRedirect, config.AppConfig.FrontendURL+"/login?error=server_error")
+				return
+			}
+			c.Redirect(http.StatusTemporaryRedirect, config.AppConfig.FrontendURL+"/login/2fa?challenge="+challenge)
+			return
 		}
 
+		// Stay within the session limit by signing out the least recently used devices
+		makeRoomForSession(h.AuthService, h.ConnManager, user.ID, "Logged in from another device via "+provider.DisplayName)
+
 		// Create new session
 		sessionID := auth.GenerateToken()
 		deviceInfo := useragent.ExtractDeviceInfo(c.Request)
-		ipAddress := useragent.ExtractIPAddress(c.Request)
+		ipAddress := config.AppConfig.ClientIP(c.Request)
 		expiresAt := time.Now().Add(30 * 24 * time.Hour)
 
 		err = h.SessionRepo.CreateSession(user.ID, sessionID, deviceInfo, ipAddress, expiresAt)
 		if err != nil {
-			log.Printf("[OAUTH] Failed to create session: %v", err)
+			requestLog(c, "oauth").Error("Failed to create session", logging.UserID(user.ID), logging.Err(err))
 			c.Redirect(http.StatusTemporaryRedirect, config.AppConfig.FrontendURL+"/login?error=server_error")
 			return
 		}
@@ -105,29 +224,99 @@ func (h *OAuthHandler) GoogleCallback(c *gin.Context) {
 	} else {
 		// --- CASE B: NEW USER (SETUP FLOW) ---
 
+		if userInfo.Email == "" {
+			c.Redirect(http.StatusTemporaryRedirect, config.AppConfig.FrontendURL+"/login?error=email_required")
+			return
+		}
+		if existing, _ := h.UserRepo.GetUserByEmail(userInfo.Email); existing != nil {
+			// The provider didn't vouch for the address, so don't hand over the account
+			c.Redirect(http.StatusTemporaryRedirect, config.AppConfig.FrontendURL+"/login?error=email_in_use")
+			return
+		}
+
 		// Do NOT create user yet. Generate a Setup Token instead.
-		setupToken, err := auth.GenerateSetupToken(userInfo.Email, userInfo.ID, userInfo.Name, userInfo.Picture)
+		setupToken, err := auth.GenerateSetupToken(name, userInfo.Subject, userInfo.Email, userInfo.EmailVerified, userInfo.Name, userInfo.Picture)
 		if err != nil {
-			log.Printf("[OAUTH] Failed to generate setup token: %v", err)
+			requestLog(c, "oauth").Error("Failed to generate setup token", "provider", name, logging.Err(err))
 			c.Redirect(http.StatusTemporaryRedirect, config.AppConfig.FrontendURL+"/login?error=setup_failed")
 			return
 		}
 
-		redirectURL := fmt.Sprintf("%s/complete-signup?token=%s&email=%s&name=%s",
+		// The token stays in a cookie so it never lands in browser history or logs
+		httputil.SetOAuthCookie(c.Writer, httputil.OAuthSetupCookieName, setupToken, int((15 * time.Minute).Seconds()))
+		redirectURL := fmt.Sprintf("%s/complete-signup?email=%s&name=%s&provider=%s",
 			config.AppConfig.FrontendURL,
-			setupToken,
-			userInfo.Email, userInfo.Name)
+			url.QueryEscape(userInfo.Email), url.QueryEscape(userInfo.Name), url.QueryEscape(name))
 
 		c.Redirect(http.StatusTemporaryRedirect, redirectURL)
 	}
 }
 
-// CompleteGoogleSignup processes the final step of Google registration
-func (h *OAuthHandler) CompleteGoogleSignup(c *gin.Context) {
+// findUser resolves a provider account to a player: by a linked identity, or
+// else by a provider-verified email, which links the identity on the way
+func (h *OAuthHandler) findUser(name string, info *oauth.UserInfo) (*domain.User, error) {
+	identity, err := h.Identities.GetIdentity(name, info.Subject)
+	if err != nil {
+		return nil, err
+	}
+	if identity != nil {
+		return h.UserRepo.GetUserByID(identity.UserID)
+	}
+	if info.Email == "" || !info.EmailVerified {
+		return nil, nil
+	}
+
+	user, err := h.UserRepo.GetUserByEmail(info.Email)
+	if err != nil || user == nil {
+		return nil, err
+	}
+	err = h.Identities.LinkIdentity(domain.UserIdentity{UserID: user.ID, Provider: name, Subject: info.Subject, Email: info.Email})
+	if err != nil {
+		// Another account from this provider is linked already; the verified email still identifies the user
+		logging.For("oauth").Warn("Not linking account", "provider", name, logging.UserID(user.ID), logging.Err(err))
+	}
+	if !user.IsVerified {
+		if err := h.UserRepo.SetEmailVerified(user.ID); err != nil {
+			logging.For("oauth").Error("Failed to mark email verified", logging.UserID(user.ID), logging.Err(err))
+		}
+	}
+	return user, nil
+}
+
+// finishLink attaches a provider account to the user who started linking
+func (h *OAuthHandler) finishLink(c *gin.Context, userID int64, name string, info *oauth.UserInfo) {
+	settings := config.AppConfig.FrontendURL + "/settings"
+
+	existing, err := h.Identities.GetIdentity(name, info.Subject)
+	if err != nil {
+		requestLog(c, "oauth").Error("Failed to look up identity", "provider", name, logging.Err(err))
+		c.Redirect(http.StatusTemporaryRedirect, settings+"?error=server_error")
+		return
+	}
+	if existing != nil {
+		if existing.UserID != userID {
+			c.Redirect(http.StatusTemporaryRedirect, settings+"?error=identity_in_use")
+			return
+		}
+		c.Redirect(http.StatusTemporaryRedirect, settings+"?linked="+name)
+		return
+	}
+
+	err = h.Identities.LinkIdentity(domain.UserIdentity{UserID: userID, Provider: name, Subject: info.Subject, Email: info.Email})
+	if err != nil {
+		requestLog(c, "oauth").Warn("Failed to link account", "provider", name, logging.UserID(userID), logging.Err(err))
+		c.Redirect(http.StatusTemporaryRedirect, settings+"?error=provider_already_linked")
+		return
+	}
+	requestLog(c, "oauth").Info("Linked account", "provider", name, logging.UserID(userID))
+	c.Redirect(http.StatusTemporaryRedirect, settings+"?linked="+name)
+}
+
+// CompleteSignup processes the final step of registration through a provider
+func (h *OAuthHandler) CompleteSignup(c *gin.Context) {
 	var req struct {
-		SetupToken string `json:"token"`
-		Username   string `json:"username"`
-		Password   string `json:"password"`
+		Username string `json:"username"`
+		Password string `json:"password"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -135,9 +324,10 @@ func (h *OAuthHandler) CompleteGoogleSignup(c *gin.Context) {
 		return
 	}
 
-	// 1. Validate Setup Token
-	claims, err := auth.ValidateSetupToken(req.SetupToken)
-	if err != nil {
+	// 1. Validate Setup Token (set as a cookie by the provider callback)
+	setupToken, _ := c.Cookie(httputil.OAuthSetupCookieName)
+	claims, err := auth.ValidateSetupToken(setupToken)
+	if err != nil || claims.Provider == "" || claims.Subject == "" {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired signup token"})
 		return
 	}
@@ -148,8 +338,8 @@ func (h *OAuthHandler) CompleteGoogleSignup(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Username must be between 3 and 50 characters"})
 		return
 	}
-	if strings.ToUpper(req.Username) == "BOT" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Username 'BOT' is reserved"})
+	if msg := reservedUsernameError(req.Username); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
 		return
 	}
 
@@ -170,25 +360,43 @@ func (h *OAuthHandler) CompleteGoogleSignup(c *gin.Context) {
 		c.JSON(http.StatusConflict, gin.H{"error": "Email already registered. Please login."})
 		return
 	}
+	identity, _ := h.Identities.GetIdentity(claims.Provider, claims.Subject)
+	if identity != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "This account is already registered. Please login."})
+		return
+	}
 
 	// 4. Create User
 	hashedPwd, _ := auth.HashPassword(req.Password)
 
-	// Get Google profile picture from setup token claims
+	// Get profile picture from setup token claims
 	avatarURL := ""
 	if claims.Picture != "" {
 		avatarURL = claims.Picture
 	}
-	userID, err := h.UserRepo.CreateUser(req.Username, claims.Name, hashedPwd, claims.Email, claims.GoogleID, avatarURL)
+	userID, err := h.UserRepo.CreateUser(req.Username, claims.Name, hashedPwd, claims.Email, avatarURL)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create account"})
 		return
 	}
+	err = h.Identities.LinkIdentity(domain.UserIdentity{UserID: userID, Provider: claims.Provider, Subject: claims.Subject, Email: claims.Email})
+	if err != nil {
+		requestLog(c, "oauth").Error("Failed to link account to new user", "provider", claims.Provider, logging.UserID(userID), logging.Err(err))
+	}
+	if claims.EmailVerified {
+		if err := h.UserRepo.SetEmailVerified(userID); err != nil {
+			requestLog(c, "oauth").Error("Failed to mark email verified", logging.UserID(userID), logging.Err(err))
+		}
+	} else if h.Verifier != nil {
+		if err := h.Verifier.SendVerification(userID); err != nil {
+			requestLog(c, "oauth").Error("Failed to send verification email", logging.UserID(userID), logging.Err(err))
+		}
+	}
 
 	// 5. Create Session
 	sessionID := auth.GenerateToken()
 	deviceInfo := useragent.ExtractDeviceInfo(c.Request)
-	ipAddress := useragent.ExtractIPAddress(c.Request)
+	ipAddress := config.AppConfig.ClientIP(c.Request)
 	expiresAt := time.Now().Add(30 * 24 * time.Hour)
 
 	h.SessionRepo.CreateSession(userID, sessionID, deviceInfo, ipAddress, expiresAt)
@@ -200,6 +408,7 @@ func (h *OAuthHandler) CompleteGoogleSignup(c *gin.Context) {
 		return
 	}
 
+	httputil.ClearOAuthCookie(c.Writer, httputil.OAuthSetupCookieName)
 	httputil.SetTokenPairCookies(c.Writer, accessToken, refreshToken)
 	c.JSON(http.StatusOK, gin.H{
 		"token": accessToken,
@@ -216,3 +425,83 @@ func (h *OAuthHandler) CompleteGoogleSignup(c *gin.Context) {
 		},
 	})
 }
+
+// ListIdentities returns the providers linked to the caller's account
+func (h *OAuthHandler) ListIdentities(c *gin.Context) {
+	identities, err := h.Identities.ListIdentities(c.GetInt64("user_id"))
+	if err != nil {
+		requestLog(c, "oauth").Error("Failed to list identities", logging.Err(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+	if identities == nil {
+		identities = []domain.UserIdentity{}
+	}
+	c.JSON(http.StatusOK, identities)
+}
+
+// StartLink returns the provider URL that links another login to the caller's account
+func (h *OAuthHandler) StartLink(c *gin.Context) {
+	name := c.Param("provider")
+	provider := h.Providers.Get(name)
+	if provider == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unknown provider"})
+		return
+	}
+	userID := c.GetInt64("user_id")
+	user, err := h.UserRepo.GetUserByID(userID)
+	if err != nil || user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	if user.IsGuest {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Create an account before linking a login provider"})
+		return
+	}
+
+	authURL, err := h.startFlow(c, provider, userID)
+	if err != nil {
+		requestLog(c, "oauth").Error("Failed to start link", logging.Err(err))
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Provider unavailable"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"url": authURL})
+}
+
+// Unlink removes a provider from the caller's account, as long as some way to sign in remains
+func (h *OAuthHandler) Unlink(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+	name := c.Param("provider")
+
+	user, err := h.UserRepo.GetUserByID(userID)
+	if err != nil || user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	identities, err := h.Identities.ListIdentities(userID)
+	if err != nil {
+		requestLog(c, "oauth").Error("Failed to list identities", logging.Err(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+	linked := false
+	for _, identity := range identities {
+		linked = linked || identity.Provider == name
+	}
+	if !linked {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Provider is not linked"})
+		return
+	}
+	if user.PasswordHash == "" && len(identities) == 1 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Set a password before removing your last sign-in method"})
+		return
+	}
+
+	if _, err := h.Identities.UnlinkIdentity(userID, name); err != nil {
+		requestLog(c, "oauth").Error("Failed to unlink identity", "provider", name, logging.Err(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+	requestLog(c, "oauth").Info("Unlinked account", "provider", name)
+	c.JSON(http.StatusOK, gin.H{"message": "Provider unlinked"})
+}
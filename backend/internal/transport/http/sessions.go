@@ -0,0 +1,93 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/iamasit07/connect4/backend/internal/logging"
+	"github.com/iamasit07/connect4/backend/internal/service/session"
+)
+
+// DeviceTracker knows which sessions have a WebSocket open (implemented by websocket.ConnectionManager)
+type DeviceTracker interface {
+	ConnectedSessions(userID int64) []string
+	PlayingDevice(userID int64) (string, bool)
+	DisconnectSession(userID int64, sessionID, reason string)
+}
+
+// SessionsHandler lets users see and sign out the devices they are signed in on
+type SessionsHandler struct {
+	AuthService *session.AuthService
+	Devices     DeviceTracker
+}
+
+func NewSessionsHandler(authSvc *session.AuthService, devices DeviceTracker) *SessionsHandler {
+	return &SessionsHandler{AuthService: authSvc, Devices: devices}
+}
+
+// ListActive returns the caller's signed-in devices, most recently used first
+func (h *SessionsHandler) ListActive(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+
+	sessions, err := h.AuthService.ListActiveSessions(userID)
+	if err != nil {
+		requestLog(c, "auth").Error("Failed to list sessions", logging.Err(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch sessions"})
+		return
+	}
+
+	connected := make(map[string]bool)
+	for _, sessionID := range h.Devices.ConnectedSessions(userID) {
+		connected[sessionID] = true
+	}
+	playing, _ := h.Devices.PlayingDevice(userID)
+
+	devices := make([]gin.H, 0, len(sessions))
+	for _, s := range sessions {
+		devices = append(devices, gin.H{
+			"id":            s.ID,
+			"device_info":   s.DeviceInfo,
+			"ip_address":    s.IPAddress,
+			"created_at":    s.CreatedAt,
+			"last_activity": s.LastActivity,
+			"expires_at":    s.ExpiresAt,
+			"current":       s.SessionID == c.GetString("session_id"),
+			"online":        connected[s.SessionID],
+			"playing":       s.SessionID == playing,
+		})
+	}
+	c.JSON(http.StatusOK, devices)
+}
+
+// Revoke signs one of the caller's devices out and closes its WebSocket
+func (h *SessionsHandler) Revoke(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID"})
+		return
+	}
+
+	sessions, err := h.AuthService.ListActiveSessions(userID)
+	if err != nil {
+		requestLog(c, "auth").Error("Failed to list sessions", logging.Err(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+	for _, s := range sessions {
+		if s.ID != id {
+			continue
+		}
+		if err := h.AuthService.RevokeSession(s.SessionID); err != nil {
+			requestLog(c, "auth").Error("Failed to revoke session", "device_id", id, logging.Err(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+		h.Devices.DisconnectSession(userID, s.SessionID, "Signed out from another device")
+		requestLog(c, "auth").Info("Signed out device", "device_id", id)
+		c.JSON(http.StatusOK, gin.H{"message": "Session revoked"})
+		return
+	}
+	c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+}
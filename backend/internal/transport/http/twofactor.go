@@ -0,0 +1,125 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/iamasit07/connect4/backend/internal/logging"
+	"github.com/iamasit07/connect4/backend/internal/service/session"
+	"github.com/iamasit07/connect4/backend/internal/service/twofactor"
+	"github.com/iamasit07/connect4/backend/pkg/auth"
+	"github.com/iamasit07/connect4/backend/pkg/httputil"
+)
+
+// TwoFactorHandler serves enrollment and management of TOTP two-factor auth
+type TwoFactorHandler struct {
+	TwoFactor   *twofactor.Service
+	AuthService *session.AuthService
+}
+
+func NewTwoFactorHandler(tf *twofactor.Service, authSvc *session.AuthService) *TwoFactorHandler {
+	return &TwoFactorHandler{TwoFactor: tf, AuthService: authSvc}
+}
+
+type twoFactorCodeRequest struct {
+	Code string `json:"code"`
+}
+
+// Enroll hands out a new secret and recovery codes. Two-factor stays off until confirmed.
+func (h *TwoFactorHandler) Enroll(c *gin.Context) {
+	enrollment, err := h.TwoFactor.Enroll(c.GetInt64("user_id"))
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, enrollment)
+}
+
+// Confirm turns two-factor on with a code from the newly set up app
+func (h *TwoFactorHandler) Confirm(c *gin.Context) {
+	var req twoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	if err := h.TwoFactor.Confirm(c.GetInt64("user_id"), req.Code); err != nil {
+		h.respondError(c, err)
+		return
+	}
+	requestLog(c, "auth").Info("Two-factor enabled")
+	c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication enabled"})
+}
+
+// Disable turns two-factor off and rotates the caller's refresh token
+func (h *TwoFactorHandler) Disable(c *gin.Context) {
+	var req twoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	if err := h.TwoFactor.Disable(c.GetInt64("user_id"), req.Code); err != nil {
+		h.respondError(c, err)
+		return
+	}
+	requestLog(c, "auth").Info("Two-factor disabled")
+	token, ok := h.reissueTokens(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication disabled", "token": token})
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes and rotates the caller's refresh token
+func (h *TwoFactorHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	var req twoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	codes, err := h.TwoFactor.RegenerateRecoveryCodes(c.GetInt64("user_id"), req.Code)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+	token, ok := h.reissueTokens(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"recovery_codes": codes, "token": token})
+}
+
+// reissueTokens revokes every refresh token the user holds and issues a fresh
+// pair for the current session, so a stolen refresh token stops working
+func (h *TwoFactorHandler) reissueTokens(c *gin.Context) (string, bool) {
+	userID := c.GetInt64("user_id")
+	if err := h.AuthService.RevokeAllUserRefreshTokens(userID); err != nil {
+		requestLog(c, "auth").Error("Failed to revoke refresh tokens", logging.Err(err))
+	}
+	accessToken, refreshToken, err := h.AuthService.GenerateTokenPair(userID, c.GetString("username"), c.GetString("session_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate tokens"})
+		return "", false
+	}
+	httputil.SetTokenPairCookies(c.Writer, accessToken, refreshToken)
+	return accessToken, true
+}
+
+func (h *TwoFactorHandler) respondError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, twofactor.ErrInvalidCode), errors.Is(err, twofactor.ErrNotEnrolled):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, twofactor.ErrGuestAccount):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, twofactor.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, twofactor.ErrAlreadyEnabled), errors.Is(err, twofactor.ErrNotEnabled):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, auth.ErrNoSecretKey):
+		requestLog(c, "auth").Error("Two-factor request failed", logging.Err(err))
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Two-factor authentication is not available"})
+	default:
+		requestLog(c, "auth").Error("Two-factor request failed", logging.Err(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+	}
+}
@@ -22,6 +22,7 @@ type liveGameResponse struct {
 	SpectatorCount int            `json:"spectatorCount"`
 	MoveCount      int            `json:"moveCount"`
 	StartedAt      string         `json:"startedAt"`
+	Rated          bool           `json:"rated"`
 }
 
 type playerResponse struct {
@@ -42,6 +43,7 @@ func (h *WatchHandler) GetLiveGames(c *gin.Context) {
 			SpectatorCount: g.SpectatorCount,
 			MoveCount:      g.MoveCount,
 			StartedAt:      g.StartedAt,
+			Rated:          g.Rated,
 		})
 	}
 
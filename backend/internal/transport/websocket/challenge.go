@@ -0,0 +1,129 @@
+package websocket
+
+import (
+	"github.com/iamasit07/connect4/backend/internal/domain"
+	"github.com/iamasit07/connect4/backend/internal/logging"
+	"github.com/iamasit07/connect4/backend/internal/protocol"
+	"github.com/iamasit07/connect4/backend/internal/service/presence"
+)
+
+// handleChallenge sends a game invitation to an online friend
+func (h *Handler) handleChallenge(userID int64, sessionID string, msg protocol.ChallengeUser) {
+	targetID := msg.UserID
+
+	areFriends, err := h.Friends.AreFriends(userID, targetID)
+	if err != nil {
+		h.connLog(userID, sessionID).Error("Friend check failed", "target_id", targetID, logging.Err(err))
+	}
+	if !areFriends {
+		h.replyError(userID, sessionID, domain.ErrForbidden, "You can only challenge friends")
+		return
+	}
+	if h.isBlocked(userID, targetID) {
+		h.replyError(userID, sessionID, domain.ErrForbidden, "Player is not accepting challenges from you")
+		return
+	}
+	if h.SessionManager.IsPlaying(userID) {
+		h.replyError(userID, sessionID, domain.ErrRejected, "Finish your current game first")
+		return
+	}
+	if status := h.Presence.Get(targetID); status != presence.Online && status != presence.Spectating {
+		h.reply(userID, sessionID, domain.ServerMessage{Type: "error", Code: domain.ErrRejected, Message: "Player is not available", Presence: string(status)})
+		return
+	}
+
+	// Challenges are rated unless the sender asks for a casual game or either player is a guest
+	guest := h.ConnManager.IsGuest(userID) || h.ConnManager.IsGuest(targetID)
+	rated := !guest
+	if msg.Rated != nil {
+		rated = *msg.Rated
+	}
+	if rated && guest {
+		h.replyError(userID, sessionID, domain.ErrForbidden, errGuestRated)
+		return
+	}
+	if rated && h.SessionManager.RatedPairLimitReached(userID, targetID) {
+		h.replyError(userID, sessionID, domain.ErrRejected, errRatedPairLimit)
+		return
+	}
+
+	fromUsername, _ := h.ConnManager.GetUsername(userID)
+	toUsername, _ := h.ConnManager.GetUsername(targetID)
+	ch, err := h.Challenges.Create(userID, fromUsername, targetID, toUsername, rated)
+	if err != nil {
+		h.replyError(userID, sessionID, domain.ErrRejected, err.Error())
+		return
+	}
+
+	// The game starts on the device that sent the challenge
+	h.playOn(userID, sessionID)
+
+	timeout := int(h.Challenges.Timeout().Seconds())
+	h.ConnManager.SendMessage(targetID, domain.ServerMessage{
+		Type:             "challenge_received",
+		ChallengeID:      ch.ID,
+		UserID:           userID,
+		Username:         fromUsername,
+		Rated:            &rated,
+		ChallengeTimeout: timeout,
+	})
+	h.reply(userID, sessionID, domain.ServerMessage{
+		Type:             "challenge_sent",
+		ChallengeID:      ch.ID,
+		UserID:           targetID,
+		Username:         toUsername,
+		Rated:            &rated,
+		ChallengeTimeout: timeout,
+	})
+}
+
+// handleChallengeResponse accepts or declines a challenge; accepting starts the game
+func (h *Handler) handleChallengeResponse(userID int64, sessionID string, msg protocol.ChallengeResponse) {
+	ch, err := h.Challenges.Take(msg.ChallengeID, userID)
+	if err != nil {
+		h.replyError(userID, sessionID, domain.ErrNotFound, err.Error())
+		return
+	}
+
+	if !msg.Accepted() {
+		h.ConnManager.SendMessage(ch.FromID, domain.ServerMessage{
+			Type:        "challenge_declined",
+			ChallengeID: ch.ID,
+			UserID:      ch.ToID,
+			Username:    ch.ToUsername,
+		})
+		return
+	}
+
+	// A block placed while the challenge was open cancels it
+	if h.isBlocked(ch.FromID, ch.ToID) || !h.ConnManager.IsOnline(ch.FromID) || h.SessionManager.IsPlaying(ch.FromID) || h.SessionManager.IsPlaying(userID) {
+		h.replyError(userID, sessionID, domain.ErrRejected, "Challenge is no longer available")
+		return
+	}
+	// The pair may have reached its rated limit since the challenge was sent
+	if ch.Rated && h.SessionManager.RatedPairLimitReached(ch.FromID, ch.ToID) {
+		h.replyError(userID, sessionID, domain.ErrRejected, errRatedPairLimit)
+		h.ConnManager.SendMessage(ch.FromID, domain.ServerMessage{Type: "error", Code: domain.ErrRejected, Message: errRatedPairLimit})
+		return
+	}
+
+	for _, id := range []int64{ch.FromID, ch.ToID} {
+		h.Matchmaking.RemovePlayer(id)
+		h.SessionManager.ForceCleanupForUser(id)
+	}
+
+	h.playOn(userID, sessionID)
+	toID := ch.ToID
+	session := h.SessionManager.CreateSession(ch.FromID, ch.FromUsername, &toID, ch.ToUsername, "", ch.Rated)
+	h.connLog(userID, sessionID).Info("Challenge accepted", logging.GameID(session.GameID), "from_id", ch.FromID, "to_id", ch.ToID, "rated", ch.Rated)
+}
+
+// isBlocked reports whether either user blocked the other. Lookup failures
+// are logged and treated as not blocked.
+func (h *Handler) isBlocked(a, b int64) bool {
+	blocked, err := h.Friends.IsBlocked(a, b)
+	if err != nil {
+		h.log.Error("Block check failed", "user_a", a, "user_b", b, logging.Err(err))
+	}
+	return blocked
+}
@@ -6,100 +6,146 @@ import (
 
 	"github.com/gorilla/websocket"
 	"github.com/iamasit07/connect4/backend/internal/domain"
+	"github.com/iamasit07/connect4/backend/internal/protocol"
 )
 
-// ConnectionManager handles active WebSocket connections thread-safely
-type ConnectionManager struct {
-	connections map[int64]*websocket.Conn
-	usernames   map[int64]string
-	
-	// writeMu ensures only one goroutine writes to a specific socket at a time.
+// device is one signed-in session's socket. A user can have one per session.
+type device struct {
+	conn    *websocket.Conn
+	version int // protocol version agreed in init
+
+	// writeMu ensures only one goroutine writes to the socket at a time.
 	// This is CRITICAL because conn.WriteJSON is not thread-safe.
-	writeMu     map[int64]*sync.Mutex 
-	
-	mu          sync.RWMutex // Protects the maps themselves
+	writeMu sync.Mutex
+}
+
+// ConnectionManager handles active WebSocket connections thread-safely.
+// Connections are kept per user and session, so a user signed in on a phone
+// and a desktop has two. Game traffic goes to the device playing the game;
+// everything else goes to all of the user's devices.
+type ConnectionManager struct {
+	devices   map[int64]map[string]*device // user ID → session ID → socket
+	usernames map[int64]string
+	guests    map[int64]bool   // users connected with a guest token
+	playing   map[int64]string // user ID → session ID of the device playing the user's game
+
+	mu sync.RWMutex // Protects the maps themselves
 }
 
 func NewConnectionManager() *ConnectionManager {
 	return &ConnectionManager{
-		connections: make(map[int64]*websocket.Conn),
-		usernames:   make(map[int64]string),
-		writeMu:     make(map[int64]*sync.Mutex),
+		devices:   make(map[int64]map[string]*device),
+		usernames: make(map[int64]string),
+		guests:    make(map[int64]bool),
+		playing:   make(map[int64]string),
 	}
 }
 
-// AddConnection registers a new connection and initializes its write lock
-func (cm *ConnectionManager) AddConnection(userID int64, conn *websocket.Conn, username string) {
+// AddConnection registers a device's connection, replacing any older socket
+// from the same session (a reconnect or a second tab). Messages to it are
+// written in the given protocol version.
+func (cm *ConnectionManager) AddConnection(userID int64, sessionID string, conn *websocket.Conn, username string, version int) {
 	cm.mu.Lock()
 	defer cm.mu.Unlock()
 
-	// 1. Close old connection if it exists (Single Session Logic)
-	if oldConn, exists := cm.connections[userID]; exists {
-		oldConn.Close()
+	devices, exists := cm.devices[userID]
+	if !exists {
+		devices = make(map[string]*device)
+		cm.devices[userID] = devices
+	}
+	if old, exists := devices[sessionID]; exists {
+		old.conn.Close()
 	}
 
-	// 2. Register new connection
-	cm.connections[userID] = conn
+	devices[sessionID] = &device{conn: conn, version: version}
 	cm.usernames[userID] = username
-	
-	// 3. Initialize the per-user write mutex (The Missing Logic)
-	cm.writeMu[userID] = &sync.Mutex{}
+	if !exists {
+		delete(cm.guests, userID)
+	}
 }
 
-// RemoveConnection removes a user's connection and cleans up locks
-func (cm *ConnectionManager) RemoveConnection(userID int64) {
+// RemoveConnectionIfMatching removes a device's connection unless it has
+// already been replaced by a newer one
+func (cm *ConnectionManager) RemoveConnectionIfMatching(userID int64, sessionID string, conn *websocket.Conn) {
 	cm.mu.Lock()
 	defer cm.mu.Unlock()
 
-	if conn, exists := cm.connections[userID]; exists {
-		conn.Close()
-		delete(cm.connections, userID)
-		delete(cm.usernames, userID)
-		delete(cm.writeMu, userID)
+	if d, exists := cm.devices[userID][sessionID]; exists && d.conn == conn {
+		cm.removeLocked(userID, sessionID)
 	}
 }
 
-// RemoveConnectionIfMatching avoids race conditions where we might accidentally 
-// close a NEW connection when trying to clean up an OLD one.
-func (cm *ConnectionManager) RemoveConnectionIfMatching(userID int64, conn *websocket.Conn) {
-	cm.mu.Lock()
-	defer cm.mu.Unlock()
-
-	if currentConn, exists := cm.connections[userID]; exists {
-		if currentConn == conn {
-			currentConn.Close()
-			delete(cm.connections, userID)
-			delete(cm.usernames, userID)
-			delete(cm.writeMu, userID)
-		}
+// removeLocked closes and forgets one device; cm.mu must be held
+func (cm *ConnectionManager) removeLocked(userID int64, sessionID string) {
+	d, exists := cm.devices[userID][sessionID]
+	if !exists {
+		return
+	}
+	d.conn.Close()
+	delete(cm.devices[userID], sessionID)
+	if cm.playing[userID] == sessionID {
+		delete(cm.playing, userID)
+	}
+	if len(cm.devices[userID]) == 0 {
+		delete(cm.devices, userID)
+		delete(cm.usernames, userID)
+		delete(cm.guests, userID)
 	}
 }
 
-func (cm *ConnectionManager) IsCurrentConnection(userID int64, conn *websocket.Conn) bool {
+// IsCurrentConnection reports whether conn is still the device's registered socket
+func (cm *ConnectionManager) IsCurrentConnection(userID int64, sessionID string, conn *websocket.Conn) bool {
 	cm.mu.RLock()
 	defer cm.mu.RUnlock()
-	currentConn, exists := cm.connections[userID]
-	return exists && currentConn == conn
+	d, exists := cm.devices[userID][sessionID]
+	return exists && d.conn == conn
 }
 
-// SendMessage sends a JSON message to a specific user securely
+// SendMessage sends a JSON message to every device the user is connected on
 func (cm *ConnectionManager) SendMessage(userID int64, message domain.ServerMessage) error {
-	// 1. Acquire global read lock to find the user's socket & mutex
 	cm.mu.RLock()
-	conn, exists := cm.connections[userID]
-	mu, muExists := cm.writeMu[userID]
+	devices := make([]*device, 0, len(cm.devices[userID]))
+	for _, d := range cm.devices[userID] {
+		devices = append(devices, d)
+	}
+	cm.mu.RUnlock()
+
+	var firstErr error
+	for _, d := range devices {
+		if err := d.write(message); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// SendToSession sends a JSON message to one of the user's devices
+func (cm *ConnectionManager) SendToSession(userID int64, sessionID string, message domain.ServerMessage) error {
+	cm.mu.RLock()
+	d, exists := cm.devices[userID][sessionID]
 	cm.mu.RUnlock()
 
-	if !exists || !muExists {
-		return nil // User disconnected, ignore
+	if !exists {
+		return nil // Device disconnected, ignore
 	}
+	return d.write(message)
+}
 
-	// 2. Acquire the PER-USER write lock (Critical Thread-Safety Fix)
-	mu.Lock()
-	defer mu.Unlock()
+// SendGameMessage sends a message about the user's own game to the device
+// playing it, or to all devices when none is
+func (cm *ConnectionManager) SendGameMessage(userID int64, message domain.ServerMessage) error {
+	if sessionID, ok := cm.PlayingDevice(userID); ok {
+		return cm.SendToSession(userID, sessionID, message)
+	}
+	return cm.SendMessage(userID, message)
+}
 
-	conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
-	return conn.WriteJSON(message)
+func (d *device) write(message domain.ServerMessage) error {
+	d.writeMu.Lock()
+	defer d.writeMu.Unlock()
+
+	d.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	return d.conn.WriteJSON(protocol.EncodeServer(d.version, message))
 }
 
 // BroadcastMessage sends a message to all connected users
@@ -107,7 +153,7 @@ func (cm *ConnectionManager) BroadcastMessage(message domain.ServerMessage) {
 	cm.mu.RLock()
 	defer cm.mu.RUnlock()
 
-	for userID := range cm.connections {
+	for userID := range cm.devices {
 		// We launch goroutines so one slow user doesn't block the broadcast
 		go func(uid int64) {
 			cm.SendMessage(uid, message)
@@ -115,7 +161,7 @@ func (cm *ConnectionManager) BroadcastMessage(message domain.ServerMessage) {
 	}
 }
 
-// DisconnectUser sends a generic disconnect message and closes the socket.
+// DisconnectUser sends a generic disconnect message and closes all of the user's sockets.
 // This satisfies the Disconnector interface used in AuthHandler.
 func (cm *ConnectionManager) DisconnectUser(userID int64, reason string) {
 	msg := domain.ServerMessage{
@@ -124,9 +170,85 @@ func (cm *ConnectionManager) DisconnectUser(userID int64, reason string) {
 	}
 	// Try to send the message (best effort)
 	_ = cm.SendMessage(userID, msg)
-	
+
 	// Then force close
-	cm.RemoveConnection(userID)
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	for sessionID := range cm.devices[userID] {
+		cm.removeLocked(userID, sessionID)
+	}
+}
+
+// DisconnectSession does what DisconnectUser does for a single device
+func (cm *ConnectionManager) DisconnectSession(userID int64, sessionID, reason string) {
+	_ = cm.SendToSession(userID, sessionID, domain.ServerMessage{
+		Type:    "force_disconnect",
+		Message: reason,
+	})
+
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	cm.removeLocked(userID, sessionID)
+}
+
+// SetPlayingDevice routes the user's game to sessionID. It returns the
+// session that was playing before, or "" when there was none.
+func (cm *ConnectionManager) SetPlayingDevice(userID int64, sessionID string) string {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	previous := cm.playing[userID]
+	cm.playing[userID] = sessionID
+	return previous
+}
+
+// ClaimPlayingDevice makes sessionID the playing device unless another one
+// already is, and reports whether sessionID is the playing device afterwards
+func (cm *ConnectionManager) ClaimPlayingDevice(userID int64, sessionID string) bool {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	if current, ok := cm.playing[userID]; ok && current != sessionID {
+		return false
+	}
+	cm.playing[userID] = sessionID
+	return true
+}
+
+// PlayingDevice returns the session ID of the device playing the user's game
+func (cm *ConnectionManager) PlayingDevice(userID int64) (string, bool) {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+	sessionID, ok := cm.playing[userID]
+	return sessionID, ok
+}
+
+// IsPlayingDevice reports whether the user's game belongs to sessionID. With
+// no playing device chosen, the user's only connected device counts.
+func (cm *ConnectionManager) IsPlayingDevice(userID int64, sessionID string) bool {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+	if current, ok := cm.playing[userID]; ok {
+		return current == sessionID
+	}
+	_, connected := cm.devices[userID][sessionID]
+	return connected && len(cm.devices[userID]) == 1
+}
+
+// ConnectedSessions returns the session IDs the user has a socket open for
+func (cm *ConnectionManager) ConnectedSessions(userID int64) []string {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+	sessions := make([]string, 0, len(cm.devices[userID]))
+	for sessionID := range cm.devices[userID] {
+		sessions = append(sessions, sessionID)
+	}
+	return sessions
+}
+
+// IsOnline reports whether the user has an open connection on any device
+func (cm *ConnectionManager) IsOnline(userID int64) bool {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+	return len(cm.devices[userID]) > 0
 }
 
 // GetUsername returns the username for a connected user
@@ -136,3 +258,19 @@ func (cm *ConnectionManager) GetUsername(userID int64) (string, bool) {
 	name, exists := cm.usernames[userID]
 	return name, exists
 }
+
+// MarkGuest records that the user's current connection belongs to a guest
+func (cm *ConnectionManager) MarkGuest(userID int64) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	if len(cm.devices[userID]) > 0 {
+		cm.guests[userID] = true
+	}
+}
+
+// IsGuest reports whether the user is connected as a guest
+func (cm *ConnectionManager) IsGuest(userID int64) bool {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+	return cm.guests[userID]
+}
@@ -2,10 +2,8 @@ package websocket
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"log"
-	"net"
+	"log/slog"
 	"net/http"
 	"sync"
 	"time"
@@ -14,33 +12,51 @@ import (
 	"github.com/gorilla/websocket"
 	"github.com/iamasit07/connect4/backend/internal/config"
 	"github.com/iamasit07/connect4/backend/internal/domain"
-	"github.com/iamasit07/connect4/backend/internal/repository/redis"
+	"github.com/iamasit07/connect4/backend/internal/logging"
+	"github.com/iamasit07/connect4/backend/internal/protocol"
+	"github.com/iamasit07/connect4/backend/internal/ratelimit"
+	"github.com/iamasit07/connect4/backend/internal/service/friends"
 	"github.com/iamasit07/connect4/backend/internal/service/game"
 	"github.com/iamasit07/connect4/backend/internal/service/matchmaking"
+	"github.com/iamasit07/connect4/backend/internal/service/presence"
 	"github.com/iamasit07/connect4/backend/internal/service/session"
+	"github.com/iamasit07/connect4/backend/internal/tracing"
+	"github.com/iamasit07/connect4/backend/pkg/auth"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
 	maxMessageSize = 4096
-	maxConnsPerIP  = 5
 	writeTimeout   = 10 * time.Second
 	pongWait       = 60 * time.Second
 	pingInterval   = 20 * time.Second
 )
 
+// errGuestRated is sent when a guest asks for a rated game
+const errGuestRated = "Guests can only play casual games. Register to play rated games."
+
+// errRatedPairLimit is sent when two players have reached their rated games
+// against each other for the day (RATED_PAIR_DAILY_LIMIT)
+const errRatedPairLimit = "You've played enough rated games against each other today. Play a casual game instead."
+
+// msgGameElsewhere goes with game_on_other_device, sent to a device asking
+// about a game another of the user's devices is playing
+const msgGameElsewhere = "This game is being played on another device"
+
 type ipConnTracker struct {
 	mu    sync.Mutex
 	conns map[string]int
+	max   int
 }
 
-func newIPConnTracker() *ipConnTracker {
-	return &ipConnTracker{conns: make(map[string]int)}
+func newIPConnTracker(max int) *ipConnTracker {
+	return &ipConnTracker{conns: make(map[string]int), max: max}
 }
 
 func (t *ipConnTracker) Increment(ip string) bool {
 	t.mu.Lock()
 	defer t.mu.Unlock()
-	if t.conns[ip] >= maxConnsPerIP {
+	if t.conns[ip] >= t.max {
 		return false
 	}
 	t.conns[ip]++
@@ -65,15 +81,32 @@ type Handler struct {
 	SessionManager *game.SessionManager
 	GameService    *game.Service
 	AuthService    *session.AuthService
+	Presence       *presence.Service
+	Friends        *friends.Service
+	Challenges     *friends.Challenges
+	APIKeys        APIKeyAuthenticator
+	Limiter        MessageLimiter
+	MessageLimits  map[string]ratelimit.Policy // "ws:<type>" or "ws:*" → policy, per user
 	Upgrader       websocket.Upgrader
 	ipTracker      *ipConnTracker
+	log            *slog.Logger
 	
 	gameLoops   map[string]bool 
 	gameLoopsMu sync.Mutex
 }
 
+// APIKeyAuthenticator resolves personal API keys (implemented by apikey.Service)
+type APIKeyAuthenticator interface {
+	Authenticate(key string) (*domain.APIKey, *domain.User, error)
+}
+
+// MessageLimiter spends tokens from per-key buckets (implemented by ratelimit.Limiter)
+type MessageLimiter interface {
+	Allow(key string, p ratelimit.Policy) (bool, time.Duration)
+}
+
 // NewHandler creates a new WebSocket handler with dependencies
-func NewHandler(cm *ConnectionManager, mq *matchmaking.MatchmakingQueue, sm *game.SessionManager, gs *game.Service, as *session.AuthService) *Handler {
+func NewHandler(cm *ConnectionManager, mq *matchmaking.MatchmakingQueue, sm *game.SessionManager, gs *game.Service, as *session.AuthService, ps *presence.Service, fs *friends.Service, challenges *friends.Challenges) *Handler {
 	allowedOrigins := config.AppConfig.AllowedOrigins
 
 	h := &Handler{
@@ -82,8 +115,12 @@ func NewHandler(cm *ConnectionManager, mq *matchmaking.MatchmakingQueue, sm *gam
 		SessionManager: sm,
 		GameService:    gs,
 		AuthService:    as,
-		ipTracker:      newIPConnTracker(),
+		Presence:       ps,
+		Friends:        fs,
+		Challenges:     challenges,
+		ipTracker:      newIPConnTracker(config.AppConfig.MaxConnsPerIP),
 		gameLoops:      make(map[string]bool),
+		log:            logging.For("ws"),
 		Upgrader: websocket.Upgrader{
 			CheckOrigin: func(r *http.Request) bool {
 				origin := r.Header.Get("Origin")
@@ -95,7 +132,7 @@ func NewHandler(cm *ConnectionManager, mq *matchmaking.MatchmakingQueue, sm *gam
 						return true
 					}
 				}
-				log.Printf("[WS] Rejected WebSocket from disallowed origin: %s", origin)
+				logging.ForContext(r.Context(), "ws").Warn("Rejected WebSocket from disallowed origin", "origin", origin)
 				return false
 			},
 			ReadBufferSize:  1024,
@@ -123,62 +160,57 @@ func (h *Handler) EnsureEventLoopRunning(gs *game.GameSession) {
 
 // ConsumeGameEvents listens to the decoupled game event channel and broadcasts to users
 func (h *Handler) ConsumeGameEvents(gs *game.GameSession) {
+	log := h.log.With(logging.GameID(gs.GameID))
 	defer func() {
 		h.gameLoopsMu.Lock()
 		delete(h.gameLoops, gs.GameID)
 		h.gameLoopsMu.Unlock()
-		log.Printf("[WS] Event loop stopped for game %s", gs.GameID)
+		log.Debug("Event loop stopped")
 	}()
 
-	log.Printf("[WS] Event loop started for game %s", gs.GameID)
+	log.Debug("Event loop started")
 
 	for {
 		select {
 		case event, ok := <-gs.Events:
 			if !ok {
-				log.Printf("[WS] Events channel closed for game %s", gs.GameID)
+				log.Debug("Events channel closed")
 				return
 			}
 			msg, ok := event.Payload.(domain.ServerMessage)
 			if !ok {
-				log.Printf("[WS] Unknown event payload type for game %s", gs.GameID)
+				log.Warn("Unknown event payload type")
 				continue
 			}
 
 			for _, recipientID := range event.Recipients {
 				go func(uid int64, m domain.ServerMessage) {
-					h.ConnManager.SendMessage(uid, m)
+					// Players get their game on the device playing it; spectators on every device
+					if gs.IsPlayer(uid) {
+						h.ConnManager.SendGameMessage(uid, m)
+					} else {
+						h.ConnManager.SendMessage(uid, m)
+					}
 				}(recipientID, msg)
 			}
-		case <-gs.Ctx.Done():
-			log.Printf("[WS] Game context cancelled for game %s", gs.GameID)
-			return
-		}
-	}
-}
 
-// extractIP returns the client IP address from the request.
-func extractIP(r *http.Request) string {
-	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
-		for i := 0; i < len(xff); i++ {
-			if xff[i] == ',' {
-				return xff[:i]
+			// Games starting or ending (including on timers) change the players' presence
+			if msg.Type == "game_start" || msg.Type == "game_over" {
+				go h.Presence.Refresh(event.Recipients...)
 			}
+		case <-gs.Ctx.Done():
+			log.Debug("Game context cancelled")
+			return
 		}
-		return xff
-	}
-	ip, _, err := net.SplitHostPort(r.RemoteAddr)
-	if err != nil {
-		return r.RemoteAddr
 	}
-	return ip
 }
 
 // HandleWebSocket is the Gin handler that upgrades the connection
 func (h *Handler) HandleWebSocket(c *gin.Context) {
-	clientIP := extractIP(c.Request)
+	clientIP := config.AppConfig.ClientIP(c.Request)
+	log := logging.ForContext(c.Request.Context(), "ws")
 	if !h.ipTracker.Increment(clientIP) {
-		log.Printf("[WS] Connection limit exceeded for IP: %s", clientIP)
+		log.Warn("Connection limit exceeded", "ip", clientIP)
 		c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many WebSocket connections"})
 		return
 	}
@@ -186,17 +218,72 @@ func (h *Handler) HandleWebSocket(c *gin.Context) {
 	conn, err := h.Upgrader.Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
 		h.ipTracker.Decrement(clientIP)
-		log.Printf("[WS] Upgrade error: %v", err)
+		log.Warn("Upgrade error", logging.Err(err))
 		return
 	}
 
 	conn.SetReadLimit(maxMessageSize)
 
-	h.handleConnection(conn, clientIP)
+	h.handleConnection(c.Request.Context(), conn, clientIP, log)
+}
+
+// SetAPIKeys lets clients sign in with a personal API key that has the play scope
+func (h *Handler) SetAPIKeys(keys APIKeyAuthenticator) {
+	h.APIKeys = keys
+}
+
+// SetRateLimits limits how often each user may send each message type
+func (h *Handler) SetRateLimits(limiter MessageLimiter, limits map[string]ratelimit.Policy) {
+	h.Limiter = limiter
+	h.MessageLimits = limits
+}
+
+// allowMessage spends a token for msgType from the user's bucket. Types without
+// their own "ws:<type>" policy share the "ws:*" one.
+func (h *Handler) allowMessage(userID int64, msgType string) (bool, time.Duration) {
+	if h.Limiter == nil {
+		return true, 0
+	}
+	name := "ws:" + msgType
+	policy, ok := h.MessageLimits[name]
+	if !ok {
+		name = "ws:*"
+		policy = h.MessageLimits[name]
+	}
+	return h.Limiter.Allow(fmt.Sprintf("%s:%d", name, userID), policy)
+}
+
+// authenticate checks the token sent with init: a JWT validated against the
+// user's session, or an API key. Each API key counts as its own device.
+func (h *Handler) authenticate(token string) (userID int64, username, sessionID string, guest bool, err error) {
+	if auth.IsAPIKey(token) && h.APIKeys != nil {
+		key, user, err := h.APIKeys.Authenticate(token)
+		if err != nil {
+			return 0, "", "", false, err
+		}
+		if !key.HasScope(domain.ScopePlay) {
+			return 0, "", "", false, fmt.Errorf("api key %d lacks the %s scope", key.ID, domain.ScopePlay)
+		}
+		return user.ID, user.Username, key.SessionID(), false, nil
+	}
+
+	// Validate JWT using AuthService (Stateful DB Check)
+	claims, err := h.AuthService.ValidateToken(token)
+	if err != nil {
+		return 0, "", "", false, err
+	}
+	return claims.UserID, claims.Username, claims.SessionID, claims.Guest, nil
+}
+
+// connLog returns the handler's logger tagged with a user and their device
+func (h *Handler) connLog(userID int64, sessionID string) *slog.Logger {
+	return h.log.With(logging.UserID(userID), logging.SessionID(sessionID))
 }
 
-// handleConnection manages the lifecycle of a single WebSocket connection
-func (h *Handler) handleConnection(conn *websocket.Conn, clientIP string) {
+// handleConnection manages the lifecycle of a single WebSocket connection.
+// reqCtx is the upgrade request's context, whose span each message's trace
+// links back to; log carries the request's ID and gains the user once init succeeds.
+func (h *Handler) handleConnection(reqCtx context.Context, conn *websocket.Conn, clientIP string, log *slog.Logger) {
 	defer h.ipTracker.Decrement(clientIP)
 
 	// Use a context to cleanly shut down the ping goroutine
@@ -233,51 +320,69 @@ func (h *Handler) handleConnection(conn *websocket.Conn, clientIP string) {
 	// 1. Wait for Initialization (Auth)
 	_, data, err := conn.ReadMessage()
 	if err != nil {
-		log.Printf("[WS] Read error during init: %v", err)
+		log.Info("Read error during init", logging.Err(err))
 		conn.Close()
 		return
 	}
 
-	var message domain.ClientMessage
-	if err := json.Unmarshal(data, &message); err != nil {
-		log.Printf("[WS] Invalid JSON during init: %v", err)
+	init, err := protocol.ParseInit(data)
+	if err != nil {
+		log.Info("Invalid initialization", logging.Err(err))
+		conn.WriteJSON(domain.ServerMessage{Type: "error", Code: protocol.CodeOf(err), Message: err.Error()})
 		conn.Close()
 		return
 	}
 
-	if message.Type == "init" && message.JWT != "" {
-		// Validate JWT using AuthService (Stateful DB Check)
-		claims, err := h.AuthService.ValidateToken(message.JWT)
-		if err != nil {
-			log.Printf("[WS] Invalid token during init: %v", err)
-			conn.WriteJSON(domain.ErrorMessage{Type: "error", Message: "Invalid token or session expired"})
-			conn.Close()
-			return
-		}
-		userID = claims.UserID
-		username = claims.Username
-		sessionID = claims.SessionID
-		h.ConnManager.AddConnection(userID, conn, username)
+	// Errors before the version is agreed are written in version 1, which
+	// every client reads
+	version, ok := protocol.Negotiate(init.Versions)
+	if !ok {
+		log.Info("Unsupported protocol versions", "versions", init.Versions)
+		conn.WriteJSON(domain.ServerMessage{Type: "error", Code: domain.ErrUnsupportedVersion, Message: "None of the offered protocol versions is supported", Versions: protocol.Supported})
+		conn.Close()
+		return
+	}
 
-		if session, exists := h.SessionManager.GetSessionByUserID(userID); exists {
-			// Ensure event loop is running for this session
-			h.EnsureEventLoopRunning(session)
-			
-			if err := session.HandleReconnect(userID); err != nil {
-				log.Printf("[WS] Reconnect failed for user %d: %v", userID, err)
-			}
-		}
-	} else {
-		log.Printf("[WS] Missing initialization or token")
+	var guest bool
+	userID, username, sessionID, guest, err = h.authenticate(init.JWT)
+	if err != nil {
+		log.Info("Invalid token during init", logging.Err(err))
+		conn.WriteJSON(protocol.EncodeServer(version, domain.ServerMessage{Type: "error", Code: domain.ErrUnauthorized, Message: "Invalid token or session expired"}))
 		conn.Close()
 		return
 	}
+	log = log.With(logging.UserID(userID), logging.SessionID(sessionID))
+	log.Debug("Connection initialized", "ip", clientIP, "protocol", version)
+	h.ConnManager.AddConnection(userID, sessionID, conn, username, version)
+	if guest {
+		h.ConnManager.MarkGuest(userID)
+	}
+	if len(init.Versions) > 0 {
+		h.reply(userID, sessionID, domain.ServerMessage{Type: "welcome", Version: version, Versions: protocol.Supported})
+	}
+	h.Presence.Refresh(userID)
+
+	// Resume the user's game here unless another device is playing it
+	if session, exists := h.SessionManager.GetSessionByUserID(userID); exists && h.ConnManager.ClaimPlayingDevice(userID, sessionID) {
+		// Ensure event loop is running for this session
+		h.EnsureEventLoopRunning(session)
+		
+		if err := session.HandleReconnect(userID); err != nil {
+			log.Warn("Reconnect failed", logging.GameID(session.GameID), logging.Err(err))
+		}
+	}
 
 	// 2. Cleanup on exit
 	defer func() {
-		h.Matchmaking.RemovePlayer(userID)
+		// Only the device playing the user's game takes it (and their queue spot) with it
+		releaseGame := h.ConnManager.IsCurrentConnection(userID, sessionID, conn) && h.ConnManager.IsPlayingDevice(userID, sessionID)
+		h.ConnManager.RemoveConnectionIfMatching(userID, sessionID, conn)
+		online := h.ConnManager.IsOnline(userID)
 
-		if h.ConnManager.IsCurrentConnection(userID, conn) {
+		if releaseGame || !online {
+			h.Matchmaking.RemovePlayer(userID)
+		}
+		if releaseGame {
 			gameSession, exists := h.SessionManager.GetSessionByUserID(userID)
 			if exists {
 				gameSession.HandleDisconnect(userID, h.SessionManager)
@@ -285,9 +390,11 @@ func (h *Handler) handleConnection(conn *websocket.Conn, clientIP string) {
 		}
 
 		// Clean up spectator status across all sessions
-		h.SessionManager.RemoveSpectatorFromAll(userID)
+		if !online {
+			h.SessionManager.RemoveSpectatorFromAll(userID)
+		}
 
-		h.ConnManager.RemoveConnectionIfMatching(userID, conn)
+		h.Presence.Refresh(userID)
 	}()
 
 	// 3. Main Message Loop
@@ -295,14 +402,17 @@ func (h *Handler) handleConnection(conn *websocket.Conn, clientIP string) {
 		_, data, err := conn.ReadMessage()
 		if err != nil {
 			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
-				log.Printf("[WS] User disconnected unexpectedly: %v", err)
+				log.Info("User disconnected unexpectedly", logging.Err(err))
 			}
 			break
 		}
 
-		var msg domain.ClientMessage
-		if err := json.Unmarshal(data, &msg); err != nil {
-			log.Printf("[WS] Invalid message format: %v", err)
+		msg, err := protocol.DecodeClient(version, data)
+		if err != nil {
+			log.Debug("Rejected message", "type", msg.Type, logging.Err(err))
+			if allowed, _ := h.allowMessage(userID, msg.Type); allowed {
+				h.replyError(userID, sessionID, protocol.CodeOf(err), err.Error())
+			}
 			continue
 		}
 
@@ -311,109 +421,160 @@ func (h *Handler) handleConnection(conn *websocket.Conn, clientIP string) {
 		if msg.JWT != "" {
 			claims, err := h.AuthService.ValidateTokenOffline(msg.JWT)
 			if err != nil {
-				log.Printf("[WS] Session revoked for user %d: %v", userID, err)
-				h.ConnManager.SendMessage(userID, domain.ServerMessage{Type: "error", Message: "Session invalidated/replaced"})
+				log.Info("Session revoked", logging.Err(err))
+				h.replyError(userID, sessionID, domain.ErrUnauthorized, "Session invalidated/replaced")
 				return // Break loop and disconnect
 			}
 			// Sanity check
 			if claims.UserID != userID || claims.SessionID != sessionID {
-				log.Printf("[WS] Session mismatch or user spoofing attempt: %d vs %d", userID, claims.UserID)
+				log.Warn("Session mismatch or user spoofing attempt", "claimed_user_id", claims.UserID)
 				return
 			}
 		} else {
 			
 			if h.AuthService.IsSessionBlocked(sessionID) {
-				log.Printf("[WS] Session blocked (Redis check): %s", sessionID)
-				h.ConnManager.SendMessage(userID, domain.ServerMessage{Type: "error", Message: "Session invalidated/replaced"})
+				log.Info("Session blocked (Redis check)")
+				h.replyError(userID, sessionID, domain.ErrUnauthorized, "Session invalidated/replaced")
 				return
 			}
 		}
 
-		h.processMessage(userID, msg)
+		h.processMessage(reqCtx, userID, sessionID, msg)
+		h.Presence.Refresh(userID)
 	}
 }
 
-// processMessage routes specific actions
-func (h *Handler) processMessage(userID int64, msg domain.ClientMessage) {
-	switch msg.Type {
-	case "find_match":
-		difficulty := msg.Difficulty
+// processMessage routes specific actions. sessionID identifies the device that sent msg.
+// Each message starts its own trace, linked to the connection's upgrade request.
+func (h *Handler) processMessage(connCtx context.Context, userID int64, sessionID string, msg protocol.Message) {
+	ctx, span := tracing.Tracer().Start(context.Background(), "ws "+msg.Type,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithLinks(trace.LinkFromContext(connCtx)),
+		trace.WithAttributes(tracing.KeyMessageType.String(msg.Type), tracing.KeyUserID.Int64(userID)),
+	)
+	defer span.End()
+
+	if allowed, wait := h.allowMessage(userID, msg.Type); !allowed {
+		h.reply(userID, sessionID, domain.ServerMessage{Type: "error", Code: domain.ErrRateLimited, Message: "Too many requests. Please wait.", RetryAfter: ratelimit.RetryAfterSeconds(wait)})
+		return
+	}
 
-		// Rate-limit find_match: max 1 request per 3 seconds per user
-		rateLimitKey := fmt.Sprintf("ratelimit:find_match:%d", userID)
-		if !h.checkRateLimit(rateLimitKey, 3*time.Second) {
-			h.ConnManager.SendMessage(userID, domain.ServerMessage{Type: "error", Message: "Too many requests. Please wait."})
+	switch payload := msg.Payload.(type) {
+	case protocol.Init:
+		h.replyError(userID, sessionID, domain.ErrRejected, "Already initialized")
+
+	case protocol.FindMatch:
+		difficulty := payload.Difficulty
+
+		// PvP games are rated unless the player asks for casual; bot games are casual unless asked.
+		// Guests only ever play casual games.
+		guest := h.ConnManager.IsGuest(userID)
+		rated := difficulty == "" && !guest
+		if payload.Rated != nil {
+			rated = *payload.Rated
+		}
+		if rated && guest {
+			h.replyError(userID, sessionID, domain.ErrForbidden, errGuestRated)
+			return
+		}
+
+		// Starting a new game from here would abandon the one running on another device
+		if h.SessionManager.IsPlaying(userID) && !h.ConnManager.IsPlayingDevice(userID, sessionID) {
+			h.reply(userID, sessionID, domain.ServerMessage{Type: "game_on_other_device", Message: msgGameElsewhere})
 			return
 		}
 
 		h.SessionManager.ForceCleanupForUser(userID)
+		h.playOn(userID, sessionID)
 
 		username, _ := h.ConnManager.GetUsername(userID)
-		err := h.Matchmaking.AddPlayerToQueue(userID, username, difficulty)
+		err := h.Matchmaking.AddPlayerToQueue(userID, username, difficulty, rated)
 		if err != nil {
-			h.ConnManager.SendMessage(userID, domain.ServerMessage{Type: "error", Message: "Failed to join queue"})
+			h.replyError(userID, sessionID, domain.ErrInternal, "Failed to join queue")
 		} else {
-			h.ConnManager.SendMessage(userID, domain.ServerMessage{Type: "queue_joined"})
+			h.reply(userID, sessionID, domain.ServerMessage{Type: "queue_joined", Rated: &rated})
 		}
 
-	case "cancel_search":
+	case protocol.CancelSearch:
 		h.Matchmaking.RemovePlayer(userID)
-		h.ConnManager.SendMessage(userID, domain.ServerMessage{Type: "queue_left"})
+		h.reply(userID, sessionID, domain.ServerMessage{Type: "queue_left"})
 
-	case "make_move":
-		gameSession, exists := h.SessionManager.GetSessionByUserID(userID)
-		if !exists {
-			h.ConnManager.SendMessage(userID, domain.ServerMessage{Type: "error", Message: "Game not found"})
+	case protocol.MakeMove:
+		gameSession, ok := h.ownGame(userID, sessionID)
+		if !ok {
 			return
 		}
-		
-		h.EnsureEventLoopRunning(gameSession)
 
-		err := gameSession.HandleMove(userID, msg.Column)
+		span.SetAttributes(tracing.KeyGameID.String(gameSession.GameID))
+		err := gameSession.HandleMove(ctx, userID, payload.Column)
 		if err != nil {
-			h.ConnManager.SendMessage(userID, domain.ServerMessage{Type: "error", Message: err.Error()})
+			h.replyError(userID, sessionID, domain.ErrRejected, err.Error())
 		}
 
-	case "request_rematch":
-		gameSession, exists := h.SessionManager.GetSessionByUserID(userID)
-		if !exists {
-			h.ConnManager.SendMessage(userID, domain.ServerMessage{Type: "error", Message: "Game not found"})
+	case protocol.RequestRematch:
+		gameSession, ok := h.ownGame(userID, sessionID)
+		if !ok {
+			return
+		}
+
+		if opponentID := gameSession.GetOpponentID(userID); opponentID != nil && h.isBlocked(userID, *opponentID) {
+			h.replyError(userID, sessionID, domain.ErrForbidden, "Rematch is not available")
 			return
 		}
-		h.EnsureEventLoopRunning(gameSession)
 		
 		err := gameSession.HandleRematchRequest(userID, h.SessionManager)
 		if err != nil {
-			h.ConnManager.SendMessage(userID, domain.ServerMessage{Type: "error", Message: err.Error()})
+			h.replyError(userID, sessionID, domain.ErrRejected, err.Error())
 		}
 
-	case "rematch_response":
-		gameSession, exists := h.SessionManager.GetSessionByUserID(userID)
-		if !exists {
-			h.ConnManager.SendMessage(userID, domain.ServerMessage{Type: "error", Message: "Game not found"})
+	case protocol.RematchResponse:
+		gameSession, ok := h.ownGame(userID, sessionID)
+		if !ok {
 			return
 		}
-		h.EnsureEventLoopRunning(gameSession)
 		
-		accept := msg.RematchResponse == "accept"
-		err := gameSession.HandleRematchResponse(userID, accept, h.SessionManager)
+		err := gameSession.HandleRematchResponse(userID, payload.Accepted(), h.SessionManager)
 		if err != nil {
-			h.ConnManager.SendMessage(userID, domain.ServerMessage{Type: "error", Message: err.Error()})
+			h.replyError(userID, sessionID, domain.ErrRejected, err.Error())
 		}
 
-	case "abandon_game":
-		gameSession, exists := h.SessionManager.GetSessionByUserID(userID)
-		if !exists {
+	case protocol.OfferDraw:
+		gameSession, ok := h.ownGame(userID, sessionID)
+		if !ok {
+			return
+		}
+
+		err := gameSession.HandleDrawOffer(userID)
+		if err != nil {
+			h.replyError(userID, sessionID, domain.ErrRejected, err.Error())
+		}
+
+	case protocol.DrawResponse:
+		gameSession, ok := h.ownGame(userID, sessionID)
+		if !ok {
+			return
+		}
+
+		err := gameSession.HandleDrawResponse(userID, payload.Accepted())
+		if err != nil {
+			h.replyError(userID, sessionID, domain.ErrRejected, err.Error())
+		}
+
+	case protocol.AbandonGame:
+		if _, exists := h.SessionManager.GetSessionByUserID(userID); !exists {
+			return
+		}
+		gameSession, ok := h.ownGame(userID, sessionID)
+		if !ok {
 			return
 		}
-		h.EnsureEventLoopRunning(gameSession)
 		
 		gameSession.TerminateSessionWithReason(userID, "surrender")
 
-	case "watch_game":
-		gameSession, exists := h.SessionManager.GetSessionByGameID(msg.GameID)
+	case protocol.WatchGame:
+		gameSession, exists := h.SessionManager.GetSessionByGameID(payload.GameID)
 		if !exists {
-			h.ConnManager.SendMessage(userID, domain.ServerMessage{Type: "error", Message: "Game not found or ended"})
+			h.replyError(userID, sessionID, domain.ErrNotFound, "Game not found or ended")
 			return
 		}
 
@@ -421,20 +582,77 @@ func (h *Handler) processMessage(userID int64, msg domain.ClientMessage) {
 			return
 		}
 
+		players := []int64{gameSession.Player1ID}
+		if gameSession.Player2ID != nil {
+			players = append(players, *gameSession.Player2ID)
+		}
+		canWatch, err := h.Friends.CanWatch(userID, players...)
+		if err != nil {
+			h.connLog(userID, sessionID).Error("Spectate check failed", logging.GameID(payload.GameID), logging.Err(err))
+		}
+		if err == nil && !canWatch {
+			h.replyError(userID, sessionID, domain.ErrForbidden, "You can't watch this game")
+			return
+		}
+
 		h.EnsureEventLoopRunning(gameSession)
 		gameSession.AddSpectator(userID)
 
-	case "leave_spectate":
-		if gameSession, exists := h.SessionManager.GetSessionByGameID(msg.GameID); exists {
+	case protocol.LeaveSpectate:
+		if gameSession, exists := h.SessionManager.GetSessionByGameID(payload.GameID); exists {
 			gameSession.RemoveSpectator(userID)
 		}
 
-	case "get_game_state":
+	case protocol.PostChat:
+		h.chat(userID, sessionID, payload.GameID, func(gameSession *game.GameSession) error {
+			username, _ := h.ConnManager.GetUsername(userID)
+			return gameSession.HandleChatMessage(userID, username, payload.Text)
+		})
+
+	case protocol.MuteUser:
+		h.chat(userID, sessionID, payload.GameID, func(gameSession *game.GameSession) error {
+			return gameSession.HandleMute(userID, payload.UserID, true)
+		})
+
+	case protocol.UnmuteUser:
+		h.chat(userID, sessionID, payload.GameID, func(gameSession *game.GameSession) error {
+			return gameSession.HandleMute(userID, payload.UserID, false)
+		})
+
+	case protocol.ReportMessage:
+		h.chat(userID, sessionID, payload.GameID, func(gameSession *game.GameSession) error {
+			return gameSession.HandleReport(userID, payload.MessageID, payload.Reason)
+		})
+
+	case protocol.ChallengeUser:
+		h.handleChallenge(userID, sessionID, payload)
+
+	case protocol.ChallengeResponse:
+		h.handleChallengeResponse(userID, sessionID, payload)
+
+	case protocol.ClaimGame:
+		// Move the user's game to this device, e.g. from a desktop to a phone
 		gameSession, exists := h.SessionManager.GetSessionByUserID(userID)
+		if !exists {
+			h.reply(userID, sessionID, domain.ServerMessage{Type: "no_active_game", Message: "No active game session found"})
+			return
+		}
+		h.playOn(userID, sessionID)
+		h.EnsureEventLoopRunning(gameSession)
+		if err := gameSession.HandleReconnect(userID); err != nil {
+			h.connLog(userID, sessionID).Warn("Claim failed", logging.GameID(gameSession.GameID), logging.Err(err))
+		}
+
+	case protocol.GetGameState:
+		gameSession, exists := h.SessionManager.GetSessionByUserID(userID)
+		if exists && !h.ConnManager.ClaimPlayingDevice(userID, sessionID) {
+			h.reply(userID, sessionID, domain.ServerMessage{Type: "game_on_other_device", GameID: gameSession.GameID, Message: msgGameElsewhere})
+			return
+		}
 		
-		if !exists && msg.GameID != "" {
-			if gs, ok := h.SessionManager.GetSessionByGameID(msg.GameID); ok {
-				if h.SessionManager.IsSpectator(userID, msg.GameID) {
+		if !exists && payload.GameID != "" {
+			if gs, ok := h.SessionManager.GetSessionByGameID(payload.GameID); ok {
+				if h.SessionManager.IsSpectator(userID, payload.GameID) {
 					gameSession = gs
 					exists = true
 				}
@@ -445,7 +663,7 @@ func (h *Handler) processMessage(userID int64, msg domain.ClientMessage) {
 			h.EnsureEventLoopRunning(gameSession)
 			gameSession.HandleGetState(userID)
 		} else {
-			h.ConnManager.SendMessage(userID, domain.ServerMessage{
+			h.reply(userID, sessionID, domain.ServerMessage{
 				Type:    "no_active_game",
 				Message: "No active game session found",
 			})
@@ -453,15 +671,64 @@ func (h *Handler) processMessage(userID int64, msg domain.ClientMessage) {
 	}
 }
 
-func (h *Handler) checkRateLimit(key string, window time.Duration) bool {
-	if redis.RedisClient == nil || !redis.IsRedisEnabled() {
-		return true
+// chat runs a chat action in the game chatSession finds, answering the
+// device with the action's error
+func (h *Handler) chat(userID int64, sessionID, gameID string, action func(*game.GameSession) error) {
+	gameSession, ok := h.chatSession(userID, sessionID, gameID)
+	if !ok {
+		return
 	}
-	ctx := context.Background()
-	ok, err := redis.RedisClient.SetNX(ctx, key, 1, window).Result()
-	if err != nil {
-		log.Printf("[WS] Rate limit check failed for key %s: %v", key, err)
-		return true
+	if err := action(gameSession); err != nil {
+		h.replyError(userID, sessionID, domain.ErrRejected, err.Error())
 	}
-	return ok
+}
+
+// chatSession finds the game a user can chat in: the game they are spectating
+// when gameID is given, otherwise their own game. It answers the device itself
+// when there is none.
+func (h *Handler) chatSession(userID int64, sessionID, gameID string) (*game.GameSession, bool) {
+	if gameID != "" && h.SessionManager.IsSpectator(userID, gameID) {
+		gameSession, exists := h.SessionManager.GetSessionByGameID(gameID)
+		if !exists {
+			h.replyError(userID, sessionID, domain.ErrNotFound, "Game not found")
+			return nil, false
+		}
+		h.EnsureEventLoopRunning(gameSession)
+		return gameSession, true
+	}
+	return h.ownGame(userID, sessionID)
+}
+
+// ownGame returns the user's game for the device sessionID. A device can play
+// the game when no other device is; otherwise it is told where the game is.
+func (h *Handler) ownGame(userID int64, sessionID string) (*game.GameSession, bool) {
+	gameSession, exists := h.SessionManager.GetSessionByUserID(userID)
+	if !exists {
+		h.replyError(userID, sessionID, domain.ErrNotFound, "Game not found")
+		return nil, false
+	}
+	if !h.ConnManager.ClaimPlayingDevice(userID, sessionID) {
+		h.reply(userID, sessionID, domain.ServerMessage{Type: "game_on_other_device", GameID: gameSession.GameID, Message: msgGameElsewhere})
+		return nil, false
+	}
+	h.EnsureEventLoopRunning(gameSession)
+	return gameSession, true
+}
+
+// playOn routes the user's games to sessionID from now on, telling the device
+// that had them
+func (h *Handler) playOn(userID int64, sessionID string) {
+	if previous := h.ConnManager.SetPlayingDevice(userID, sessionID); previous != "" && previous != sessionID {
+		h.ConnManager.SendToSession(userID, previous, domain.ServerMessage{Type: "game_on_other_device", Message: msgGameElsewhere})
+	}
+}
+
+// reply answers the device that sent a message
+func (h *Handler) reply(userID int64, sessionID string, msg domain.ServerMessage) {
+	h.ConnManager.SendToSession(userID, sessionID, msg)
+}
+
+// replyError answers the device with an error message carrying code
+func (h *Handler) replyError(userID int64, sessionID string, code domain.ErrorCode, message string) {
+	h.reply(userID, sessionID, domain.ServerMessage{Type: "error", Code: code, Message: message})
 }
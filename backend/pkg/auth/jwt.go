@@ -13,6 +13,7 @@ type Claims struct {
 	UserID    int64  `json:"user_id"`
 	Username  string `json:"username"`
 	SessionID string `json:"session_id"`
+	Guest     bool   `json:"guest,omitempty"`
 	jwt.RegisteredClaims
 }
 
@@ -43,6 +44,27 @@ func GenerateAccessToken(userID int64, username, sessionID string) (string, erro
 	return token.SignedString([]byte(secret))
 }
 
+// GenerateGuestAccessToken creates an access token for a guest. Guests get no
+// refresh token, so it lives as long as the guest session.
+func GenerateGuestAccessToken(userID int64, username, sessionID string) (string, error) {
+	secret := config.AppConfig.JWTSecret
+	ttl := time.Duration(config.AppConfig.GuestSessionTTLHours) * time.Hour
+
+	claims := &Claims{
+		UserID:    userID,
+		Username:  username,
+		SessionID: sessionID,
+		Guest:     true,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(secret))
+}
+
 // ValidateAccessToken validates a JWT access token and returns the claims
 func ValidateAccessToken(tokenString string) (*Claims, error) {
 	secret := config.AppConfig.JWTSecret
@@ -120,24 +142,29 @@ func ValidateJWT(tokenString string) (*Claims, error) {
 
 // --- Setup Token (unchanged) ---
 
-// SetupClaims represents JWT claims for the setup phase
+// SetupClaims represents JWT claims for the setup phase: the provider account
+// that will be linked to the new player
 type SetupClaims struct {
-	Email    string `json:"email"`
-	GoogleID string `json:"google_id"`
-	Name     string `json:"name"`
-	Picture  string `json:"picture"`
+	Provider      string `json:"provider"`
+	Subject       string `json:"subject"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	Name          string `json:"name"`
+	Picture       string `json:"picture"`
 	jwt.RegisteredClaims
 }
 
 // GenerateSetupToken creates a short-lived token for the signup completion step
-func GenerateSetupToken(email, googleID, name, picture string) (string, error) {
+func GenerateSetupToken(provider, subject, email string, emailVerified bool, name, picture string) (string, error) {
 	secret := config.AppConfig.JWTSecret
 
 	claims := &SetupClaims{
-		Email:    email,
-		GoogleID: googleID,
-		Name:     name,
-		Picture:  picture,
+		Provider:      provider,
+		Subject:       subject,
+		Email:         email,
+		EmailVerified: emailVerified,
+		Name:          name,
+		Picture:       picture,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(15 * time.Minute)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
@@ -169,3 +196,107 @@ func ValidateSetupToken(tokenString string) (*SetupClaims, error) {
 
 	return nil, errors.New("invalid token")
 }
+
+// --- Two-factor challenge ---
+
+const twoFactorPurpose = "two_factor"
+
+// TwoFactorClaims identify a user who passed the password step of login and
+// still owes a TOTP or recovery code
+type TwoFactorClaims struct {
+	UserID  int64  `json:"user_id"`
+	Purpose string `json:"purpose"`
+	jwt.RegisteredClaims
+}
+
+// GenerateTwoFactorChallenge creates a five-minute token for the second login step
+func GenerateTwoFactorChallenge(userID int64) (string, error) {
+	secret := config.AppConfig.JWTSecret
+
+	claims := &TwoFactorClaims{
+		UserID:  userID,
+		Purpose: twoFactorPurpose,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(5 * time.Minute)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(secret))
+}
+
+// ValidateTwoFactorChallenge validates a challenge token. Other token kinds
+// signed with the same secret are refused.
+func ValidateTwoFactorChallenge(tokenString string) (*TwoFactorClaims, error) {
+	secret := config.AppConfig.JWTSecret
+
+	token, err := jwt.ParseWithClaims(tokenString, &TwoFactorClaims{}, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, errors.New("invalid signing method")
+		}
+		return []byte(secret), nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if claims, ok := token.Claims.(*TwoFactorClaims); ok && token.Valid && claims.Purpose == twoFactorPurpose {
+		return claims, nil
+	}
+
+	return nil, errors.New("invalid token")
+}
+
+// --- OAuth state ---
+
+const oauthStatePurpose = "oauth_state"
+
+// OAuthStateClaims hold what a login or link flow needs back on the provider's
+// callback. They travel in a cookie, so the callback only succeeds in the
+// browser that started the flow.
+type OAuthStateClaims struct {
+	State      string `json:"state"`    // must match the callback's state parameter
+	Verifier   string `json:"verifier"` // PKCE code verifier
+	Provider   string `json:"provider"`
+	LinkUserID int64  `json:"link_user_id,omitempty"` // set when a signed-in user is linking the provider
+	Purpose    string `json:"purpose"`
+	jwt.RegisteredClaims
+}
+
+// GenerateOAuthState signs claims into a ten-minute token
+func GenerateOAuthState(claims OAuthStateClaims) (string, error) {
+	secret := config.AppConfig.JWTSecret
+
+	claims.Purpose = oauthStatePurpose
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(10 * time.Minute)),
+		IssuedAt:  jwt.NewNumericDate(time.Now()),
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, &claims)
+	return token.SignedString([]byte(secret))
+}
+
+// ValidateOAuthState validates a token from GenerateOAuthState
+func ValidateOAuthState(tokenString string) (*OAuthStateClaims, error) {
+	secret := config.AppConfig.JWTSecret
+
+	token, err := jwt.ParseWithClaims(tokenString, &OAuthStateClaims{}, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, errors.New("invalid signing method")
+		}
+		return []byte(secret), nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if claims, ok := token.Claims.(*OAuthStateClaims); ok && token.Valid && claims.Purpose == oauthStatePurpose {
+		return claims, nil
+	}
+
+	return nil, errors.New("invalid token")
+}
@@ -5,12 +5,13 @@ import (
 	"strings"
 	"unicode"
 
+	"github.com/iamasit07/connect4/backend/internal/config"
 	"golang.org/x/crypto/bcrypt"
 )
 
-// HashPassword hashes a password using bcrypt
+// HashPassword hashes a password using bcrypt at the configured cost
 func HashPassword(password string) (string, error) {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), 14) // Cost 14 is secure
+	bytes, err := bcrypt.GenerateFromPassword([]byte(password), config.AppConfig.PasswordHashCost)
 	return string(bytes), err
 }
 
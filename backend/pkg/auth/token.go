@@ -1,8 +1,13 @@
 package auth
 
 import (
+	"crypto/hmac"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
+	"strings"
+
+	"github.com/iamasit07/connect4/backend/internal/config"
 )
 
 // GenerateToken creates a cryptographically secure random token
@@ -11,3 +16,26 @@ func GenerateToken() string {
 	rand.Read(bytes)
 	return hex.EncodeToString(bytes)
 }
+
+// HashAccountToken returns the keyed hash stored for a single-use account
+// token. The purpose is mixed in so a token only works for what it was issued for.
+func HashAccountToken(purpose, token string) string {
+	mac := hmac.New(sha256.New, []byte(config.AppConfig.JWTSecret))
+	mac.Write([]byte(purpose + ":" + token))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// APIKeyPrefix starts every personal API key so it can't be mistaken for a JWT
+const APIKeyPrefix = "c4k_"
+
+// GenerateAPIKey creates a new personal API key
+func GenerateAPIKey() string {
+	bytes := make([]byte, 32)
+	rand.Read(bytes)
+	return APIKeyPrefix + hex.EncodeToString(bytes)
+}
+
+// IsAPIKey reports whether a bearer token is an API key rather than a JWT
+func IsAPIKey(token string) bool {
+	return strings.HasPrefix(token, APIKeyPrefix)
+}
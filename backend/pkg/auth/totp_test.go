@@ -0,0 +1,65 @@
+package auth
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+)
+
+// rfcSecret is the SHA-1 key from RFC 6238 appendix B
+var rfcSecret = base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+func TestTOTPCode(t *testing.T) {
+	// RFC 6238 vectors, truncated to six digits
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	for _, tt := range tests {
+		got, err := TOTPCode(rfcSecret, time.Unix(tt.unix, 0))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("TOTPCode at %d = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	code, _ := TOTPCode(rfcSecret, now)
+	prev, _ := TOTPCode(rfcSecret, now.Add(-30*time.Second))
+	old, _ := TOTPCode(rfcSecret, now.Add(-90*time.Second))
+	step := now.Unix() / totpPeriod
+
+	tests := []struct {
+		name     string
+		code     string
+		lastStep int64
+		wantOK   bool
+		wantStep int64
+	}{
+		{"current", code, 0, true, step},
+		{"previous step within skew", prev, 0, true, step - 1},
+		{"outside skew", old, 0, false, 0},
+		{"replayed", code, step, false, 0},
+		{"wrong length", "12345", 0, false, 0},
+		{"wrong code", "000000", 0, false, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ValidateTOTP(rfcSecret, tt.code, now, tt.lastStep)
+			if ok != tt.wantOK || got != tt.wantStep {
+				t.Errorf("ValidateTOTP = (%d, %t), want (%d, %t)", got, ok, tt.wantStep, tt.wantOK)
+			}
+		})
+	}
+}
@@ -10,10 +10,22 @@ import (
 const AuthCookieName = "auth_token"
 const RefreshCookieName = "refresh_token"
 
+// OAuth flow cookies, scoped to the auth routes that read them
+const OAuthStateCookieName = "oauth_state"
+const OAuthSetupCookieName = "oauth_setup"
+
 func SetAccessCookie(w http.ResponseWriter, token string) {
 	ttlMinutes := config.AppConfig.AccessTokenTTLMinutes
-	maxAge := ttlMinutes * 60
+	setAccessCookie(w, token, ttlMinutes*60)
+}
+
+// SetGuestCookie sets a guest's access token, which lasts the whole guest session
+func SetGuestCookie(w http.ResponseWriter, token string) {
+	ttlHours := config.AppConfig.GuestSessionTTLHours
+	setAccessCookie(w, token, ttlHours*60*60)
+}
 
+func setAccessCookie(w http.ResponseWriter, token string, maxAge int) {
 	isProduction := config.GetEnv("ENVIRONMENT", "development") == "production"
 
 	cookie := &http.Cookie{
@@ -162,3 +174,25 @@ func GetTokenFromRequest(r *http.Request) (string, error) {
 
 	return "", errors.New("no auth token found in cookie or header")
 }
+
+// SetOAuthCookie sets a short-lived OAuth flow cookie. It is SameSite=Lax even
+// in production: the provider's redirect back to us is a cross-site navigation,
+// and a Strict cookie would not be sent with it.
+func SetOAuthCookie(w http.ResponseWriter, name, value string, maxAge int) {
+	isProduction := config.GetEnv("ENVIRONMENT", "development") == "production"
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/api/auth",
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   isProduction,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// ClearOAuthCookie removes a cookie set by SetOAuthCookie
+func ClearOAuthCookie(w http.ResponseWriter, name string) {
+	SetOAuthCookie(w, name, "", -1)
+}
@@ -0,0 +1,13 @@
+package uid
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// GenerateRequestID returns a short random ID for correlating one request's logs
+func GenerateRequestID() string {
+	bytes := make([]byte, 8)
+	rand.Read(bytes)
+	return hex.EncodeToString(bytes)
+}
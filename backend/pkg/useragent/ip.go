@@ -0,0 +1,115 @@
+package useragent
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// Header is the forwarding header a deployment's proxy sets. Only that one is
+// read: proxies add to their own header but pass any other through as the
+// client sent it.
+type Header string
+
+const (
+	HeaderXForwardedFor Header = "xff"
+	HeaderForwarded     Header = "forwarded" // RFC 7239
+	HeaderXRealIP       Header = "x-real-ip"
+)
+
+// ParseHeader reads a Header from its configured name
+func ParseHeader(name string) (Header, bool) {
+	switch h := Header(strings.ToLower(strings.TrimSpace(name))); h {
+	case HeaderXForwardedFor, HeaderForwarded, HeaderXRealIP:
+		return h, true
+	}
+	return "", false
+}
+
+// ClientIP returns the client IP for r. The forwarding header is only read
+// when the direct peer is one of the trusted proxies; its chain is then walked
+// right to left and the first address that isn't a trusted proxy is the client.
+func ClientIP(r *http.Request, trusted []*net.IPNet, header Header) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	peer := net.ParseIP(host)
+	if peer == nil {
+		return host
+	}
+	if !isTrusted(peer, trusted) {
+		return peer.String()
+	}
+
+	var chain []string
+	switch header {
+	case HeaderForwarded:
+		chain = parseForwarded(r.Header.Values("Forwarded"))
+	case HeaderXForwardedFor:
+		chain = splitList(r.Header.Values("X-Forwarded-For"))
+	case HeaderXRealIP:
+		if xri := strings.TrimSpace(r.Header.Get("X-Real-IP")); xri != "" {
+			chain = []string{xri}
+		}
+	}
+
+	client := peer
+	for i := len(chain) - 1; i >= 0; i-- {
+		ip := parseHop(chain[i])
+		if ip == nil {
+			// Anything left of a malformed hop can't be trusted
+			break
+		}
+		client = ip
+		if !isTrusted(ip, trusted) {
+			break
+		}
+	}
+	return client.String()
+}
+
+func isTrusted(ip net.IP, trusted []*net.IPNet) bool {
+	for _, n := range trusted {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitList joins repeated headers and splits them into their entries
+func splitList(values []string) []string {
+	var entries []string
+	for _, v := range values {
+		for _, entry := range strings.Split(v, ",") {
+			entries = append(entries, strings.TrimSpace(entry))
+		}
+	}
+	return entries
+}
+
+// parseForwarded returns the for= value of each Forwarded element, in order
+func parseForwarded(values []string) []string {
+	var hops []string
+	for _, element := range splitList(values) {
+		hop := ""
+		for _, pair := range strings.Split(element, ";") {
+			key, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+			if ok && strings.EqualFold(key, "for") {
+				hop = strings.Trim(value, `"`)
+			}
+		}
+		hops = append(hops, hop)
+	}
+	return hops
+}
+
+// parseHop parses one hop, which may carry a port ("1.2.3.4:80", "[::1]:80");
+// obfuscated or "unknown" hops return nil
+func parseHop(hop string) net.IP {
+	if host, _, err := net.SplitHostPort(hop); err == nil {
+		hop = host
+	}
+	return net.ParseIP(strings.Trim(hop, "[]"))
+}
@@ -0,0 +1,68 @@
+package useragent
+
+import (
+	"net"
+	"net/http"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	var trusted []*net.IPNet
+	for _, cidr := range []string{"10.0.0.0/8", "::1/128"} {
+		_, n, _ := net.ParseCIDR(cidr)
+		trusted = append(trusted, n)
+	}
+
+	tests := []struct {
+		name    string
+		remote  string
+		header  Header
+		headers map[string]string
+		want    string
+	}{
+		{"no headers", "203.0.113.1:5000", HeaderXForwardedFor, nil, "203.0.113.1"},
+		{"untrusted peer", "203.0.113.1:5000", HeaderXForwardedFor, map[string]string{"X-Forwarded-For": "198.51.100.1"}, "203.0.113.1"},
+		{"single hop", "10.0.0.1:5000", HeaderXForwardedFor, map[string]string{"X-Forwarded-For": "198.51.100.1"}, "198.51.100.1"},
+		{"spoofed prefix", "10.0.0.1:5000", HeaderXForwardedFor, map[string]string{"X-Forwarded-For": "1.1.1.1, 198.51.100.1"}, "198.51.100.1"},
+		{"chained proxies", "10.0.0.1:5000", HeaderXForwardedFor, map[string]string{"X-Forwarded-For": "198.51.100.1, 10.0.0.2"}, "198.51.100.1"},
+		{"all trusted", "10.0.0.1:5000", HeaderXForwardedFor, map[string]string{"X-Forwarded-For": "10.0.0.3, 10.0.0.2"}, "10.0.0.3"},
+		{"malformed hop", "10.0.0.1:5000", HeaderXForwardedFor, map[string]string{"X-Forwarded-For": "198.51.100.1, junk"}, "10.0.0.1"},
+		{"real ip", "10.0.0.1:5000", HeaderXRealIP, map[string]string{"X-Real-IP": "198.51.100.1"}, "198.51.100.1"},
+		{"forwarded", "[::1]:5000", HeaderForwarded, map[string]string{"Forwarded": `for=192.0.2.60;proto=http, for="[2001:db8::1]:4711"`}, "2001:db8::1"},
+		{"forwarded unknown", "10.0.0.1:5000", HeaderForwarded, map[string]string{"Forwarded": "for=unknown"}, "10.0.0.1"},
+		// A proxy that only appends to X-Forwarded-For passes a client's Forwarded through
+		{"forwarded ignored behind xff proxy", "10.0.0.1:5000", HeaderXForwardedFor, map[string]string{"Forwarded": "for=192.0.2.60", "X-Forwarded-For": "198.51.100.1"}, "198.51.100.1"},
+		{"xff ignored behind forwarded proxy", "10.0.0.1:5000", HeaderForwarded, map[string]string{"Forwarded": "for=192.0.2.60", "X-Forwarded-For": "198.51.100.1"}, "192.0.2.60"},
+		{"real ip ignored behind xff proxy", "10.0.0.1:5000", HeaderXForwardedFor, map[string]string{"X-Real-IP": "198.51.100.1"}, "10.0.0.1"},
+	}
+
+	for _, tt := range tests {
+		r := &http.Request{RemoteAddr: tt.remote, Header: http.Header{}}
+		for k, v := range tt.headers {
+			r.Header.Set(k, v)
+		}
+		if got := ClientIP(r, trusted, tt.header); got != tt.want {
+			t.Errorf("%s: ClientIP = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseHeader(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Header
+		ok   bool
+	}{
+		{"xff", HeaderXForwardedFor, true},
+		{" Forwarded ", HeaderForwarded, true},
+		{"X-Real-IP", HeaderXRealIP, true},
+		{"x-forwarded-for", "", false},
+		{"", "", false},
+	}
+
+	for _, tt := range tests {
+		if got, ok := ParseHeader(tt.in); got != tt.want || ok != tt.ok {
+			t.Errorf("ParseHeader(%q) = %q, %v; want %q, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -32,8 +32,9 @@ const CompleteSignupPage = () => {
   const { completeSignup, isLoading } = useAuth();
   const [showPassword, setShowPassword] = useState(false);
 
-  // Get name/email from either route state (manual) or search params (Google OAuth)
-  const googleToken = searchParams.get('token') || '';
+  // Get name/email from either route state (manual) or search params (OAuth).
+  // A provider signup is identified by the setup cookie the callback set.
+  const provider = searchParams.get('provider') || '';
   const nameFromParams = searchParams.get('name') || '';
   const emailFromParams = searchParams.get('email') || '';
   const stateData = location.state as { name?: string; email?: string } | null;
@@ -50,11 +51,11 @@ const CompleteSignupPage = () => {
 
   useEffect(() => {
     // Redirect if we don't have the required data
-    if (!name && !email && !googleToken) {
+    if (!name && !email && !provider) {
       toast.error('Please start the signup process first');
       navigate('/signup');
     }
-  }, [name, email, googleToken, navigate]);
+  }, [name, email, provider, navigate]);
 
   const validate = () => {
     const result = step2Schema.safeParse(formData);
@@ -77,7 +78,7 @@ const CompleteSignupPage = () => {
 
     try {
       await completeSignup({
-        token: googleToken || undefined,
+        provider: provider || undefined,
         name,
         email,
         username: formData.username,
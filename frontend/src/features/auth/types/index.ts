@@ -28,7 +28,7 @@ export interface SignupCredentials {
 }
 
 export interface CompleteSignupRequest {
-  token?: string;
+  provider?: string;
   name: string;
   email: string;
   username: string;
@@ -26,16 +26,15 @@ export const useLogin = () => useMutation({
   },
 });
 
-// Complete Signup — handles both manual and Google flows
+// Complete Signup — handles both manual and OAuth provider flows
 export const useCompleteSignup = () => useMutation({
   mutationFn: async (request: CompleteSignupRequest) => {
-    // If there's a Google token, use the Google complete endpoint
-    if (request.token) {
-      const { data } = await api.post<AuthResponse>('/auth/google/complete', {
-        token: request.token,
+    // Arriving from a provider: the setup token is in the oauth_setup cookie
+    if (request.provider) {
+      const { data } = await api.post<AuthResponse>('/auth/oauth/complete', {
         username: request.username,
         password: request.password,
-      });
+      }, { withCredentials: true });
       return data;
     }
     // Otherwise use the regular register endpoint